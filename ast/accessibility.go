@@ -0,0 +1,17 @@
+package ast
+
+// Accessibility holds the accTitle and accDescr statements of a diagram, which
+// Mermaid renders as the title and description of the SVG for screen readers:
+//
+//	accTitle: Order flow
+//	accDescr {
+//	    How an order moves from the basket
+//	    to the warehouse
+//	}
+type Accessibility struct {
+	Title       string // Text of accTitle, empty if absent
+	Description string // Text of accDescr, with the lines of a block joined by newlines
+
+	TitleSpan       Span // Location of Title
+	DescriptionSpan Span // Location of Description, across several lines for the block form
+}
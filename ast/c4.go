@@ -3,14 +3,19 @@ package ast
 // C4Diagram represents any C4 diagram (Context, Container, Component, Dynamic, Deployment).
 // All C4 diagram types share the same AST structure with common elements.
 type C4Diagram struct {
-	DiagramType   string             // "c4Context", "c4Container", "c4Component", "c4Dynamic", "c4Deployment"
-	Title         string             // Optional title
-	Elements      []C4Element        // All elements (Person, System, Container, Component, Node)
-	Boundaries    []C4Boundary       // Boundary elements (can be nested)
-	Relationships []C4Relationship   // All relationships (Rel, BiRel, etc.)
-	Styles        []C4Style          // Style overrides
-	Source        string             // Original source
-	Pos           Position           // Position in source
+	DiagramType   string              // "c4Context", "c4Container", "c4Component", "c4Dynamic", "c4Deployment"
+	Title         string              // Optional title
+	Elements      []C4Element         // All elements (Person, System, Container, Component, Node)
+	Boundaries    []C4Boundary        // Boundary elements (can be nested)
+	Relationships []C4Relationship    // All relationships (Rel, BiRel, etc.)
+	Styles        []C4Style           // Style overrides
+	Unknown       []*UnknownStatement // Lines the parser could not recognise
+	Source        string              // Original source
+	Pos           Position            // Position in source
+	End           Position            // End of the diagram source
+	Metadata                          // Metadata shared by all diagram types
+
+	TitleSpan Span // Location of Title
 }
 
 // GetType implements the Diagram interface.
@@ -42,17 +47,23 @@ type C4Element struct {
 	Database    bool     // True for Db variants (ContainerDb, ComponentDb)
 	Queue       bool     // True for Queue variants (ContainerQueue, ComponentQueue)
 	Pos         Position // Position in source
+	End         Position // End of the element
+
+	Params []Span // Location of each parameter, excluding quotes
 }
 
 // C4Boundary represents a boundary element that can contain other elements.
 type C4Boundary struct {
-	BoundaryType string         // "Boundary", "Enterprise_Boundary", "System_Boundary", "Container_Boundary"
-	ID           string         // Boundary identifier
-	Label        string         // Display label
-	Type         string         // Optional type (for generic Boundary)
-	Elements     []C4Element    // Nested elements
-	Boundaries   []C4Boundary   // Nested boundaries
-	Pos          Position       // Position in source
+	BoundaryType string       // "Boundary", "Enterprise_Boundary", "System_Boundary", "Container_Boundary"
+	ID           string       // Boundary identifier
+	Label        string       // Display label
+	Type         string       // Optional type (for generic Boundary)
+	Elements     []C4Element  // Nested elements
+	Boundaries   []C4Boundary // Nested boundaries
+	Pos          Position     // Position in source
+	End          Position     // End of the closing brace
+
+	Params []Span // Location of each parameter, excluding quotes
 }
 
 // C4Relationship represents a relationship between elements.
@@ -67,6 +78,9 @@ type C4Relationship struct {
 	Tags        string   // Optional tags
 	Link        string   // Optional link
 	Pos         Position // Position in source
+	End         Position // End of the relationship
+
+	Params []Span // Location of each parameter, excluding quotes
 }
 
 // C4Style represents a style override for elements or relationships.
@@ -85,4 +99,7 @@ type C4Style struct {
 	OffsetX     string   // X offset (for relationships)
 	OffsetY     string   // Y offset (for relationships)
 	Pos         Position // Position in source
+	End         Position // End of the style
+
+	Params []Span // Location of each parameter, excluding quotes
 }
@@ -6,6 +6,8 @@ type ClassDiagram struct {
 	Statements []ClassStmt // All statements in the diagram
 	Source     string      // Original source
 	Pos        Position    // Position in source
+	End        Position    // End of the diagram source
+	Metadata               // Metadata shared by all diagram types
 }
 
 // ClassStmt is the interface for all class diagram statements.
@@ -16,11 +18,19 @@ type ClassStmt interface {
 
 // Class represents a class definition.
 type Class struct {
-	Name        string       // Class name
-	Stereotype  string       // Optional stereotype (e.g., "interface", "abstract")
+	Name        string        // Class name, without backticks
+	Generic     string        // Generic type, as in class List~T~, without the outer tildes (optional)
+	Label       string        // Display label, as in class A["Display name"] (optional)
+	Stereotype  string        // Optional stereotype (e.g., "interface", "abstract")
 	Members     []ClassMember // Class members (attributes and methods)
-	Annotations []string     // Annotations like <<interface>>
+	Annotations []string      // Annotations like <<interface>>
 	Pos         Position
+	End         Position // End of the declaration, including any body
+
+	NameSpan       Span // Location of Name, excluding backticks
+	GenericSpan    Span // Location of Generic
+	LabelSpan      Span // Location of Label, excluding quotes
+	StereotypeSpan Span // Location of Stereotype, excluding << >>
 }
 
 func (c *Class) classStmt() {}
@@ -28,18 +38,38 @@ func (c *Class) classStmt() {}
 // GetPosition returns the position in source.
 func (c *Class) GetPosition() Position { return c.Pos }
 
-// ClassMember represents an attribute or method in a class.
+// ClassMember represents an attribute or method in a class, as in +String name,
+// +area() double or +count()$.
 type ClassMember struct {
 	Visibility string   // +, -, #, ~ (public, private, protected, package)
 	Name       string   // Member name
 	Type       string   // Type for attributes, return type for methods
 	IsMethod   bool     // true if method, false if attribute
 	Parameters []string // Method parameters (if IsMethod)
-	IsStatic   bool     // Class-level member
-	IsAbstract bool     // Abstract method
+	IsStatic   bool     // Class-level member, marked with $
+	IsAbstract bool     // Abstract method, marked with *
+	Pos        Position
+	End        Position
+
+	NameSpan Span // Location of Name
+	TypeSpan Span // Location of Type
+}
+
+// Namespace groups classes, as in namespace Billing { class Invoice }.
+type Namespace struct {
+	Name       string      // Namespace name
+	Statements []ClassStmt // Classes and other statements in the namespace
 	Pos        Position
+	End        Position // End of the closing brace
+
+	NameSpan Span // Location of Name
 }
 
+func (n *Namespace) classStmt() {}
+
+// GetPosition returns the position in source.
+func (n *Namespace) GetPosition() Position { return n.Pos }
+
 // Relationship represents a relationship between classes.
 type Relationship struct {
 	From             string // Source class name
@@ -51,6 +81,13 @@ type Relationship struct {
 	FromCardinality  string // Cardinality on source end (alternative to multiplicity)
 	ToCardinality    string // Cardinality on target end
 	Pos              Position
+	End              Position
+
+	FromSpan            Span // Location of From
+	ToSpan              Span // Location of To
+	LabelSpan           Span // Location of Label
+	FromCardinalitySpan Span // Location of FromCardinality, excluding quotes
+	ToCardinalitySpan   Span // Location of ToCardinality, excluding quotes
 }
 
 func (r *Relationship) classStmt() {}
@@ -60,9 +97,13 @@ func (r *Relationship) GetPosition() Position { return r.Pos }
 
 // ClassNote represents a note attached to a class.
 type ClassNote struct {
-	ClassName string   // Class the note is attached to
-	Text      string   // Note text
+	ClassName string // Class the note is attached to
+	Text      string // Note text
 	Pos       Position
+	End       Position
+
+	ClassNameSpan Span // Location of ClassName
+	TextSpan      Span // Location of Text, excluding quotes
 }
 
 func (n *ClassNote) classStmt() {}
@@ -72,8 +113,9 @@ func (n *ClassNote) GetPosition() Position { return n.Pos }
 
 // ClassComment represents a comment in the class diagram.
 type ClassComment struct {
-	Text string   // Comment text (without %%)
+	Text string // Comment text (without %%)
 	Pos  Position
+	End  Position
 }
 
 func (c *ClassComment) classStmt() {}
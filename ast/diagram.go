@@ -1,16 +1,70 @@
 // Package ast defines the Abstract Syntax Tree types for all Mermaid diagram types.
 package ast
 
+import "strings"
+
 // Diagram is the base interface that all diagram types implement.
 type Diagram interface {
 	// GetType returns the diagram type (e.g., "flowchart", "sequence", "class").
 	GetType() string
 	// GetPosition returns the position in the source where this diagram starts.
 	GetPosition() Position
+	// GetMetadata returns the metadata shared by all diagram types, such as frontmatter.
+	GetMetadata() *Metadata
 }
 
 // Position represents a location in the source text.
 type Position struct {
 	Line   int // Line number (1-indexed)
-	Column int // Column number (1-indexed)
+	Column int // Column number in bytes (1-indexed)
+	Offset int // Byte offset from the start of the source (0-indexed)
+}
+
+// IsValid reports whether the position has been set.
+func (p Position) IsValid() bool { return p.Line > 0 }
+
+// SourceEnd returns the position just past the last byte of source.
+func SourceEnd(source string) Position {
+	lastLine := strings.LastIndexByte(source, '\n') + 1
+	return Position{
+		Line:   strings.Count(source, "\n") + 1,
+		Column: len(source) - lastLine + 1,
+		Offset: len(source),
+	}
 }
+
+// Span represents a range of source text. End is exclusive, so an empty span
+// has Start equal to End.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// IsValid reports whether the span has been set.
+func (s Span) IsValid() bool { return s.Start.IsValid() }
+
+// Text returns the source text covered by the span. It returns an empty string
+// if the span is not set or does not lie within source.
+func (s Span) Text(source string) string {
+	if !s.IsValid() || s.Start.Offset < 0 || s.End.Offset < s.Start.Offset || s.End.Offset > len(source) {
+		return ""
+	}
+	return source[s.Start.Offset:s.End.Offset]
+}
+
+// UnknownStatement is a line the parser could not recognise. It is kept in the
+// AST, rather than dropped, so that validators can report it. Diagrams with a
+// list of statements keep it among them, and others in their Unknown field.
+type UnknownStatement struct {
+	Text string // The unrecognised line, without surrounding whitespace
+	Pos  Position
+	End  Position
+}
+
+func (u *UnknownStatement) statement() {}
+func (u *UnknownStatement) classStmt() {}
+func (u *UnknownStatement) stateStmt() {}
+func (u *UnknownStatement) seqStmt()   {}
+
+// GetPosition returns the position of this statement in the source.
+func (u *UnknownStatement) GetPosition() Position { return u.Pos }
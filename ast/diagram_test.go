@@ -0,0 +1,48 @@
+package ast
+
+import (
+	"testing"
+)
+
+func TestSourceEnd(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   Position
+	}{
+		{"empty", "", Position{Line: 1, Column: 1, Offset: 0}},
+		{"single line", "pie", Position{Line: 1, Column: 4, Offset: 3}},
+		{"multiple lines", "pie\n  \"A\" : 1", Position{Line: 2, Column: 10, Offset: 13}},
+		{"trailing newline", "pie\n", Position{Line: 2, Column: 1, Offset: 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SourceEnd(tt.source); got != tt.want {
+				t.Errorf("SourceEnd() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpan_Text(t *testing.T) {
+	source := "graph TD\n    A --> B"
+	tests := []struct {
+		name string
+		span Span
+		want string
+	}{
+		{"unset", Span{}, ""},
+		{"arrow", Span{Start: Position{Line: 2, Column: 7, Offset: 15}, End: Position{Line: 2, Column: 10, Offset: 18}}, "-->"},
+		{"across lines", Span{Start: Position{Line: 1, Column: 7, Offset: 6}, End: Position{Line: 2, Column: 6, Offset: 14}}, "TD\n    A"},
+		{"out of range", Span{Start: Position{Line: 2, Column: 7, Offset: 15}, End: Position{Line: 2, Column: 99, Offset: 99}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.span.Text(source); got != tt.want {
+				t.Errorf("Text() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
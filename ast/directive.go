@@ -0,0 +1,23 @@
+package ast
+
+// Directive is a "%%{...}%%" directive, which configures a diagram from within its
+// source:
+//
+//	%%{init: {"theme": "forest", "flowchart": {"curve": "basis"}}}%%
+type Directive struct {
+	Name   string         // Directive name, e.g. "init" or "wrap"
+	Args   string         // Raw text after the name and colon, empty if there is none
+	Config map[string]any // Decoded Args of an init or initialize directive, nil otherwise
+	Keys   []ConfigKey    // Location of each key in Config
+	Pos    Position       // Position of the opening "%%{"
+	End    Position       // End of the closing "}%%"
+
+	NameSpan Span // Location of Name
+	ArgsSpan Span // Location of Args
+}
+
+// KeyPos returns the position of the Config key at the given dotted path, or the
+// position of the directive itself if there is no such key.
+func (d *Directive) KeyPos(path string) Position {
+	return keyPos(d.Keys, path, d.Pos)
+}
@@ -2,12 +2,15 @@ package ast
 
 // ERDiagram represents an Entity Relationship diagram AST.
 type ERDiagram struct {
-	Type          string           // Always "erDiagram"
-	Direction     string           // Optional: TB, BT, LR, RL
-	Entities      []EREntity       // Entity definitions
-	Relationships []ERRelationship // Relationships between entities
-	Source        string           // Original source
-	Pos           Position         // Position in source
+	Type          string              // Always "erDiagram"
+	Direction     string              // Optional: TB, BT, LR, RL
+	Entities      []EREntity          // Entity definitions
+	Relationships []ERRelationship    // Relationships between entities
+	Unknown       []*UnknownStatement // Lines the parser could not recognise
+	Source        string              // Original source
+	Pos           Position            // Position in source
+	End           Position            // End of the diagram source
+	Metadata                          // Metadata shared by all diagram types
 }
 
 // EREntity represents an entity in an ER diagram.
@@ -16,6 +19,10 @@ type EREntity struct {
 	Alias      string        // Optional alias
 	Attributes []ERAttribute // Entity attributes
 	Pos        Position      // Position in source
+	End        Position      // End of the entity, including any attribute block
+
+	NameSpan  Span // Location of Name, excluding any quotes
+	AliasSpan Span // Location of Alias, excluding brackets
 }
 
 // ERAttribute represents an entity attribute.
@@ -25,6 +32,12 @@ type ERAttribute struct {
 	Keys    []string // Key indicators: PK, FK, UK
 	Comment string   // Optional comment
 	Pos     Position // Position in source
+	End     Position // End of the attribute
+
+	TypeSpan    Span // Location of Type
+	NameSpan    Span // Location of Name, including any leading '*'
+	KeysSpan    Span // Location of the key list
+	CommentSpan Span // Location of Comment, excluding quotes
 }
 
 // ERRelationship represents a relationship between entities.
@@ -36,6 +49,11 @@ type ERRelationship struct {
 	Type     string   // Identifying (--) or non-identifying (..)
 	Label    string   // Optional relationship label
 	Pos      Position // Position in source
+	End      Position // End of the relationship
+
+	FromSpan  Span // Location of From, excluding any quotes
+	ToSpan    Span // Location of To, excluding any quotes
+	LabelSpan Span // Location of Label
 }
 
 // GetType returns the diagram type.
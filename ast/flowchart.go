@@ -4,10 +4,14 @@ package ast
 // Flowchart represents a complete Mermaid flowchart or graph diagram.
 type Flowchart struct {
 	Type       string      // "flowchart" or "graph"
-	Direction  string      // TB, TD, BT, RL, LR
+	Direction  string      // TB, TD, BT, RL, LR; TB if the header gives none
 	Statements []Statement // All statements in the diagram
 	Source     string      // Original source
 	Pos        Position    // Position in source
+	End        Position    // End of the diagram source
+	Metadata               // Metadata shared by all diagram types
+
+	DirectionSpan Span // Location of Direction, unset if the header gives none
 }
 
 // GetType returns the diagram type.
@@ -25,12 +29,21 @@ type Statement interface {
 	GetPosition() Position
 }
 
-// NodeDef represents a node definition.
+// NodeDef represents a node definition, such as A[text] or A@{ shape: cyl }.
 type NodeDef struct {
-	ID    string   // Node identifier
-	Shape string   // Shape type (bracket style)
-	Label string   // Node label/text
-	Pos   Position
+	ID         string            // Node identifier
+	Shape      string            // Shape as written: the brackets, such as "[]" or "[()]", or the name in an @{} block
+	Kind       NodeShape         // Normalised shape, ShapeRect if none is given and empty if Shape is not recognised
+	Label      string            // Node label as displayed, without quotes and with entity codes such as #quot; resolved
+	RawLabel   string            // Node label as written, including any quotes and markdown backticks
+	Markdown   bool              // Label is a markdown string, written "`**bold** text`"
+	Properties map[string]string // Entries of an @{} block, such as shape, label or icon
+	Pos        Position
+	End        Position
+
+	IDSpan    Span // Location of ID
+	ShapeSpan Span // Location of Shape: the brackets and label, or the name in an @{} block
+	LabelSpan Span // Location of the label text as written, excluding brackets, quotes and markdown backticks
 }
 
 func (n *NodeDef) statement() {}
@@ -40,14 +53,49 @@ func (n *NodeDef) GetPosition() Position { return n.Pos }
 
 // Link represents a link between nodes.
 type Link struct {
-	From      string   // Source node ID
-	To        string   // Target node ID
-	Arrow     string   // Arrow type (-->, -.>, ==>, etc.)
-	Label     string   // Link label (optional)
-	BiDir     bool     // Bidirectional arrow
-	Pos       Position
+	ID         string            // Edge ID, as in A e1@--> B (optional)
+	From       string            // Source node ID
+	To         string            // Target node ID
+	Arrow      string            // Arrow as written without inline text (-->, -.->, o--o, ~~~, etc.)
+	Label      string            // Link label (optional)
+	Head       ArrowHead         // Ending at To
+	Tail       ArrowHead         // Ending at From
+	Stroke     LinkStroke        // Line style
+	Length     int               // Rank span of the link: 1 for -->, 2 for --->, and so on
+	BiDir      bool              // Bidirectional arrow, with a head at each end
+	Properties map[string]string // Entries of an e1@{ ... } statement for the edge ID, such as animate
+	Pos        Position
+	End        Position
+
+	IDSpan    Span // Location of ID
+	FromSpan  Span // Location of From
+	ToSpan    Span // Location of To
+	ArrowSpan Span // Location of the arrow, including any text written inside it
+	LabelSpan Span // Location of Label, excluding pipes
 }
 
+// ArrowHead is the ending of a link at one of its nodes.
+type ArrowHead string
+
+// Link endings.
+const (
+	ArrowNone   ArrowHead = ""       // No head, as at both ends of ---
+	ArrowPoint  ArrowHead = "point"  // --> or <--
+	ArrowCircle ArrowHead = "circle" // --o or o--
+	ArrowCross  ArrowHead = "cross"  // --x or x--
+)
+
+// LinkStroke is the line style of a link.
+type LinkStroke string
+
+// Link line styles.
+const (
+	StrokeNormal    LinkStroke = "normal"    // --
+	StrokeThick     LinkStroke = "thick"     // ==
+	StrokeDotted    LinkStroke = "dotted"    // -.-
+	StrokeInvisible LinkStroke = "invisible" // ~~~
+)
+
 func (l *Link) statement() {}
 
 // GetPosition returns the position of this link in the source.
@@ -55,9 +103,16 @@ func (l *Link) GetPosition() Position { return l.Pos }
 
 // Subgraph represents a subgraph block.
 type Subgraph struct {
+	ID         string      // Subgraph identifier, which links can use as an endpoint (empty for a quoted title)
 	Title      string      // Subgraph title
+	Direction  string      // Direction set by a direction statement inside the subgraph (optional)
 	Statements []Statement // Nested statements
 	Pos        Position
+	End        Position // End of the closing 'end'
+
+	IDSpan        Span // Location of ID
+	TitleSpan     Span // Location of Title
+	DirectionSpan Span // Location of Direction, unset if the header gives none
 }
 
 func (s *Subgraph) statement() {}
@@ -70,6 +125,10 @@ type ClassDef struct {
 	Name   string            // Class name
 	Styles map[string]string // CSS properties
 	Pos    Position
+	End    Position
+
+	NameSpan   Span // Location of Name
+	StylesSpan Span // Location of the style list
 }
 
 func (c *ClassDef) statement() {}
@@ -77,11 +136,17 @@ func (c *ClassDef) statement() {}
 // GetPosition returns the position of this class definition in the source.
 func (c *ClassDef) GetPosition() Position { return c.Pos }
 
-// ClassAssignment represents assigning classes to nodes.
+// ClassAssignment represents assigning classes to nodes, either with a class
+// statement or with the A:::class shorthand.
 type ClassAssignment struct {
 	NodeIDs   []string // Node IDs to apply class to
 	ClassName string   // Class name to apply
+	Shorthand bool     // Written as A:::class after a node reference
 	Pos       Position
+	End       Position
+
+	NodeIDSpans   []Span // Location of each entry in NodeIDs
+	ClassNameSpan Span   // Location of ClassName
 }
 
 func (c *ClassAssignment) statement() {}
@@ -89,10 +154,68 @@ func (c *ClassAssignment) statement() {}
 // GetPosition returns the position of this class assignment in the source.
 func (c *ClassAssignment) GetPosition() Position { return c.Pos }
 
+// Style represents styling a single node, such as style A fill:#f9f.
+type Style struct {
+	NodeID string            // Node to style
+	Styles map[string]string // CSS properties
+	Pos    Position
+	End    Position
+
+	NodeIDSpan Span // Location of NodeID
+	StylesSpan Span // Location of the style list
+}
+
+func (s *Style) statement() {}
+
+// GetPosition returns the position of this style statement in the source.
+func (s *Style) GetPosition() Position { return s.Pos }
+
+// LinkStyle represents styling links by their position in the diagram, such as
+// linkStyle 0,3 stroke:red or linkStyle default stroke:blue.
+type LinkStyle struct {
+	Indexes    []int             // Zero-based indexes of the links to style, in source order, -1 if too large
+	RawIndexes []string          // Indexes as written
+	Default    bool              // Applies to all links without a style of their own
+	Styles     map[string]string // CSS properties
+	Pos        Position
+	End        Position
+
+	IndexSpans []Span // Location of each entry in Indexes
+	StylesSpan Span   // Location of the style list
+}
+
+func (l *LinkStyle) statement() {}
+
+// GetPosition returns the position of this link style in the source.
+func (l *LinkStyle) GetPosition() Position { return l.Pos }
+
+// Click represents an interaction on a node, either opening a URL, as in
+// click A href "https://example.com", or calling a function, as in click A callback.
+type Click struct {
+	NodeID   string // Node that can be clicked
+	URL      string // URL to open (optional)
+	Callback string // Function to call, including any arguments (optional)
+	Tooltip  string // Tooltip text (optional)
+	Target   string // Link target such as _blank (optional)
+	Pos      Position
+	End      Position
+
+	NodeIDSpan   Span // Location of NodeID
+	URLSpan      Span // Location of URL, excluding quotes
+	CallbackSpan Span // Location of Callback
+	TooltipSpan  Span // Location of Tooltip, excluding quotes
+}
+
+func (c *Click) statement() {}
+
+// GetPosition returns the position of this click statement in the source.
+func (c *Click) GetPosition() Position { return c.Pos }
+
 // Comment represents a comment line.
 type Comment struct {
 	Text string
 	Pos  Position
+	End  Position
 }
 
 func (c *Comment) statement() {}
@@ -87,6 +87,32 @@ func TestComment_GetPosition(t *testing.T) {
 	}
 }
 
+func TestLookupShape(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   NodeShape
+		wantOK bool
+	}{
+		{"cyl", ShapeCylinder, true},
+		{"database", ShapeCylinder, true},
+		{"trap-t", ShapeInvTrapezoid, true},
+		{"manual", ShapeInvTrapezoid, true},
+		{"tag-rect", ShapeTaggedRect, true},
+		{"Cyl", "", false},
+		{"blob", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := LookupShape(tt.name)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("LookupShape(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
 // Compile-time interface compliance checks
 var (
 	_ Statement = (*NodeDef)(nil)
@@ -94,5 +120,8 @@ var (
 	_ Statement = (*Subgraph)(nil)
 	_ Statement = (*ClassDef)(nil)
 	_ Statement = (*ClassAssignment)(nil)
+	_ Statement = (*Style)(nil)
+	_ Statement = (*LinkStyle)(nil)
+	_ Statement = (*Click)(nil)
 	_ Statement = (*Comment)(nil)
 )
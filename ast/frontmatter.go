@@ -0,0 +1,54 @@
+package ast
+
+// Metadata holds what every diagram type shares regardless of its syntax. It is
+// embedded in each diagram AST.
+type Metadata struct {
+	Frontmatter   *Frontmatter   // YAML frontmatter before the header, nil if absent
+	Directives    []*Directive   // %%{...}%% directives in source order
+	Accessibility *Accessibility // accTitle and accDescr statements, nil if there are none
+}
+
+// GetMetadata returns the metadata shared by all diagram types.
+func (m *Metadata) GetMetadata() *Metadata { return m }
+
+// Frontmatter is the YAML block, delimited by "---" lines, that may precede a
+// diagram header:
+//
+//	---
+//	title: Order flow
+//	config:
+//	  theme: forest
+//	---
+//	flowchart LR
+type Frontmatter struct {
+	Title  string         // Value of the title key
+	Config map[string]any // Value of the config key, nil if absent
+	Values map[string]any // Every top-level key, including title and config
+	Raw    string         // YAML text between the delimiters
+	Keys   []ConfigKey
+	Pos    Position // Position of the opening delimiter
+	End    Position // End of the closing delimiter
+
+	TitleSpan Span // Location of Title, excluding quotes
+}
+
+// ConfigKey records where a key appears in frontmatter or a directive.
+type ConfigKey struct {
+	Path string // Dotted path of the key, e.g. "config.theme"
+	Span Span   // Location of the key
+}
+
+// KeyPos returns the position of the key at the given dotted path, or the
+// position of the frontmatter itself if there is no such key.
+func (f *Frontmatter) KeyPos(path string) Position {
+	return keyPos(f.Keys, path, f.Pos)
+}
+
+func keyPos(keys []ConfigKey, path string, fallback Position) Position {
+	for _, k := range keys {
+		if k.Path == path {
+			return k.Span.Start
+		}
+	}
+	return fallback
+}
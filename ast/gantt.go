@@ -2,15 +2,24 @@ package ast
 
 // GanttDiagram represents a Gantt chart diagram AST.
 type GanttDiagram struct {
-	Type        string         // Always "gantt"
-	Title       string         // Optional title
-	DateFormat  string         // Date format (default YYYY-MM-DD)
-	AxisFormat  string         // Optional axis format for display
-	Excludes    string         // Excluded days (weekends, holidays, etc.)
-	TodayMarker string         // "on", "off", or colour value
-	Sections    []GanttSection // Sections with tasks
-	Source      string         // Original source
-	Pos         Position       // Position in source
+	Type        string              // Always "gantt"
+	Title       string              // Optional title
+	DateFormat  string              // Date format (default YYYY-MM-DD)
+	AxisFormat  string              // Optional axis format for display
+	Excludes    string              // Excluded days (weekends, holidays, etc.)
+	TodayMarker string              // "on", "off", or colour value
+	Sections    []GanttSection      // Sections with tasks
+	Unknown     []*UnknownStatement // Lines the parser could not recognise
+	Source      string              // Original source
+	Pos         Position            // Position in source
+	End         Position            // End of the diagram source
+	Metadata                        // Metadata shared by all diagram types
+
+	TitleSpan       Span // Location of Title
+	DateFormatSpan  Span // Location of DateFormat, unset when the default is used
+	AxisFormatSpan  Span // Location of AxisFormat
+	ExcludesSpan    Span // Location of Excludes
+	TodayMarkerSpan Span // Location of TodayMarker
 }
 
 // GanttSection represents a section within a Gantt chart.
@@ -18,6 +27,9 @@ type GanttSection struct {
 	Name  string      // Section name
 	Tasks []GanttTask // Tasks within this section
 	Pos   Position    // Position in source
+	End   Position    // End of the section line
+
+	NameSpan Span // Location of Name
 }
 
 // GanttTask represents a task within a Gantt section.
@@ -29,6 +41,10 @@ type GanttTask struct {
 	StartDate    string   // Start date or dependency reference
 	EndDate      string   // End date or duration (e.g., "10d")
 	Pos          Position // Position in source
+	End          Position // End of the task
+
+	NameSpan   Span // Location of Name
+	ParamsSpan Span // Location of the comma-separated task parameters
 }
 
 // GetType returns the diagram type.
@@ -7,6 +7,8 @@ type GenericDiagram struct {
 	Source      string   // Raw diagram source
 	Lines       []string // Split lines for line-based validation
 	Pos         Position // Position in source
+	End         Position // End of the diagram source
+	Metadata             // Metadata shared by all diagram types
 }
 
 // GetType returns the diagram type.
@@ -23,6 +25,7 @@ func NewGenericDiagram(diagramType, source string, pos Position) *GenericDiagram
 		Source:      source,
 		Lines:       lines,
 		Pos:         pos,
+		End:         SourceEnd(source),
 	}
 }
 
@@ -2,13 +2,16 @@ package ast
 
 // GitGraphDiagram represents a git graph diagram AST.
 type GitGraphDiagram struct {
-	Type            string         // Always "gitGraph"
-	Theme           string         // Optional theme
-	MainBranchName  string         // Optional main branch name (default "main")
-	MainBranchOrder int            // Optional main branch order
-	Operations      []GitOperation // All git operations (commits, branches, merges, etc.)
-	Source          string         // Original source
-	Pos             Position       // Position in source
+	Type            string              // Always "gitGraph"
+	Theme           string              // Optional theme
+	MainBranchName  string              // Optional main branch name (default "main")
+	MainBranchOrder int                 // Optional main branch order
+	Operations      []GitOperation      // All git operations (commits, branches, merges, etc.)
+	Unknown         []*UnknownStatement // Lines the parser could not recognise
+	Source          string              // Original source
+	Pos             Position            // Position in source
+	End             Position            // End of the diagram source
+	Metadata                            // Metadata shared by all diagram types
 }
 
 // GitOperation represents a single git operation.
@@ -21,6 +24,13 @@ type GitOperation struct {
 	Order      int      // Branch order (for branch operation)
 	ParentID   string   // Parent commit ID (for cherry-pick)
 	Pos        Position // Position in source
+	End        Position // End of the operation
+
+	IDSpan         Span // Location of ID, excluding quotes
+	TagSpan        Span // Location of Tag, excluding quotes
+	CommitTypeSpan Span // Location of CommitType
+	BranchNameSpan Span // Location of BranchName
+	ParentIDSpan   Span // Location of ParentID, excluding quotes
 }
 
 // GetType returns the diagram type.
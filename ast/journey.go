@@ -2,11 +2,16 @@ package ast
 
 // JourneyDiagram represents a user journey diagram AST.
 type JourneyDiagram struct {
-	Type     string    // Always "journey"
-	Title    string    // Optional title
-	Sections []Section // Journey sections
-	Source   string    // Original source
-	Pos      Position  // Position in source
+	Type     string              // Always "journey"
+	Title    string              // Optional title
+	Sections []Section           // Journey sections
+	Unknown  []*UnknownStatement // Lines the parser could not recognise
+	Source   string              // Original source
+	Pos      Position            // Position in source
+	End      Position            // End of the diagram source
+	Metadata                     // Metadata shared by all diagram types
+
+	TitleSpan Span // Location of Title
 }
 
 // Section represents a section within a user journey diagram.
@@ -14,6 +19,9 @@ type Section struct {
 	Name  string   // Section name
 	Tasks []Task   // Tasks within this section
 	Pos   Position // Position in source
+	End   Position // End of the section line
+
+	NameSpan Span // Location of Name
 }
 
 // Task represents a task within a journey section.
@@ -22,6 +30,11 @@ type Task struct {
 	Score  int      // Task score (1-5)
 	Actors []string // Actors involved in the task
 	Pos    Position // Position in source
+	End    Position // End of the task
+
+	NameSpan   Span   // Location of Name
+	ScoreSpan  Span   // Location of Score
+	ActorSpans []Span // Location of each entry in Actors
 }
 
 // GetType returns the diagram type.
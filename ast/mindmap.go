@@ -2,10 +2,12 @@ package ast
 
 // MindmapDiagram represents a mindmap diagram AST.
 type MindmapDiagram struct {
-	Type   string       // Always "mindmap"
-	Root   *MindmapNode // Root node (required)
-	Source string       // Original source
-	Pos    Position     // Position in source
+	Type     string       // Always "mindmap"
+	Root     *MindmapNode // Root node (required)
+	Source   string       // Original source
+	Pos      Position     // Position in source
+	End      Position     // End of the diagram source
+	Metadata              // Metadata shared by all diagram types
 }
 
 // MindmapNode represents a node in a mindmap diagram.
@@ -16,6 +18,10 @@ type MindmapNode struct {
 	Level    int            // Indentation level (0 for root)
 	Children []*MindmapNode // Child nodes
 	Pos      Position       // Position in source
+	End      Position       // End of the node line
+
+	TextSpan Span // Location of Text, excluding shape markers
+	IconSpan Span // Location of Icon
 }
 
 // GetType returns the diagram type.
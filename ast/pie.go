@@ -2,12 +2,17 @@ package ast
 
 // PieDiagram represents a pie chart diagram AST.
 type PieDiagram struct {
-	Type        string      // Always "pie"
-	Title       string      // Optional title
-	ShowData    bool        // Whether to show data values
-	DataEntries []PieEntry  // Data entries
-	Source      string      // Original source
-	Pos         Position    // Position in source
+	Type        string              // Always "pie"
+	Title       string              // Optional title
+	ShowData    bool                // Whether to show data values
+	DataEntries []PieEntry          // Data entries
+	Unknown     []*UnknownStatement // Lines the parser could not recognise
+	Source      string              // Original source
+	Pos         Position            // Position in source
+	End         Position            // End of the diagram source
+	Metadata                        // Metadata shared by all diagram types
+
+	TitleSpan Span // Location of Title
 }
 
 // PieEntry represents a single data entry in a pie chart.
@@ -15,6 +20,10 @@ type PieEntry struct {
 	Label string   // Entry label (must be quoted in source)
 	Value float64  // Numeric value (must be positive)
 	Pos   Position // Position in source
+	End   Position // End of the entry
+
+	LabelSpan Span // Location of Label, excluding quotes
+	ValueSpan Span // Location of Value
 }
 
 // GetType returns the diagram type.
@@ -2,20 +2,31 @@ package ast
 
 // QuadrantDiagram represents a quadrant chart diagram AST.
 type QuadrantDiagram struct {
-	Type           string          // Always "quadrantChart"
-	Title          string          // Optional title
-	XAxis          QuadrantAxis    // X-axis configuration
-	YAxis          QuadrantAxis    // Y-axis configuration
-	QuadrantLabels [4]string       // Labels for quadrants 1-4 (indexed 0-3)
-	Points         []QuadrantPoint // Data points
-	Source         string          // Original source
-	Pos            Position        // Position in source
+	Type           string              // Always "quadrantChart"
+	Title          string              // Optional title
+	XAxis          QuadrantAxis        // X-axis configuration
+	YAxis          QuadrantAxis        // Y-axis configuration
+	QuadrantLabels [4]string           // Labels for quadrants 1-4 (indexed 0-3)
+	Points         []QuadrantPoint     // Data points
+	Unknown        []*UnknownStatement // Lines the parser could not recognise
+	Source         string              // Original source
+	Pos            Position            // Position in source
+	End            Position            // End of the diagram source
+	Metadata                           // Metadata shared by all diagram types
+
+	TitleSpan          Span    // Location of Title
+	QuadrantLabelSpans [4]Span // Location of each entry in QuadrantLabels
 }
 
 // QuadrantAxis represents an axis definition in a quadrant chart.
 type QuadrantAxis struct {
-	Min string // Left/bottom label
-	Max string // Right/top label
+	Min string   // Left/bottom label
+	Max string   // Right/top label
+	Pos Position // Position in source
+	End Position // End of the axis line
+
+	MinSpan Span // Location of Min
+	MaxSpan Span // Location of Max
 }
 
 // QuadrantPoint represents a data point in a quadrant chart.
@@ -24,6 +35,11 @@ type QuadrantPoint struct {
 	X    float64  // X coordinate (0.0-1.0)
 	Y    float64  // Y coordinate (0.0-1.0)
 	Pos  Position // Position in source
+	End  Position // End of the point
+
+	NameSpan Span // Location of Name
+	XSpan    Span // Location of X
+	YSpan    Span // Location of Y
 }
 
 // GetType returns the diagram type.
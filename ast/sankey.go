@@ -2,10 +2,13 @@ package ast
 
 // SankeyDiagram represents a Sankey diagram AST.
 type SankeyDiagram struct {
-	Type   string       // Always "sankey"
-	Links  []SankeyLink // Flow links between nodes
-	Source string       // Original source
-	Pos    Position     // Position in source
+	Type     string              // Always "sankey"
+	Links    []SankeyLink        // Flow links between nodes
+	Unknown  []*UnknownStatement // Lines the parser could not recognise
+	Source   string              // Original source
+	Pos      Position            // Position in source
+	End      Position            // End of the diagram source
+	Metadata                     // Metadata shared by all diagram types
 }
 
 // SankeyLink represents a flow link between two nodes.
@@ -14,6 +17,11 @@ type SankeyLink struct {
 	Target string   // Target node name
 	Value  float64  // Flow value (must be positive)
 	Pos    Position // Position in source
+	End    Position // End of the link
+
+	SourceSpan Span // Location of Source
+	TargetSpan Span // Location of Target
+	ValueSpan  Span // Location of Value
 }
 
 // GetType returns the diagram type.
@@ -2,10 +2,12 @@ package ast
 
 // SequenceDiagram represents a complete Mermaid sequence diagram.
 type SequenceDiagram struct {
-	Type       string      // "sequence"
-	Statements []SeqStmt   // All statements in the diagram
-	Source     string      // Original source
-	Pos        Position    // Position in source
+	Type       string    // "sequence"
+	Statements []SeqStmt // All statements in the diagram
+	Source     string    // Original source
+	Pos        Position  // Position in source
+	End        Position  // End of the diagram source
+	Metadata             // Metadata shared by all diagram types
 }
 
 // GetType returns the diagram type.
@@ -25,10 +27,16 @@ type SeqStmt interface {
 
 // Participant represents a participant declaration.
 type Participant struct {
-	ID    string   // Participant identifier
-	Alias string   // Display name (optional)
-	Type  string   // "participant", "actor", "boundary", "control", "entity", "database", "collections", "queue"
-	Pos   Position
+	ID         string            // Participant identifier
+	Alias      string            // Display name (optional)
+	Type       string            // "participant", "actor", "boundary", "control", "entity", "database", "collections", "queue"
+	Properties map[string]string // Entries of an @{} block, as in participant DB@{ "type": "database" }
+	Pos        Position
+	End        Position
+
+	IDSpan    Span // Location of ID
+	AliasSpan Span // Location of Alias
+	TypeSpan  Span // Location of Type: the keyword, or the value in an @{} block, excluding quotes
 }
 
 func (p *Participant) seqStmt() {}
@@ -36,15 +44,49 @@ func (p *Participant) seqStmt() {}
 // GetPosition returns the position of this participant in the source.
 func (p *Participant) GetPosition() Position { return p.Pos }
 
+// Create represents a participant created part way through the diagram, as in
+// create participant Carl. Mermaid starts its lifeline at the next message.
+type Create struct {
+	Participant Participant // The participant created
+	Pos         Position
+	End         Position
+}
+
+func (c *Create) seqStmt() {}
+
+// GetPosition returns the position of this create statement in the source.
+func (c *Create) GetPosition() Position { return c.Pos }
+
+// Destroy represents the destruction of a participant, as in destroy Carl.
+// Mermaid ends its lifeline at the next message.
+type Destroy struct {
+	Participant string // Participant ID
+	Pos         Position
+	End         Position
+
+	ParticipantSpan Span // Location of Participant
+}
+
+func (d *Destroy) seqStmt() {}
+
+// GetPosition returns the position of this destroy statement in the source.
+func (d *Destroy) GetPosition() Position { return d.Pos }
+
 // Message represents a message between participants.
 type Message struct {
-	From   string   // Source participant ID
-	To     string   // Target participant ID
-	Arrow  string   // Arrow type: "->", "-->", "->>", "-->>", "-x", "--x", "-)", "--)", "<<->>", "<<-->>"
-	Text   string   // Message text (optional)
-	Activate   bool // Activate target on this message
-	Deactivate bool // Deactivate source on this message
-	Pos    Position
+	From       string // Source participant ID
+	To         string // Target participant ID
+	Arrow      string // Arrow type: "->", "-->", "->>", "-->>", "-x", "--x", "-)", "--)", "<<->>", "<<-->>"
+	Text       string // Message text (optional)
+	Activate   bool   // Activate target on this message
+	Deactivate bool   // Deactivate source on this message
+	Pos        Position
+	End        Position
+
+	FromSpan  Span // Location of From
+	ToSpan    Span // Location of To
+	ArrowSpan Span // Location of Arrow
+	TextSpan  Span // Location of Text
 }
 
 func (m *Message) seqStmt() {}
@@ -54,9 +96,12 @@ func (m *Message) GetPosition() Position { return m.Pos }
 
 // Activation represents explicit activation/deactivation.
 type Activation struct {
-	Participant string   // Participant ID
-	Active      bool     // true for activate, false for deactivate
+	Participant string // Participant ID
+	Active      bool   // true for activate, false for deactivate
 	Pos         Position
+	End         Position
+
+	ParticipantSpan Span // Location of Participant
 }
 
 func (a *Activation) seqStmt() {}
@@ -69,6 +114,9 @@ type Loop struct {
 	Label      string    // Loop description
 	Statements []SeqStmt // Nested statements
 	Pos        Position
+	End        Position // End of the closing 'end'
+
+	LabelSpan Span // Location of Label
 }
 
 func (l *Loop) seqStmt() {}
@@ -80,6 +128,7 @@ func (l *Loop) GetPosition() Position { return l.Pos }
 type Alt struct {
 	Conditions []AltCondition // Alt/else branches
 	Pos        Position
+	End        Position // End of the closing 'end'
 }
 
 // AltCondition represents one branch of an alt block.
@@ -87,6 +136,9 @@ type AltCondition struct {
 	Label      string    // Condition description
 	Statements []SeqStmt // Statements in this branch
 	IsElse     bool      // true for else branch
+	Pos        Position  // Position of the alt or else keyword
+
+	LabelSpan Span // Location of Label
 }
 
 func (a *Alt) seqStmt() {}
@@ -99,6 +151,9 @@ type Opt struct {
 	Label      string    // Condition description
 	Statements []SeqStmt // Nested statements
 	Pos        Position
+	End        Position // End of the closing 'end'
+
+	LabelSpan Span // Location of Label
 }
 
 func (o *Opt) seqStmt() {}
@@ -110,12 +165,16 @@ func (o *Opt) GetPosition() Position { return o.Pos }
 type Par struct {
 	Branches []ParBranch // Parallel branches
 	Pos      Position
+	End      Position // End of the closing 'end'
 }
 
 // ParBranch represents one parallel execution path.
 type ParBranch struct {
 	Label      string    // Branch description
 	Statements []SeqStmt // Statements in this branch
+	Pos        Position  // Position of the par or and keyword
+
+	LabelSpan Span // Location of Label
 }
 
 func (p *Par) seqStmt() {}
@@ -125,16 +184,22 @@ func (p *Par) GetPosition() Position { return p.Pos }
 
 // Critical represents a critical region block.
 type Critical struct {
-	Label      string    // Description
+	Label      string           // Description
 	Options    []CriticalOption // Critical option branches
-	Statements []SeqStmt // Main statements
+	Statements []SeqStmt        // Main statements
 	Pos        Position
+	End        Position // End of the closing 'end'
+
+	LabelSpan Span // Location of Label
 }
 
 // CriticalOption represents an option branch in a critical block.
 type CriticalOption struct {
 	Label      string    // Option description
 	Statements []SeqStmt // Statements in this option
+	Pos        Position  // Position of the option keyword
+
+	LabelSpan Span // Location of Label
 }
 
 func (c *Critical) seqStmt() {}
@@ -147,6 +212,9 @@ type Break struct {
 	Label      string    // Break description
 	Statements []SeqStmt // Nested statements
 	Pos        Position
+	End        Position // End of the closing 'end'
+
+	LabelSpan Span // Location of Label
 }
 
 func (b *Break) seqStmt() {}
@@ -154,12 +222,31 @@ func (b *Break) seqStmt() {}
 // GetPosition returns the position of this break block in the source.
 func (b *Break) GetPosition() Position { return b.Pos }
 
+// Rect represents a block drawn over a background colour, as in rect rgb(191, 223, 255).
+type Rect struct {
+	Colour     string    // Background colour as written
+	Statements []SeqStmt // Nested statements
+	Pos        Position
+	End        Position // End of the closing 'end'
+
+	ColourSpan Span // Location of Colour
+}
+
+func (r *Rect) seqStmt() {}
+
+// GetPosition returns the position of this rect block in the source.
+func (r *Rect) GetPosition() Position { return r.Pos }
+
 // Note represents a note attached to participants.
 type Note struct {
-	Position string   // "left of", "right of", "over"
+	Position     string   // "left of", "right of", "over"
 	Participants []string // Participant IDs
-	Text     string   // Note content
-	Pos      Position
+	Text         string   // Note content
+	Pos          Position
+	End          Position
+
+	ParticipantSpans []Span // Location of each entry in Participants
+	TextSpan         Span   // Location of Text
 }
 
 func (n *Note) seqStmt() {}
@@ -169,10 +256,14 @@ func (n *Note) GetPosition() Position { return n.Pos }
 
 // Box represents a grouping box around participants.
 type Box struct {
-	Colour     string        // Box colour (optional)
-	Label      string        // Box label
+	Colour       string        // Box colour (optional)
+	Label        string        // Box label
 	Participants []Participant // Participants in this box
-	Pos        Position
+	Pos          Position
+	End          Position // End of the closing 'end'
+
+	ColourSpan Span // Location of Colour
+	LabelSpan  Span // Location of Label
 }
 
 func (b *Box) seqStmt() {}
@@ -180,10 +271,61 @@ func (b *Box) seqStmt() {}
 // GetPosition returns the position of this box in the source.
 func (b *Box) GetPosition() Position { return b.Pos }
 
-// Autonumber represents the autonumber directive.
+// MenuLink is one entry in a participant's popup menu.
+type MenuLink struct {
+	Label string // Text of the menu entry
+	URL   string // Target of the link
+
+	LabelSpan Span // Location of Label, excluding any quotes
+	URLSpan   Span // Location of URL, excluding any quotes
+}
+
+// ParticipantLinks adds entries to a participant's popup menu. A link statement
+// adds one, as in link Alice: Dashboard @ https://example.com/alice, and a links
+// statement adds a JSON object of them, as in links Alice: {"Wiki": "https://..."}.
+type ParticipantLinks struct {
+	Participant string     // Participant ID
+	Links       []MenuLink // Entries in the order written
+	JSON        bool       // true for a links statement
+	Pos         Position
+	End         Position
+
+	ParticipantSpan Span // Location of Participant
+}
+
+func (l *ParticipantLinks) seqStmt() {}
+
+// GetPosition returns the position of this link statement in the source.
+func (l *ParticipantLinks) GetPosition() Position { return l.Pos }
+
+// ParticipantProperties sets properties of a participant from a JSON object, as
+// in properties Alice: {"class": "internal-service-actor", "icon": "@clipboard"}.
+type ParticipantProperties struct {
+	Participant string            // Participant ID
+	Properties  map[string]string // Entries of the JSON object
+	Pos         Position
+	End         Position
+
+	ParticipantSpan Span // Location of Participant
+	PropertiesSpan  Span // Location of the JSON object
+}
+
+func (p *ParticipantProperties) seqStmt() {}
+
+// GetPosition returns the position of this properties statement in the source.
+func (p *ParticipantProperties) GetPosition() Position { return p.Pos }
+
+// Autonumber represents the autonumber directive, as in autonumber, autonumber 10 5
+// or autonumber off.
 type Autonumber struct {
-	Enabled bool     // Enable/disable autonumbering
+	Enabled bool // Enable/disable autonumbering
+	Start   int  // Number of the next message, 1 if not given
+	Step    int  // Increment between messages, 1 if not given
 	Pos     Position
+	End     Position
+
+	StartSpan Span // Location of Start, unset if not given
+	StepSpan  Span // Location of Step, unset if not given
 }
 
 func (a *Autonumber) seqStmt() {}
@@ -195,6 +337,7 @@ func (a *Autonumber) GetPosition() Position { return a.Pos }
 type SeqComment struct {
 	Text string
 	Pos  Position
+	End  Position
 }
 
 func (c *SeqComment) seqStmt() {}
@@ -0,0 +1,171 @@
+package ast
+
+// NodeShape is the normalised shape of a flowchart node. Its value is the short
+// name Mermaid uses for the shape in an @{ shape: ... } block.
+type NodeShape string
+
+// Flowchart node shapes. The comment after each gives the bracket syntax, where
+// the shape has one.
+const (
+	ShapeRect               NodeShape = "rect"     // A[text], or no brackets
+	ShapeRounded            NodeShape = "rounded"  // A(text)
+	ShapeStadium            NodeShape = "stadium"  // A([text])
+	ShapeSubroutine         NodeShape = "fr-rect"  // A[[text]]
+	ShapeCylinder           NodeShape = "cyl"      // A[(text)]
+	ShapeCircle             NodeShape = "circle"   // A((text))
+	ShapeAsymmetric         NodeShape = "odd"      // A>text]
+	ShapeDiamond            NodeShape = "diam"     // A{text}
+	ShapeHexagon            NodeShape = "hex"      // A{{text}}
+	ShapeLeanRight          NodeShape = "lean-r"   // A[/text/]
+	ShapeLeanLeft           NodeShape = "lean-l"   // A[\text\]
+	ShapeTrapezoid          NodeShape = "trap-b"   // A[/text\]
+	ShapeInvTrapezoid       NodeShape = "trap-t"   // A[\text/]
+	ShapeDoubleCircle       NodeShape = "dbl-circ" // A(((text)))
+	ShapeText               NodeShape = "text"
+	ShapeNotchedRect        NodeShape = "notch-rect"
+	ShapeLinedRect          NodeShape = "lin-rect"
+	ShapeSmallCircle        NodeShape = "sm-circ"
+	ShapeFramedCircle       NodeShape = "fr-circ"
+	ShapeFork               NodeShape = "fork"
+	ShapeHourglass          NodeShape = "hourglass"
+	ShapeBraceLeft          NodeShape = "brace"
+	ShapeBraceRight         NodeShape = "brace-r"
+	ShapeBraces             NodeShape = "braces"
+	ShapeBolt               NodeShape = "bolt"
+	ShapeDocument           NodeShape = "doc"
+	ShapeDelay              NodeShape = "delay"
+	ShapeHorizontalCylinder NodeShape = "h-cyl"
+	ShapeLinedCylinder      NodeShape = "lin-cyl"
+	ShapeCurvedTrapezoid    NodeShape = "curv-trap"
+	ShapeDividedRect        NodeShape = "div-rect"
+	ShapeTriangle           NodeShape = "tri"
+	ShapeWindowPane         NodeShape = "win-pane"
+	ShapeFilledCircle       NodeShape = "f-circ"
+	ShapeLinedDocument      NodeShape = "lin-doc"
+	ShapeNotchedPentagon    NodeShape = "notch-pent"
+	ShapeFlippedTriangle    NodeShape = "flip-tri"
+	ShapeSlopedRect         NodeShape = "sl-rect"
+	ShapeDocuments          NodeShape = "docs"
+	ShapeStackedRect        NodeShape = "st-rect"
+	ShapeFlag               NodeShape = "flag"
+	ShapeBowTieRect         NodeShape = "bow-rect"
+	ShapeCrossedCircle      NodeShape = "cross-circ"
+	ShapeTaggedDocument     NodeShape = "tag-doc"
+	ShapeTaggedRect         NodeShape = "tag-rect"
+)
+
+// shapeAliases maps the other names Mermaid accepts for a shape to the shape.
+var shapeAliases = map[string]NodeShape{
+	"bow-tie-rectangle":      ShapeBowTieRect,
+	"brace-l":                ShapeBraceLeft,
+	"card":                   ShapeNotchedRect,
+	"circ":                   ShapeCircle,
+	"collate":                ShapeHourglass,
+	"com-link":               ShapeBolt,
+	"comment":                ShapeBraceLeft,
+	"crossed-circle":         ShapeCrossedCircle,
+	"curved-trapezoid":       ShapeCurvedTrapezoid,
+	"cylinder":               ShapeCylinder,
+	"das":                    ShapeHorizontalCylinder,
+	"database":               ShapeCylinder,
+	"db":                     ShapeCylinder,
+	"decision":               ShapeDiamond,
+	"diamond":                ShapeDiamond,
+	"disk":                   ShapeLinedCylinder,
+	"display":                ShapeCurvedTrapezoid,
+	"div-proc":               ShapeDividedRect,
+	"divided-process":        ShapeDividedRect,
+	"divided-rectangle":      ShapeDividedRect,
+	"document":               ShapeDocument,
+	"documents":              ShapeDocuments,
+	"double-circle":          ShapeDoubleCircle,
+	"event":                  ShapeRounded,
+	"extract":                ShapeTriangle,
+	"filled-circle":          ShapeFilledCircle,
+	"flipped-triangle":       ShapeFlippedTriangle,
+	"framed-circle":          ShapeFramedCircle,
+	"framed-rectangle":       ShapeSubroutine,
+	"half-rounded-rectangle": ShapeDelay,
+	"hexagon":                ShapeHexagon,
+	"horizontal-cylinder":    ShapeHorizontalCylinder,
+	"in-out":                 ShapeLeanRight,
+	"internal-storage":       ShapeWindowPane,
+	"inv-trapezoid":          ShapeInvTrapezoid,
+	"join":                   ShapeFork,
+	"junction":               ShapeFilledCircle,
+	"lean-left":              ShapeLeanLeft,
+	"lean-right":             ShapeLeanRight,
+	"lightning-bolt":         ShapeBolt,
+	"lin-proc":               ShapeLinedRect,
+	"lined-cylinder":         ShapeLinedCylinder,
+	"lined-document":         ShapeLinedDocument,
+	"lined-process":          ShapeLinedRect,
+	"lined-rectangle":        ShapeLinedRect,
+	"loop-limit":             ShapeNotchedPentagon,
+	"manual":                 ShapeInvTrapezoid,
+	"manual-file":            ShapeFlippedTriangle,
+	"manual-input":           ShapeSlopedRect,
+	"notched-pentagon":       ShapeNotchedPentagon,
+	"notched-rectangle":      ShapeNotchedRect,
+	"out-in":                 ShapeLeanLeft,
+	"paper-tape":             ShapeFlag,
+	"pill":                   ShapeStadium,
+	"prepare":                ShapeHexagon,
+	"priority":               ShapeTrapezoid,
+	"proc":                   ShapeRect,
+	"process":                ShapeRect,
+	"processes":              ShapeStackedRect,
+	"procs":                  ShapeStackedRect,
+	"question":               ShapeDiamond,
+	"rectangle":              ShapeRect,
+	"shaded-process":         ShapeLinedRect,
+	"sloped-rectangle":       ShapeSlopedRect,
+	"small-circle":           ShapeSmallCircle,
+	"st-doc":                 ShapeDocuments,
+	"stacked-document":       ShapeDocuments,
+	"stacked-rectangle":      ShapeStackedRect,
+	"start":                  ShapeSmallCircle,
+	"stop":                   ShapeFramedCircle,
+	"stored-data":            ShapeBowTieRect,
+	"subproc":                ShapeSubroutine,
+	"subprocess":             ShapeSubroutine,
+	"subroutine":             ShapeSubroutine,
+	"summary":                ShapeCrossedCircle,
+	"tag-proc":               ShapeTaggedRect,
+	"tagged-document":        ShapeTaggedDocument,
+	"tagged-process":         ShapeTaggedRect,
+	"tagged-rectangle":       ShapeTaggedRect,
+	"terminal":               ShapeStadium,
+	"trapezoid":              ShapeTrapezoid,
+	"trapezoid-bottom":       ShapeTrapezoid,
+	"trapezoid-top":          ShapeInvTrapezoid,
+	"triangle":               ShapeTriangle,
+	"window-pane":            ShapeWindowPane,
+}
+
+// shapes holds every NodeShape, to recognise short names.
+var shapes = map[NodeShape]bool{
+	ShapeRect: true, ShapeRounded: true, ShapeStadium: true, ShapeSubroutine: true,
+	ShapeCylinder: true, ShapeCircle: true, ShapeAsymmetric: true, ShapeDiamond: true,
+	ShapeHexagon: true, ShapeLeanRight: true, ShapeLeanLeft: true, ShapeTrapezoid: true,
+	ShapeInvTrapezoid: true, ShapeDoubleCircle: true, ShapeText: true, ShapeNotchedRect: true,
+	ShapeLinedRect: true, ShapeSmallCircle: true, ShapeFramedCircle: true, ShapeFork: true,
+	ShapeHourglass: true, ShapeBraceLeft: true, ShapeBraceRight: true, ShapeBraces: true,
+	ShapeBolt: true, ShapeDocument: true, ShapeDelay: true, ShapeHorizontalCylinder: true,
+	ShapeLinedCylinder: true, ShapeCurvedTrapezoid: true, ShapeDividedRect: true, ShapeTriangle: true,
+	ShapeWindowPane: true, ShapeFilledCircle: true, ShapeLinedDocument: true, ShapeNotchedPentagon: true,
+	ShapeFlippedTriangle: true, ShapeSlopedRect: true, ShapeDocuments: true, ShapeStackedRect: true,
+	ShapeFlag: true, ShapeBowTieRect: true, ShapeCrossedCircle: true, ShapeTaggedDocument: true,
+	ShapeTaggedRect: true,
+}
+
+// LookupShape returns the shape for a name used in an @{ shape: ... } block,
+// which may be the short name or one of its aliases, such as "database" for
+// ShapeCylinder. It reports false if Mermaid does not know the name.
+func LookupShape(name string) (NodeShape, bool) {
+	if shapes[NodeShape(name)] {
+		return NodeShape(name), true
+	}
+	shape, ok := shapeAliases[name]
+	return shape, ok
+}
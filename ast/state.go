@@ -6,6 +6,8 @@ type StateDiagram struct {
 	Statements []StateStmt // All statements in the diagram
 	Source     string      // Original source
 	Pos        Position    // Position in source
+	End        Position    // End of the diagram source
+	Metadata               // Metadata shared by all diagram types
 }
 
 // StateStmt is the interface for all state diagram statements.
@@ -16,11 +18,15 @@ type StateStmt interface {
 
 // State represents a state in the diagram.
 type State struct {
-	ID          string       // State ID
-	Description string       // State description/label
-	IsComposite bool         // true if this state contains nested states
-	Nested      []StateStmt  // Nested statements (for composite states)
+	ID          string      // State ID
+	Description string      // State description/label
+	IsComposite bool        // true if this state contains nested states
+	Nested      []StateStmt // Nested statements (for composite states)
 	Pos         Position
+	End         Position // End of the declaration, including any composite body
+
+	IDSpan          Span // Location of ID
+	DescriptionSpan Span // Location of the first line of Description, excluding quotes
 }
 
 func (s *State) stateStmt() {}
@@ -30,10 +36,15 @@ func (s *State) GetPosition() Position { return s.Pos }
 
 // Transition represents a transition between states.
 type Transition struct {
-	From      string   // Source state ID
-	To        string   // Target state ID
-	Label     string   // Transition label/condition
-	Pos       Position
+	From  string // Source state ID
+	To    string // Target state ID
+	Label string // Transition label/condition
+	Pos   Position
+	End   Position
+
+	FromSpan  Span // Location of From
+	ToSpan    Span // Location of To
+	LabelSpan Span // Location of Label
 }
 
 func (t *Transition) stateStmt() {}
@@ -43,8 +54,11 @@ func (t *Transition) GetPosition() Position { return t.Pos }
 
 // StartState represents the start state [*].
 type StartState struct {
-	To  string   // Target state after start
+	To  string // Target state after start
 	Pos Position
+	End Position
+
+	ToSpan Span // Location of To
 }
 
 func (s *StartState) stateStmt() {}
@@ -54,8 +68,11 @@ func (s *StartState) GetPosition() Position { return s.Pos }
 
 // EndState represents the end state transition.
 type EndState struct {
-	From string   // State transitioning to end
+	From string // State transitioning to end
 	Pos  Position
+	End  Position
+
+	FromSpan Span // Location of From
 }
 
 func (e *EndState) stateStmt() {}
@@ -65,8 +82,11 @@ func (e *EndState) GetPosition() Position { return e.Pos }
 
 // Fork represents a fork node for concurrent states.
 type Fork struct {
-	ID  string   // Fork ID
+	ID  string // Fork ID
 	Pos Position
+	End Position
+
+	IDSpan Span // Location of ID
 }
 
 func (f *Fork) stateStmt() {}
@@ -76,8 +96,11 @@ func (f *Fork) GetPosition() Position { return f.Pos }
 
 // Join represents a join node for concurrent states.
 type Join struct {
-	ID  string   // Join ID
+	ID  string // Join ID
 	Pos Position
+	End Position
+
+	IDSpan Span // Location of ID
 }
 
 func (j *Join) stateStmt() {}
@@ -87,8 +110,11 @@ func (j *Join) GetPosition() Position { return j.Pos }
 
 // Choice represents a choice node (conditional).
 type Choice struct {
-	ID  string   // Choice ID
+	ID  string // Choice ID
 	Pos Position
+	End Position
+
+	IDSpan Span // Location of ID
 }
 
 func (c *Choice) stateStmt() {}
@@ -98,10 +124,14 @@ func (c *Choice) GetPosition() Position { return c.Pos }
 
 // StateNote represents a note attached to a state.
 type StateNote struct {
-	StateID  string   // State the note is attached to
-	Text     string   // Note text
-	Position string   // "left of", "right of"
+	StateID  string // State the note is attached to
+	Text     string // Note text
+	Position string // "left of", "right of"
 	Pos      Position
+	End      Position
+
+	StateIDSpan Span // Location of StateID
+	TextSpan    Span // Location of Text
 }
 
 func (n *StateNote) stateStmt() {}
@@ -111,8 +141,9 @@ func (n *StateNote) GetPosition() Position { return n.Pos }
 
 // StateComment represents a comment in the state diagram.
 type StateComment struct {
-	Text string   // Comment text (without %%)
+	Text string // Comment text (without %%)
 	Pos  Position
+	End  Position
 }
 
 func (c *StateComment) stateStmt() {}
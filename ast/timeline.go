@@ -2,11 +2,16 @@ package ast
 
 // TimelineDiagram represents a timeline diagram AST.
 type TimelineDiagram struct {
-	Type     string            // Always "timeline"
-	Title    string            // Optional title
-	Sections []TimelineSection // Sections containing periods
-	Source   string            // Original source
-	Pos      Position          // Position in source
+	Type     string              // Always "timeline"
+	Title    string              // Optional title
+	Sections []TimelineSection   // Sections containing periods
+	Unknown  []*UnknownStatement // Lines the parser could not recognise
+	Source   string              // Original source
+	Pos      Position            // Position in source
+	End      Position            // End of the diagram source
+	Metadata                     // Metadata shared by all diagram types
+
+	TitleSpan Span // Location of Title
 }
 
 // TimelineSection represents a section in a timeline diagram.
@@ -14,6 +19,9 @@ type TimelineSection struct {
 	Name    string           // Section name (empty for default section)
 	Periods []TimelinePeriod // Time periods in this section
 	Pos     Position         // Position in source
+	End     Position         // End of the section line, unset for the default section
+
+	NameSpan Span // Location of Name
 }
 
 // TimelinePeriod represents a time period with associated events.
@@ -21,6 +29,10 @@ type TimelinePeriod struct {
 	TimePeriod string   // Time period text (e.g., "1940s", "Early Stage")
 	Events     []string // Events that occurred in this period
 	Pos        Position // Position in source
+	End        Position // End of the last event, including continuation lines
+
+	TimePeriodSpan Span   // Location of TimePeriod
+	EventSpans     []Span // Location of each entry in Events
 }
 
 // GetType returns the diagram type.
@@ -2,24 +2,35 @@ package ast
 
 // XYChartDiagram represents an XY chart diagram AST.
 type XYChartDiagram struct {
-	Type        string           // Always "xyChart"
-	Orientation string           // "horizontal" or "vertical" (default "vertical")
-	Title       string           // Optional title
-	XAxis       XYChartAxis      // X-axis configuration
-	YAxis       XYChartAxis      // Y-axis configuration
-	Series      []XYChartSeries  // Data series (bar, line)
-	Source      string           // Original source
-	Pos         Position         // Position in source
+	Type        string              // Always "xyChart"
+	Orientation string              // "horizontal" or "vertical" (default "vertical")
+	Title       string              // Optional title
+	XAxis       XYChartAxis         // X-axis configuration
+	YAxis       XYChartAxis         // Y-axis configuration
+	Series      []XYChartSeries     // Data series (bar, line)
+	Unknown     []*UnknownStatement // Lines the parser could not recognise
+	Source      string              // Original source
+	Pos         Position            // Position in source
+	End         Position            // End of the diagram source
+	Metadata                        // Metadata shared by all diagram types
+
+	TitleSpan Span // Location of Title, excluding quotes
 }
 
 // XYChartAxis represents an axis configuration in an XY chart.
 type XYChartAxis struct {
-	Label      string    // Axis label (optional)
-	Categories []string  // Category labels (for categorical axis)
-	Min        float64   // Minimum value (for numeric axis)
-	Max        float64   // Maximum value (for numeric axis)
-	IsNumeric  bool      // True if numeric, false if categorical
-	Pos        Position  // Position in source
+	Label      string   // Axis label (optional)
+	Categories []string // Category labels (for categorical axis)
+	Min        float64  // Minimum value (for numeric axis)
+	Max        float64  // Maximum value (for numeric axis)
+	IsNumeric  bool     // True if numeric, false if categorical
+	Pos        Position // Position in source
+	End        Position // End of the axis definition
+
+	LabelSpan     Span   // Location of Label, excluding quotes
+	CategorySpans []Span // Location of each entry in Categories
+	MinSpan       Span   // Location of Min
+	MaxSpan       Span   // Location of Max
 }
 
 // XYChartSeries represents a data series in an XY chart.
@@ -27,6 +38,9 @@ type XYChartSeries struct {
 	Type   string    // "bar" or "line"
 	Values []float64 // Data values
 	Pos    Position  // Position in source
+	End    Position  // End of the series definition
+
+	ValueSpans []Span // Location of each entry in Values
 }
 
 // GetType returns the diagram type.
@@ -2,16 +2,22 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/fatih/color"
 	mermaid "github.com/sammcj/mermaid-check"
 	"github.com/sammcj/mermaid-check/ast"
 	"github.com/sammcj/mermaid-check/extractor"
 	"github.com/sammcj/mermaid-check/internal/inpututil"
+	"github.com/sammcj/mermaid-check/parser"
+	"github.com/sammcj/mermaid-check/trace"
+	"github.com/sammcj/mermaid-check/validator"
 )
 
 const version = "0.1.0"
@@ -31,7 +37,10 @@ func main() {
 	// Define flags
 	var (
 		strict        = flag.Bool("strict", false, "use strict validation rules")
+		requireAcc    = flag.Bool("require-accessibility", false, "require accTitle and accDescr on every diagram")
+		lintFlag      = flag.String("lint", "", "comma-separated flowchart lint rules to run, or 'all'")
 		formatFlag    = flag.String("format", "", "force input format (mermaid or markdown)")
+		traceFlag     = flag.Bool("trace", false, "print the messages of each sequence diagram as JSON instead of validating")
 		errorOnEmpty  = flag.Bool("error-on-empty", false, "treat files with no Mermaid diagrams as errors")
 		showHelp      = flag.Bool("help", false, "show help message")
 		showVersion   = flag.Bool("version", false, "show version")
@@ -51,20 +60,29 @@ func main() {
 
 	// Determine input source
 	args := flag.Args()
+	if *traceFlag {
+		os.Exit(printTraces(args, *formatFlag))
+	}
+	lint, err := lintRules(*lintFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	checks := validation{strict: *strict, requireAccessibility: *requireAcc, lint: lint}
 	var exitCode int
 
 	if len(args) == 0 {
 		// Read from stdin
-		exitCode = processStdin(*formatFlag, *strict, *errorOnEmpty)
+		exitCode = processStdin(*formatFlag, checks, *errorOnEmpty)
 	} else {
 		// Process files
-		exitCode = processFiles(args, *strict, *errorOnEmpty)
+		exitCode = processFiles(args, checks, *errorOnEmpty)
 	}
 
 	os.Exit(exitCode)
 }
 
-func processStdin(format string, strict bool, errorOnEmpty bool) int {
+func processStdin(format string, checks validation, errorOnEmpty bool) int {
 	data, err := io.ReadAll(os.Stdin)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
@@ -104,10 +122,10 @@ func processStdin(format string, strict bool, errorOnEmpty bool) int {
 		// Collect statistics
 		stats := make(map[string]int)
 		for i, block := range blocks {
-			displayName := diagramTypeDisplayName(block.DiagramType)
+			displayName := parser.DisplayName(block.DiagramType)
 			fmt.Printf("\n--- Diagram %d - %s (%s, line %d) ---\n", i+1, displayName, block.DiagramType, block.LineOffset)
 			stats[block.DiagramType]++
-			if processBlock(&block, strict) {
+			if processBlock(&block, checks) {
 				hasErrors = true
 			}
 		}
@@ -123,14 +141,14 @@ func processStdin(format string, strict bool, errorOnEmpty bool) int {
 		// Parse as raw Mermaid
 		diagram, err := mermaid.Parse(content)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Parse error: %v\n", err)
+			printParseErrors(stdinName, 1, err)
 			return 1
 		}
 
 		diagramType := diagram.GetType()
-		displayName := diagramTypeDisplayName(diagramType)
+		displayName := parser.DisplayName(diagramType)
 		fmt.Printf("Diagram type: %s (%s)\n", displayName, diagramType)
-		if validateDiagram(diagram, strict, "") {
+		if validateDiagram(diagram, checks, "") {
 			hasErrors = true
 		}
 	}
@@ -149,6 +167,7 @@ type fileResult struct {
 	blocks       []blockResult
 	stats        map[string]int
 	errorMsg     string
+	parseErrors  []string
 }
 
 // blockResult represents the validation result for a single diagram block
@@ -156,6 +175,7 @@ type blockResult struct {
 	diagramType string
 	lineRange   string
 	isValid     bool
+	parseFailed bool
 	errors      []string
 	blockNum    int
 }
@@ -171,7 +191,7 @@ const (
 	resultUnsupportedType
 )
 
-func processFiles(paths []string, strict bool, errorOnEmpty bool) int {
+func processFiles(paths []string, checks validation, errorOnEmpty bool) int {
 	var hasErrors bool
 	results := make([]fileResult, 0, len(paths))
 
@@ -239,13 +259,14 @@ func processFiles(paths []string, strict bool, errorOnEmpty bool) int {
 				diagram, err := mermaid.Parse(block.Source)
 				if err != nil {
 					blockRes.isValid = false
-					blockRes.errors = []string{fmt.Sprintf("parse error: %v", err)}
+					blockRes.parseFailed = true
+					blockRes.errors = parseErrorMessages(path, block.LineOffset, err)
 					result.blocks = append(result.blocks, blockRes)
 					hasValidationErrors = true
 					continue
 				}
 
-				validationErrors := mermaid.Validate(diagram, strict)
+				validationErrors := checks.validate(diagram)
 				if len(validationErrors) == 0 {
 					blockRes.isValid = true
 				} else {
@@ -286,7 +307,7 @@ func processFiles(paths []string, strict bool, errorOnEmpty bool) int {
 			diagram, err := mermaid.Parse(content)
 			if err != nil {
 				result.resultType = resultParseError
-				result.errorMsg = err.Error()
+				result.parseErrors = parseErrorMessages(path, 1, err)
 				results = append(results, result)
 				hasErrors = true
 				continue
@@ -301,7 +322,7 @@ func processFiles(paths []string, strict bool, errorOnEmpty bool) int {
 				blockNum:    1,
 			}
 
-			validationErrors := mermaid.Validate(diagram, strict)
+			validationErrors := checks.validate(diagram)
 			if len(validationErrors) == 0 {
 				blockRes.isValid = true
 				result.resultType = resultSuccess
@@ -396,7 +417,12 @@ func printGroupedResults(results []fileResult, errorOnEmpty bool) {
 		// Print parse errors
 		if len(parseErrors) > 0 {
 			for _, r := range parseErrors {
-				fmt.Printf("  %s %s: %s\n", red("✗"), red(r.path), r.errorMsg)
+				if len(r.parseErrors) == 0 {
+					fmt.Printf("  %s %s: %s\n", red("✗"), red(r.path), r.errorMsg)
+				}
+				for _, msg := range r.parseErrors {
+					fmt.Printf("  %s %s\n", red("✗"), msg)
+				}
 			}
 		}
 
@@ -427,7 +453,7 @@ func printGroupedResults(results []fileResult, errorOnEmpty bool) {
 
 			for _, block := range r.blocks {
 				var prefix string
-				displayName := diagramTypeDisplayName(block.diagramType)
+				displayName := parser.DisplayName(block.diagramType)
 				if r.diagramCount > 1 {
 					prefix = fmt.Sprintf("  %s - %s %s: ",
 						bold(fmt.Sprintf("Diagram %d", block.blockNum)),
@@ -443,7 +469,11 @@ func printGroupedResults(results []fileResult, errorOnEmpty bool) {
 				if block.isValid {
 					fmt.Printf("%s%s %s\n", prefix, green("✓"), dim("Valid"))
 				} else {
-					fmt.Printf("%s%s %s:\n", prefix, red("✗"), red(fmt.Sprintf("%d validation error(s)", len(block.errors))))
+					kind := "validation"
+					if block.parseFailed {
+						kind = "syntax"
+					}
+					fmt.Printf("%s%s %s:\n", prefix, red("✗"), red(fmt.Sprintf("%d %s error(s)", len(block.errors), kind)))
 					for _, errMsg := range block.errors {
 						fmt.Printf("%s  %s\n", prefix, yellow(errMsg))
 					}
@@ -461,18 +491,96 @@ func printGroupedResults(results []fileResult, errorOnEmpty bool) {
 	}
 }
 
-func processBlock(block *extractor.DiagramBlock, strict bool) bool {
+func processBlock(block *extractor.DiagramBlock, checks validation) bool {
 	diagram, err := mermaid.Parse(block.Source)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Parse error: %v\n", err)
+		printParseErrors(stdinName, block.LineOffset, err)
 		return true
 	}
 
-	return validateDiagram(diagram, strict, "")
+	return validateDiagram(diagram, checks, "")
+}
+
+// stdinName is the file name used when reporting errors in standard input.
+const stdinName = "<stdin>"
+
+// parseErrorMessages returns one "file:line:col: message" string per syntax error
+// carried by err. lineOffset is the line of the file on which the parsed source starts.
+func parseErrorMessages(path string, lineOffset int, err error) []string {
+	list := parser.Errors(err)
+	if list == nil {
+		return []string{fmt.Sprintf("%s:%d:1: %v", path, lineOffset, err)}
+	}
+	msgs := make([]string, 0, len(list))
+	for _, e := range list {
+		line, col := e.Pos.Line, e.Pos.Column
+		if line == 0 {
+			// Errors about the diagram as a whole are reported at its start
+			line, col = 1, 1
+		}
+		msg := fmt.Sprintf("%s:%d:%d: %s", path, line+lineOffset-1, col, e.Message)
+		if e.Hint != "" {
+			msg += " (hint: " + e.Hint + ")"
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+// printParseErrors writes every syntax error carried by err to stderr.
+func printParseErrors(path string, lineOffset int, err error) {
+	for _, msg := range parseErrorMessages(path, lineOffset, err) {
+		fmt.Fprintln(os.Stderr, msg)
+	}
 }
 
-func validateDiagram(diagram ast.Diagram, strict bool, prefix string) bool {
-	errors := mermaid.Validate(diagram, strict)
+// validation holds the validation settings chosen on the command line.
+type validation struct {
+	strict               bool
+	requireAccessibility bool
+	lint                 []validator.Rule // Lint rules run on flowcharts
+}
+
+// validate runs the chosen validation rules on a diagram.
+func (v validation) validate(diagram ast.Diagram) []validator.ValidationError {
+	errors := mermaid.Validate(diagram, v.strict)
+	if v.requireAccessibility {
+		errors = append(errors, mermaid.ValidateAccessibility(diagram)...)
+	}
+	if flowchart, ok := diagram.(*ast.Flowchart); ok && len(v.lint) > 0 {
+		errors = append(errors, mermaid.ValidateFlowchart(flowchart, v.lint...)...)
+	}
+	return errors
+}
+
+// lintRules returns the lint rules named in the --lint flag. Lint rules that
+// are also default rules, such as no-undefined-classes, are left out as every
+// flowchart is already checked with them.
+func lintRules(names string) ([]validator.Rule, error) {
+	if names == "" {
+		return nil, nil
+	}
+	var rules []validator.Rule
+	if names == "all" {
+		rules = validator.LintRules()
+	} else {
+		for name := range strings.SplitSeq(names, ",") {
+			rule, ok := validator.LintRule(strings.TrimSpace(name))
+			if !ok {
+				return nil, fmt.Errorf("unknown lint rule %q", strings.TrimSpace(name))
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return slices.DeleteFunc(rules, func(rule validator.Rule) bool {
+		return slices.ContainsFunc(validator.DefaultRules(), func(d validator.Rule) bool {
+			return d.Name() == rule.Name()
+		})
+	}), nil
+}
+
+func validateDiagram(diagram ast.Diagram, checks validation, prefix string) bool {
+	errors := checks.validate(diagram)
 
 	if len(errors) == 0 {
 		fmt.Printf("%s%s %s\n", prefix, green("✓"), dim("Valid"))
@@ -502,39 +610,6 @@ func containsMarkdownFences(content string) bool {
 		contains(content, "``` mermaid"))
 }
 
-// diagramTypeDisplayName returns a user-friendly display name for a diagram type.
-func diagramTypeDisplayName(diagType string) string {
-	displayNames := map[string]string{
-		"flowchart":        "Flowchart",
-		"graph":            "Flow Chart",
-		"sequence":         "Sequence Diagram",
-		"class":            "Class Diagram",
-		"state":            "State Diagram",
-		"stateDiagram-v2":  "State Diagram",
-		"er":               "ER Diagram",
-		"gantt":            "Gantt Chart",
-		"pie":              "Pie Chart",
-		"journey":          "User Journey",
-		"timeline":         "Timeline",
-		"gitGraph":         "Git Graph",
-		"mindmap":          "Mindmap",
-		"sankey":           "Sankey Diagram",
-		"quadrantChart":    "Quadrant Chart",
-		"xyChart":          "XY Chart",
-		"c4Context":        "C4 Context Diagram",
-		"c4Container":      "C4 Container Diagram",
-		"c4Component":      "C4 Component Diagram",
-		"c4Dynamic":        "C4 Dynamic Diagram",
-		"c4Deployment":     "C4 Deployment Diagram",
-	}
-
-	if displayName, ok := displayNames[diagType]; ok {
-		return displayName
-	}
-	// Fallback to the original type if not in the map
-	return diagType
-}
-
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && findSubstring(s, substr)
 }
@@ -548,6 +623,99 @@ func findSubstring(s, substr string) bool {
 	return false
 }
 
+// diagramTrace is the message trace of one sequence diagram, as printed by --trace.
+type diagramTrace struct {
+	File   string        `json:"file"`
+	Line   int           `json:"line"` // Line of the file on which the diagram starts
+	Events []trace.Event `json:"events"`
+}
+
+// printTraces prints the message trace of every sequence diagram in the named
+// files, or in standard input if there are none, as a JSON array. Lines are
+// given in the file rather than the diagram. Other diagrams are skipped.
+func printTraces(paths []string, format string) int {
+	type input struct {
+		path   string
+		blocks []extractor.DiagramBlock
+	}
+	var inputs []input
+	exitCode := 0
+
+	if len(paths) == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+			return 1
+		}
+		content := string(data)
+		isMarkdown := format == "markdown" || (format == "" && containsCodeBlocks(content))
+		blocks, err := diagramBlocks(content, isMarkdown)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error extracting Mermaid blocks: %v\n", err)
+			return 1
+		}
+		inputs = append(inputs, input{stdinName, blocks})
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exitCode = 1
+			continue
+		}
+		content := string(data)
+		fileType := inpututil.DetectFileType(path)
+		isMarkdown := fileType == inpututil.FileTypeMarkdown ||
+			(fileType == inpututil.FileTypeMermaid && containsMarkdownFences(content))
+		blocks, err := diagramBlocks(content, isMarkdown)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			exitCode = 1
+			continue
+		}
+		inputs = append(inputs, input{path, blocks})
+	}
+
+	traces := []diagramTrace{}
+	for _, in := range inputs {
+		for _, block := range in.blocks {
+			diagram, err := mermaid.Parse(block.Source)
+			if err != nil {
+				printParseErrors(in.path, block.LineOffset, err)
+				exitCode = 1
+				continue
+			}
+			sequence, ok := diagram.(*ast.SequenceDiagram)
+			if !ok {
+				continue
+			}
+			events := trace.Flatten(sequence)
+			for i := range events {
+				events[i].Line += block.LineOffset - 1
+			}
+			traces = append(traces, diagramTrace{File: in.path, Line: block.LineOffset, Events: events})
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(traces); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing trace: %v\n", err)
+		return 1
+	}
+	return exitCode
+}
+
+// diagramBlocks returns the Mermaid diagrams in content: the fenced blocks of a
+// markdown document, or the whole of a Mermaid source.
+func diagramBlocks(content string, isMarkdown bool) ([]extractor.DiagramBlock, error) {
+	if isMarkdown {
+		return extractor.ExtractFromMarkdown(content)
+	}
+	return []extractor.DiagramBlock{{Source: content, LineOffset: 1}}, nil
+}
+
 func printHelp() {
 	fmt.Print(`mermaid-check - Mermaid diagram validator and linter
 
@@ -558,8 +726,14 @@ Flags:
   --help             Show this help message
   --version          Show version information
   --strict           Use strict validation rules (includes style checks)
+  --require-accessibility
+                     Require accTitle and accDescr on every diagram
+  --lint RULES       Run flowchart lint rules: a comma-separated list of rule
+                     names, or 'all'
   --error-on-empty   Treat files with no Mermaid diagrams as errors
   --format FORMAT    Force input format: 'mermaid' or 'markdown'
+  --trace            Print the messages of each sequence diagram as JSON
+                     instead of validating
 
 Examples:
   # Validate a Mermaid file
@@ -577,9 +751,18 @@ Examples:
   # Use strict rules
   mermaid-check --strict diagram.mmd
 
+  # Check every diagram in a document is accessible
+  mermaid-check --require-accessibility docs/*.md
+
+  # Report orphaned nodes and unused classes in flowcharts
+  mermaid-check --lint no-isolated-nodes,no-unused-classes diagram.mmd
+
   # Treat empty files as errors
   mermaid-check --error-on-empty *.md
 
+  # List who calls whom in each sequence diagram
+  mermaid-check --trace docs/api.md
+
 Exit codes:
   0 - All diagrams are valid (or no diagrams found unless --error-on-empty is set)
   1 - Validation errors found or processing failed
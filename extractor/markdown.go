@@ -5,6 +5,8 @@ import (
 	"bufio"
 	"fmt"
 	"strings"
+
+	"github.com/sammcj/mermaid-check/parser"
 )
 
 // DiagramBlock represents a Mermaid diagram extracted from a source file.
@@ -61,7 +63,7 @@ func ExtractFromMarkdown(markdown string) ([]DiagramBlock, error) {
 
 			// Only add non-empty blocks
 			if strings.TrimSpace(source) != "" {
-				diagramType := detectDiagramType(source)
+				diagramType := parser.DetectType(source)
 				blocks = append(blocks, DiagramBlock{
 					Source:      source,
 					LineOffset:  blockStartLine,
@@ -89,7 +91,7 @@ func ExtractFromMarkdown(markdown string) ([]DiagramBlock, error) {
 	if inMermaidBlock {
 		source := currentBlock.String()
 		if strings.TrimSpace(source) != "" {
-			diagramType := detectDiagramType(source)
+			diagramType := parser.DetectType(source)
 			blocks = append(blocks, DiagramBlock{
 				Source:      source,
 				LineOffset:  blockStartLine,
@@ -101,89 +103,3 @@ func ExtractFromMarkdown(markdown string) ([]DiagramBlock, error) {
 
 	return blocks, nil
 }
-
-// detectDiagramType attempts to determine the diagram type from the source.
-func detectDiagramType(source string) string {
-	lines := strings.SplitSeq(source, "\n")
-	for line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
-			continue // Skip empty lines and comments
-		}
-
-		// Check for diagram type keywords in order of specificity
-		// State diagrams - check v2 first to avoid matching base stateDiagram
-		if strings.HasPrefix(trimmed, "stateDiagram-v2") {
-			return "stateDiagram-v2"
-		}
-		if strings.HasPrefix(trimmed, "stateDiagram") {
-			return "state"
-		}
-
-		// C4 diagrams - multiple variants
-		if strings.HasPrefix(trimmed, "C4Context") {
-			return "c4Context"
-		}
-		if strings.HasPrefix(trimmed, "C4Container") {
-			return "c4Container"
-		}
-		if strings.HasPrefix(trimmed, "C4Component") {
-			return "c4Component"
-		}
-		if strings.HasPrefix(trimmed, "C4Dynamic") {
-			return "c4Dynamic"
-		}
-		if strings.HasPrefix(trimmed, "C4Deployment") {
-			return "c4Deployment"
-		}
-
-		// Other diagram types
-		if strings.HasPrefix(trimmed, "sequenceDiagram") {
-			return "sequence"
-		}
-		if strings.HasPrefix(trimmed, "classDiagram") {
-			return "class"
-		}
-		if strings.HasPrefix(trimmed, "erDiagram") {
-			return "er"
-		}
-		if strings.HasPrefix(trimmed, "gantt") {
-			return "gantt"
-		}
-		if strings.HasPrefix(trimmed, "pie") {
-			return "pie"
-		}
-		if strings.HasPrefix(trimmed, "journey") {
-			return "journey"
-		}
-		if strings.HasPrefix(trimmed, "gitGraph") {
-			return "gitGraph"
-		}
-		if strings.HasPrefix(trimmed, "mindmap") {
-			return "mindmap"
-		}
-		if strings.HasPrefix(trimmed, "timeline") {
-			return "timeline"
-		}
-		if strings.HasPrefix(trimmed, "sankey-beta") {
-			return "sankey"
-		}
-		if strings.HasPrefix(trimmed, "quadrantChart") {
-			return "quadrantChart"
-		}
-		if strings.HasPrefix(trimmed, "xychart-beta") {
-			return "xyChart"
-		}
-		if strings.HasPrefix(trimmed, "flowchart") {
-			return "flowchart"
-		}
-		if strings.HasPrefix(trimmed, "graph") {
-			return "graph"
-		}
-
-		// If we found a non-empty, non-comment line, stop looking
-		break
-	}
-
-	return "unknown"
-}
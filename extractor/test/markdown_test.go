@@ -185,6 +185,23 @@ flowchart TD
 	}
 }
 
+func TestExtractFromMarkdown_WithFrontmatter(t *testing.T) {
+	markdown := "```mermaid\n---\ntitle: pie chart\nconfig:\n  theme: forest\n---\nsequenceDiagram\n    A->>B: Hi\n```\n"
+
+	blocks, err := extractor.ExtractFromMarkdown(markdown)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(blocks) != 1 {
+		t.Fatalf("expected 1 block, got %d", len(blocks))
+	}
+
+	if blocks[0].DiagramType != "sequence" {
+		t.Errorf("expected diagram type 'sequence', got %q", blocks[0].DiagramType)
+	}
+}
+
 func TestExtractFromMarkdown_DifferentDiagramTypes(t *testing.T) {
 	tests := []struct {
 		name         string
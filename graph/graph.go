@@ -0,0 +1,499 @@
+// Package graph builds a directed graph model of a flowchart for analysis, such
+// as finding unreachable nodes, cycles or the shortest path between two nodes.
+//
+// Each link is an edge from its From node to its To node, whatever its arrow
+// heads, as that is the direction Mermaid lays it out in. Results list nodes in
+// the order they first appear in the diagram, so they are stable between runs.
+package graph
+
+import (
+	"container/heap"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
+
+// Node is a node of the flowchart, defined by a node statement or by appearing
+// in a link.
+type Node struct {
+	ID         string        // Node identifier
+	Label      string        // Label from the first definition with one, empty if the node has none
+	Shape      ast.NodeShape // Normalised shape from the first definition with one, ShapeRect if none is given
+	Subgraph   *Subgraph     // Subgraph the node belongs to, nil at the top level
+	IsSubgraph bool          // The node is a subgraph used as a link endpoint
+	Pos        ast.Position  // Where the node first appears
+}
+
+// Edge is a link between two nodes.
+type Edge struct {
+	From  string    // Source node ID
+	To    string    // Target node ID
+	Label string    // Link label (optional)
+	Link  *ast.Link // The link statement
+}
+
+// Subgraph is a subgraph of the flowchart.
+type Subgraph struct {
+	ID        string        // Subgraph identifier, empty for a quoted title
+	Title     string        // Subgraph title
+	Direction string        // Direction set inside the subgraph (optional)
+	Parent    *Subgraph     // Enclosing subgraph, nil at the top level
+	Nodes     []string      // IDs of the nodes that belong directly to the subgraph
+	Pos       ast.Position  // Position of the subgraph statement
+	Statement *ast.Subgraph // The subgraph statement
+}
+
+// Graph is a directed graph built from a flowchart. It is not modified after
+// New returns, so it is safe for concurrent use. Methods return new slices, but
+// the nodes, edges and subgraphs in them are shared and must not be modified.
+type Graph struct {
+	nodes     []*Node
+	index     map[string]int // Node ID to position in nodes
+	edges     []*Edge
+	out       [][]int // Successors of each node, by index, in link order
+	in        [][]int // Predecessors of each node, by index, in link order
+	subgraphs []*Subgraph
+}
+
+// New builds the graph of a flowchart, including the nodes and links nested in
+// subgraphs. As in Mermaid, a node mentioned in several subgraphs belongs to the
+// first one to close, so a nested subgraph takes precedence over its parent.
+func New(flowchart *ast.Flowchart) *Graph {
+	g := &Graph{index: make(map[string]int)}
+	b := &builder{
+		g:           g,
+		subgraphs:   make(map[*ast.Subgraph]*Subgraph),
+		subgraphIDs: make(map[string]*Subgraph),
+		member:      make(map[string]bool),
+		shaped:      make(map[string]bool),
+	}
+	b.collectSubgraphs(flowchart.Statements, nil)
+	b.walk(flowchart.Statements, nil)
+
+	for _, node := range g.nodes {
+		if node.Subgraph != nil {
+			node.Subgraph.Nodes = append(node.Subgraph.Nodes, node.ID)
+		}
+	}
+	return g
+}
+
+// builder holds the state used while building a graph.
+type builder struct {
+	g           *Graph
+	subgraphs   map[*ast.Subgraph]*Subgraph
+	subgraphIDs map[string]*Subgraph
+	member      map[string]bool // Nodes already assigned to a subgraph
+	shaped      map[string]bool // Nodes given a shape by a definition
+}
+
+// collectSubgraphs records every subgraph before the links are walked, as a link
+// may name a subgraph defined after it.
+func (b *builder) collectSubgraphs(statements []ast.Statement, parent *Subgraph) {
+	for _, stmt := range statements {
+		s, ok := stmt.(*ast.Subgraph)
+		if !ok {
+			continue
+		}
+		sg := &Subgraph{ID: s.ID, Title: s.Title, Direction: s.Direction, Parent: parent, Pos: s.Pos, Statement: s}
+		b.g.subgraphs = append(b.g.subgraphs, sg)
+		b.subgraphs[s] = sg
+		if s.ID != "" {
+			if _, exists := b.subgraphIDs[s.ID]; !exists {
+				b.subgraphIDs[s.ID] = sg
+			}
+		}
+		b.collectSubgraphs(s.Statements, sg)
+	}
+}
+
+// walk adds the nodes and edges in statements and returns the IDs of the nodes
+// mentioned, including in nested subgraphs.
+func (b *builder) walk(statements []ast.Statement, parent *Subgraph) []string {
+	var mentioned []string
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *ast.NodeDef:
+			node := b.node(s.ID, s.Pos)
+			if node.Label == "" && s.Label != "" {
+				node.Label = s.Label
+			}
+			if s.Shape != "" && !b.shaped[s.ID] {
+				b.shaped[s.ID] = true
+				node.Shape = s.Kind
+			}
+			mentioned = append(mentioned, s.ID)
+		case *ast.Link:
+			b.node(s.From, spanStart(s.FromSpan, s.Pos))
+			b.node(s.To, spanStart(s.ToSpan, s.Pos))
+			b.edge(s)
+			mentioned = append(mentioned, s.From, s.To)
+		case *ast.Subgraph:
+			nested := b.walk(s.Statements, b.subgraphs[s])
+			mentioned = append(mentioned, nested...)
+		}
+	}
+
+	if parent == nil {
+		return mentioned
+	}
+	for _, id := range mentioned {
+		node := b.g.nodes[b.g.index[id]]
+		if !node.IsSubgraph && !b.member[id] {
+			b.member[id] = true
+			node.Subgraph = parent
+		}
+	}
+	return mentioned
+}
+
+// node returns the node with the given ID, adding it if it is new.
+func (b *builder) node(id string, pos ast.Position) *Node {
+	if i, ok := b.g.index[id]; ok {
+		return b.g.nodes[i]
+	}
+	node := &Node{ID: id, Shape: ast.ShapeRect, Pos: pos}
+	if sg, ok := b.subgraphIDs[id]; ok {
+		node.IsSubgraph = true
+		node.Label = sg.Title
+		node.Subgraph = sg.Parent
+	}
+	b.g.index[id] = len(b.g.nodes)
+	b.g.nodes = append(b.g.nodes, node)
+	b.g.out = append(b.g.out, nil)
+	b.g.in = append(b.g.in, nil)
+	return node
+}
+
+func (b *builder) edge(link *ast.Link) {
+	from, to := b.g.index[link.From], b.g.index[link.To]
+	b.g.edges = append(b.g.edges, &Edge{From: link.From, To: link.To, Label: link.Label, Link: link})
+	b.g.out[from] = append(b.g.out[from], to)
+	b.g.in[to] = append(b.g.in[to], from)
+}
+
+func spanStart(span ast.Span, fallback ast.Position) ast.Position {
+	if span.IsValid() {
+		return span.Start
+	}
+	return fallback
+}
+
+// Nodes returns every node in the order they first appear.
+func (g *Graph) Nodes() []*Node { return slices.Clone(g.nodes) }
+
+// Node returns the node with the given ID.
+func (g *Graph) Node(id string) (*Node, bool) {
+	i, ok := g.index[id]
+	if !ok {
+		return nil, false
+	}
+	return g.nodes[i], true
+}
+
+// Edges returns every edge in link order.
+func (g *Graph) Edges() []*Edge { return slices.Clone(g.edges) }
+
+// Subgraphs returns every subgraph, each before the subgraphs nested in it.
+func (g *Graph) Subgraphs() []*Subgraph { return slices.Clone(g.subgraphs) }
+
+// Successors returns the IDs of the nodes id links to, without repeats.
+func (g *Graph) Successors(id string) []string {
+	i, ok := g.index[id]
+	if !ok {
+		return nil
+	}
+	return g.ids(unique(g.out[i]))
+}
+
+// Predecessors returns the IDs of the nodes that link to id, without repeats.
+func (g *Graph) Predecessors(id string) []string {
+	i, ok := g.index[id]
+	if !ok {
+		return nil
+	}
+	return g.ids(unique(g.in[i]))
+}
+
+// Sources returns the nodes no link points to, which are usually the start of
+// the flow.
+func (g *Graph) Sources() []string {
+	var sources []string
+	for i, node := range g.nodes {
+		if len(g.in[i]) == 0 {
+			sources = append(sources, node.ID)
+		}
+	}
+	return sources
+}
+
+// Sinks returns the nodes with no links out of them, which are usually the end
+// of the flow.
+func (g *Graph) Sinks() []string {
+	var sinks []string
+	for i, node := range g.nodes {
+		if len(g.out[i]) == 0 {
+			sinks = append(sinks, node.ID)
+		}
+	}
+	return sinks
+}
+
+// Reachable returns the nodes that can be reached from any of the from nodes by
+// following links, including the from nodes themselves. Unknown IDs are ignored.
+func (g *Graph) Reachable(from ...string) []string {
+	seen := g.reach(from)
+	var reachable []string
+	for i, node := range g.nodes {
+		if seen[i] {
+			reachable = append(reachable, node.ID)
+		}
+	}
+	return reachable
+}
+
+// Unreachable returns the nodes that cannot be reached from any of the from
+// nodes. Passing Sources finds nodes only reachable through a cycle.
+func (g *Graph) Unreachable(from ...string) []string {
+	seen := g.reach(from)
+	var unreachable []string
+	for i, node := range g.nodes {
+		if !seen[i] {
+			unreachable = append(unreachable, node.ID)
+		}
+	}
+	return unreachable
+}
+
+// reach marks the nodes reachable from the from nodes.
+func (g *Graph) reach(from []string) []bool {
+	seen := make([]bool, len(g.nodes))
+	var stack []int
+	for _, id := range from {
+		if i, ok := g.index[id]; ok && !seen[i] {
+			seen[i] = true
+			stack = append(stack, i)
+		}
+	}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, next := range g.out[i] {
+			if !seen[next] {
+				seen[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+	return seen
+}
+
+// ShortestPath returns the node IDs on a path from one node to another with the
+// fewest links, including both ends. It returns nil if there is no such path or
+// either node is unknown.
+func (g *Graph) ShortestPath(from, to string) []string {
+	start, ok := g.index[from]
+	if !ok {
+		return nil
+	}
+	end, ok := g.index[to]
+	if !ok {
+		return nil
+	}
+
+	prev := make([]int, len(g.nodes))
+	for i := range prev {
+		prev[i] = -1
+	}
+	prev[start] = start
+	queue := []int{start}
+	for len(queue) > 0 && prev[end] == -1 {
+		i := queue[0]
+		queue = queue[1:]
+		for _, next := range g.out[i] {
+			if prev[next] == -1 {
+				prev[next] = i
+				queue = append(queue, next)
+			}
+		}
+	}
+	if prev[end] == -1 {
+		return nil
+	}
+
+	path := []int{end}
+	for i := end; i != start; i = prev[i] {
+		path = append(path, prev[i])
+	}
+	slices.Reverse(path)
+	return g.ids(path)
+}
+
+// StronglyConnectedComponents returns the groups of nodes that can all reach one
+// another. Every node is in exactly one component. Components are in topological
+// order, so no link leads from a component to an earlier one, and the nodes of
+// each are in the order they first appear.
+func (g *Graph) StronglyConnectedComponents() [][]string {
+	t := &tarjan{
+		g:       g,
+		index:   make([]int, len(g.nodes)),
+		low:     make([]int, len(g.nodes)),
+		onStack: make([]bool, len(g.nodes)),
+	}
+	for i := range g.nodes {
+		if t.index[i] == 0 {
+			t.visit(i)
+		}
+	}
+
+	// Tarjan's algorithm finds the components in reverse topological order
+	var components [][]string
+	for _, c := range slices.Backward(t.components) {
+		slices.Sort(c)
+		components = append(components, g.ids(c))
+	}
+	return components
+}
+
+// tarjan holds the state of Tarjan's strongly connected components algorithm.
+// Indexes start at 1 so that 0 means a node has not been visited.
+type tarjan struct {
+	g          *Graph
+	next       int
+	index      []int
+	low        []int
+	onStack    []bool
+	stack      []int
+	components [][]int
+}
+
+func (t *tarjan) visit(i int) {
+	t.next++
+	t.index[i], t.low[i] = t.next, t.next
+	t.stack = append(t.stack, i)
+	t.onStack[i] = true
+
+	for _, next := range t.g.out[i] {
+		switch {
+		case t.index[next] == 0:
+			t.visit(next)
+			t.low[i] = min(t.low[i], t.low[next])
+		case t.onStack[next]:
+			t.low[i] = min(t.low[i], t.index[next])
+		}
+	}
+
+	if t.low[i] != t.index[i] {
+		return
+	}
+	var component []int
+	for {
+		top := t.stack[len(t.stack)-1]
+		t.stack = t.stack[:len(t.stack)-1]
+		t.onStack[top] = false
+		component = append(component, top)
+		if top == i {
+			break
+		}
+	}
+	t.components = append(t.components, component)
+}
+
+// Cycles returns the strongly connected components that contain a cycle: those
+// with more than one node, and single nodes that link to themselves.
+func (g *Graph) Cycles() [][]string {
+	var cycles [][]string
+	for _, component := range g.StronglyConnectedComponents() {
+		if len(component) > 1 || slices.Contains(g.Successors(component[0]), component[0]) {
+			cycles = append(cycles, component)
+		}
+	}
+	return cycles
+}
+
+// HasCycle reports whether any node can reach itself by following links.
+func (g *Graph) HasCycle() bool {
+	return len(g.Cycles()) > 0
+}
+
+// CycleError is returned by TopologicalOrder for a graph with a cycle.
+type CycleError struct {
+	Nodes []string // Nodes of one strongly connected component that contains a cycle
+}
+
+// Error implements the error interface.
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("graph has a cycle through %s", strings.Join(e.Nodes, ", "))
+}
+
+// TopologicalOrder returns every node ordered so that each link leads to a later
+// node. Where the order is free, nodes keep the order they first appear in. If
+// the graph has a cycle, it returns a *CycleError instead.
+func (g *Graph) TopologicalOrder() ([]string, error) {
+	inDegree := make([]int, len(g.nodes))
+	for i := range g.nodes {
+		inDegree[i] = len(g.in[i])
+	}
+
+	// Always take the earliest ready node, so the order is stable
+	ready := &indexHeap{}
+	for i := range g.nodes {
+		if inDegree[i] == 0 {
+			heap.Push(ready, i)
+		}
+	}
+	order := make([]int, 0, len(g.nodes))
+	for ready.Len() > 0 {
+		i := heap.Pop(ready).(int)
+		order = append(order, i)
+		for _, next := range g.out[i] {
+			inDegree[next]--
+			if inDegree[next] == 0 {
+				heap.Push(ready, next)
+			}
+		}
+	}
+	if len(order) < len(g.nodes) {
+		return nil, &CycleError{Nodes: g.Cycles()[0]}
+	}
+	return g.ids(order), nil
+}
+
+// indexHeap is a min-heap of node indexes.
+type indexHeap []int
+
+func (h indexHeap) Len() int           { return len(h) }
+func (h indexHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h indexHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *indexHeap) Push(x any)        { *h = append(*h, x.(int)) }
+func (h *indexHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// ids returns the node IDs for node indexes.
+func (g *Graph) ids(indexes []int) []string {
+	if len(indexes) == 0 {
+		return nil
+	}
+	ids := make([]string, len(indexes))
+	for i, index := range indexes {
+		ids[i] = g.nodes[index].ID
+	}
+	return ids
+}
+
+// unique returns indexes without repeats, keeping the first of each.
+func unique(indexes []int) []int {
+	var result []int
+	for _, i := range indexes {
+		if !slices.Contains(result, i) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
@@ -0,0 +1,266 @@
+package graph_test
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/sammcj/mermaid-check/ast"
+	"github.com/sammcj/mermaid-check/graph"
+	"github.com/sammcj/mermaid-check/parser"
+)
+
+// build parses a flowchart and builds its graph.
+func build(t *testing.T, source string) *graph.Graph {
+	t.Helper()
+	d, err := parser.NewFlowchartParser().Parse(source)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	return graph.New(d.(*ast.Flowchart))
+}
+
+func TestNew(t *testing.T) {
+	g := build(t, `flowchart LR
+    start([Begin]) --> check{OK?}
+    subgraph api [API Layer]
+        direction TB
+        check -->|yes| handler
+        subgraph inner
+            handler --> store[(Store)]
+        end
+    end
+    check -->|no| start
+    api --> done
+    store
+    store@{ shape: doc }`)
+
+	tests := []struct {
+		id         string
+		label      string
+		shape      ast.NodeShape
+		subgraph   string
+		isSubgraph bool
+	}{
+		{"start", "Begin", ast.ShapeStadium, "", false},
+		{"check", "OK?", ast.ShapeDiamond, "api", false},
+		{"handler", "", ast.ShapeRect, "inner", false},
+		{"store", "Store", ast.ShapeCylinder, "inner", false},
+		{"api", "API Layer", ast.ShapeRect, "", true},
+		{"done", "", ast.ShapeRect, "", false},
+	}
+	nodes := g.Nodes()
+	if len(nodes) != len(tests) {
+		t.Fatalf("expected %d nodes, got %d", len(tests), len(nodes))
+	}
+	for i, tt := range tests {
+		node := nodes[i]
+		var subgraph string
+		if node.Subgraph != nil {
+			subgraph = node.Subgraph.ID
+		}
+		if node.ID != tt.id || node.Label != tt.label || node.Shape != tt.shape || subgraph != tt.subgraph || node.IsSubgraph != tt.isSubgraph {
+			t.Errorf("node %d = {%s %q %s in %q subgraph %v}, want {%s %q %s in %q subgraph %v}",
+				i, node.ID, node.Label, node.Shape, subgraph, node.IsSubgraph, tt.id, tt.label, tt.shape, tt.subgraph, tt.isSubgraph)
+		}
+	}
+	if node, ok := g.Node("check"); !ok || node.Pos != (ast.Position{Line: 2, Column: 24, Offset: 36}) {
+		t.Errorf("Node(check) = %+v, want position 2:24", node)
+	}
+	if _, ok := g.Node("missing"); ok {
+		t.Error("expected Node to report an unknown ID")
+	}
+
+	var edges []string
+	for _, e := range g.Edges() {
+		edges = append(edges, e.From+">"+e.To+":"+e.Label)
+	}
+	want := []string{"start>check:", "check>handler:yes", "handler>store:", "check>start:no", "api>done:"}
+	if !reflect.DeepEqual(edges, want) {
+		t.Errorf("edges = %v, want %v", edges, want)
+	}
+
+	subgraphs := g.Subgraphs()
+	if len(subgraphs) != 2 {
+		t.Fatalf("expected 2 subgraphs, got %d", len(subgraphs))
+	}
+	if sg := subgraphs[0]; sg.ID != "api" || sg.Direction != "TB" || sg.Parent != nil || !reflect.DeepEqual(sg.Nodes, []string{"check"}) {
+		t.Errorf("unexpected first subgraph: %+v", sg)
+	}
+	if sg := subgraphs[1]; sg.ID != "inner" || sg.Parent != subgraphs[0] || !reflect.DeepEqual(sg.Nodes, []string{"handler", "store"}) {
+		t.Errorf("unexpected nested subgraph: %+v", sg)
+	}
+}
+
+func TestAccessorsReturnCopies(t *testing.T) {
+	g := build(t, "flowchart LR\n    subgraph s\n        A --> B\n    end\n    B --> C")
+
+	nodes := g.Nodes()
+	nodes[0] = nodes[1]
+	_ = append(nodes[:1], nodes[2])
+	if got := g.Nodes(); got[0].ID != "A" || got[1].ID != "B" {
+		t.Errorf("Nodes() changed by its caller: %v, %v", got[0].ID, got[1].ID)
+	}
+
+	edges := g.Edges()
+	slices.Reverse(edges)
+	if got := g.Edges(); got[0].From != "A" {
+		t.Errorf("Edges() changed by its caller: first edge from %s", got[0].From)
+	}
+
+	subgraphs := g.Subgraphs()
+	subgraphs[0] = nil
+	if got := g.Subgraphs(); got[0] == nil {
+		t.Error("Subgraphs() changed by its caller")
+	}
+}
+
+func TestNeighbours(t *testing.T) {
+	g := build(t, "flowchart LR\n    A --> B\n    A --> B\n    A --> C\n    C --> A")
+
+	tests := []struct {
+		id                       string
+		successors, predecessors []string
+	}{
+		{"A", []string{"B", "C"}, []string{"C"}},
+		{"B", nil, []string{"A"}},
+		{"C", []string{"A"}, []string{"A"}},
+		{"missing", nil, nil},
+	}
+	for _, tt := range tests {
+		if got := g.Successors(tt.id); !reflect.DeepEqual(got, tt.successors) {
+			t.Errorf("Successors(%s) = %v, want %v", tt.id, got, tt.successors)
+		}
+		if got := g.Predecessors(tt.id); !reflect.DeepEqual(got, tt.predecessors) {
+			t.Errorf("Predecessors(%s) = %v, want %v", tt.id, got, tt.predecessors)
+		}
+	}
+}
+
+func TestAnalysis(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		sources     []string
+		sinks       []string
+		unreachable []string
+		components  [][]string
+		cycles      [][]string
+		order       []string
+	}{
+		{
+			name:       "acyclic",
+			source:     "flowchart TD\n    A --> B & C\n    C --> D\n    B --> D\n    E",
+			sources:    []string{"A", "E"},
+			sinks:      []string{"D", "E"},
+			components: [][]string{{"E"}, {"A"}, {"C"}, {"B"}, {"D"}},
+			order:      []string{"A", "B", "C", "D", "E"},
+		},
+		{
+			name:        "cycle",
+			source:      "flowchart TD\n    A --> B\n    B --> C\n    C --> B\n    C --> D\n    E --> F\n    F --> E",
+			sources:     []string{"A"},
+			sinks:       []string{"D"},
+			unreachable: []string{"E", "F"},
+			components:  [][]string{{"E", "F"}, {"A"}, {"B", "C"}, {"D"}},
+			cycles:      [][]string{{"E", "F"}, {"B", "C"}},
+		},
+		{
+			name:        "self loop",
+			source:      "flowchart TD\n    A --> A\n    A --> B",
+			sinks:       []string{"B"},
+			unreachable: []string{"A", "B"},
+			components:  [][]string{{"A"}, {"B"}},
+			cycles:      [][]string{{"A"}},
+		},
+		{
+			name:   "empty",
+			source: "flowchart TD",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := build(t, tt.source)
+			if got := g.Sources(); !reflect.DeepEqual(got, tt.sources) {
+				t.Errorf("Sources() = %v, want %v", got, tt.sources)
+			}
+			if got := g.Sinks(); !reflect.DeepEqual(got, tt.sinks) {
+				t.Errorf("Sinks() = %v, want %v", got, tt.sinks)
+			}
+			if got := g.Unreachable(g.Sources()...); !reflect.DeepEqual(got, tt.unreachable) {
+				t.Errorf("Unreachable(Sources()...) = %v, want %v", got, tt.unreachable)
+			}
+			if got := g.StronglyConnectedComponents(); !reflect.DeepEqual(got, tt.components) {
+				t.Errorf("StronglyConnectedComponents() = %v, want %v", got, tt.components)
+			}
+			if got := g.Cycles(); !reflect.DeepEqual(got, tt.cycles) {
+				t.Errorf("Cycles() = %v, want %v", got, tt.cycles)
+			}
+			if got := g.HasCycle(); got != (tt.cycles != nil) {
+				t.Errorf("HasCycle() = %v, want %v", got, tt.cycles != nil)
+			}
+
+			order, err := g.TopologicalOrder()
+			if tt.cycles != nil {
+				var ce *graph.CycleError
+				if !errors.As(err, &ce) || !reflect.DeepEqual(ce.Nodes, tt.cycles[0]) {
+					t.Errorf("TopologicalOrder() error = %v, want a cycle through %v", err, tt.cycles[0])
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(order, tt.order) {
+				t.Errorf("TopologicalOrder() = %v, %v, want %v", order, err, tt.order)
+			}
+		})
+	}
+}
+
+func TestReachable(t *testing.T) {
+	g := build(t, "flowchart LR\n    A --> B --> C\n    D --> C\n    E")
+
+	tests := []struct {
+		from []string
+		want []string
+	}{
+		{[]string{"A"}, []string{"A", "B", "C"}},
+		{[]string{"D", "E"}, []string{"C", "D", "E"}},
+		{[]string{"C"}, []string{"C"}},
+		{[]string{"missing"}, nil},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		if got := g.Reachable(tt.from...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Reachable(%v) = %v, want %v", tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	g := build(t, "flowchart LR\n    A --> B --> C --> D\n    A --> E --> D\n    D --> A\n    F")
+
+	tests := []struct {
+		from, to string
+		want     []string
+	}{
+		{"A", "D", []string{"A", "E", "D"}},
+		{"B", "E", []string{"B", "C", "D", "A", "E"}},
+		{"A", "A", []string{"A"}},
+		{"A", "F", nil},
+		{"A", "missing", nil},
+		{"missing", "A", nil},
+	}
+	for _, tt := range tests {
+		if got := g.ShortestPath(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ShortestPath(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestCycleErrorMessage(t *testing.T) {
+	err := &graph.CycleError{Nodes: []string{"B", "C"}}
+	if got, want := err.Error(), "graph has a cycle through B, C"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -1,6 +1,7 @@
 package mermaid
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -14,10 +15,21 @@ import (
 
 // Parse parses a raw Mermaid diagram from a string.
 // Returns a Diagram interface that can be a Flowchart or GenericDiagram depending on type.
+//
+// If the diagram contains syntax errors, the partially parsed diagram is returned
+// together with a parser.ErrorList holding every error found. Use parser.Errors
+// to inspect them individually.
 func Parse(source string) (ast.Diagram, error) {
 	return parser.Parse(source)
 }
 
+// ParseWithOptions parses a raw Mermaid diagram from a string, enforcing the
+// resource limits in opts and stopping if ctx is cancelled. Use it for sources that
+// cannot be trusted. Exceeding a limit returns a *parser.LimitError.
+func ParseWithOptions(ctx context.Context, source string, opts parser.ParseOptions) (ast.Diagram, error) {
+	return parser.ParseWithOptions(ctx, source, opts)
+}
+
 // ParseReader parses a raw Mermaid diagram from an io.Reader.
 // Returns a Diagram interface that can be a Flowchart or GenericDiagram depending on type.
 func ParseReader(r io.Reader) (ast.Diagram, error) {
@@ -29,18 +41,19 @@ func ParseReader(r io.Reader) (ast.Diagram, error) {
 }
 
 // ParseFlowchart parses a flowchart/graph diagram specifically.
-// Use this if you need the full Flowchart AST.
+// Use this if you need the full Flowchart AST. As with Parse, a partial
+// flowchart is returned alongside any syntax errors.
 func ParseFlowchart(source string) (*ast.Flowchart, error) {
 	p := parser.NewFlowchartParser()
 	diagram, err := p.Parse(source)
-	if err != nil {
+	if diagram == nil {
 		return nil, err
 	}
 	flowchart, ok := diagram.(*ast.Flowchart)
 	if !ok {
 		return nil, fmt.Errorf("parsed diagram is not a flowchart: %T", diagram)
 	}
-	return flowchart, nil
+	return flowchart, err
 }
 
 // ParseFile parses a file containing Mermaid diagram(s).
@@ -50,6 +63,9 @@ func ParseFlowchart(source string) (*ast.Flowchart, error) {
 // - If a .mmd file contains markdown code fences, it's treated as markdown
 //
 // Returns a slice of diagrams (potentially multiple for markdown files).
+// Every Mermaid block is parsed even if earlier blocks contain syntax errors; all
+// errors are returned as a single parser.ErrorList with positions relative to the
+// file. Positions in the returned diagrams stay relative to their own block.
 func ParseFile(path string) ([]ast.Diagram, error) {
 	data, err := os.ReadFile(path) //nolint:gosec // User-provided file path is intentional
 	if err != nil {
@@ -68,10 +84,10 @@ func ParseFile(path string) ([]ast.Diagram, error) {
 	case inpututil.FileTypeMermaid:
 		// Parse as raw Mermaid
 		diagram, err := Parse(content)
-		if err != nil {
+		if diagram == nil {
 			return nil, err
 		}
-		return []ast.Diagram{diagram}, nil
+		return []ast.Diagram{diagram}, err
 
 	case inpututil.FileTypeMarkdown:
 		// Extract and parse Mermaid blocks from markdown
@@ -81,20 +97,57 @@ func ParseFile(path string) ([]ast.Diagram, error) {
 		}
 
 		var diagrams []ast.Diagram
+		var errs parser.ErrorList
+		starts := parser.LineStarts(content)
 		for _, block := range blocks {
 			diagram, err := Parse(block.Source)
 			if err != nil {
-				return nil, fmt.Errorf("error parsing Mermaid block at line %d: %w", block.LineOffset, err)
+				addBlockErrors(&errs, err, block.LineOffset, starts)
+			}
+			if diagram != nil {
+				diagrams = append(diagrams, diagram)
 			}
-			diagrams = append(diagrams, diagram)
 		}
-		return diagrams, nil
+		return diagrams, errs.Err()
 
 	default:
 		return nil, fmt.Errorf("unsupported file type for %s", path)
 	}
 }
 
+// addBlockErrors appends the syntax errors from a Mermaid block to errs, shifting
+// their positions so they are relative to the containing file. starts holds the
+// byte offset at which each line of the file starts.
+func addBlockErrors(errs *parser.ErrorList, err error, lineOffset int, starts []int) {
+	blockErrs := parser.Errors(err)
+	if blockErrs == nil {
+		errs.Add(filePosition(lineOffset, 1, starts), "%v", err)
+		return
+	}
+	for _, e := range blockErrs {
+		shifted := *e
+		if shifted.Pos.Line > 0 {
+			shifted.Pos = filePosition(shifted.Pos.Line+lineOffset-1, shifted.Pos.Column, starts)
+		} else {
+			shifted.Pos = filePosition(lineOffset, 1, starts)
+		}
+		if shifted.End.Line > 0 {
+			shifted.End = filePosition(shifted.End.Line+lineOffset-1, shifted.End.Column, starts)
+		}
+		*errs = append(*errs, &shifted)
+	}
+}
+
+// filePosition returns the position of a line and column in a file whose lines
+// start at the given byte offsets.
+func filePosition(line, column int, starts []int) ast.Position {
+	pos := ast.Position{Line: line, Column: column}
+	if line >= 1 && line <= len(starts) {
+		pos.Offset = starts[line-1] + column - 1
+	}
+	return pos
+}
+
 // containsMarkdownFences checks if the content contains markdown code fences.
 func containsMarkdownFences(content string) bool {
 	// Check for ```mermaid or ~~~mermaid code fences
@@ -119,8 +172,29 @@ func ExtractFromMarkdown(markdown string) ([]extractor.DiagramBlock, error) {
 }
 
 // Validate validates any diagram using the appropriate validator.
-// Automatically detects diagram type and applies corresponding rules.
+// Automatically detects diagram type and applies corresponding rules, or those
+// registered for the type with validator.Register.
+// Frontmatter and directives are checked for every diagram type.
 func Validate(diagram ast.Diagram, strict bool) []validator.ValidationError {
+	validationErrors := validateDiagram(diagram, strict)
+	if diagram != nil {
+		meta := diagram.GetMetadata()
+		errors := validator.ValidateFrontmatter(meta.Frontmatter)
+		errors = append(errors, validator.ValidateDirectives(meta.Directives)...)
+		for _, err := range errors {
+			validationErrors = append(validationErrors, *err)
+		}
+	}
+	return validationErrors
+}
+
+func validateDiagram(diagram ast.Diagram, strict bool) []validator.ValidationError {
+	if diagram != nil {
+		if validate, ok := validator.Lookup(diagram.GetType()); ok {
+			return validate(diagram, strict)
+		}
+	}
+
 	switch d := diagram.(type) {
 	case *ast.Flowchart:
 		var rules []validator.Rule
@@ -281,6 +355,13 @@ func ValidateFlowchart(diagram *ast.Flowchart, rules ...validator.Rule) []valida
 	return v.Validate(diagram)
 }
 
+// ValidateAccessibility checks that a diagram has both an accTitle and an accDescr.
+// Validate does not include this check, so it must be run separately where
+// accessibility is required.
+func ValidateAccessibility(diagram ast.Diagram) []validator.ValidationError {
+	return (&validator.RequireAccessibility{}).ValidateDiagram(diagram)
+}
+
 // Exported validation rules for convenience
 var (
 	// NoParenthesesInLabels is a validation rule that checks node labels don't contain parentheses.
@@ -291,6 +372,24 @@ var (
 	NoUndefinedNodes = &validator.NoUndefinedNodes{}
 	// NoDuplicateNodeIDs checks that node IDs are unique.
 	NoDuplicateNodeIDs = &validator.NoDuplicateNodeIDs{}
+	// ValidNodeShapes checks that node shapes are ones Mermaid knows.
+	ValidNodeShapes = &validator.ValidNodeShapes{}
+	// NoUndefinedReferences checks that style, click and class statements name existing nodes.
+	NoUndefinedReferences = &validator.NoUndefinedReferences{}
+	// ValidLinkStyleIndexes checks that linkStyle indexes refer to existing links.
+	ValidLinkStyleIndexes = &validator.ValidLinkStyleIndexes{}
+	// NoUndefinedClasses checks that classes applied to nodes are defined by a classDef.
+	NoUndefinedClasses = &validator.NoUndefinedClasses{}
+	// NoIsolatedNodes is a lint rule that checks every node has a link.
+	NoIsolatedNodes = &validator.NoIsolatedNodes{}
+	// NoUnreachableNodes is a lint rule that checks every node can be reached from a start node.
+	NoUnreachableNodes = &validator.NoUnreachableNodes{}
+	// NoUnexpectedCycles is a lint rule that checks flowcharts marked acyclic have no cycles.
+	NoUnexpectedCycles = &validator.NoUnexpectedCycles{}
+	// NoUnusedClasses is a lint rule that checks every classDef is applied to a node.
+	NoUnusedClasses = &validator.NoUnusedClasses{}
+	// NoEmptySubgraphs is a lint rule that checks subgraphs are not empty.
+	NoEmptySubgraphs = &validator.NoEmptySubgraphs{}
 )
 
 // DefaultRules returns the default set of validation rules.
@@ -302,3 +401,9 @@ func DefaultRules() []validator.Rule {
 func StrictRules() []validator.Rule {
 	return validator.StrictRules()
 }
+
+// LintRules returns the optional flowchart lint rules, which are in neither the
+// default nor the strict rules.
+func LintRules() []validator.Rule {
+	return validator.LintRules()
+}
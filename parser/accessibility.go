@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
+
+var (
+	accTitlePattern      = regexp.MustCompile(`^accTitle\s*:\s*(.*)$`)
+	accDescrPattern      = regexp.MustCompile(`^accDescr\s*:\s*(.*)$`)
+	accDescrBlockPattern = regexp.MustCompile(`^accDescr\s*\{(.*)$`)
+)
+
+// parseAccessibility collects the accTitle and accDescr statements in lines,
+// starting at lines[start], and blanks them out so the parsers for each diagram
+// type see only blank lines in their place. It returns nil if there are none.
+// A later statement replaces an earlier one, as in Mermaid.
+func parseAccessibility(lines []string, start int, errs *ErrorList) *ast.Accessibility {
+	var acc *ast.Accessibility
+	get := func() *ast.Accessibility {
+		if acc == nil {
+			acc = &ast.Accessibility{}
+		}
+		return acc
+	}
+
+	for i := start; i < len(lines); i++ {
+		lineNum := i + 1
+		if m := matchTrimmed(accTitlePattern, lines[i], lineNum); m != nil {
+			a := get()
+			a.Title, a.TitleSpan = m.trimmed(1)
+			lines[i] = ""
+			continue
+		}
+		if m := matchTrimmed(accDescrPattern, lines[i], lineNum); m != nil {
+			a := get()
+			a.Description, a.DescriptionSpan = m.trimmed(1)
+			lines[i] = ""
+			continue
+		}
+		m := matchTrimmed(accDescrBlockPattern, lines[i], lineNum)
+		if m == nil {
+			continue
+		}
+
+		// Collect the block up to the closing brace
+		end := -1
+		var parts []string
+		var spans []ast.Span
+		for j := i; j < len(lines); j++ {
+			text, s := m.groups[1], m.span(1)
+			if j > i {
+				text, s = lines[j], lineSpan(j+1, 0, len(lines[j]))
+			}
+			if k := strings.IndexByte(text, '}'); k >= 0 {
+				s.End.Column = s.Start.Column + k
+				text = text[:k]
+				end = j
+			}
+			if part := strings.TrimSpace(text); part != "" {
+				parts = append(parts, part)
+				spans = append(spans, narrow(s, text, part))
+			}
+			if end >= 0 {
+				break
+			}
+		}
+		if end < 0 {
+			e := errs.addLine(lineNum, lines[i], "unclosed accDescr block, missing '}'")
+			e.Expected = "'}'"
+			lines[i] = ""
+			continue
+		}
+
+		a := get()
+		a.Description = strings.Join(parts, "\n")
+		a.DescriptionSpan = ast.Span{}
+		if len(spans) > 0 {
+			a.DescriptionSpan = ast.Span{Start: spans[0].Start, End: spans[len(spans)-1].End}
+		}
+		for j := i; j <= end; j++ {
+			lines[j] = ""
+		}
+		i = end
+	}
+	return acc
+}
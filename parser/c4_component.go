@@ -1,6 +1,10 @@
 package parser
 
-import "github.com/sammcj/mermaid-check/ast"
+import (
+	"context"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
 
 // C4ComponentParser parses C4 Component diagrams.
 type C4ComponentParser struct{}
@@ -12,7 +16,12 @@ func NewC4ComponentParser() *C4ComponentParser {
 
 // Parse parses a C4 Component diagram and returns a C4Diagram AST.
 func (p *C4ComponentParser) Parse(source string) (ast.Diagram, error) {
-	return parseC4Diagram(source, "c4Component", "C4Component")
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses a C4 Component diagram within the limits in opts.
+func (p *C4ComponentParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseC4Diagram(ctx, source, opts, "c4Component", "C4Component")
 }
 
 // SupportedTypes returns the diagram types this parser supports.
@@ -1,6 +1,10 @@
 package parser
 
-import "github.com/sammcj/mermaid-check/ast"
+import (
+	"context"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
 
 // C4ContainerParser parses C4 Container diagrams.
 type C4ContainerParser struct{}
@@ -12,7 +16,12 @@ func NewC4ContainerParser() *C4ContainerParser {
 
 // Parse parses a C4 Container diagram and returns a C4Diagram AST.
 func (p *C4ContainerParser) Parse(source string) (ast.Diagram, error) {
-	return parseC4Diagram(source, "c4Container", "C4Container")
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses a C4 Container diagram within the limits in opts.
+func (p *C4ContainerParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseC4Diagram(ctx, source, opts, "c4Container", "C4Container")
 }
 
 // SupportedTypes returns the diagram types this parser supports.
@@ -1,7 +1,7 @@
 package parser
 
 import (
-	"fmt"
+	"context"
 	"regexp"
 	"strings"
 
@@ -34,7 +34,12 @@ func NewC4ContextParser() *C4ContextParser {
 
 // Parse parses a C4 Context diagram and returns a C4Diagram AST.
 func (p *C4ContextParser) Parse(source string) (ast.Diagram, error) {
-	return parseC4Diagram(source, "c4Context", "C4Context")
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses a C4 Context diagram within the limits in opts.
+func (p *C4ContextParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseC4Diagram(ctx, source, opts, "c4Context", "C4Context")
 }
 
 // SupportedTypes returns the diagram types this parser supports.
@@ -43,16 +48,28 @@ func (p *C4ContextParser) SupportedTypes() []string {
 }
 
 // parseC4Diagram is a shared parser for all C4 diagram types.
-func parseC4Diagram(source, diagramType, expectedHeader string) (*ast.C4Diagram, error) {
+func parseC4Diagram(ctx context.Context, source string, opts ParseOptions, diagramType, expectedHeader string) (ast.Diagram, error) {
+	return parseLimited(ctx, source, opts, func(lim *limiter) (ast.Diagram, error) {
+		return parseC4Source(source, diagramType, expectedHeader, lim)
+	})
+}
+
+func parseC4Source(source, diagramType, expectedHeader string, lim *limiter) (ast.Diagram, error) {
 	lines := strings.Split(source, "\n")
 	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty diagram source")
+		return nil, emptySourceError(diagramType)
+	}
+
+	var errs ErrorList
+	meta, header, err := parseMetadata(lines, diagramType, &errs)
+	if err != nil {
+		return nil, err
 	}
 
 	// Check header
-	firstLine := strings.TrimSpace(lines[0])
+	firstLine := strings.TrimSpace(lines[header])
 	if firstLine != expectedHeader {
-		return nil, fmt.Errorf("expected %s header, got: %s", expectedHeader, firstLine)
+		return nil, headerError(diagramType, header+1, lines[header], "'"+expectedHeader+"'")
 	}
 
 	diagram := &ast.C4Diagram{
@@ -62,21 +79,19 @@ func parseC4Diagram(source, diagramType, expectedHeader string) (*ast.C4Diagram,
 		Relationships: []ast.C4Relationship{},
 		Styles:        []ast.C4Style{},
 		Source:        source,
-		Pos:           ast.Position{Line: 1, Column: 1},
+		Pos:           linePos(header+1, lines[header]),
+		End:           ast.SourceEnd(source),
+		Metadata:      meta,
 	}
 
 	// Parse body (skip header)
-	var err error
-	diagram.Boundaries, err = parseC4Body(lines[1:], 2, diagram)
-	if err != nil {
-		return nil, err
-	}
+	diagram.Boundaries = parseC4Body(lines[header+1:], header+2, diagram, &errs, lim)
 
-	return diagram, nil
+	return diagram, errs.errFor(diagram.GetType())
 }
 
 // parseC4Body parses the body of a C4 diagram, handling nested boundaries.
-func parseC4Body(lines []string, startLine int, diagram *ast.C4Diagram) ([]ast.C4Boundary, error) {
+func parseC4Body(lines []string, startLine int, diagram *ast.C4Diagram, errs *ErrorList, lim *limiter) []ast.C4Boundary {
 	var boundaries []ast.C4Boundary
 	i := 0
 
@@ -90,21 +105,26 @@ func parseC4Body(lines []string, startLine int, diagram *ast.C4Diagram) ([]ast.C
 			i++
 			continue
 		}
+		if !lim.statement(lineNum) {
+			break
+		}
 
 		// Parse title
-		if matches := c4TitlePattern.FindStringSubmatch(trimmed); matches != nil {
-			diagram.Title = strings.TrimSpace(matches[1])
+		if m := matchTrimmed(c4TitlePattern, line, lineNum); m != nil {
+			diagram.Title, diagram.TitleSpan = m.trimmed(1)
 			i++
 			continue
 		}
 
 		// Parse boundary start
-		if matches := c4BoundaryStartPattern.FindStringSubmatch(trimmed); matches != nil {
-			boundaryType := matches[1]
-			params := parseC4Parameters(matches[2])
+		if m := matchTrimmed(c4BoundaryStartPattern, line, lineNum); m != nil {
+			boundaryType := m.groups[1]
+			params, spans := parseC4Parameters(m.groups[2], m.span(2))
 
 			if len(params) < 2 {
-				return nil, fmt.Errorf("line %d: boundary requires at least id and label", lineNum)
+				errs.addLine(lineNum, line, "boundary requires at least id and label")
+				i += c4BoundaryLength(lines[i:])
+				continue
 			}
 
 			boundary := ast.C4Boundary{
@@ -113,7 +133,8 @@ func parseC4Body(lines []string, startLine int, diagram *ast.C4Diagram) ([]ast.C
 				Label:        params[1],
 				Elements:     []ast.C4Element{},
 				Boundaries:   []ast.C4Boundary{},
-				Pos:          ast.Position{Line: lineNum, Column: 1},
+				Pos:          linePos(lineNum, line),
+				Params:       spans,
 			}
 
 			// For generic Boundary, third parameter is type
@@ -136,16 +157,20 @@ func parseC4Body(lines []string, startLine int, diagram *ast.C4Diagram) ([]ast.C
 			}
 
 			if depth > 0 {
-				return nil, fmt.Errorf("line %d: unclosed boundary %s", lineNum, boundary.ID)
+				e := errs.addLine(lineNum, line, "unclosed boundary %s", boundary.ID)
+				e.Expected = "'}'"
 			}
 
+			last := min(boundaryEnd, len(lines)-1)
+			boundary.End = lineEnd(startLine+last, lines[last])
+
 			// Parse boundary contents recursively
-			boundaryLines := lines[i+1 : boundaryEnd]
-			nestedBoundaries, err := parseC4BoundaryContents(boundaryLines, lineNum+1, diagram, &boundary)
-			if err != nil {
-				return nil, err
+			if !lim.enter(lineNum) {
+				break
 			}
-			boundary.Boundaries = nestedBoundaries
+			boundaryLines := lines[i+1 : boundaryEnd]
+			boundary.Boundaries = parseC4BoundaryContents(boundaryLines, lineNum+1, diagram, &boundary, errs, lim)
+			lim.leave()
 
 			boundaries = append(boundaries, boundary)
 			i = boundaryEnd + 1
@@ -153,35 +178,36 @@ func parseC4Body(lines []string, startLine int, diagram *ast.C4Diagram) ([]ast.C
 		}
 
 		// Parse elements
-		if elem, ok := parseC4Element(trimmed, lineNum); ok {
+		if elem, ok := parseC4Element(line, lineNum); ok {
 			diagram.Elements = append(diagram.Elements, elem)
 			i++
 			continue
 		}
 
 		// Parse relationships
-		if rel, ok := parseC4Relationship(trimmed, lineNum); ok {
+		if rel, ok := parseC4Relationship(line, lineNum); ok {
 			diagram.Relationships = append(diagram.Relationships, rel)
 			i++
 			continue
 		}
 
 		// Parse styles
-		if style, ok := parseC4Style(trimmed, lineNum); ok {
+		if style, ok := parseC4Style(line, lineNum); ok {
 			diagram.Styles = append(diagram.Styles, style)
 			i++
 			continue
 		}
 
-		// Unknown line
-		return nil, fmt.Errorf("line %d: unrecognised C4 syntax: %s", lineNum, trimmed)
+		// Keep lines we can't parse so validators can report them
+		diagram.Unknown = append(diagram.Unknown, unknownStatement(lineNum, line))
+		i++
 	}
 
-	return boundaries, nil
+	return boundaries
 }
 
 // parseC4BoundaryContents parses the contents of a boundary (elements and nested boundaries).
-func parseC4BoundaryContents(lines []string, startLine int, diagram *ast.C4Diagram, boundary *ast.C4Boundary) ([]ast.C4Boundary, error) {
+func parseC4BoundaryContents(lines []string, startLine int, diagram *ast.C4Diagram, boundary *ast.C4Boundary, errs *ErrorList, lim *limiter) []ast.C4Boundary {
 	var boundaries []ast.C4Boundary
 	i := 0
 
@@ -195,14 +221,19 @@ func parseC4BoundaryContents(lines []string, startLine int, diagram *ast.C4Diagr
 			i++
 			continue
 		}
+		if !lim.statement(lineNum) {
+			break
+		}
 
 		// Parse nested boundary
-		if matches := c4BoundaryStartPattern.FindStringSubmatch(trimmed); matches != nil {
-			boundaryType := matches[1]
-			params := parseC4Parameters(matches[2])
+		if m := matchTrimmed(c4BoundaryStartPattern, line, lineNum); m != nil {
+			boundaryType := m.groups[1]
+			params, spans := parseC4Parameters(m.groups[2], m.span(2))
 
 			if len(params) < 2 {
-				return nil, fmt.Errorf("line %d: boundary requires at least id and label", lineNum)
+				errs.addLine(lineNum, line, "boundary requires at least id and label")
+				i += c4BoundaryLength(lines[i:])
+				continue
 			}
 
 			nestedBoundary := ast.C4Boundary{
@@ -211,7 +242,8 @@ func parseC4BoundaryContents(lines []string, startLine int, diagram *ast.C4Diagr
 				Label:        params[1],
 				Elements:     []ast.C4Element{},
 				Boundaries:   []ast.C4Boundary{},
-				Pos:          ast.Position{Line: lineNum, Column: 1},
+				Pos:          linePos(lineNum, line),
+				Params:       spans,
 			}
 
 			if boundaryType == "Boundary" && len(params) >= 3 {
@@ -233,16 +265,20 @@ func parseC4BoundaryContents(lines []string, startLine int, diagram *ast.C4Diagr
 			}
 
 			if depth > 0 {
-				return nil, fmt.Errorf("line %d: unclosed boundary %s", lineNum, nestedBoundary.ID)
+				e := errs.addLine(lineNum, line, "unclosed boundary %s", nestedBoundary.ID)
+				e.Expected = "'}'"
 			}
 
+			last := min(boundaryEnd, len(lines)-1)
+			nestedBoundary.End = lineEnd(startLine+last, lines[last])
+
 			// Parse nested boundary contents
-			nestedLines := lines[i+1 : boundaryEnd]
-			nestedNestedBoundaries, err := parseC4BoundaryContents(nestedLines, lineNum+1, diagram, &nestedBoundary)
-			if err != nil {
-				return nil, err
+			if !lim.enter(lineNum) {
+				break
 			}
-			nestedBoundary.Boundaries = nestedNestedBoundaries
+			nestedLines := lines[i+1 : boundaryEnd]
+			nestedBoundary.Boundaries = parseC4BoundaryContents(nestedLines, lineNum+1, diagram, &nestedBoundary, errs, lim)
+			lim.leave()
 
 			boundaries = append(boundaries, nestedBoundary)
 			i = boundaryEnd + 1
@@ -250,31 +286,53 @@ func parseC4BoundaryContents(lines []string, startLine int, diagram *ast.C4Diagr
 		}
 
 		// Parse elements in boundary
-		if elem, ok := parseC4Element(trimmed, lineNum); ok {
+		if elem, ok := parseC4Element(line, lineNum); ok {
 			boundary.Elements = append(boundary.Elements, elem)
 			i++
 			continue
 		}
 
 		// Parse relationships in boundary
-		if rel, ok := parseC4Relationship(trimmed, lineNum); ok {
+		if rel, ok := parseC4Relationship(line, lineNum); ok {
 			diagram.Relationships = append(diagram.Relationships, rel)
 			i++
 			continue
 		}
 
-		// Unknown line in boundary
-		return nil, fmt.Errorf("line %d: unrecognised C4 syntax in boundary: %s", lineNum, trimmed)
+		// Keep lines we can't parse so validators can report them
+		diagram.Unknown = append(diagram.Unknown, unknownStatement(lineNum, line))
+		i++
 	}
 
-	return boundaries, nil
+	return boundaries
+}
+
+// c4BoundaryLength returns the number of lines taken up by the boundary starting
+// at lines[0], including its closing brace. An unclosed boundary runs to the end.
+func c4BoundaryLength(lines []string) int {
+	depth := 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if c4BoundaryStartPattern.MatchString(trimmed) {
+			depth++
+		} else if c4BoundaryEndPattern.MatchString(trimmed) {
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(lines)
 }
 
 // parseC4Element parses a C4 element (Person, System, Container, Component, Node).
+// line is the raw source line.
 func parseC4Element(line string, lineNum int) (ast.C4Element, bool) {
+	span := textSpan(lineNum, line)
+
 	// Try Person
-	if matches := c4PersonPattern.FindStringSubmatch(line); matches != nil {
-		params := parseC4Parameters(matches[1])
+	if m := matchLine(c4PersonPattern, line, lineNum, 0); m != nil {
+		params, spans := parseC4Parameters(m.groups[1], m.span(1))
 		if len(params) < 2 {
 			return ast.C4Element{}, false
 		}
@@ -287,13 +345,15 @@ func parseC4Element(line string, lineNum int) (ast.C4Element, bool) {
 			Tags:        getParam(params, 4),
 			Link:        getParam(params, 5),
 			External:    strings.Contains(line, "Person_Ext"),
-			Pos:         ast.Position{Line: lineNum, Column: 1},
+			Pos:         span.Start,
+			End:         span.End,
+			Params:      spans,
 		}, true
 	}
 
 	// Try System
-	if matches := c4SystemPattern.FindStringSubmatch(line); matches != nil {
-		params := parseC4Parameters(matches[1])
+	if m := matchLine(c4SystemPattern, line, lineNum, 0); m != nil {
+		params, spans := parseC4Parameters(m.groups[1], m.span(1))
 		if len(params) < 2 {
 			return ast.C4Element{}, false
 		}
@@ -306,13 +366,15 @@ func parseC4Element(line string, lineNum int) (ast.C4Element, bool) {
 			Tags:        getParam(params, 4),
 			Link:        getParam(params, 5),
 			External:    strings.Contains(line, "System_Ext"),
-			Pos:         ast.Position{Line: lineNum, Column: 1},
+			Pos:         span.Start,
+			End:         span.End,
+			Params:      spans,
 		}, true
 	}
 
 	// Try Container
-	if matches := c4ContainerPattern.FindStringSubmatch(line); matches != nil {
-		params := parseC4Parameters(matches[1])
+	if m := matchLine(c4ContainerPattern, line, lineNum, 0); m != nil {
+		params, spans := parseC4Parameters(m.groups[1], m.span(1))
 		if len(params) < 2 {
 			return ast.C4Element{}, false
 		}
@@ -327,13 +389,15 @@ func parseC4Element(line string, lineNum int) (ast.C4Element, bool) {
 			Link:        getParam(params, 6),
 			Database:    strings.Contains(line, "ContainerDb"),
 			Queue:       strings.Contains(line, "ContainerQueue"),
-			Pos:         ast.Position{Line: lineNum, Column: 1},
+			Pos:         span.Start,
+			End:         span.End,
+			Params:      spans,
 		}, true
 	}
 
 	// Try Component
-	if matches := c4ComponentPattern.FindStringSubmatch(line); matches != nil {
-		params := parseC4Parameters(matches[1])
+	if m := matchLine(c4ComponentPattern, line, lineNum, 0); m != nil {
+		params, spans := parseC4Parameters(m.groups[1], m.span(1))
 		if len(params) < 2 {
 			return ast.C4Element{}, false
 		}
@@ -348,13 +412,15 @@ func parseC4Element(line string, lineNum int) (ast.C4Element, bool) {
 			Link:        getParam(params, 6),
 			Database:    strings.Contains(line, "ComponentDb"),
 			Queue:       strings.Contains(line, "ComponentQueue"),
-			Pos:         ast.Position{Line: lineNum, Column: 1},
+			Pos:         span.Start,
+			End:         span.End,
+			Params:      spans,
 		}, true
 	}
 
 	// Try Node (leaf nodes without braces)
-	if matches := c4NodePattern.FindStringSubmatch(line); matches != nil {
-		params := parseC4Parameters(matches[1])
+	if m := matchLine(c4NodePattern, line, lineNum, 0); m != nil {
+		params, spans := parseC4Parameters(m.groups[1], m.span(1))
 		if len(params) < 2 {
 			return ast.C4Element{}, false
 		}
@@ -367,7 +433,9 @@ func parseC4Element(line string, lineNum int) (ast.C4Element, bool) {
 			Sprite:      getParam(params, 4),
 			Tags:        getParam(params, 5),
 			Link:        getParam(params, 6),
-			Pos:         ast.Position{Line: lineNum, Column: 1},
+			Pos:         span.Start,
+			End:         span.End,
+			Params:      spans,
 		}, true
 	}
 
@@ -376,13 +444,14 @@ func parseC4Element(line string, lineNum int) (ast.C4Element, bool) {
 
 // parseC4Relationship parses a C4 relationship.
 func parseC4Relationship(line string, lineNum int) (ast.C4Relationship, bool) {
-	matches := c4RelPattern.FindStringSubmatch(line)
-	if matches == nil {
+	m := matchLine(c4RelPattern, line, lineNum, 0)
+	if m == nil {
 		return ast.C4Relationship{}, false
 	}
 
-	relType := matches[1]
-	params := parseC4Parameters(matches[2])
+	relType := m.groups[1]
+	params, spans := parseC4Parameters(m.groups[2], m.span(2))
+	span := textSpan(lineNum, line)
 
 	if len(params) < 3 {
 		return ast.C4Relationship{}, false
@@ -398,15 +467,19 @@ func parseC4Relationship(line string, lineNum int) (ast.C4Relationship, bool) {
 		Sprite:      getParam(params, 5),
 		Tags:        getParam(params, 6),
 		Link:        getParam(params, 7),
-		Pos:         ast.Position{Line: lineNum, Column: 1},
+		Pos:         span.Start,
+		End:         span.End,
+		Params:      spans,
 	}, true
 }
 
 // parseC4Style parses a C4 style override.
 func parseC4Style(line string, lineNum int) (ast.C4Style, bool) {
+	span := textSpan(lineNum, line)
+
 	// Try element style
-	if matches := c4ElementStylePattern.FindStringSubmatch(line); matches != nil {
-		params := parseC4Parameters(matches[1])
+	if m := matchLine(c4ElementStylePattern, line, lineNum, 0); m != nil {
+		params, spans := parseC4Parameters(m.groups[1], m.span(1))
 		if len(params) < 1 {
 			return ast.C4Style{}, false
 		}
@@ -418,13 +491,15 @@ func parseC4Style(line string, lineNum int) (ast.C4Style, bool) {
 			BorderColor: getParam(params, 3),
 			Shadowing:   getParam(params, 4),
 			Shape:       getParam(params, 5),
-			Pos:         ast.Position{Line: lineNum, Column: 1},
+			Pos:         span.Start,
+			End:         span.End,
+			Params:      spans,
 		}, true
 	}
 
 	// Try relationship style
-	if matches := c4RelStylePattern.FindStringSubmatch(line); matches != nil {
-		params := parseC4Parameters(matches[1])
+	if m := matchLine(c4RelStylePattern, line, lineNum, 0); m != nil {
+		params, spans := parseC4Parameters(m.groups[1], m.span(1))
 		if len(params) < 2 {
 			return ast.C4Style{}, false
 		}
@@ -436,7 +511,9 @@ func parseC4Style(line string, lineNum int) (ast.C4Style, bool) {
 			LineColor: getParam(params, 3),
 			OffsetX:   getParam(params, 4),
 			OffsetY:   getParam(params, 5),
-			Pos:       ast.Position{Line: lineNum, Column: 1},
+			Pos:       span.Start,
+			End:       span.End,
+			Params:    spans,
 		}, true
 	}
 
@@ -444,11 +521,34 @@ func parseC4Style(line string, lineNum int) (ast.C4Style, bool) {
 }
 
 // parseC4Parameters parses comma-separated parameters, handling quoted strings.
-func parseC4Parameters(params string) []string {
+// It also returns the location of each parameter, given that params is located at s.
+func parseC4Parameters(params string, s ast.Span) ([]string, []ast.Span) {
 	var result []string
+	var spans []ast.Span
 	var current strings.Builder
 	inQuotes := false
 	escaped := false
+	start := 0
+
+	// flush ends the parameter that runs from start to byte end of params
+	flush := func(end int) {
+		result = append(result, strings.TrimSpace(current.String()))
+		current.Reset()
+
+		raw := strings.TrimSpace(params[start:end])
+		lo := start + indentOf(params[start:end])
+		hi := lo + len(raw)
+		if len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"' {
+			lo++
+			hi--
+		}
+		if s.IsValid() {
+			spans = append(spans, lineSpan(s.Start.Line, s.Start.Column-1+lo, s.Start.Column-1+hi))
+		} else {
+			spans = append(spans, ast.Span{})
+		}
+		start = end + 1
+	}
 
 	for i, ch := range params {
 		switch {
@@ -460,16 +560,15 @@ func parseC4Parameters(params string) []string {
 		case ch == '"':
 			inQuotes = !inQuotes
 		case ch == ',' && !inQuotes:
-			result = append(result, strings.TrimSpace(current.String()))
-			current.Reset()
+			flush(i)
 		default:
 			current.WriteRune(ch)
 		}
+	}
 
-		// Handle last character
-		if i == len(params)-1 {
-			result = append(result, strings.TrimSpace(current.String()))
-		}
+	// Handle the last parameter
+	if params != "" {
+		flush(len(params))
 	}
 
 	// Remove surrounding quotes from each parameter
@@ -479,7 +578,7 @@ func parseC4Parameters(params string) []string {
 		}
 	}
 
-	return result
+	return result, spans
 }
 
 // getParam safely gets a parameter at index, returning empty string if out of bounds.
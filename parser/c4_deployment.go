@@ -1,6 +1,10 @@
 package parser
 
-import "github.com/sammcj/mermaid-check/ast"
+import (
+	"context"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
 
 // C4DeploymentParser parses C4 Deployment diagrams.
 type C4DeploymentParser struct{}
@@ -12,7 +16,12 @@ func NewC4DeploymentParser() *C4DeploymentParser {
 
 // Parse parses a C4 Deployment diagram and returns a C4Diagram AST.
 func (p *C4DeploymentParser) Parse(source string) (ast.Diagram, error) {
-	return parseC4Diagram(source, "c4Deployment", "C4Deployment")
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses a C4 Deployment diagram within the limits in opts.
+func (p *C4DeploymentParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseC4Diagram(ctx, source, opts, "c4Deployment", "C4Deployment")
 }
 
 // SupportedTypes returns the diagram types this parser supports.
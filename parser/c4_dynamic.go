@@ -1,6 +1,10 @@
 package parser
 
-import "github.com/sammcj/mermaid-check/ast"
+import (
+	"context"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
 
 // C4DynamicParser parses C4 Dynamic diagrams.
 type C4DynamicParser struct{}
@@ -12,7 +16,12 @@ func NewC4DynamicParser() *C4DynamicParser {
 
 // Parse parses a C4 Dynamic diagram and returns a C4Diagram AST.
 func (p *C4DynamicParser) Parse(source string) (ast.Diagram, error) {
-	return parseC4Diagram(source, "c4Dynamic", "C4Dynamic")
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses a C4 Dynamic diagram within the limits in opts.
+func (p *C4DynamicParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseC4Diagram(ctx, source, opts, "c4Dynamic", "C4Dynamic")
 }
 
 // SupportedTypes returns the diagram types this parser supports.
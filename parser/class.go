@@ -1,7 +1,7 @@
 package parser
 
 import (
-	"fmt"
+	"context"
 	"regexp"
 	"strings"
 
@@ -13,13 +13,25 @@ var (
 	classHeaderPattern = regexp.MustCompile(`^classDiagram\s*$`)
 	classCommentPattern = regexp.MustCompile(`^%%(.*)$`)
 
-	// Class declaration patterns
-	classDeclPattern = regexp.MustCompile(`^class\s+(\w+)(?:\s*<<(.+)>>)?\s*$`)
-	classBodyStartPattern = regexp.MustCompile(`^class\s+(\w+)(?:\s*<<(.+)>>)?\s*\{\s*$`)
+	// Class declaration patterns. Groups: name, generic type, label and stereotype
+	classDeclPattern = regexp.MustCompile(`^` + classDecl + `\s*$`)
+	classBodyStartPattern = regexp.MustCompile(`^` + classDecl + `\s*\{\s*$`)
 	classBodyEndPattern = regexp.MustCompile(`^\}\s*$`)
 
-	// Member patterns
-	memberPattern = regexp.MustCompile(`^([+\-#~])(\w+)(?:\(([^)]*)\))?(?:\s+(.+))?\s*$`)
+	// Namespace pattern, as in namespace Billing {
+	namespacePattern = regexp.MustCompile(`^namespace\s+(` + ident + `(?:\.` + ident + `)*)\s*\{\s*$`)
+
+	// Annotation patterns: <<interface>> Shape, or <<interface>> alone in a class body
+	annotationStmtPattern = regexp.MustCompile(`^<<(.+)>>\s*(` + className + `)$`)
+	bodyAnnotationPattern = regexp.MustCompile(`^<<(.+)>>$`)
+
+	// Member patterns. A method has parentheses and may give its return type after
+	// them, and an attribute may give its type before its name. A $ marks a static
+	// member and a * an abstract one.
+	// Method groups: visibility, leading type, name, parameters, classifier, return type and classifier
+	methodPattern = regexp.MustCompile(`^([+\-#~])?(?:(` + memberType + `)\s+)?(` + ident + `)\(([^)]*)\)([$*])?(?:\s+(.+?))?([$*])?\s*$`)
+	// Attribute groups: visibility, type, name and classifier
+	attributePattern = regexp.MustCompile(`^([+\-#~])?(?:(` + memberType + `)\s+)?(` + ident + `)([$*])?\s*$`)
 
 	// Relationship patterns
 	// Inheritance: --|>, <|--
@@ -28,10 +40,27 @@ var (
 	// Association: --, -->
 	// Dependency: .., ..>, <..
 	// Realization: ..|>, <|..
-	relationshipPattern = regexp.MustCompile(`^(\w+)\s+(?:"([^"]+)"\s+)?([<*o])?(-{2}|\.{2})([>|*o]?)\s+(?:"([^"]+)"\s+)?(\w+)(?:\s*:\s*(.+))?\s*$`)
+	relationshipPattern = regexp.MustCompile(`^(` + className + `)\s+(?:"([^"]+)"\s+)?(<\||[<*o])?(-{2}|\.{2})(\|>|[>*o])?\s+(?:"([^"]+)"\s+)?(` + className + `)(?:\s*:\s*(.+))?\s*$`)
+
+	// Standalone member pattern: ClassName : member
+	classMemberStmtPattern = regexp.MustCompile(`^(` + className + `)\s*:\s*(.+)$`)
 
 	// Note pattern
-	classNotePattern = regexp.MustCompile(`^note\s+for\s+(\w+)\s+"([^"]+)"\s*$`)
+	classNotePattern = regexp.MustCompile(`^note\s+for\s+(` + className + `)\s+"([^"]+)"\s*$`)
+)
+
+const (
+	// className matches a class name, either an identifier or any text in
+	// backticks, as in `My Class`.
+	className = ident + "|`[^`]+`"
+
+	// memberType matches the type of a member written before its name, such as
+	// int, List~int~ or String[].
+	memberType = `[` + identChars + `~.,\[\]]+`
+
+	// classDecl matches a class declaration, such as class List~T~,
+	// class A["Display name"] or class Shape <<interface>>.
+	classDecl = `class\s+(` + className + `)(?:~([^\[{<"]+)~)?(?:\["([^"]*)"\])?(?:\s*<<(.+)>>)?`
 )
 
 // ClassParser parses Mermaid class diagrams.
@@ -49,35 +78,52 @@ func (p *ClassParser) SupportedTypes() []string {
 
 // Parse parses a Mermaid class diagram from a string.
 func (p *ClassParser) Parse(source string) (ast.Diagram, error) {
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses a Mermaid class diagram within the limits in opts.
+func (p *ClassParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseLimited(ctx, source, opts, func(lim *limiter) (ast.Diagram, error) {
+		return p.parse(source, lim)
+	})
+}
+
+func (p *ClassParser) parse(source string, lim *limiter) (ast.Diagram, error) {
 	lines := strings.Split(source, "\n")
 	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty diagram")
+		return nil, emptySourceError("class")
+	}
+
+	var errs ErrorList
+	meta, headerIdx, err := parseMetadata(lines, "class", &errs)
+	if err != nil {
+		return nil, err
 	}
 
 	// Parse header
-	header := strings.TrimSpace(lines[0])
+	header := strings.TrimSpace(lines[headerIdx])
 	if !classHeaderPattern.MatchString(header) {
-		return nil, fmt.Errorf("invalid class diagram header: expected 'classDiagram'")
+		return nil, headerError("class", headerIdx+1, lines[headerIdx], "'classDiagram'")
 	}
 
 	diagram := &ast.ClassDiagram{
-		Type:   "class",
-		Source: source,
-		Pos:    ast.Position{Line: 1, Column: 1},
+		Type:     "class",
+		Source:   source,
+		Pos:      linePos(headerIdx+1, lines[headerIdx]),
+		End:      ast.SourceEnd(source),
+		Metadata: meta,
 	}
 
 	// Parse statements
-	statements, err := p.parseStatements(lines[1:], 1)
-	if err != nil {
-		return nil, err
-	}
-	diagram.Statements = statements
+	diagram.Statements, _, _ = p.parseStatements(lines[headerIdx+1:], headerIdx+1, false, &errs, lim)
 
-	return diagram, nil
+	return diagram, errs.errFor(diagram.GetType())
 }
 
-func (p *ClassParser) parseStatements(lines []string, startLine int) ([]ast.ClassStmt, error) {
-	var statements []ast.ClassStmt
+// parseStatements parses the statements of the diagram, or of a namespace if
+// nested is true, in which case they end at the closing brace. It returns the
+// number of lines consumed, including the brace, and whether it was found.
+func (p *ClassParser) parseStatements(lines []string, startLine int, nested bool, errs *ErrorList, lim *limiter) (statements []ast.ClassStmt, consumed int, closed bool) {
 	lineNum := startLine
 
 	for i := 0; i < len(lines); i++ {
@@ -89,110 +135,202 @@ func (p *ClassParser) parseStatements(lines []string, startLine int) ([]ast.Clas
 		if trimmed == "" {
 			continue
 		}
+		if nested && classBodyEndPattern.MatchString(trimmed) {
+			return statements, i + 1, true
+		}
+		if !lim.statement(lineNum) {
+			break
+		}
+		span := textSpan(lineNum, line)
 
 		// Handle comments
 		if matches := classCommentPattern.FindStringSubmatch(trimmed); matches != nil {
 			statements = append(statements, &ast.ClassComment{
 				Text: strings.TrimSpace(matches[1]),
-				Pos:  ast.Position{Line: lineNum, Column: 1},
+				Pos:  span.Start,
+				End:  span.End,
 			})
 			continue
 		}
 
-		// Handle class with body
-		if matches := classBodyStartPattern.FindStringSubmatch(trimmed); matches != nil {
-			className := matches[1]
-			stereotype := ""
-			if len(matches) > 2 && matches[2] != "" {
-				stereotype = matches[2]
+		// Handle namespaces, which run to their closing brace
+		if m := matchTrimmed(namespacePattern, line, lineNum); m != nil {
+			if !lim.enter(lineNum) {
+				break
 			}
-
-			// Find closing brace
-			members, consumed, err := p.parseClassBody(lines[i+1:], lineNum+1)
-			if err != nil {
-				return nil, err
+			nestedStatements, nestedConsumed, nestedClosed := p.parseStatements(lines[i+1:], lineNum, true, errs, lim)
+			lim.leave()
+			if !nestedClosed {
+				e := errs.addLine(lineNum, line, "unclosed namespace %s, missing '}'", m.groups[1])
+				e.Expected = "'}'"
 			}
 
-			class := &ast.Class{
-				Name:       className,
-				Stereotype: stereotype,
-				Members:    members,
-				Pos:        ast.Position{Line: lineNum, Column: 1},
+			statements = append(statements, &ast.Namespace{
+				Name:       m.groups[1],
+				Statements: nestedStatements,
+				Pos:        span.Start,
+				End:        lineEnd(lineNum+nestedConsumed, lines[i+nestedConsumed]),
+				NameSpan:   m.span(1),
+			})
+
+			i += nestedConsumed
+			lineNum += nestedConsumed
+			continue
+		}
+
+		// Handle class with body
+		if m := matchTrimmed(classBodyStartPattern, line, lineNum); m != nil {
+			class := newClass(m, span)
+
+			// Find closing brace. An unclosed body runs to the end of the diagram.
+			if !lim.enter(lineNum) {
+				break
+			}
+			unknown, bodyConsumed, bodyClosed := p.parseClassBody(class, lines[i+1:], lineNum, lim)
+			lim.leave()
+			if !bodyClosed {
+				e := errs.addLine(lineNum, line, "unclosed class body for %s, missing '}'", class.Name)
+				e.Expected = "'}'"
 			}
+
+			class.End = lineEnd(lineNum+bodyConsumed, lines[i+bodyConsumed])
 			statements = append(statements, class)
+			for _, u := range unknown {
+				statements = append(statements, u)
+			}
 
-			i += consumed
-			lineNum += consumed
+			i += bodyConsumed
+			lineNum += bodyConsumed
 			continue
 		}
 
 		// Handle simple class declaration
-		if matches := classDeclPattern.FindStringSubmatch(trimmed); matches != nil {
-			className := matches[1]
-			stereotype := ""
-			if len(matches) > 2 && matches[2] != "" {
-				stereotype = matches[2]
-			}
+		if m := matchTrimmed(classDeclPattern, line, lineNum); m != nil {
+			statements = append(statements, newClass(m, span))
+			continue
+		}
 
-			class := &ast.Class{
-				Name:       className,
-				Stereotype: stereotype,
-				Members:    []ast.ClassMember{},
-				Pos:        ast.Position{Line: lineNum, Column: 1},
+		// Handle annotations outside a class, as in <<interface>> Shape
+		if m := matchTrimmed(annotationStmtPattern, line, lineNum); m != nil {
+			name, nameSpan := m.unquoted(2)
+			class, created := classFor(statements, name, nameSpan, span)
+			class.Stereotype, class.StereotypeSpan = m.trimmed(1)
+			if created {
+				statements = append(statements, class)
 			}
-			statements = append(statements, class)
 			continue
 		}
 
 		// Handle relationships
-		if matches := relationshipPattern.FindStringSubmatch(trimmed); matches != nil {
-			from := matches[1]
-			fromCard := matches[2]
-			leftSymbol := matches[3]
-			linkType := matches[4]
-			rightSymbol := matches[5]
-			toCard := matches[6]
-			to := matches[7]
-			label := ""
-			if len(matches) > 8 {
-				label = matches[8]
-			}
+		if m := matchTrimmed(relationshipPattern, line, lineNum); m != nil {
+			leftSymbol := m.groups[3]
+			linkType := m.groups[4]
+			rightSymbol := m.groups[5]
 
 			relType := p.determineRelationshipType(leftSymbol, linkType, rightSymbol)
+			from, fromSpan := m.unquoted(1)
+			to, toSpan := m.unquoted(7)
 
 			relationship := &ast.Relationship{
-				From:            from,
-				To:              to,
-				Type:            relType,
-				Label:           label,
-				FromCardinality: fromCard,
-				ToCardinality:   toCard,
-				Pos:             ast.Position{Line: lineNum, Column: 1},
+				From:                from,
+				To:                  to,
+				Type:                relType,
+				Label:               m.groups[8],
+				FromCardinality:     m.groups[2],
+				ToCardinality:       m.groups[6],
+				Pos:                 span.Start,
+				End:                 span.End,
+				FromSpan:            fromSpan,
+				ToSpan:              toSpan,
+				LabelSpan:           m.span(8),
+				FromCardinalitySpan: m.span(2),
+				ToCardinalitySpan:   m.span(6),
 			}
 			statements = append(statements, relationship)
 			continue
 		}
 
 		// Handle notes
-		if matches := classNotePattern.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(classNotePattern, line, lineNum); m != nil {
+			name, nameSpan := m.unquoted(1)
 			note := &ast.ClassNote{
-				ClassName: matches[1],
-				Text:      matches[2],
-				Pos:       ast.Position{Line: lineNum, Column: 1},
+				ClassName:     name,
+				Text:          m.groups[2],
+				Pos:           span.Start,
+				End:           span.End,
+				ClassNameSpan: nameSpan,
+				TextSpan:      m.span(2),
 			}
 			statements = append(statements, note)
 			continue
 		}
 
-		// Skip lines we can't parse (for now)
-		continue
+		// Handle members declared outside a class body, adding them to the class
+		if m := matchTrimmed(classMemberStmtPattern, line, lineNum); m != nil {
+			text, textSpan := m.trimmed(2)
+			if member, ok := parseClassMember(text, textSpan); ok {
+				name, nameSpan := m.unquoted(1)
+				class, created := classFor(statements, name, nameSpan, span)
+				class.Members = append(class.Members, member)
+				if created {
+					statements = append(statements, class)
+				}
+				continue
+			}
+		}
+
+		// Keep lines we can't parse so validators can report them
+		statements = append(statements, &ast.UnknownStatement{
+			Text: trimmed,
+			Pos:  span.Start,
+			End:  span.End,
+		})
 	}
 
-	return statements, nil
+	return statements, len(lines), false
 }
 
-func (p *ClassParser) parseClassBody(lines []string, startLine int) ([]ast.ClassMember, int, error) {
-	var members []ast.ClassMember
+// newClass returns the class declared by a match of classDeclPattern or
+// classBodyStartPattern on the line at span.
+func newClass(m *lineMatch, span ast.Span) *ast.Class {
+	name, nameSpan := m.unquoted(1)
+	stereotype, stereotypeSpan := m.trimmed(4)
+	return &ast.Class{
+		Name:           name,
+		Generic:        m.groups[2],
+		Label:          m.groups[3],
+		Stereotype:     stereotype,
+		Members:        []ast.ClassMember{},
+		Pos:            span.Start,
+		End:            span.End,
+		NameSpan:       nameSpan,
+		GenericSpan:    m.span(2),
+		LabelSpan:      m.span(3),
+		StereotypeSpan: stereotypeSpan,
+	}
+}
+
+// classFor returns the class with the given name declared in statements, or a
+// new one declared by the statement at span if there is none, in which case
+// created is true and the caller adds it to statements.
+func classFor(statements []ast.ClassStmt, name string, nameSpan, span ast.Span) (class *ast.Class, created bool) {
+	if class := findClass(statements, name); class != nil {
+		return class, false
+	}
+	return &ast.Class{
+		Name:     name,
+		Members:  []ast.ClassMember{},
+		Pos:      span.Start,
+		End:      span.End,
+		NameSpan: nameSpan,
+	}, true
+}
+
+// parseClassBody parses the members of class up to the closing brace and returns
+// the number of lines consumed. A <<stereotype>> line sets the class stereotype,
+// and other lines that are not members are returned as unknown statements. If
+// there is no closing brace, all remaining lines are consumed and closed is false.
+func (p *ClassParser) parseClassBody(class *ast.Class, lines []string, startLine int, lim *limiter) (unknown []*ast.UnknownStatement, consumed int, closed bool) {
 	lineNum := startLine
 
 	for i, line := range lines {
@@ -201,48 +339,101 @@ func (p *ClassParser) parseClassBody(lines []string, startLine int) ([]ast.Class
 
 		// Check for end of class body
 		if classBodyEndPattern.MatchString(trimmed) {
-			return members, i + 1, nil
+			return unknown, i + 1, true
 		}
 
 		// Skip empty lines
 		if trimmed == "" {
 			continue
 		}
+		if !lim.statement(lineNum) {
+			break
+		}
+
+		if m := matchTrimmed(bodyAnnotationPattern, line, lineNum); m != nil {
+			class.Stereotype, class.StereotypeSpan = m.trimmed(1)
+			continue
+		}
 
 		// Parse member
-		if matches := memberPattern.FindStringSubmatch(trimmed); matches != nil {
-			visibility := matches[1]
-			name := matches[2]
-			params := matches[3]
-			typ := ""
-			if len(matches) > 4 {
-				typ = matches[4]
-			}
+		span := textSpan(lineNum, line)
+		if member, ok := parseClassMember(trimmed, span); ok {
+			class.Members = append(class.Members, member)
+			continue
+		}
 
-			member := ast.ClassMember{
-				Visibility: visibility,
-				Name:       name,
-				Type:       typ,
-				IsMethod:   params != "",
-				Pos:        ast.Position{Line: lineNum, Column: 1},
-			}
+		unknown = append(unknown, &ast.UnknownStatement{
+			Text: trimmed,
+			Pos:  span.Start,
+			End:  span.End,
+		})
+	}
 
-			if params != "" {
-				// Parse parameters
-				if params != "" {
-					paramList := strings.Split(params, ",")
-					for i := range paramList {
-						paramList[i] = strings.TrimSpace(paramList[i])
-					}
-					member.Parameters = paramList
-				}
+	return unknown, len(lines), false
+}
+
+// findClass returns the class with the given name declared in statements or the
+// namespaces among them, or nil.
+func findClass(statements []ast.ClassStmt, name string) *ast.Class {
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *ast.Class:
+			if s.Name == name {
+				return s
 			}
+		case *ast.Namespace:
+			if class := findClass(s.Statements, name); class != nil {
+				return class
+			}
+		}
+	}
+	return nil
+}
 
-			members = append(members, member)
+// parseClassMember parses a single attribute or method declaration, located at span.
+func parseClassMember(text string, span ast.Span) (ast.ClassMember, bool) {
+	if m := matchLine(methodPattern, text, span.Start.Line, span.Start.Column-1); m != nil {
+		// A return type after the parentheses takes precedence over one before the name
+		returnType, typeSpan := m.trimmed(6)
+		if returnType == "" {
+			returnType, typeSpan = m.groups[2], m.span(2)
 		}
+		classifier := m.groups[5] + m.groups[7]
+		member := ast.ClassMember{
+			Visibility: m.groups[1],
+			Name:       m.groups[3],
+			Type:       returnType,
+			IsMethod:   true,
+			IsStatic:   strings.Contains(classifier, "$"),
+			IsAbstract: strings.Contains(classifier, "*"),
+			Pos:        span.Start,
+			End:        span.End,
+			NameSpan:   m.span(3),
+			TypeSpan:   typeSpan,
+		}
+		if params := strings.TrimSpace(m.groups[4]); params != "" {
+			for param := range strings.SplitSeq(params, ",") {
+				member.Parameters = append(member.Parameters, strings.TrimSpace(param))
+			}
+		}
+		return member, true
 	}
 
-	return nil, 0, fmt.Errorf("line %d: unclosed class body", startLine)
+	m := matchLine(attributePattern, text, span.Start.Line, span.Start.Column-1)
+	if m == nil {
+		return ast.ClassMember{}, false
+	}
+	return ast.ClassMember{
+		Visibility: m.groups[1],
+		Name:       m.groups[3],
+		Type:       m.groups[2],
+		IsStatic:   m.groups[4] == "$",
+		IsAbstract: m.groups[4] == "*",
+		Pos:        span.Start,
+		End:        span.End,
+		NameSpan:   m.span(3),
+		TypeSpan:   m.span(2),
+	}, true
 }
 
 func (p *ClassParser) determineRelationshipType(left, link, right string) string {
@@ -0,0 +1,308 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
+
+const (
+	directiveOpen  = "%%{"
+	directiveClose = "}%%"
+)
+
+// isDirective reports whether a trimmed line opens a directive.
+func isDirective(trimmed string) bool {
+	return strings.HasPrefix(trimmed, directiveOpen)
+}
+
+// directiveEnd returns the index of the line closing the directive opened on
+// lines[start], or -1 if it is never closed.
+func directiveEnd(lines []string, start int) int {
+	open := strings.Index(lines[start], directiveOpen)
+	if strings.Contains(lines[start][open+len(directiveOpen):], directiveClose) {
+		return start
+	}
+	for i := start + 1; i < len(lines); i++ {
+		if strings.Contains(lines[i], directiveClose) {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseDirectives collects the directives in lines, starting at lines[start].
+// Directives may span several lines, which are blanked out of lines so that the
+// parser for each diagram type does not see them.
+func parseDirectives(lines []string, start int, errs *ErrorList) []*ast.Directive {
+	var directives []*ast.Directive
+	for i := start; i < len(lines); i++ {
+		if !isDirective(strings.TrimSpace(lines[i])) {
+			continue
+		}
+		end := directiveEnd(lines, i)
+		if end < 0 {
+			e := errs.addLine(i+1, lines[i], "unclosed directive, missing '}%%'")
+			e.Expected = "'}%%'"
+			break
+		}
+		if d := parseDirective(lines[i:end+1], i+1, errs); d != nil {
+			directives = append(directives, d)
+		}
+		for ; i <= end; i++ {
+			lines[i] = ""
+		}
+		i = end
+	}
+	return directives
+}
+
+// parseDirective parses a directive spanning lines, the first of which is line
+// number lineNum.
+func parseDirective(lines []string, lineNum int, errs *ErrorList) *ast.Directive {
+	text := strings.Join(lines, "\n")
+	starts := LineStarts(text)
+	pos := func(offset int) ast.Position {
+		line := 0
+		for line+1 < len(starts) && starts[line+1] <= offset {
+			line++
+		}
+		return ast.Position{Line: lineNum + line, Column: offset - starts[line] + 1}
+	}
+	span := func(start, end int) ast.Span {
+		return ast.Span{Start: pos(start), End: pos(end)}
+	}
+
+	open := strings.Index(text, directiveOpen)
+	closing := strings.LastIndex(text, directiveClose)
+	d := &ast.Directive{
+		Pos: pos(open),
+		End: pos(closing + len(directiveClose)),
+	}
+	body := open + len(directiveOpen)
+	inner := text[body:closing]
+
+	name := strings.TrimLeft(inner, " \t\n")
+	nameStart := body + len(inner) - len(name)
+	nameLen := 0
+	for nameLen < len(name) && isDirectiveNameByte(name[nameLen]) {
+		nameLen++
+	}
+	d.Name = name[:nameLen]
+	d.NameSpan = span(nameStart, nameStart+nameLen)
+	if d.Name == "" {
+		errs.addLine(lineNum, lines[0], "missing directive name: %s", strings.TrimSpace(text))
+		return nil
+	}
+
+	rest := strings.TrimSpace(name[nameLen:])
+	if rest == "" {
+		return d
+	}
+	if rest[0] != ':' {
+		errs.addLine(lineNum, lines[0], "invalid directive, expected ':' after %q", d.Name)
+		return d
+	}
+	d.Args = strings.TrimSpace(rest[1:])
+	argsStart := strings.Index(text[nameStart+nameLen:], d.Args) + nameStart + nameLen
+	d.ArgsSpan = span(argsStart, argsStart+len(d.Args))
+
+	if d.Name != "init" && d.Name != "initialize" {
+		return d
+	}
+	p := &flowParser{
+		text: d.Args,
+		onKey: func(path string, start, end int) {
+			d.Keys = append(d.Keys, ast.ConfigKey{Path: path, Span: span(argsStart+start, argsStart+end)})
+		},
+	}
+	v, err := p.parse()
+	if err != nil {
+		errs.addLine(lineNum, lines[0], "invalid %s directive: %v", d.Name, err)
+		return d
+	}
+	config, ok := v.(map[string]any)
+	if !ok {
+		errs.addLine(lineNum, lines[0], "invalid %s directive: expected a {...} object, got %s", d.Name, d.Args)
+		return d
+	}
+	d.Config = config
+	return d
+}
+
+func isDirectiveNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// flowParser parses a YAML flow collection or a JSON value, such as the payload of
+// an init directive. Strings may be single- or double-quoted, and keys may also be
+// left unquoted.
+type flowParser struct {
+	text  string
+	i     int
+	onKey func(path string, start, end int) // Called with the location of each key, if set
+}
+
+// parse parses the whole text as a single value.
+func (p *flowParser) parse() (any, error) {
+	v, err := p.value("")
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.i < len(p.text) {
+		return nil, fmt.Errorf("unexpected %q after value", p.text[p.i:])
+	}
+	return v, nil
+}
+
+func (p *flowParser) skipSpace() {
+	for p.i < len(p.text) && strings.IndexByte(" \t\r\n", p.text[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+func (p *flowParser) value(path string) (any, error) {
+	p.skipSpace()
+	if p.i >= len(p.text) {
+		return nil, fmt.Errorf("missing value")
+	}
+	switch p.text[p.i] {
+	case '{':
+		return p.mapping(path)
+	case '[':
+		return p.sequence(path)
+	case '"', '\'':
+		return p.quoted()
+	}
+	start := p.i
+	for p.i < len(p.text) && strings.IndexByte(",]}", p.text[p.i]) < 0 {
+		p.i++
+	}
+	text := strings.TrimSpace(p.text[start:p.i])
+	if text == "" {
+		return nil, fmt.Errorf("missing value")
+	}
+	return plainScalar(text), nil
+}
+
+func (p *flowParser) mapping(path string) (map[string]any, error) {
+	m := map[string]any{}
+	p.i++ // Opening brace
+	for {
+		p.skipSpace()
+		if p.i >= len(p.text) {
+			return nil, fmt.Errorf("missing '}'")
+		}
+		if p.text[p.i] == '}' {
+			p.i++
+			return m, nil
+		}
+
+		start := p.i
+		var key string
+		if c := p.text[p.i]; c == '"' || c == '\'' {
+			k, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			key = k
+		} else {
+			for p.i < len(p.text) && strings.IndexByte(":,{}[]", p.text[p.i]) < 0 {
+				p.i++
+			}
+			key = strings.TrimSpace(p.text[start:p.i])
+		}
+		end := p.i
+		if key == "" {
+			return nil, fmt.Errorf("missing key")
+		}
+		p.skipSpace()
+		if p.i >= len(p.text) || p.text[p.i] != ':' {
+			return nil, fmt.Errorf("expected ':' after key %q", key)
+		}
+		p.i++
+
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+		if p.onKey != nil {
+			p.onKey(keyPath, start, end)
+		}
+		v, err := p.value(keyPath)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+
+		if err := p.separator('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *flowParser) sequence(path string) ([]any, error) {
+	items := []any{}
+	p.i++ // Opening bracket
+	for {
+		p.skipSpace()
+		if p.i >= len(p.text) {
+			return nil, fmt.Errorf("missing ']'")
+		}
+		if p.text[p.i] == ']' {
+			p.i++
+			return items, nil
+		}
+		v, err := p.value(path)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+		if err := p.separator(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// separator consumes the comma between entries, leaving a closing bracket for the
+// caller.
+func (p *flowParser) separator(closing byte) error {
+	p.skipSpace()
+	switch {
+	case p.i >= len(p.text):
+		return fmt.Errorf("missing '%c'", closing)
+	case p.text[p.i] == ',':
+		p.i++
+	case p.text[p.i] != closing:
+		return fmt.Errorf("expected ',' or '%c', got %q", closing, p.text[p.i:])
+	}
+	return nil
+}
+
+func (p *flowParser) quoted() (string, error) {
+	quote := p.text[p.i]
+	start := p.i
+	for p.i++; p.i < len(p.text); p.i++ {
+		switch c := p.text[p.i]; {
+		case c == '\\' && quote == '"':
+			p.i++
+		case c == quote && quote == '\'' && p.i+1 < len(p.text) && p.text[p.i+1] == '\'':
+			p.i++ // Escaped quote
+		case c == quote:
+			p.i++
+			raw := p.text[start:p.i]
+			if quote == '\'' {
+				return strings.ReplaceAll(raw[1:len(raw)-1], "''", "'"), nil
+			}
+			s, err := strconv.Unquote(raw)
+			if err != nil {
+				return "", fmt.Errorf("invalid string %s", raw)
+			}
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unclosed string %s", p.text[start:])
+}
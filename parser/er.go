@@ -1,7 +1,7 @@
 package parser
 
 import (
-	"fmt"
+	"context"
 	"regexp"
 	"strings"
 
@@ -18,17 +18,38 @@ func NewERParser() *ERParser {
 
 var (
 	erHeaderRegex     = regexp.MustCompile(`^erDiagram\s*(?:(TB|BT|LR|RL)\s*)?$`)
-	entityHeaderRegex = regexp.MustCompile(`^([A-Z_][A-Z0-9_-]*)\s*(?:\[([^\]]+)\])?\s*\{?\s*$`)
-	attributeRegex    = regexp.MustCompile(`^\s+([a-zA-Z][a-zA-Z0-9\-_\(\)\[\]]*)\s+([a-zA-Z_][a-zA-Z0-9_-]*|\*[a-zA-Z_][a-zA-Z0-9_-]*)\s*(?:([A-Z,]+))?\s*(?:"([^"]*)")?\s*$`)
-	relationshipRegex = regexp.MustCompile(`^([A-Z_][A-Z0-9_-]*)\s+(\|\||\|o|\}\||\}o)(--|\.\.)(\|\||\|o|o\||o\{|\|\{|\}\||\}o)\s+([A-Z_][A-Z0-9_-]*)\s*(?::\s*(.+))?$`)
-	simpleEntityRegex = regexp.MustCompile(`^([A-Z_][A-Z0-9_-]*)\s*(?:\[([^\]]+)\])?\s*$`)
+	entityHeaderRegex = regexp.MustCompile(`^(` + erEntityName + `)\s*(?:\[([^\]]+)\])?\s*\{?\s*$`)
+	attributeRegex    = regexp.MustCompile(`^\s+(\p{L}[` + identChars + `\-\(\)\[\]]*)\s+(\*?[\p{L}_][` + identChars + `-]*)\s*(?:([A-Z,]+))?\s*(?:"([^"]*)")?\s*$`)
+	relationshipRegex = regexp.MustCompile(`^(` + erEntityName + `)\s+(\|\||\|o|\}\||\}o)(--|\.\.)(\|\||\|o|o\||o\{|\|\{|\}\||\}o)\s+(` + erEntityName + `)\s*(?::\s*(.+))?$`)
+	simpleEntityRegex = regexp.MustCompile(`^(` + erEntityName + `)\s*(?:\[([^\]]+)\])?\s*$`)
 )
 
+// erEntityName matches an entity name: a word that starts with a letter or
+// underscore and may contain hyphens, or a quoted name that may contain spaces.
+const erEntityName = `[\p{L}_][` + identChars + `-]*|"[^"]+"`
+
 // Parse parses an ER diagram source.
 func (p *ERParser) Parse(source string) (ast.Diagram, error) {
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses an ER diagram source within the limits in opts.
+func (p *ERParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseLimited(ctx, source, opts, func(lim *limiter) (ast.Diagram, error) {
+		return p.parse(source, lim)
+	})
+}
+
+func (p *ERParser) parse(source string, lim *limiter) (ast.Diagram, error) {
 	lines := strings.Split(source, "\n")
 	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty diagram source")
+		return nil, emptySourceError("er")
+	}
+
+	var errs ErrorList
+	meta, header, err := parseMetadata(lines, "er", &errs)
+	if err != nil {
+		return nil, err
 	}
 
 	diagram := &ast.ERDiagram{
@@ -36,14 +57,16 @@ func (p *ERParser) Parse(source string) (ast.Diagram, error) {
 		Source:        source,
 		Entities:      []ast.EREntity{},
 		Relationships: []ast.ERRelationship{},
-		Pos:           ast.Position{Line: 1, Column: 1},
+		Pos:           linePos(header+1, lines[header]),
+		End:           ast.SourceEnd(source),
+		Metadata:      meta,
 	}
 
 	// Parse header line
-	firstLine := strings.TrimSpace(lines[0])
+	firstLine := strings.TrimSpace(lines[header])
 	matches := erHeaderRegex.FindStringSubmatch(firstLine)
 	if matches == nil {
-		return nil, fmt.Errorf("invalid ER diagram header: %s", firstLine)
+		return nil, headerError("er", header+1, lines[header], "'erDiagram'")
 	}
 
 	// Extract direction if present
@@ -55,7 +78,7 @@ func (p *ERParser) Parse(source string) (ast.Diagram, error) {
 	var currentEntity *ast.EREntity
 	inEntityBlock := false
 
-	for i := 1; i < len(lines); i++ {
+	for i := header + 1; i < len(lines); i++ {
 		line := lines[i]
 		trimmed := strings.TrimSpace(line)
 
@@ -63,10 +86,16 @@ func (p *ERParser) Parse(source string) (ast.Diagram, error) {
 		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
 			continue
 		}
+		if !lim.statement(i + 1) {
+			break
+		}
+		lineNum := i + 1
+		span := textSpan(lineNum, line)
 
 		// Check for closing brace
 		if trimmed == "}" {
 			if currentEntity != nil {
+				currentEntity.End = span.End
 				diagram.Entities = append(diagram.Entities, *currentEntity)
 				currentEntity = nil
 				inEntityBlock = false
@@ -76,12 +105,16 @@ func (p *ERParser) Parse(source string) (ast.Diagram, error) {
 
 		// Try to parse as attribute if we're in an entity block
 		if inEntityBlock && currentEntity != nil {
-			attrMatches := attributeRegex.FindStringSubmatch(line)
-			if attrMatches != nil {
+			if m := matchLine(attributeRegex, line, lineNum, 0); m != nil {
 				attr := ast.ERAttribute{
-					Type: attrMatches[1],
-					Name: attrMatches[2],
-					Pos:  ast.Position{Line: i + 1, Column: 1},
+					Type:        m.groups[1],
+					Name:        m.groups[2],
+					Pos:         span.Start,
+					End:         span.End,
+					TypeSpan:    m.span(1),
+					NameSpan:    m.span(2),
+					KeysSpan:    m.span(3),
+					CommentSpan: m.span(4),
 				}
 
 				// Handle asterisk notation for primary keys
@@ -91,8 +124,8 @@ func (p *ERParser) Parse(source string) (ast.Diagram, error) {
 				}
 
 				// Parse key indicators
-				if attrMatches[3] != "" {
-					keys := strings.SplitSeq(attrMatches[3], ",")
+				if m.groups[3] != "" {
+					keys := strings.SplitSeq(m.groups[3], ",")
 					for key := range keys {
 						key = strings.TrimSpace(key)
 						if key == "PK" || key == "FK" || key == "UK" {
@@ -104,50 +137,47 @@ func (p *ERParser) Parse(source string) (ast.Diagram, error) {
 				}
 
 				// Parse comment
-				if attrMatches[4] != "" {
-					attr.Comment = attrMatches[4]
+				if m.groups[4] != "" {
+					attr.Comment = m.groups[4]
 				}
 
 				currentEntity.Attributes = append(currentEntity.Attributes, attr)
+				currentEntity.End = span.End
 				continue
 			}
 		}
 
 		// Try to parse as relationship
-		relMatches := relationshipRegex.FindStringSubmatch(trimmed)
-		if relMatches != nil {
+		if m := matchTrimmed(relationshipRegex, line, lineNum); m != nil {
 			rel := ast.ERRelationship{
-				From:     relMatches[1],
-				FromCard: relMatches[2],
-				Type:     relMatches[3],
-				ToCard:   relMatches[4],
-				To:       relMatches[5],
-				Pos:      ast.Position{Line: i + 1, Column: 1},
-			}
-			if relMatches[6] != "" {
-				rel.Label = strings.TrimSpace(relMatches[6])
+				FromCard: m.groups[2],
+				Type:     m.groups[3],
+				ToCard:   m.groups[4],
+				Pos:      span.Start,
+				End:      span.End,
 			}
+			rel.From, rel.FromSpan = m.unquoted(1)
+			rel.To, rel.ToSpan = m.unquoted(5)
+			rel.Label, rel.LabelSpan = m.trimmed(6)
 			diagram.Relationships = append(diagram.Relationships, rel)
 			continue
 		}
 
 		// Try to parse as entity header
-		entityMatches := entityHeaderRegex.FindStringSubmatch(trimmed)
-		if entityMatches != nil {
+		if m := matchTrimmed(entityHeaderRegex, line, lineNum); m != nil {
 			// Save previous entity if it exists
 			if currentEntity != nil {
 				diagram.Entities = append(diagram.Entities, *currentEntity)
 			}
 
 			currentEntity = &ast.EREntity{
-				Name:       entityMatches[1],
+				Alias:      m.groups[2],
 				Attributes: []ast.ERAttribute{},
-				Pos:        ast.Position{Line: i + 1, Column: 1},
-			}
-
-			if entityMatches[2] != "" {
-				currentEntity.Alias = entityMatches[2]
+				Pos:        span.Start,
+				End:        span.End,
+				AliasSpan:  m.span(2),
 			}
+			currentEntity.Name, currentEntity.NameSpan = m.unquoted(1)
 
 			// Check if this is a block entity (has opening brace)
 			if strings.HasSuffix(trimmed, "{") {
@@ -161,21 +191,21 @@ func (p *ERParser) Parse(source string) (ast.Diagram, error) {
 		}
 
 		// Try to parse as simple entity (no block)
-		simpleMatches := simpleEntityRegex.FindStringSubmatch(trimmed)
-		if simpleMatches != nil {
+		if m := matchTrimmed(simpleEntityRegex, line, lineNum); m != nil {
 			entity := ast.EREntity{
-				Name:       simpleMatches[1],
+				Alias:      m.groups[2],
 				Attributes: []ast.ERAttribute{},
-				Pos:        ast.Position{Line: i + 1, Column: 1},
-			}
-			if simpleMatches[2] != "" {
-				entity.Alias = simpleMatches[2]
+				Pos:        span.Start,
+				End:        span.End,
+				AliasSpan:  m.span(2),
 			}
+			entity.Name, entity.NameSpan = m.unquoted(1)
 			diagram.Entities = append(diagram.Entities, entity)
 			continue
 		}
 
-		return nil, fmt.Errorf("line %d: invalid ER diagram syntax: %s", i+1, trimmed)
+		// Keep lines we can't parse so validators can report them
+		diagram.Unknown = append(diagram.Unknown, unknownStatement(lineNum, line))
 	}
 
 	// Save final entity if exists
@@ -183,7 +213,7 @@ func (p *ERParser) Parse(source string) (ast.Diagram, error) {
 		diagram.Entities = append(diagram.Entities, *currentEntity)
 	}
 
-	return diagram, nil
+	return diagram, errs.errFor(diagram.GetType())
 }
 
 // SupportedTypes returns the diagram types this parser supports.
@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
+
+// ParseError describes a single syntax error found while parsing a diagram.
+// Every DiagramParser reports syntax errors as ParseError values, either on their
+// own or inside an ErrorList, so callers can use errors.As to get at the position.
+type ParseError struct {
+	Pos         ast.Position // Start of the offending text
+	End         ast.Position // End of the offending text (exclusive), zero if unknown
+	DiagramType string       // Type of diagram being parsed, e.g. "flowchart"
+	Found       string       // The offending text, if any
+	Expected    string       // What the parser expected instead, if known
+	Hint        string       // Optional suggestion for fixing the problem
+	Message     string       // Description of the problem
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string {
+	switch {
+	case e.Pos.Line > 0 && e.Pos.Column > 0:
+		return fmt.Sprintf("line %d, column %d: %s", e.Pos.Line, e.Pos.Column, e.Message)
+	case e.Pos.Line > 0:
+		return fmt.Sprintf("line %d: %s", e.Pos.Line, e.Message)
+	}
+	return e.Message
+}
+
+// ErrorList is the list of syntax errors found in a single diagram.
+//
+// Parsers do not stop at the first syntax error. They skip the offending line or
+// block, carry on, and return the partially parsed diagram together with an
+// ErrorList describing every problem found.
+type ErrorList []*ParseError
+
+// Add appends a new error at the given position.
+func (l *ErrorList) Add(pos ast.Position, format string, args ...any) {
+	*l = append(*l, &ParseError{Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// addLine appends a new error covering the non-blank text of a source line and
+// returns it so the caller can fill in Expected or Hint.
+func (l *ErrorList) addLine(lineNum int, line, format string, args ...any) *ParseError {
+	e := lineError(lineNum, line, fmt.Sprintf(format, args...))
+	*l = append(*l, e)
+	return e
+}
+
+// Error implements the error interface.
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
+
+// Unwrap returns the individual errors so they can be inspected with errors.Is and errors.As.
+func (l ErrorList) Unwrap() []error {
+	errs := make([]error, len(l))
+	for i, e := range l {
+		errs[i] = e
+	}
+	return errs
+}
+
+// Err returns the list as an error, or nil if the list is empty.
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// errFor records the diagram type on every error and returns the list as an error.
+func (l ErrorList) errFor(diagramType string) error {
+	for _, e := range l {
+		if e.DiagramType == "" {
+			e.DiagramType = diagramType
+		}
+	}
+	return l.Err()
+}
+
+// Errors returns every syntax error carried by err.
+// It returns nil if err is nil or does not contain any ParseError.
+func Errors(err error) ErrorList {
+	if err == nil {
+		return nil
+	}
+	var list ErrorList
+	if errors.As(err, &list) {
+		return list
+	}
+	var single *ParseError
+	if errors.As(err, &single) {
+		return ErrorList{single}
+	}
+	return nil
+}
+
+// linePos returns the position of the first non-blank character on a line.
+func linePos(lineNum int, line string) ast.Position {
+	return ast.Position{Line: lineNum, Column: indentOf(line) + 1}
+}
+
+// lineError returns an error covering the non-blank text of a source line.
+func lineError(lineNum int, line, message string) *ParseError {
+	span := textSpan(lineNum, line)
+	return &ParseError{
+		Pos:     span.Start,
+		End:     span.End,
+		Found:   strings.TrimSpace(line),
+		Message: message,
+	}
+}
+
+// unknownStatement records a source line the parser could not recognise, so
+// that validators can report it.
+func unknownStatement(lineNum int, line string) *ast.UnknownStatement {
+	span := textSpan(lineNum, line)
+	return &ast.UnknownStatement{
+		Text: strings.TrimSpace(line),
+		Pos:  span.Start,
+		End:  span.End,
+	}
+}
+
+// emptySourceError returns the error reported when there is nothing to parse.
+func emptySourceError(diagramType string) error {
+	return &ParseError{
+		DiagramType: diagramType,
+		Message:     "empty diagram source",
+		Hint:        "the first line must be the diagram header",
+	}
+}
+
+// headerError returns the error reported when a diagram does not start with the
+// header its parser expects.
+func headerError(diagramType string, lineNum int, line, expected string) error {
+	e := lineError(lineNum, line, fmt.Sprintf("invalid %s diagram header: expected %s, got %q", diagramType, expected, strings.TrimSpace(line)))
+	e.DiagramType = diagramType
+	e.Expected = expected
+	return e
+}
@@ -2,41 +2,83 @@
 package parser
 
 import (
+	"context"
 	"fmt"
+	"html"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/sammcj/mermaid-check/ast"
 )
 
 var (
 	// Regex patterns for Mermaid syntax
-	headerPattern        = regexp.MustCompile(`^\s*(flowchart|graph)\s+(TB|TD|BT|RL|LR)\s*$`)
+	headerPattern        = regexp.MustCompile(`^\s*(flowchart|graph)(?:\s+(TB|TD|BT|RL|LR))?\s*$`)
 	commentPattern       = regexp.MustCompile(`^\s*%%(.*)$`)
-	subgraphStartPattern = regexp.MustCompile(`^\s*subgraph\s+(?:(\w+)\s*\[([^\]]+)\]|(\w+)|"([^"]+)")\s*$`)
+	subgraphStartPattern = regexp.MustCompile(`^\s*subgraph\s+(?:(` + hyphenIdent + `)\s*\[([^\]]+)\]|(` + hyphenIdent + `)|"([^"]+)")\s*$`)
 	subgraphEndPattern   = regexp.MustCompile(`^\s*end\s*$`)
-	classDefPattern      = regexp.MustCompile(`^\s*classDef\s+(\w+)\s+(.+)$`)
-	classAssignPattern   = regexp.MustCompile(`^\s*class\s+([\w,\s]+?)\s+(\w+)\s*$`)
-
-	// Node and link patterns
-	// NOTE: Order matters in alternation - longer patterns must come before shorter ones
-	nodeDefPattern = regexp.MustCompile(`^\s*(\w+)\s*(\{\{|\[\[|\(\(|\[\(|\(\[|\[|\(|\{|>)([^\])\}]*?)(\}\}|\]\]|\)\)|\)\]|\]\)|\]|\)|\})?\s*$`)
-
-	// Pattern to match a node reference with optional inline definition
-	// Captures: nodeID + optional (openBracket + label + closeBracket)
-	// NOTE: Order matters in alternation - longer patterns must come before shorter ones
-	nodeWithOptDef   = `(\w+)(?:\s*(\{\{|\[\[|\(\(|\[\(|\(\[|\[|\(|\{|>)([^\])\}]*?)(\}\}|\]\]|\)\)|\)\]|\]\)|\]|\)|\}))?`
-	linkPattern      = regexp.MustCompile(`^` + nodeWithOptDef + `\s*(<)?(-{2,3}|-\.{1,2}-|={2,3})(>)?\s*(\|([^|]+)\|)?\s*` + nodeWithOptDef + `$`)
-	biDirLinkPattern = regexp.MustCompile(`^` + nodeWithOptDef + `\s*(<)(--|==|-\.-)(>)\s*(\|([^|]+)\|)?\s*` + nodeWithOptDef + `$`)
+	directionPattern     = regexp.MustCompile(`^\s*direction\s+(TB|TD|BT|RL|LR)\s*$`)
+	classDefPattern      = regexp.MustCompile(`^\s*classDef\s+(` + hyphenIdent + `)\s+(.+)$`)
+	classAssignPattern   = regexp.MustCompile(`^\s*class\s+([` + identChars + `,\s-]+?)\s+(` + hyphenIdent + `)\s*$`)
+	stylePattern         = regexp.MustCompile(`^\s*style\s+(` + hyphenIdent + `)\s+(.+)$`)
+	linkStylePattern     = regexp.MustCompile(`^\s*linkStyle\s+(default|\d+(?:\s*,\s*\d+)*)\s+(.+)$`)
+
+	// Click groups: node ID, then a URL (optionally after href), a call expression
+	// or a callback name, then an optional tooltip and link target
+	clickPattern = regexp.MustCompile(`^\s*click\s+(` + hyphenIdent + `)\s+(?:(?:href\s+)?"([^"]*)"|call\s+(` + ident + `\([^)]*\))|(` + ident + `))(?:\s+"([^"]*)")?(?:\s+(_blank|_self|_parent|_top))?\s*$`)
+
+	// Node patterns. A node's shape is given by brackets, matched using nodeShapes,
+	// or by an @{} block such as A@{ shape: cyl, label: "Database" }
+	nodeIDPattern    = regexp.MustCompile(`^\s*(` + hyphenIdent + `)`)
+	nodePropsPattern = regexp.MustCompile(`^@\{([^}]*)\}`)
+	nodeClassPattern = regexp.MustCompile(`^:::(` + hyphenIdent + `)`)
+
+	// A link statement is a chain of node groups joined by arrows, where a group is
+	// one or more node references joined by '&'. These patterns match one part of
+	// the chain at a time, from the start of the remaining text.
+	nodeSepPattern = regexp.MustCompile(`^\s*&`)
+
+	// Arrows, with an optional edge ID such as e1@ and ends such as < > o x. Groups:
+	// edge ID, tail, line, head and |label| for arrowPattern; edge ID, tail, line
+	// start, inline text and line end with head for textArrowPattern, as in -- text -->
+	arrowPattern     = regexp.MustCompile(`^\s*(?:(` + hyphenIdent + `)@)?([<ox])?(-{2,}|={2,}|-\.+-|~{3,})([>ox])?(?:\s*\|([^|]+)\|)?`)
+	textArrowPattern = regexp.MustCompile(`^\s*(?:(` + hyphenIdent + `)@)?([<ox])?(--|==|-\.)\s*([^|]+?)\s*(-{2,}[>ox]|-{3,}|={2,}[>ox]|={3,}|\.+-[>ox]?)`)
 )
 
+// nodeShapes lists the bracket syntax of each node shape. Brackets that start with
+// another shape's brackets come first, so that (( is tried before (.
+var nodeShapes = []struct {
+	open, close string
+	kind        ast.NodeShape
+}{
+	{"(((", ")))", ast.ShapeDoubleCircle},
+	{"([", "])", ast.ShapeStadium},
+	{"[(", ")]", ast.ShapeCylinder},
+	{"[[", "]]", ast.ShapeSubroutine},
+	{"((", "))", ast.ShapeCircle},
+	{"{{", "}}", ast.ShapeHexagon},
+	{"[/", "/]", ast.ShapeLeanRight},
+	{"[/", `\]`, ast.ShapeTrapezoid},
+	{`[\`, `\]`, ast.ShapeLeanLeft},
+	{`[\`, "/]", ast.ShapeInvTrapezoid},
+	{"[", "]", ast.ShapeRect},
+	{"(", ")", ast.ShapeRounded},
+	{"{", "}", ast.ShapeDiamond},
+	{">", "]", ast.ShapeAsymmetric},
+}
+
 // FlowchartParser parses Mermaid flowchart and graph diagrams.
-type FlowchartParser struct {
-	// Pending NodeDefs from link parsing (from and to nodes)
-	pendingFromNode *ast.NodeDef
-	pendingToNode   *ast.NodeDef
-	// Track which nodes have been defined to avoid duplicates
-	definedNodes map[string]bool
+type FlowchartParser struct{}
+
+// flowchartState holds what is tracked while parsing a single flowchart, so that
+// FlowchartParser itself has no state.
+type flowchartState struct {
+	errs         *ErrorList
+	lim          *limiter
+	definedNodes map[string]bool      // Nodes defined so far, so inline definitions are not duplicated
+	edges        map[string]*ast.Link // Links with an edge ID, for e1@{ ... } statements
 }
 
 // SupportedTypes returns the diagram types this parser handles.
@@ -46,206 +88,276 @@ func (p *FlowchartParser) SupportedTypes() []string {
 
 // NewFlowchartParser creates a new flowchart parser.
 func NewFlowchartParser() *FlowchartParser {
-	return &FlowchartParser{
-		definedNodes: make(map[string]bool),
-	}
+	return &FlowchartParser{}
 }
 
 // Parse parses a Mermaid flowchart/graph diagram from a string.
 func (p *FlowchartParser) Parse(source string) (ast.Diagram, error) {
-	lines := strings.Split(source, "\n")
-	flowchart, err := p.parseLines(lines)
-	if err != nil {
-		return nil, err
-	}
-	// Set the source field
-	flowchart.Source = source
-	return flowchart, nil
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses a Mermaid flowchart/graph diagram within the limits in opts.
+func (p *FlowchartParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseLimited(ctx, source, opts, func(lim *limiter) (ast.Diagram, error) {
+		flowchart, err := p.parseLines(strings.Split(source, "\n"), lim)
+		if flowchart == nil {
+			return nil, err
+		}
+		// Set the source field
+		flowchart.Source = source
+		flowchart.End = ast.SourceEnd(source)
+		return flowchart, err
+	})
 }
 
 // ParseBytes parses a Mermaid flowchart/graph diagram from bytes.
 func (p *FlowchartParser) ParseBytes(_ string, source []byte) (*ast.Flowchart, error) {
 	diagram, err := p.Parse(string(source))
-	if err != nil {
+	if diagram == nil {
 		return nil, err
 	}
 	flowchart, ok := diagram.(*ast.Flowchart)
 	if !ok {
 		return nil, fmt.Errorf("parsed diagram is not a flowchart: %T", diagram)
 	}
-	return flowchart, nil
+	return flowchart, err
 }
 
-func (p *FlowchartParser) parseLines(lines []string) (*ast.Flowchart, error) {
+// parseLines parses the diagram lines. A non-nil flowchart is returned whenever the
+// header is valid; any syntax errors found in the body are returned alongside it.
+func (p *FlowchartParser) parseLines(lines []string, lim *limiter) (*ast.Flowchart, error) {
 	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty diagram")
+		return nil, emptySourceError("flowchart")
 	}
 
-	// Parse header
-	header := strings.TrimSpace(lines[0])
-	matches := headerPattern.FindStringSubmatch(header)
-	if matches == nil {
-		return nil, fmt.Errorf("invalid diagram header: expected 'flowchart' or 'graph' followed by direction")
+	var errs ErrorList
+	meta, header, err := parseMetadata(lines, "flowchart", &errs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse header, which statements may follow on the same line after a ';'
+	body := splitStatements(lines[header:], header)
+	var m *lineMatch
+	if len(body) > 0 && body[0].num == header+1 {
+		m = matchTrimmed(headerPattern, body[0].text, header+1)
+	}
+	if m == nil {
+		return nil, headerError("flowchart", header+1, lines[header], "'flowchart' or 'graph', optionally followed by a direction (TB, TD, BT, RL, LR)")
 	}
 
 	flowchart := &ast.Flowchart{
-		Type:      matches[1],
-		Direction: matches[2],
-		Pos:       ast.Position{Line: 1, Column: 1},
+		Type:          m.groups[1],
+		Direction:     m.groups[2],
+		Pos:           linePos(header+1, lines[header]),
+		DirectionSpan: m.span(2),
+		Metadata:      meta,
+	}
+	if flowchart.Direction == "" {
+		// Mermaid draws top to bottom by default
+		flowchart.Direction = "TB"
 	}
 
 	// Parse statements
-	statements, err := p.parseStatements(lines[1:], 1, false)
-	if err != nil {
-		return nil, err
+	st := &flowchartState{
+		errs:         &errs,
+		lim:          lim,
+		definedNodes: make(map[string]bool),
+		edges:        make(map[string]*ast.Link),
 	}
-	flowchart.Statements = statements
+	flowchart.Statements = p.parseStatements(body[1:], nil, st)
 
-	return flowchart, nil
+	return flowchart, errs.errFor(flowchart.Type)
 }
 
-func (p *FlowchartParser) parseStatements(lines []string, startLine int, inSubgraph bool) ([]ast.Statement, error) {
+// parseStatements parses the statements of the diagram body, or of subgraph if it
+// is not nil, stopping at the subgraph's 'end'.
+func (p *FlowchartParser) parseStatements(lines []statementLine, subgraph *ast.Subgraph, st *flowchartState) []ast.Statement {
 	var statements []ast.Statement
-	lineNum := startLine
 
 	for i := 0; i < len(lines); i++ {
-		lineNum++
-		line := lines[i]
+		lineNum, line := lines[i].num, lines[i].text
 		trimmed := strings.TrimSpace(line)
 
 		// Skip empty lines
 		if trimmed == "" {
 			continue
 		}
+		if !st.lim.statement(lineNum) {
+			break
+		}
+		span := textSpan(lineNum, line)
 
 		// Handle comments
 		if commentPattern.MatchString(trimmed) {
 			matches := commentPattern.FindStringSubmatch(trimmed)
 			statements = append(statements, &ast.Comment{
 				Text: strings.TrimSpace(matches[1]),
-				Pos:  ast.Position{Line: lineNum, Column: 1},
+				Pos:  span.Start,
+				End:  span.End,
 			})
 			continue
 		}
 
 		// Handle subgraph end
 		if subgraphEndPattern.MatchString(trimmed) {
-			if !inSubgraph {
-				return nil, fmt.Errorf("line %d: 'end' without matching 'subgraph'", lineNum)
+			if subgraph == nil {
+				e := st.errs.addLine(lineNum, line, "'end' without matching 'subgraph'")
+				e.Hint = "remove the 'end' or open a subgraph before it"
+				continue
 			}
-			return statements, nil
+			return statements
 		}
 
 		// Handle subgraph start
-		if matches := subgraphStartPattern.FindStringSubmatch(trimmed); matches != nil {
-			// Find the matching 'end'
-			nestedLines, consumed, err := p.extractSubgraphLines(lines[i+1:], lineNum+1)
-			if err != nil {
-				return nil, err
+		if m := matchTrimmed(subgraphStartPattern, line, lineNum); m != nil {
+			// Find the matching 'end'. An unclosed subgraph runs to the end of the diagram.
+			nestedLines, consumed, closed := p.extractSubgraphLines(lines[i+1:])
+			if !closed {
+				e := st.errs.addLine(lineNum, line, "unclosed subgraph, missing 'end'")
+				e.Expected = "'end'"
 			}
 
-			nestedStatements, err := p.parseStatements(nestedLines, lineNum, true)
-			if err != nil {
-				return nil, err
+			// Extract the ID and title from matches
+			// 1: ID (if using ID[display] syntax)
+			// 2: Display name in brackets (if using ID[display] syntax)
+			// 3: Bare ID, also used as the title (if using ID syntax)
+			// 4: Quoted name, with no ID (if using "name" syntax)
+			nested := &ast.Subgraph{Pos: span.Start}
+			switch {
+			case m.groups[1] != "":
+				// Use display name from brackets, strip quotes if present
+				nested.ID, nested.IDSpan = m.groups[1], m.span(1)
+				nested.Title = strings.Trim(m.groups[2], `"`)
+				nested.TitleSpan = narrow(m.span(2), m.groups[2], nested.Title)
+			case m.groups[3] != "":
+				nested.ID, nested.IDSpan = m.groups[3], m.span(3)
+				nested.Title, nested.TitleSpan = m.groups[3], m.span(3)
+			default:
+				nested.Title, nested.TitleSpan = m.groups[4], m.span(4)
 			}
 
-			// Extract title from matches
-			// matches[1]: ID (if using ID[display] syntax)
-			// matches[2]: Display name in brackets (if using ID[display] syntax)
-			// matches[3]: Quoted name (if using "name" syntax)
-			var title string
-			if matches[2] != "" {
-				// Use display name from brackets, strip quotes if present
-				title = strings.Trim(matches[2], `"`)
-			} else if matches[1] != "" {
-				// Use ID if no display name
-				title = matches[1]
-			} else if matches[3] != "" {
-				// Use quoted name
-				title = matches[3]
+			if !st.lim.enter(lineNum) {
+				break
 			}
+			nested.Statements = p.parseStatements(nestedLines, nested, st)
+			st.lim.leave()
 
-			statements = append(statements, &ast.Subgraph{
-				Title:      title,
-				Statements: nestedStatements,
-				Pos:        ast.Position{Line: lineNum, Column: 1},
-			})
+			last := lines[i+consumed]
+			nested.End = lineEnd(last.num, last.text)
+			statements = append(statements, nested)
 
 			i += consumed
-			lineNum += consumed
+			continue
+		}
+
+		// Handle direction, which only applies inside a subgraph
+		if m := matchTrimmed(directionPattern, line, lineNum); m != nil && subgraph != nil {
+			subgraph.Direction, subgraph.DirectionSpan = m.groups[1], m.span(1)
 			continue
 		}
 
 		// Handle classDef
-		if matches := classDefPattern.FindStringSubmatch(trimmed); matches != nil {
-			styles := p.parseStyles(matches[2])
+		if m := matchTrimmed(classDefPattern, line, lineNum); m != nil {
+			_, stylesSpan := m.trimmed(2)
 			statements = append(statements, &ast.ClassDef{
-				Name:   matches[1],
-				Styles: styles,
-				Pos:    ast.Position{Line: lineNum, Column: 1},
+				Name:       m.groups[1],
+				Styles:     p.parseStyles(m.groups[2]),
+				Pos:        span.Start,
+				End:        span.End,
+				NameSpan:   m.span(1),
+				StylesSpan: stylesSpan,
 			})
 			continue
 		}
 
 		// Handle class assignment
-		if matches := classAssignPattern.FindStringSubmatch(trimmed); matches != nil {
-			nodeIDs := strings.Split(matches[1], ",")
-			for i, id := range nodeIDs {
-				nodeIDs[i] = strings.TrimSpace(id)
-			}
+		if m := matchTrimmed(classAssignPattern, line, lineNum); m != nil {
+			nodeIDs, nodeIDSpans := splitTrimmed(m.groups[1], m.span(1), ",")
 			statements = append(statements, &ast.ClassAssignment{
-				NodeIDs:   nodeIDs,
-				ClassName: matches[2],
-				Pos:       ast.Position{Line: lineNum, Column: 1},
+				NodeIDs:       nodeIDs,
+				ClassName:     m.groups[2],
+				Pos:           span.Start,
+				End:           span.End,
+				NodeIDSpans:   nodeIDSpans,
+				ClassNameSpan: m.span(2),
 			})
 			continue
 		}
 
-		// Try to parse as link (bidirectional or unidirectional)
-		if stmt := p.parseLink(trimmed, lineNum); stmt != nil {
-			// Insert inline NodeDefs in the correct order:
-			// 1. "from" node definition (if present)
-			// 2. Link statement
-			// 3. "to" node definition (if present)
-			if p.pendingFromNode != nil {
-				statements = append(statements, p.pendingFromNode)
-			}
-			statements = append(statements, stmt)
-			if p.pendingToNode != nil {
-				statements = append(statements, p.pendingToNode)
-			}
-			// Clear pending nodes
-			p.pendingFromNode = nil
-			p.pendingToNode = nil
+		// Handle style
+		if m := matchTrimmed(stylePattern, line, lineNum); m != nil {
+			_, stylesSpan := m.trimmed(2)
+			statements = append(statements, &ast.Style{
+				NodeID:     m.groups[1],
+				Styles:     p.parseStyles(m.groups[2]),
+				Pos:        span.Start,
+				End:        span.End,
+				NodeIDSpan: m.span(1),
+				StylesSpan: stylesSpan,
+			})
+			continue
+		}
+
+		// Handle linkStyle
+		if m := matchTrimmed(linkStylePattern, line, lineNum); m != nil {
+			statements = append(statements, p.parseLinkStyle(m, span))
+			continue
+		}
+
+		// Handle click
+		if m := matchTrimmed(clickPattern, line, lineNum); m != nil {
+			statements = append(statements, p.parseClick(m, span))
+			continue
+		}
+
+		// Try to parse as link, which may be a chain or fan out with '&'
+		if linkStatements := p.parseLink(line, lineNum, st); linkStatements != nil {
+			statements = append(statements, linkStatements...)
 			continue
 		}
 
 		// Try to parse as node definition
-		if stmt := p.parseNodeDef(trimmed, lineNum); stmt != nil {
-			if nodeDef, ok := stmt.(*ast.NodeDef); ok {
-				p.definedNodes[nodeDef.ID] = true
+		if ref, ok := p.parseNodeDef(line, lineNum); ok {
+			// e1@{ animate: true } sets properties of the link with edge ID e1
+			if link := st.edges[ref.node.ID]; link != nil && ref.node.Properties != nil && ref.class == "" {
+				link.Properties = ref.node.Properties
+				continue
+			}
+			// A bare A or A:::class only refers to a node defined earlier
+			if st.definedNodes[ref.node.ID] && !ref.inline {
+				if assign := ref.classAssignment(); assign != nil {
+					statements = append(statements, assign)
+				}
+				continue
+			}
+			st.definedNodes[ref.node.ID] = true
+			statements = append(statements, ref.node)
+			if assign := ref.classAssignment(); assign != nil {
+				statements = append(statements, assign)
 			}
-			statements = append(statements, stmt)
 			continue
 		}
 
-		// If we can't parse the line, skip it (for now - could return error in strict mode)
-		continue
-	}
-
-	if inSubgraph {
-		return nil, fmt.Errorf("unclosed subgraph")
+		// Keep lines we can't parse so validators can report them
+		statements = append(statements, &ast.UnknownStatement{
+			Text: trimmed,
+			Pos:  span.Start,
+			End:  span.End,
+		})
 	}
 
-	return statements, nil
+	return statements
 }
 
-func (p *FlowchartParser) extractSubgraphLines(lines []string, startLine int) ([]string, int, error) {
-	var subgraphLines []string
+// extractSubgraphLines returns the lines of a subgraph body up to and including its
+// matching 'end', and the number of lines consumed. If no matching 'end' is found,
+// all remaining lines are returned and closed is false.
+func (p *FlowchartParser) extractSubgraphLines(lines []statementLine) (subgraphLines []statementLine, consumed int, closed bool) {
 	depth := 0
 
 	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
+		trimmed := strings.TrimSpace(line.text)
 
 		//nolint:gocritic // if-else chain is more readable here than a switch
 		if subgraphStartPattern.MatchString(trimmed) {
@@ -255,7 +367,7 @@ func (p *FlowchartParser) extractSubgraphLines(lines []string, startLine int) ([
 			if depth == 0 {
 				// This is the end of our subgraph - include it
 				subgraphLines = append(subgraphLines, line)
-				return subgraphLines, i + 1, nil
+				return subgraphLines, i + 1, true
 			}
 			depth--
 			subgraphLines = append(subgraphLines, line)
@@ -264,167 +376,513 @@ func (p *FlowchartParser) extractSubgraphLines(lines []string, startLine int) ([
 		}
 	}
 
-	return nil, 0, fmt.Errorf("line %d: unclosed subgraph", startLine)
+	return lines, len(lines), false
+}
+
+// statementLine is one statement of the diagram body. Statements are usually one
+// per line, but a line may hold several separated by ';' and end with a %%
+// comment. text is the source line with everything outside the statement blanked
+// out, so byte indexes in text are also byte indexes in the source line.
+type statementLine struct {
+	num  int // Line number (1-indexed)
+	text string
+}
+
+// splitStatements splits source lines, the first of which is line start+1, into
+// statements. A trailing %% comment becomes a statement of its own. Separators
+// inside quotes, brackets and |link labels| are part of the statement.
+func splitStatements(lines []string, start int) []statementLine {
+	var statements []statementLine
+	for i, line := range lines {
+		num := start + i + 1
+		add := func(from, to int) {
+			if strings.TrimSpace(line[from:to]) != "" {
+				statements = append(statements, statementLine{num: num, text: strings.Repeat(" ", from) + line[from:to]})
+			}
+		}
+
+		from, depth := 0, 0
+		inQuote, inLabel := false, false
+		for j := 0; j < len(line); j++ {
+			c := line[j]
+			switch {
+			case c == '"':
+				inQuote = !inQuote
+			case inQuote:
+			case c == '[' || c == '(' || c == '{':
+				depth++
+			case c == ']' || c == ')' || c == '}':
+				depth = max(depth-1, 0)
+			case depth > 0:
+			case c == '|':
+				inLabel = !inLabel
+			case inLabel:
+			case c == ';':
+				add(from, j)
+				from = j + 1
+			case c == '%' && strings.HasPrefix(line[j:], "%%"):
+				// The rest of the line is a comment
+				add(from, j)
+				from = j
+				j = len(line)
+			}
+		}
+		add(from, len(line))
+	}
+	return statements
+}
+
+// nodeRef is a node referenced in a statement. If inline is set, node is the
+// inline definition that followed the ID, such as B[Label] or B@{ shape: cyl }.
+// class is set by the B:::class shorthand.
+type nodeRef struct {
+	node      *ast.NodeDef
+	inline    bool
+	class     string
+	classSpan ast.Span
 }
 
-// extractNodeDef extracts a NodeDef from a node reference that may include an inline definition
-// e.g., "B[Label]" -> NodeDef{ID: "B", Label: "Label", Shape: "[]"}
-// Returns nil if the node reference is just an ID without a definition
-func (p *FlowchartParser) extractNodeDef(nodeID, openBracket, label, closeBracket string, lineNum int) *ast.NodeDef {
-	// If no brackets, it's just a node reference, not a definition
-	if openBracket == "" || closeBracket == "" {
+// classAssignment returns the class assignment made by the reference, or nil.
+func (r nodeRef) classAssignment() *ast.ClassAssignment {
+	if r.class == "" {
 		return nil
 	}
+	return &ast.ClassAssignment{
+		NodeIDs:       []string{r.node.ID},
+		ClassName:     r.class,
+		Shorthand:     true,
+		Pos:           r.node.Pos,
+		End:           r.classSpan.End,
+		NodeIDSpans:   []ast.Span{r.node.IDSpan},
+		ClassNameSpan: r.classSpan,
+	}
+}
 
-	return &ast.NodeDef{
-		ID:    nodeID,
-		Shape: openBracket + closeBracket,
-		Label: strings.TrimSpace(label),
-		Pos:   ast.Position{Line: lineNum, Column: 1},
+// scanNode scans a node reference at the start of text, which starts at byte base
+// of line lineNum. It returns the reference and the number of bytes scanned, or
+// false if text does not start with a node reference.
+func scanNode(text string, lineNum, base int) (ref nodeRef, n int, ok bool) {
+	m := matchLine(nodeIDPattern, text, lineNum, base)
+	if m == nil {
+		return nodeRef{}, 0, false
+	}
+	ref.node = &ast.NodeDef{
+		ID:     m.groups[1],
+		Kind:   ast.ShapeRect,
+		Pos:    m.span(1).Start,
+		End:    m.span(1).End,
+		IDSpan: m.span(1),
 	}
+	n = m.loc[1]
+
+	// An @{} block must directly follow the ID
+	if props := matchLine(nodePropsPattern, text[n:], lineNum, base+n); props != nil {
+		if !parseNodeProperties(ref.node, props) {
+			return nodeRef{}, 0, false
+		}
+		ref.node.End = props.span(0).End
+		ref.inline = true
+		n += props.loc[1]
+	} else if end := scanShape(ref.node, text, n, lineNum, base); end > 0 {
+		ref.inline = true
+		n = end
+	}
+
+	if class := matchLine(nodeClassPattern, text[n:], lineNum, base+n); class != nil {
+		ref.class, ref.classSpan = class.groups[1], class.span(1)
+		n += class.loc[1]
+	}
+	return ref, n, true
 }
 
-func (p *FlowchartParser) parseLink(line string, lineNum int) ast.Statement {
-	// Clear pending nodes from previous calls
-	p.pendingFromNode = nil
-	p.pendingToNode = nil
-
-	// Try bidirectional link first
-	if matches := biDirLinkPattern.FindStringSubmatch(line); matches != nil {
-		// Updated match groups with inline node definitions:
-		// 1: from ID
-		// 2: from open bracket (optional)
-		// 3: from label (optional)
-		// 4: from close bracket (optional)
-		// 5: left arrow part <
-		// 6: arrow middle (-->, ===, ---)
-		// 7: right arrow part >
-		// 8: link label with pipes (optional)
-		// 9: link label content (optional)
-		// 10: to ID
-		// 11: to open bracket (optional)
-		// 12: to label (optional)
-		// 13: to close bracket (optional)
-
-		fromID := matches[1]
-		toID := matches[10]
-
-		// Extract inline NodeDefs if present and not already defined
-		if !p.definedNodes[fromID] {
-			p.pendingFromNode = p.extractNodeDef(matches[1], matches[2], matches[3], matches[4], lineNum)
-			if p.pendingFromNode != nil {
-				p.definedNodes[fromID] = true
+// scanShape scans the brackets giving a node's shape and label, which may follow
+// whitespace at byte start of text, and sets them on node. It returns the index
+// just past the closing bracket, or 0 if there are no brackets.
+func scanShape(node *ast.NodeDef, text string, start, lineNum, base int) int {
+	rest := strings.TrimLeftFunc(text[start:], unicode.IsSpace)
+	start = len(text) - len(rest)
+	for _, shape := range nodeShapes {
+		if !strings.HasPrefix(rest, shape.open) {
+			continue
+		}
+		body := rest[len(shape.open):]
+		var label string
+		if n := quotedLength(body); n > 0 {
+			// A quoted label may contain brackets, so only the closing bracket may follow it
+			after := strings.TrimLeftFunc(body[n:], unicode.IsSpace)
+			if !strings.HasPrefix(after, shape.close) {
+				continue
 			}
+			label = body[:len(body)-len(after)]
+		} else {
+			// The label runs to the first closing bracket, which must be the whole of
+			// shape.close so [/a] is not read as the start of [/a/]
+			var found bool
+			label, _, found = strings.Cut(body, shape.close)
+			if !found || strings.Contains(label, shape.close[len(shape.close)-1:]) {
+				continue
+			}
+		}
+
+		labelStart := start + len(shape.open)
+		end := labelStart + len(label) + len(shape.close)
+		node.Shape = shape.open + shape.close
+		node.Kind = shape.kind
+		setLabel(node, label, lineSpan(lineNum, base+labelStart, base+labelStart+len(label)))
+		node.ShapeSpan = lineSpan(lineNum, base+start, base+end)
+		node.End = node.ShapeSpan.End
+		return end
+	}
+	return 0
+}
+
+// quotedLength returns the length of the quoted string at the start of text,
+// after any whitespace, or 0 if text does not start with one. A markdown string,
+// "`...`", runs to the closing backtick and quote, so it may contain quotes.
+func quotedLength(text string) int {
+	quoted := strings.TrimLeftFunc(text, unicode.IsSpace)
+	if !strings.HasPrefix(quoted, `"`) {
+		return 0
+	}
+	open, closing := `"`, `"`
+	if strings.HasPrefix(quoted, "\"`") {
+		open, closing = "\"`", "`\""
+	}
+	i := strings.Index(quoted[len(open):], closing)
+	if i < 0 {
+		return 0
+	}
+	return len(text) - len(quoted) + len(open) + i + len(closing)
+}
+
+// setLabel sets the label of node from raw, the label as written, which is at
+// span in the source. Surrounding quotes and the backticks of a markdown string
+// are removed and entity codes are resolved.
+func setLabel(node *ast.NodeDef, raw string, span ast.Span) {
+	node.RawLabel = strings.TrimSpace(raw)
+	if node.RawLabel == "" {
+		return
+	}
+	text := node.RawLabel
+	if len(text) >= 2 && text[0] == '"' && text[len(text)-1] == '"' {
+		text = text[1 : len(text)-1]
+		if len(text) >= 2 && text[0] == '`' && text[len(text)-1] == '`' {
+			text = text[1 : len(text)-1]
+			node.Markdown = true
+		}
+	}
+	node.Label = decodeEntities(text)
+	node.LabelSpan = narrow(span, raw, text)
+}
+
+// entityPattern matches Mermaid's entity codes, such as #quot; or #35;.
+var entityPattern = regexp.MustCompile(`#([A-Za-z][A-Za-z0-9]*|[0-9]+);`)
+
+// decodeEntities resolves the entity codes in a label. Codes that are not HTML
+// entities are left as written.
+func decodeEntities(text string) string {
+	if !strings.Contains(text, "#") {
+		return text
+	}
+	return entityPattern.ReplaceAllStringFunc(text, func(code string) string {
+		name := code[1 : len(code)-1]
+		entity := "&" + name + ";"
+		if name[0] >= '0' && name[0] <= '9' {
+			entity = "&#" + name + ";"
+		}
+		if decoded := html.UnescapeString(entity); decoded != entity {
+			return decoded
 		}
-		if !p.definedNodes[toID] {
-			p.pendingToNode = p.extractNodeDef(matches[10], matches[11], matches[12], matches[13], lineNum)
-			if p.pendingToNode != nil {
-				p.definedNodes[toID] = true
+		return code
+	})
+}
+
+// parseNodeProperties sets the properties of node from the body of an @{} block
+// matched by nodePropsPattern, such as `shape: cyl, label: "Database"`. The shape
+// and label entries also set the node's shape and label. It returns false if an
+// entry is not a key: value pair.
+func parseNodeProperties(node *ast.NodeDef, m *lineMatch) bool {
+	props, ok := parseProperties(m, 1)
+	if !ok {
+		return false
+	}
+	node.Properties = make(map[string]string)
+	for _, prop := range props {
+		node.Properties[prop.key] = prop.value
+		switch prop.key {
+		case "shape":
+			node.Shape, node.ShapeSpan = prop.value, prop.span
+			node.Kind, _ = ast.LookupShape(prop.value)
+		case "label":
+			node.RawLabel = prop.raw
+			node.Label, node.LabelSpan = decodeEntities(prop.value), prop.span
+		}
+	}
+	return true
+}
+
+// parseLink parses a link statement, expanding chains such as A --> B --> C and
+// groups such as A & B --> C & D into one link for each pair of nodes. Inline
+// definitions of nodes not yet defined are returned alongside the links, each
+// group's definitions following the links into it: A[x] --> B[y] gives A's
+// definition, the link, then B's definition.
+// It returns nil if the line is not a link statement.
+func (p *FlowchartParser) parseLink(line string, lineNum int, st *flowchartState) []ast.Statement {
+	groups, edges := splitLink(strings.TrimSpace(line), lineNum, indentOf(line))
+	if groups == nil {
+		return nil
+	}
+
+	var statements []ast.Statement
+	addDefs := func(group []nodeRef) {
+		for _, ref := range group {
+			if ref.inline && !st.definedNodes[ref.node.ID] {
+				st.definedNodes[ref.node.ID] = true
+				statements = append(statements, ref.node)
+			}
+			if assign := ref.classAssignment(); assign != nil {
+				statements = append(statements, assign)
 			}
 		}
+	}
 
-		label := ""
-		if len(matches) > 9 && matches[9] != "" {
-			label = strings.TrimSpace(matches[9])
-		}
-
-		return &ast.Link{
-			From:  fromID,
-			To:    toID,
-			Arrow: matches[5] + matches[6] + matches[7], // <-->
-			Label: label,
-			BiDir: true,
-			Pos:   ast.Position{Line: lineNum, Column: 1},
-		}
-	}
-
-	// Try unidirectional link
-	if matches := linkPattern.FindStringSubmatch(line); matches != nil {
-		// Updated match groups with inline node definitions:
-		// 1: from ID
-		// 2: from open bracket (optional)
-		// 3: from label (optional)
-		// 4: from close bracket (optional)
-		// 5: left arrow part < (optional)
-		// 6: arrow middle (--, ---, -.-, etc.)
-		// 7: right arrow part > (optional)
-		// 8: link label with pipes (optional)
-		// 9: link label content (optional)
-		// 10: to ID
-		// 11: to open bracket (optional)
-		// 12: to label (optional)
-		// 13: to close bracket (optional)
-
-		fromID := matches[1]
-		toID := matches[10]
-
-		// Extract inline NodeDefs if present and not already defined
-		if !p.definedNodes[fromID] {
-			p.pendingFromNode = p.extractNodeDef(matches[1], matches[2], matches[3], matches[4], lineNum)
-			if p.pendingFromNode != nil {
-				p.definedNodes[fromID] = true
+	addDefs(groups[0])
+	for i, e := range edges {
+		for _, from := range groups[i] {
+			for _, to := range groups[i+1] {
+				link := e.link(from.node, to.node)
+				if link.ID != "" {
+					st.edges[link.ID] = link
+				}
+				statements = append(statements, link)
 			}
 		}
-		if !p.definedNodes[toID] {
-			p.pendingToNode = p.extractNodeDef(matches[10], matches[11], matches[12], matches[13], lineNum)
-			if p.pendingToNode != nil {
-				p.definedNodes[toID] = true
+		addDefs(groups[i+1])
+	}
+	return statements
+}
+
+// splitLink splits a link statement into its groups of node references and the
+// edges between them. text starts at byte base of line lineNum. It returns nil
+// unless all of text is a link statement.
+func splitLink(text string, lineNum, base int) (groups [][]nodeRef, edges []edge) {
+	pos := 0
+	for {
+		var group []nodeRef
+		for {
+			ref, n, ok := scanNode(text[pos:], lineNum, base+pos)
+			if !ok {
+				return nil, nil
+			}
+			group = append(group, ref)
+			pos += n
+			sep := matchLine(nodeSepPattern, text[pos:], lineNum, base+pos)
+			if sep == nil {
+				break
 			}
+			pos += sep.loc[1]
 		}
+		groups = append(groups, group)
 
-		arrow := matches[6]
-		if matches[5] == "<" {
-			arrow = "<" + arrow
+		if pos == len(text) {
+			break
 		}
-		if matches[7] == ">" {
-			arrow += ">"
+		e, n, ok := scanEdge(text[pos:], lineNum, base+pos)
+		if !ok {
+			return nil, nil
 		}
+		edges = append(edges, e)
+		pos += n
+	}
 
-		label := ""
-		if len(matches) > 9 && matches[9] != "" {
-			label = strings.TrimSpace(matches[9])
+	if len(edges) == 0 {
+		return nil, nil
+	}
+	return groups, edges
+}
+
+// edge is an arrow between two groups of nodes in a link statement.
+type edge struct {
+	id         string
+	arrow      string
+	label      string
+	head, tail ast.ArrowHead
+	stroke     ast.LinkStroke
+	length     int
+
+	idSpan, span, labelSpan ast.Span
+}
+
+// arrowHeads maps the characters that can end an arrow to the heads they draw.
+var arrowHeads = map[string]ast.ArrowHead{
+	"<": ast.ArrowPoint,
+	">": ast.ArrowPoint,
+	"o": ast.ArrowCircle,
+	"x": ast.ArrowCross,
+}
+
+// scanEdge scans an arrow at the start of text, which starts at byte base of line
+// lineNum, such as -->, e1@==>|label|, o-.-o or -- text -->. It returns the edge
+// and the number of bytes scanned, or false if text does not start with an arrow.
+func scanEdge(text string, lineNum, base int) (e edge, n int, ok bool) {
+	if m := matchLine(arrowPattern, text, lineNum, base); m != nil {
+		tail, line, head := m.groups[2], m.groups[3], m.groups[4]
+		e = newEdge(m, tail, line, head)
+		// A bare -- or == starts an arrow with inline text, and ~~~ has no ends
+		short := len(line) == 2 && head == ""
+		invisibleEnds := e.stroke == ast.StrokeInvisible && (tail != "" || head != "")
+		if !short && !invisibleEnds {
+			e.arrow = tail + line + head
+			e.label, e.labelSpan = m.trimmed(5)
+			e.span = m.span(3)
+			if tail != "" {
+				e.span.Start = m.span(2).Start
+			}
+			if head != "" {
+				e.span.End = m.span(4).End
+			}
+			return e, m.loc[1], true
 		}
+	}
 
-		return &ast.Link{
-			From:  fromID,
-			To:    toID,
-			Arrow: arrow,
-			Label: label,
-			BiDir: false,
-			Pos:   ast.Position{Line: lineNum, Column: 1},
+	if m := matchLine(textArrowPattern, text, lineNum, base); m != nil {
+		tail, start, end := m.groups[2], m.groups[3], m.groups[5]
+		// The text is closed by the same kind of line that opened it
+		if end[0] != start[1] {
+			return edge{}, 0, false
+		}
+		head := ""
+		if arrowHeads[end[len(end)-1:]] != "" {
+			head, end = end[len(end)-1:], end[:len(end)-1]
+		}
+		line := end
+		if start == "-." {
+			line = "-" + end
 		}
+		e = newEdge(m, tail, line, head)
+		e.arrow = tail + line + head
+		e.label, e.labelSpan = m.trimmed(4)
+		e.span = ast.Span{Start: m.span(3).Start, End: m.span(5).End}
+		if tail != "" {
+			e.span.Start = m.span(2).Start
+		}
+		return e, m.loc[1], true
 	}
 
-	return nil
+	return edge{}, 0, false
 }
 
-func (p *FlowchartParser) parseNodeDef(line string, lineNum int) ast.Statement {
-	matches := nodeDefPattern.FindStringSubmatch(line)
-	if matches == nil {
-		return nil
+// newEdge returns an edge with the ID in group 1 of m and the given ends and line,
+// such as "--", "===" or "-..-".
+func newEdge(m *lineMatch, tail, line, head string) edge {
+	e := edge{
+		id:     m.groups[1],
+		idSpan: m.span(1),
+		head:   arrowHeads[head],
+		tail:   arrowHeads[tail],
+		stroke: ast.StrokeNormal,
 	}
 
-	id := matches[1]
-	shape := ""
-	label := ""
+	// Lines without a head need an extra character, so --- and --> are both length 1
+	length := len(line) - 1
+	if head == "" {
+		length--
+	}
+	switch {
+	case line[0] == '=':
+		e.stroke = ast.StrokeThick
+	case line[0] == '~':
+		e.stroke = ast.StrokeInvisible
+	case strings.Contains(line, "."):
+		e.stroke = ast.StrokeDotted
+		length = strings.Count(line, ".")
+	}
+	e.length = max(length, 1)
+	return e
+}
 
-	if len(matches) > 2 && matches[2] != "" {
-		// Shape is opening + closing brackets
-		shape = matches[2]
-		if len(matches) > 4 && matches[4] != "" {
-			shape += matches[4]
-		}
-		if len(matches) > 3 {
-			label = strings.TrimSpace(matches[3])
+// link returns the link drawn by the edge between two referenced nodes, running
+// from the start of from to the end of to.
+func (e edge) link(from, to *ast.NodeDef) *ast.Link {
+	return &ast.Link{
+		ID:        e.id,
+		From:      from.ID,
+		To:        to.ID,
+		Arrow:     e.arrow,
+		Label:     e.label,
+		Head:      e.head,
+		Tail:      e.tail,
+		Stroke:    e.stroke,
+		Length:    e.length,
+		BiDir:     e.head != ast.ArrowNone && e.tail != ast.ArrowNone,
+		Pos:       from.IDSpan.Start,
+		End:       to.End,
+		IDSpan:    e.idSpan,
+		FromSpan:  from.IDSpan,
+		ToSpan:    to.IDSpan,
+		ArrowSpan: e.span,
+		LabelSpan: e.labelSpan,
+	}
+}
+
+// parseNodeDef parses a line holding a single node, with or without a shape.
+func (p *FlowchartParser) parseNodeDef(line string, lineNum int) (nodeRef, bool) {
+	text := strings.TrimSpace(line)
+	ref, n, ok := scanNode(text, lineNum, indentOf(line))
+	if !ok || n != len(text) {
+		return nodeRef{}, false
+	}
+	return ref, true
+}
+
+// parseLinkStyle builds a link style from a match of linkStylePattern.
+func (p *FlowchartParser) parseLinkStyle(m *lineMatch, span ast.Span) *ast.LinkStyle {
+	_, stylesSpan := m.trimmed(2)
+	linkStyle := &ast.LinkStyle{
+		Styles:     p.parseStyles(m.groups[2]),
+		Pos:        span.Start,
+		End:        span.End,
+		StylesSpan: stylesSpan,
+	}
+	if m.groups[1] == "default" {
+		linkStyle.Default = true
+		return linkStyle
+	}
+
+	indexes, indexSpans := splitTrimmed(m.groups[1], m.span(1), ",")
+	for _, index := range indexes {
+		// The pattern only matches digits, so this can only fail on overflow
+		n, err := strconv.Atoi(index)
+		if err != nil {
+			n = -1
 		}
+		linkStyle.Indexes = append(linkStyle.Indexes, n)
 	}
+	linkStyle.RawIndexes = indexes
+	linkStyle.IndexSpans = indexSpans
+	return linkStyle
+}
 
-	return &ast.NodeDef{
-		ID:    id,
-		Shape: shape,
-		Label: label,
-		Pos:   ast.Position{Line: lineNum, Column: 1},
+// parseClick builds a click statement from a match of clickPattern.
+func (p *FlowchartParser) parseClick(m *lineMatch, span ast.Span) *ast.Click {
+	click := &ast.Click{
+		NodeID:      m.groups[1],
+		URL:         m.groups[2],
+		Tooltip:     m.groups[5],
+		Target:      m.groups[6],
+		Pos:         span.Start,
+		End:         span.End,
+		NodeIDSpan:  m.span(1),
+		URLSpan:     m.span(2),
+		TooltipSpan: m.span(5),
+	}
+	switch {
+	case m.groups[3] != "":
+		click.Callback, click.CallbackSpan = m.groups[3], m.span(3)
+	case m.groups[4] != "":
+		click.Callback, click.CallbackSpan = m.groups[4], m.span(4)
 	}
+	return click
 }
 
 func (p *FlowchartParser) parseStyles(styleStr string) map[string]string {
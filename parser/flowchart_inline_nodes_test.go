@@ -24,9 +24,6 @@ func TestInlineNodeDefinitions(t *testing.T) {
 				&ast.NodeDef{ID: "B", Label: "End", Shape: "[]"},
 			},
 		},
-		// TODO: Chained links (A --> B --> C) on a single line are not yet supported
-		// This would require additional parsing logic beyond inline node extraction
-		/*
 		{
 			name: "chain of inline nodes",
 			source: `graph TD
@@ -39,7 +36,32 @@ func TestInlineNodeDefinitions(t *testing.T) {
 				&ast.NodeDef{ID: "C", Label: "Third", Shape: "[]"},
 			},
 		},
-		*/
+		{
+			name: "fan out with inline nodes",
+			source: `graph TD
+    A[One] & B --> C[Three] & D(Four)`,
+			expected: []ast.Statement{
+				&ast.NodeDef{ID: "A", Label: "One", Shape: "[]"},
+				&ast.Link{From: "A", To: "C", Arrow: "-->"},
+				&ast.Link{From: "A", To: "D", Arrow: "-->"},
+				&ast.Link{From: "B", To: "C", Arrow: "-->"},
+				&ast.Link{From: "B", To: "D", Arrow: "-->"},
+				&ast.NodeDef{ID: "C", Label: "Three", Shape: "[]"},
+				&ast.NodeDef{ID: "D", Label: "Four", Shape: "()"},
+			},
+		},
+		{
+			name: "chain with labels, arrow types and a repeated node",
+			source: `graph LR
+    A[Start] -->|yes| B{Check & retry} <-.-> A[Again] ==> C`,
+			expected: []ast.Statement{
+				&ast.NodeDef{ID: "A", Label: "Start", Shape: "[]"},
+				&ast.Link{From: "A", To: "B", Arrow: "-->", Label: "yes"},
+				&ast.NodeDef{ID: "B", Label: "Check & retry", Shape: "{}"},
+				&ast.Link{From: "B", To: "A", Arrow: "<-.->", BiDir: true},
+				&ast.Link{From: "A", To: "C", Arrow: "==>"},
+			},
+		},
 		{
 			name: "mixed standalone and inline",
 			source: `graph LR
@@ -0,0 +1,338 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
+
+// frontmatterDelimiter opens and closes a YAML frontmatter block.
+const frontmatterDelimiter = "---"
+
+// parseFrontmatter parses the YAML frontmatter at the start of lines, if there is
+// any. It returns the frontmatter and the index of the first line after it.
+// Problems in the YAML are added to errs. A frontmatter block that is never closed
+// is returned as an error since there is no diagram left to parse.
+func parseFrontmatter(lines []string, diagramType string, errs *ErrorList) (*ast.Frontmatter, int, error) {
+	if !hasFrontmatter(lines) {
+		return nil, 0, nil
+	}
+
+	end := frontmatterClose(lines)
+	if end < 0 {
+		e := lineError(1, lines[0], "unclosed frontmatter, missing closing '---'")
+		e.DiagramType = diagramType
+		e.Expected = "'---'"
+		return nil, 0, e
+	}
+
+	fm := &ast.Frontmatter{
+		Raw: strings.Join(lines[1:end], "\n"),
+		Pos: linePos(1, lines[0]),
+		End: lineEnd(end+1, lines[end]),
+	}
+	y := &yamlParser{errs: errs, fm: fm}
+	y.tokenise(lines[1:end], 2)
+	if values, ok := y.parseBlock(0, "").(map[string]any); ok {
+		fm.Values = values
+	}
+	if fm.Values == nil {
+		fm.Values = map[string]any{}
+	}
+	if title, ok := fm.Values["title"]; ok {
+		fm.Title = fmt.Sprint(title)
+		fm.TitleSpan = y.titleSpan
+	}
+	if config, ok := fm.Values["config"].(map[string]any); ok {
+		fm.Config = config
+	}
+	return fm, end + 1, nil
+}
+
+// hasFrontmatter reports whether lines open with a frontmatter delimiter.
+func hasFrontmatter(lines []string) bool {
+	return len(lines) > 0 && strings.TrimSpace(lines[0]) == frontmatterDelimiter
+}
+
+// frontmatterClose returns the index of the line closing the frontmatter that
+// opens lines, or -1 if the block is never closed.
+func frontmatterClose(lines []string) int {
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == frontmatterDelimiter {
+			return i
+		}
+	}
+	return -1
+}
+
+// yamlParser parses the subset of YAML found in Mermaid frontmatter: nested
+// mappings, block sequences, block scalars, flow collections and plain, single-
+// or double-quoted scalars. Anchors, tags and multi-document streams are not
+// supported.
+type yamlParser struct {
+	lines     []yamlLine
+	pos       int
+	errs      *ErrorList
+	fm        *ast.Frontmatter
+	titleSpan ast.Span
+}
+
+// yamlLine is a non-blank, non-comment line of YAML.
+type yamlLine struct {
+	num    int    // Line number in the diagram source
+	raw    string // The whole line
+	indent int    // Number of leading spaces
+	text   string // The line without indentation or trailing comment
+}
+
+func (y *yamlParser) tokenise(lines []string, firstLine int) {
+	for i, raw := range lines {
+		text := strings.TrimSpace(stripYAMLComment(raw))
+		if text == "" {
+			continue
+		}
+		y.lines = append(y.lines, yamlLine{
+			num:    firstLine + i,
+			raw:    raw,
+			indent: len(raw) - len(strings.TrimLeft(raw, " ")),
+			text:   text,
+		})
+	}
+}
+
+// stripYAMLComment removes a trailing "# comment" that is not inside quotes.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseBlock parses the mapping or sequence whose lines are indented by at least
+// minIndent. path is the dotted path of the enclosing key.
+func (y *yamlParser) parseBlock(minIndent int, path string) any {
+	if y.pos >= len(y.lines) || y.lines[y.pos].indent < minIndent {
+		return nil
+	}
+	indent := y.lines[y.pos].indent
+	if isSequenceItem(y.lines[y.pos].text) {
+		return y.parseSequence(indent, path)
+	}
+	return y.parseMapping(indent, path)
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func (y *yamlParser) parseMapping(indent int, path string) map[string]any {
+	m := map[string]any{}
+	for y.pos < len(y.lines) {
+		line := y.lines[y.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			y.errorf(line, "unexpected indentation in frontmatter")
+			y.pos++
+			continue
+		}
+
+		key, value, ok := splitYAMLKey(line.text)
+		if !ok {
+			y.errorf(line, "invalid frontmatter line, expected 'key: value': %s", line.text)
+			y.pos++
+			continue
+		}
+		if _, exists := m[key]; exists {
+			y.errorf(line, "duplicate frontmatter key %q", key)
+		}
+
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+		keyLen := len(key)
+		if line.text[0] == '"' || line.text[0] == '\'' {
+			keyLen += 2
+		}
+		y.fm.Keys = append(y.fm.Keys, ast.ConfigKey{
+			Path: keyPath,
+			Span: lineSpan(line.num, line.indent, line.indent+keyLen),
+		})
+		if keyPath == "title" {
+			y.titleSpan = y.valueSpan(line, value)
+		}
+
+		y.pos++
+		m[key] = y.parseValue(line, value, indent, keyPath)
+	}
+	return m
+}
+
+func (y *yamlParser) parseSequence(indent int, path string) []any {
+	var items []any
+	for y.pos < len(y.lines) {
+		line := y.lines[y.pos]
+		if line.indent < indent || (line.indent == indent && !isSequenceItem(line.text)) {
+			break
+		}
+		if line.indent > indent {
+			y.errorf(line, "unexpected indentation in frontmatter")
+			y.pos++
+			continue
+		}
+
+		rest := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+		if _, _, isMap := splitYAMLKey(rest); isMap && !isFlowCollection(rest) {
+			// "- key: value" starts a mapping indented to the key
+			y.lines[y.pos].indent = line.indent + len(line.text) - len(rest)
+			y.lines[y.pos].text = rest
+			items = append(items, y.parseMapping(y.lines[y.pos].indent, path))
+			continue
+		}
+
+		y.pos++
+		items = append(items, y.parseValue(line, rest, indent, path))
+	}
+	return items
+}
+
+// parseValue parses the value following a key or sequence dash. An empty value
+// introduces a nested block.
+func (y *yamlParser) parseValue(line yamlLine, value string, indent int, path string) any {
+	switch {
+	case value == "":
+		// Sequences may sit at the same indentation as their key
+		if y.pos < len(y.lines) && y.lines[y.pos].indent == indent && isSequenceItem(y.lines[y.pos].text) {
+			return y.parseSequence(indent, path)
+		}
+		return y.parseBlock(indent+1, path)
+	case value == "|" || value == ">" || value == "|-" || value == ">-":
+		return y.parseBlockScalar(value, indent)
+	}
+	v, err := parseYAMLScalar(value)
+	if err != nil {
+		y.errorf(line, "%v", err)
+	}
+	return v
+}
+
+// parseBlockScalar collects the lines of a literal (|) or folded (>) scalar.
+func (y *yamlParser) parseBlockScalar(style string, indent int) string {
+	var parts []string
+	for y.pos < len(y.lines) && y.lines[y.pos].indent > indent {
+		parts = append(parts, strings.TrimSpace(y.lines[y.pos].raw))
+		y.pos++
+	}
+	sep := "\n"
+	if strings.HasPrefix(style, ">") {
+		sep = " "
+	}
+	return strings.Join(parts, sep)
+}
+
+// valueSpan returns the location of value, without quotes, on line.
+func (y *yamlParser) valueSpan(line yamlLine, value string) ast.Span {
+	if value == "" {
+		return ast.Span{}
+	}
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	start := strings.LastIndex(stripYAMLComment(line.raw), value)
+	if start < 0 {
+		return ast.Span{}
+	}
+	return lineSpan(line.num, start, start+len(value))
+}
+
+func (y *yamlParser) errorf(line yamlLine, format string, args ...any) {
+	y.errs.addLine(line.num, line.raw, format, args...)
+}
+
+// splitYAMLKey splits "key: value" into its key and value. Keys may be quoted.
+func splitYAMLKey(text string) (key, value string, ok bool) {
+	if text != "" && (text[0] == '"' || text[0] == '\'') {
+		end := strings.IndexByte(text[1:], text[0])
+		if end < 0 {
+			return "", "", false
+		}
+		rest := text[end+2:]
+		if rest != ":" && !strings.HasPrefix(rest, ": ") {
+			return "", "", false
+		}
+		return text[1 : end+1], strings.TrimSpace(rest[1:]), true
+	}
+	if i := strings.Index(text, ": "); i > 0 {
+		return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+2:]), true
+	}
+	if len(text) > 1 && strings.HasSuffix(text, ":") {
+		return strings.TrimSpace(text[:len(text)-1]), "", true
+	}
+	return "", "", false
+}
+
+func isFlowCollection(text string) bool {
+	return strings.HasPrefix(text, "{") || strings.HasPrefix(text, "[")
+}
+
+// parseYAMLScalar parses a scalar or flow collection.
+func parseYAMLScalar(text string) (any, error) {
+	text = strings.TrimSpace(text)
+	switch {
+	case text == "":
+		return nil, nil
+	case text[0] == '"':
+		s, err := strconv.Unquote(text)
+		if err != nil {
+			return text, fmt.Errorf("invalid double-quoted string in frontmatter: %s", text)
+		}
+		return s, nil
+	case text[0] == '\'':
+		if len(text) < 2 || text[len(text)-1] != '\'' {
+			return text, fmt.Errorf("invalid single-quoted string in frontmatter: %s", text)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	case isFlowCollection(text):
+		p := &flowParser{text: text}
+		v, err := p.parse()
+		if err != nil {
+			return text, fmt.Errorf("invalid flow collection in frontmatter: %v", err)
+		}
+		return v, nil
+	}
+	return plainScalar(text), nil
+}
+
+// plainScalar parses an unquoted scalar as a boolean, null, number or string.
+func plainScalar(text string) any {
+	switch text {
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	case "null", "Null", "NULL", "~":
+		return nil
+	}
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return int(i)
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f
+	}
+	return text
+}
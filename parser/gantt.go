@@ -1,7 +1,8 @@
 package parser
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"regexp"
 	"strings"
 
@@ -30,9 +31,26 @@ var (
 
 // Parse parses a Gantt chart diagram source.
 func (p *GanttParser) Parse(source string) (ast.Diagram, error) {
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses a Gantt chart diagram source within the limits in opts.
+func (p *GanttParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseLimited(ctx, source, opts, func(lim *limiter) (ast.Diagram, error) {
+		return p.parse(source, lim)
+	})
+}
+
+func (p *GanttParser) parse(source string, lim *limiter) (ast.Diagram, error) {
 	lines := strings.Split(source, "\n")
 	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty diagram source")
+		return nil, emptySourceError("gantt")
+	}
+
+	var errs ErrorList
+	meta, header, err := parseMetadata(lines, "gantt", &errs)
+	if err != nil {
+		return nil, err
 	}
 
 	diagram := &ast.GanttDiagram{
@@ -40,20 +58,22 @@ func (p *GanttParser) Parse(source string) (ast.Diagram, error) {
 		DateFormat: "YYYY-MM-DD", // Default date format
 		Source:     source,
 		Sections:   []ast.GanttSection{},
-		Pos:        ast.Position{Line: 1, Column: 1},
+		Pos:        linePos(header+1, lines[header]),
+		End:        ast.SourceEnd(source),
+		Metadata:   meta,
 	}
 
 	// Parse header line
-	firstLine := strings.TrimSpace(lines[0])
+	firstLine := strings.TrimSpace(lines[header])
 	if !ganttHeaderRegex.MatchString(firstLine) {
-		return nil, fmt.Errorf("invalid gantt diagram header: %s", firstLine)
+		return nil, headerError("gantt", header+1, lines[header], "'gantt'")
 	}
 
 	var currentSection *ast.GanttSection
 	hasContent := false
 
 	// Parse subsequent lines
-	for i := 1; i < len(lines); i++ {
+	for i := header + 1; i < len(lines); i++ {
 		line := lines[i]
 		trimmed := strings.TrimSpace(line)
 
@@ -61,80 +81,93 @@ func (p *GanttParser) Parse(source string) (ast.Diagram, error) {
 		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
 			continue
 		}
+		if !lim.statement(i + 1) {
+			break
+		}
+
+		lineNum := i + 1
+		span := textSpan(lineNum, line)
 
 		// Check for title
-		if matches := ganttTitleRegex.FindStringSubmatch(trimmed); matches != nil {
-			diagram.Title = strings.TrimSpace(matches[1])
+		if m := matchTrimmed(ganttTitleRegex, line, lineNum); m != nil {
+			diagram.Title, diagram.TitleSpan = m.trimmed(1)
 			hasContent = true
 			continue
 		}
 
 		// Check for dateFormat
-		if matches := ganttDateFormatRegex.FindStringSubmatch(trimmed); matches != nil {
-			diagram.DateFormat = strings.TrimSpace(matches[1])
+		if m := matchTrimmed(ganttDateFormatRegex, line, lineNum); m != nil {
+			diagram.DateFormat, diagram.DateFormatSpan = m.trimmed(1)
 			hasContent = true
 			continue
 		}
 
 		// Check for axisFormat
-		if matches := ganttAxisFormatRegex.FindStringSubmatch(trimmed); matches != nil {
-			diagram.AxisFormat = strings.TrimSpace(matches[1])
+		if m := matchTrimmed(ganttAxisFormatRegex, line, lineNum); m != nil {
+			diagram.AxisFormat, diagram.AxisFormatSpan = m.trimmed(1)
 			hasContent = true
 			continue
 		}
 
 		// Check for excludes
-		if matches := ganttExcludesRegex.FindStringSubmatch(trimmed); matches != nil {
-			diagram.Excludes = strings.TrimSpace(matches[1])
+		if m := matchTrimmed(ganttExcludesRegex, line, lineNum); m != nil {
+			diagram.Excludes, diagram.ExcludesSpan = m.trimmed(1)
 			hasContent = true
 			continue
 		}
 
 		// Check for todayMarker
-		if matches := ganttTodayMarkerRegex.FindStringSubmatch(trimmed); matches != nil {
-			diagram.TodayMarker = strings.TrimSpace(matches[1])
+		if m := matchTrimmed(ganttTodayMarkerRegex, line, lineNum); m != nil {
+			diagram.TodayMarker, diagram.TodayMarkerSpan = m.trimmed(1)
 			hasContent = true
 			continue
 		}
 
 		// Check for section
-		if matches := ganttSectionRegex.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(ganttSectionRegex, line, lineNum); m != nil {
 			// Save previous section if exists
 			if currentSection != nil {
 				diagram.Sections = append(diagram.Sections, *currentSection)
 			}
 			// Create new section
 			currentSection = &ast.GanttSection{
-				Name:  strings.TrimSpace(matches[1]),
 				Tasks: []ast.GanttTask{},
-				Pos:   ast.Position{Line: i + 1, Column: 1},
+				Pos:   span.Start,
+				End:   span.End,
 			}
+			currentSection.Name, currentSection.NameSpan = m.trimmed(1)
 			hasContent = true
 			continue
 		}
 
 		// Check for task
-		if matches := ganttTaskRegex.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(ganttTaskRegex, line, lineNum); m != nil {
 			if currentSection == nil {
-				return nil, fmt.Errorf("line %d: task defined outside of section", i+1)
+				errs.addLine(lineNum, line, "task defined outside of section")
+				continue
 			}
 
-			taskName := strings.TrimSpace(matches[1])
-			taskParams := strings.TrimSpace(matches[2])
+			taskName, nameSpan := m.trimmed(1)
+			taskParams, paramsSpan := m.trimmed(2)
 
 			// Parse task parameters
-			task, err := parseGanttTask(taskName, taskParams, i+1)
+			task, err := parseGanttTask(taskName, taskParams, lineNum)
 			if err != nil {
-				return nil, err
+				errs.addLine(lineNum, line, "%s", err)
+				continue
 			}
+			task.Pos = span.Start
+			task.End = span.End
+			task.NameSpan = nameSpan
+			task.ParamsSpan = paramsSpan
 
 			currentSection.Tasks = append(currentSection.Tasks, task)
 			hasContent = true
 			continue
 		}
 
-		// If we get here, it's an invalid line
-		return nil, fmt.Errorf("line %d: invalid gantt syntax: %s", i+1, trimmed)
+		// Keep lines we can't parse so validators can report them
+		diagram.Unknown = append(diagram.Unknown, unknownStatement(lineNum, line))
 	}
 
 	// Save last section if exists
@@ -144,10 +177,10 @@ func (p *GanttParser) Parse(source string) (ast.Diagram, error) {
 
 	// Validate we have at least some content
 	if !hasContent {
-		return nil, fmt.Errorf("gantt diagram must have at least a title or one section with tasks")
+		errs.Add(ast.Position{}, "gantt diagram must have at least a title or one section with tasks")
 	}
 
-	return diagram, nil
+	return diagram, errs.errFor(diagram.GetType())
 }
 
 // parseGanttTask parses task parameters from the colon-separated format.
@@ -165,7 +198,7 @@ func parseGanttTask(name, params string, lineNum int) (ast.GanttTask, error) {
 	// Split parameters by comma
 	parts := strings.Split(params, ",")
 	if len(parts) < 1 {
-		return task, fmt.Errorf("line %d: task must have at least duration", lineNum)
+		return task, errors.New("task must have at least duration")
 	}
 
 	// Trim all parts
@@ -182,7 +215,7 @@ func parseGanttTask(name, params string, lineNum int) (ast.GanttTask, error) {
 			return task, nil
 		}
 		// Otherwise it's likely an ID without duration, which is invalid
-		return task, fmt.Errorf("line %d: task must have at least duration", lineNum)
+		return task, errors.New("task must have at least duration")
 	}
 
 	// Parse parameters - we need to detect which format is being used
@@ -215,7 +248,7 @@ func parseGanttTask(name, params string, lineNum int) (ast.GanttTask, error) {
 
 	// Parse start date or dependency (required)
 	if currentIdx >= len(parts) {
-		return task, fmt.Errorf("line %d: task missing start date", lineNum)
+		return task, errors.New("task missing start date")
 	}
 
 	// Check if this is an "after" dependency
@@ -234,7 +267,7 @@ func parseGanttTask(name, params string, lineNum int) (ast.GanttTask, error) {
 
 	// Parse end date/duration (required)
 	if currentIdx >= len(parts) {
-		return task, fmt.Errorf("line %d: task missing end date or duration", lineNum)
+		return task, errors.New("task missing end date or duration")
 	}
 	task.EndDate = parts[currentIdx]
 
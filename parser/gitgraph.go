@@ -1,7 +1,7 @@
 package parser
 
 import (
-	"fmt"
+	"context"
 	"regexp"
 	"strconv"
 	"strings"
@@ -19,56 +19,56 @@ func NewGitGraphParser() *GitGraphParser {
 
 var (
 	gitGraphHeaderRegex   = regexp.MustCompile(`^gitGraph\s*$`)
-	gitGraphThemeRegex    = regexp.MustCompile(`^\s*%%\{init:\s*\{\s*'theme'\s*:\s*'([^']+)'\s*\}\s*\}%%\s*$`)
 	gitGraphCommitRegex   = regexp.MustCompile(`^\s*commit(?:\s+id:\s*"([^"]+)")?(?:\s+tag:\s*"([^"]+)")?(?:\s+type:\s*(NORMAL|REVERSE|HIGHLIGHT))?\s*$`)
-	gitGraphBranchRegex   = regexp.MustCompile(`^\s*branch\s+([\w-]+)(?:\s+order:\s*(\d+))?\s*$`)
-	gitGraphCheckoutRegex = regexp.MustCompile(`^\s*checkout\s+([\w-]+)\s*$`)
-	gitGraphMergeRegex    = regexp.MustCompile(`^\s*merge\s+([\w-]+)(?:\s+id:\s*"([^"]+)")?(?:\s+tag:\s*"([^"]+)")?(?:\s+type:\s*(NORMAL|REVERSE|HIGHLIGHT))?\s*$`)
+	gitGraphBranchRegex   = regexp.MustCompile(`^\s*branch\s+([` + identChars + `-]+)(?:\s+order:\s*(\d+))?\s*$`)
+	gitGraphCheckoutRegex = regexp.MustCompile(`^\s*checkout\s+([` + identChars + `-]+)\s*$`)
+	gitGraphMergeRegex    = regexp.MustCompile(`^\s*merge\s+([` + identChars + `-]+)(?:\s+id:\s*"([^"]+)")?(?:\s+tag:\s*"([^"]+)")?(?:\s+type:\s*(NORMAL|REVERSE|HIGHLIGHT))?\s*$`)
 	gitGraphCherryRegex   = regexp.MustCompile(`^\s*cherry-pick\s+id:\s*"([^"]+)"(?:\s+tag:\s*"([^"]+)")?\s*$`)
 	gitGraphOptionRegex   = regexp.MustCompile(`^\s*(mainBranchName|mainBranchOrder)\s*:\s*(.+)\s*$`)
 )
 
 // Parse parses a git graph diagram source.
 func (p *GitGraphParser) Parse(source string) (ast.Diagram, error) {
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses a git graph diagram source within the limits in opts.
+func (p *GitGraphParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseLimited(ctx, source, opts, func(lim *limiter) (ast.Diagram, error) {
+		return p.parse(source, lim)
+	})
+}
+
+func (p *GitGraphParser) parse(source string, lim *limiter) (ast.Diagram, error) {
 	lines := strings.Split(source, "\n")
 	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty diagram source")
+		return nil, emptySourceError("gitGraph")
+	}
+
+	var errs ErrorList
+	meta, headerIdx, err := parseMetadata(lines, "gitGraph", &errs)
+	if err != nil {
+		return nil, err
+	}
+
+	if !gitGraphHeaderRegex.MatchString(strings.TrimSpace(lines[headerIdx])) {
+		return nil, headerError("gitGraph", headerIdx+1, lines[headerIdx], "'gitGraph'")
 	}
 
 	diagram := &ast.GitGraphDiagram{
 		Type:       "gitGraph",
 		Source:     source,
 		Operations: []ast.GitOperation{},
-		Pos:        ast.Position{Line: 1, Column: 1},
+		Pos:        linePos(headerIdx+1, lines[headerIdx]),
+		End:        ast.SourceEnd(source),
+		Metadata:   meta,
 	}
 
-	// Find header line, skipping config comments
-	headerIdx := -1
-	for i := range lines {
-		trimmed := strings.TrimSpace(lines[i])
-		// Skip empty lines
-		if trimmed == "" {
-			continue
-		}
-		// Check for theme config comment
-		if themeMatches := gitGraphThemeRegex.FindStringSubmatch(trimmed); themeMatches != nil {
-			diagram.Theme = themeMatches[1]
-			continue
-		}
-		// Skip regular comments
-		if strings.HasPrefix(trimmed, "%%") {
-			continue
-		}
-		// Found first non-comment, non-empty line - should be header
-		if !gitGraphHeaderRegex.MatchString(trimmed) {
-			return nil, fmt.Errorf("invalid gitGraph header: %s", trimmed)
+	// The theme set by the last init directive wins
+	for _, d := range meta.Directives {
+		if theme, ok := d.Config["theme"].(string); ok {
+			diagram.Theme = theme
 		}
-		headerIdx = i
-		break
-	}
-
-	if headerIdx == -1 {
-		return nil, fmt.Errorf("no gitGraph header found")
 	}
 
 	// Parse operations
@@ -80,35 +80,43 @@ func (p *GitGraphParser) Parse(source string) (ast.Diagram, error) {
 		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
 			continue
 		}
+		if !lim.statement(i + 1) {
+			break
+		}
 
-		pos := ast.Position{Line: i + 1, Column: 1}
+		lineNum := i + 1
+		span := textSpan(lineNum, line)
 
 		// Try to match commit
-		if matches := gitGraphCommitRegex.FindStringSubmatch(trimmed); matches != nil {
-			op := ast.GitOperation{
-				Type: "commit",
-				ID:   matches[1],
-				Tag:  matches[2],
-				Pos:  pos,
-			}
-			if matches[3] != "" {
-				op.CommitType = matches[3]
-			}
-			diagram.Operations = append(diagram.Operations, op)
+		if m := matchTrimmed(gitGraphCommitRegex, line, lineNum); m != nil {
+			diagram.Operations = append(diagram.Operations, ast.GitOperation{
+				Type:           "commit",
+				ID:             m.groups[1],
+				Tag:            m.groups[2],
+				CommitType:     m.groups[3],
+				Pos:            span.Start,
+				End:            span.End,
+				IDSpan:         m.span(1),
+				TagSpan:        m.span(2),
+				CommitTypeSpan: m.span(3),
+			})
 			continue
 		}
 
 		// Try to match branch
-		if matches := gitGraphBranchRegex.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(gitGraphBranchRegex, line, lineNum); m != nil {
 			op := ast.GitOperation{
-				Type:       "branch",
-				BranchName: matches[1],
-				Pos:        pos,
+				Type:           "branch",
+				BranchName:     m.groups[1],
+				Pos:            span.Start,
+				End:            span.End,
+				BranchNameSpan: m.span(1),
 			}
-			if matches[2] != "" {
-				order, err := strconv.Atoi(matches[2])
+			if m.groups[2] != "" {
+				order, err := strconv.Atoi(m.groups[2])
 				if err != nil {
-					return nil, fmt.Errorf("line %d: invalid branch order: %s", i+1, matches[2])
+					errs.addLine(lineNum, line, "invalid branch order: %s", m.groups[2])
+					continue
 				}
 				op.Order = order
 			}
@@ -117,41 +125,46 @@ func (p *GitGraphParser) Parse(source string) (ast.Diagram, error) {
 		}
 
 		// Try to match checkout
-		if matches := gitGraphCheckoutRegex.FindStringSubmatch(trimmed); matches != nil {
-			op := ast.GitOperation{
-				Type:       "checkout",
-				BranchName: matches[1],
-				Pos:        pos,
-			}
-			diagram.Operations = append(diagram.Operations, op)
+		if m := matchTrimmed(gitGraphCheckoutRegex, line, lineNum); m != nil {
+			diagram.Operations = append(diagram.Operations, ast.GitOperation{
+				Type:           "checkout",
+				BranchName:     m.groups[1],
+				Pos:            span.Start,
+				End:            span.End,
+				BranchNameSpan: m.span(1),
+			})
 			continue
 		}
 
 		// Try to match merge
-		if matches := gitGraphMergeRegex.FindStringSubmatch(trimmed); matches != nil {
-			op := ast.GitOperation{
-				Type:       "merge",
-				BranchName: matches[1],
-				ID:         matches[2],
-				Tag:        matches[3],
-				Pos:        pos,
-			}
-			if matches[4] != "" {
-				op.CommitType = matches[4]
-			}
-			diagram.Operations = append(diagram.Operations, op)
+		if m := matchTrimmed(gitGraphMergeRegex, line, lineNum); m != nil {
+			diagram.Operations = append(diagram.Operations, ast.GitOperation{
+				Type:           "merge",
+				BranchName:     m.groups[1],
+				ID:             m.groups[2],
+				Tag:            m.groups[3],
+				CommitType:     m.groups[4],
+				Pos:            span.Start,
+				End:            span.End,
+				BranchNameSpan: m.span(1),
+				IDSpan:         m.span(2),
+				TagSpan:        m.span(3),
+				CommitTypeSpan: m.span(4),
+			})
 			continue
 		}
 
 		// Try to match cherry-pick
-		if matches := gitGraphCherryRegex.FindStringSubmatch(trimmed); matches != nil {
-			op := ast.GitOperation{
-				Type:     "cherry-pick",
-				ParentID: matches[1],
-				Tag:      matches[2],
-				Pos:      pos,
-			}
-			diagram.Operations = append(diagram.Operations, op)
+		if m := matchTrimmed(gitGraphCherryRegex, line, lineNum); m != nil {
+			diagram.Operations = append(diagram.Operations, ast.GitOperation{
+				Type:         "cherry-pick",
+				ParentID:     m.groups[1],
+				Tag:          m.groups[2],
+				Pos:          span.Start,
+				End:          span.End,
+				ParentIDSpan: m.span(1),
+				TagSpan:      m.span(2),
+			})
 			continue
 		}
 
@@ -166,21 +179,23 @@ func (p *GitGraphParser) Parse(source string) (ast.Diagram, error) {
 			case "mainBranchOrder":
 				order, err := strconv.Atoi(value)
 				if err != nil {
-					return nil, fmt.Errorf("line %d: invalid mainBranchOrder: %s", i+1, value)
+					errs.addLine(i+1, line, "invalid mainBranchOrder: %s", value)
+					continue
 				}
 				diagram.MainBranchOrder = order
 			}
 			continue
 		}
 
-		return nil, fmt.Errorf("line %d: unrecognised gitGraph syntax: %s", i+1, trimmed)
+		// Keep lines we can't parse so validators can report them
+		diagram.Unknown = append(diagram.Unknown, unknownStatement(i+1, line))
 	}
 
 	if len(diagram.Operations) == 0 {
-		return nil, fmt.Errorf("gitGraph must have at least one operation")
+		errs.Add(ast.Position{}, "gitGraph must have at least one operation")
 	}
 
-	return diagram, nil
+	return diagram, errs.errFor(diagram.GetType())
 }
 
 // SupportedTypes returns the diagram types this parser supports.
@@ -0,0 +1,24 @@
+package parser
+
+import "regexp"
+
+// Regular expression fragments for identifiers. RE2's \w only matches ASCII, but
+// Mermaid accepts letters, combining marks and digits from any script, so patterns
+// are built from these instead.
+const (
+	// identChars is the content of a character class matching one identifier
+	// character, so it can be combined with others as in [`+identChars+`,\s].
+	identChars = `\p{L}\p{M}\p{N}_`
+
+	// ident matches an identifier such as a state or class name.
+	ident = `[` + identChars + `]+`
+
+	// hyphenIdent matches an identifier that may contain single hyphens between
+	// other characters, as flowchart nodes and sequence participants may. A hyphen
+	// must be followed by an identifier character, so arrows such as --> and -.->
+	// that follow an identifier are not taken as part of it.
+	hyphenIdent = ident + `(?:-` + ident + `)*`
+)
+
+// hyphenIdentPattern matches a whole string that is a hyphenIdent.
+var hyphenIdentPattern = regexp.MustCompile(`^` + hyphenIdent + `$`)
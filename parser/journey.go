@@ -1,7 +1,7 @@
 package parser
 
 import (
-	"fmt"
+	"context"
 	"regexp"
 	"strconv"
 	"strings"
@@ -26,29 +26,48 @@ var (
 
 // Parse parses a user journey diagram source.
 func (p *JourneyParser) Parse(source string) (ast.Diagram, error) {
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses a user journey diagram source within the limits in opts.
+func (p *JourneyParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseLimited(ctx, source, opts, func(lim *limiter) (ast.Diagram, error) {
+		return p.parse(source, lim)
+	})
+}
+
+func (p *JourneyParser) parse(source string, lim *limiter) (ast.Diagram, error) {
 	lines := strings.Split(source, "\n")
 	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty diagram source")
+		return nil, emptySourceError("journey")
+	}
+
+	var errs ErrorList
+	meta, header, err := parseMetadata(lines, "journey", &errs)
+	if err != nil {
+		return nil, err
 	}
 
 	diagram := &ast.JourneyDiagram{
 		Type:     "journey",
 		Source:   source,
 		Sections: []ast.Section{},
-		Pos:      ast.Position{Line: 1, Column: 1},
+		Pos:      linePos(header+1, lines[header]),
+		End:      ast.SourceEnd(source),
+		Metadata: meta,
 	}
 
 	// Parse header line
-	firstLine := strings.TrimSpace(lines[0])
+	firstLine := strings.TrimSpace(lines[header])
 	if !journeyHeaderRegex.MatchString(firstLine) {
-		return nil, fmt.Errorf("invalid journey diagram header: %s", firstLine)
+		return nil, headerError("journey", header+1, lines[header], "'journey'")
 	}
 
 	var currentSection *ast.Section
 	hasContent := false
 
 	// Parse subsequent lines
-	for i := 1; i < len(lines); i++ {
+	for i := header + 1; i < len(lines); i++ {
 		line := lines[i]
 		trimmed := strings.TrimSpace(line)
 
@@ -56,77 +75,92 @@ func (p *JourneyParser) Parse(source string) (ast.Diagram, error) {
 		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
 			continue
 		}
+		if !lim.statement(i + 1) {
+			break
+		}
+
+		lineNum := i + 1
+		span := textSpan(lineNum, line)
 
 		// Check for title
-		if matches := journeyTitleRegex.FindStringSubmatch(trimmed); matches != nil {
-			diagram.Title = strings.TrimSpace(matches[1])
+		if m := matchTrimmed(journeyTitleRegex, line, lineNum); m != nil {
+			diagram.Title, diagram.TitleSpan = m.trimmed(1)
 			hasContent = true
 			continue
 		}
 
 		// Check for section
-		if matches := journeySectionRegex.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(journeySectionRegex, line, lineNum); m != nil {
 			// Save previous section if exists
 			if currentSection != nil {
 				diagram.Sections = append(diagram.Sections, *currentSection)
 			}
 			// Create new section
 			currentSection = &ast.Section{
-				Name:  strings.TrimSpace(matches[1]),
 				Tasks: []ast.Task{},
-				Pos:   ast.Position{Line: i + 1, Column: 1},
+				Pos:   span.Start,
+				End:   span.End,
 			}
+			currentSection.Name, currentSection.NameSpan = m.trimmed(1)
 			hasContent = true
 			continue
 		}
 
 		// Check for task
-		if matches := journeyTaskRegex.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(journeyTaskRegex, line, lineNum); m != nil {
 			if currentSection == nil {
-				return nil, fmt.Errorf("line %d: task defined outside of section", i+1)
+				errs.addLine(i+1, line, "task defined outside of section")
+				continue
 			}
 
-			taskName := strings.TrimSpace(matches[1])
-			scoreStr := matches[2]
-			actorsStr := strings.TrimSpace(matches[3])
+			taskName, nameSpan := m.trimmed(1)
+			scoreStr := m.groups[2]
 
 			// Parse score
 			score, err := strconv.Atoi(scoreStr)
 			if err != nil {
-				return nil, fmt.Errorf("line %d: invalid score value: %s", i+1, scoreStr)
+				errs.addLine(i+1, line, "invalid score value: %s", scoreStr)
+				continue
 			}
 
 			// Validate score range
 			if score < 1 || score > 5 {
-				return nil, fmt.Errorf("line %d: score must be between 1 and 5 (got %d)", i+1, score)
+				errs.addLine(i+1, line, "score must be between 1 and 5 (got %d)", score)
+				continue
 			}
 
 			// Parse actors (comma-separated)
-			actorsParts := strings.Split(actorsStr, ",")
+			actorsParts, actorSpans := splitTrimmed(m.groups[3], m.span(3), ",")
 			actors := make([]string, 0, len(actorsParts))
-			for _, actor := range actorsParts {
-				trimmedActor := strings.TrimSpace(actor)
-				if trimmedActor != "" {
-					actors = append(actors, trimmedActor)
+			spans := make([]ast.Span, 0, len(actorsParts))
+			for j, actor := range actorsParts {
+				if actor != "" {
+					actors = append(actors, actor)
+					spans = append(spans, actorSpans[j])
 				}
 			}
 
 			if len(actors) == 0 {
-				return nil, fmt.Errorf("line %d: task must have at least one actor", i+1)
+				errs.addLine(i+1, line, "task must have at least one actor")
+				continue
 			}
 
 			currentSection.Tasks = append(currentSection.Tasks, ast.Task{
-				Name:   taskName,
-				Score:  score,
-				Actors: actors,
-				Pos:    ast.Position{Line: i + 1, Column: 1},
+				Name:       taskName,
+				Score:      score,
+				Actors:     actors,
+				Pos:        span.Start,
+				End:        span.End,
+				NameSpan:   nameSpan,
+				ScoreSpan:  m.span(2),
+				ActorSpans: spans,
 			})
 			hasContent = true
 			continue
 		}
 
-		// If we get here, it's an invalid line
-		return nil, fmt.Errorf("line %d: invalid journey syntax: %s", i+1, trimmed)
+		// Keep lines we can't parse so validators can report them
+		diagram.Unknown = append(diagram.Unknown, unknownStatement(i+1, line))
 	}
 
 	// Save last section if exists
@@ -136,10 +170,10 @@ func (p *JourneyParser) Parse(source string) (ast.Diagram, error) {
 
 	// Validate we have at least some content
 	if !hasContent {
-		return nil, fmt.Errorf("journey diagram must have at least a title or one section with tasks")
+		errs.Add(ast.Position{}, "journey diagram must have at least a title or one section with tasks")
 	}
 
-	return diagram, nil
+	return diagram, errs.errFor(diagram.GetType())
 }
 
 // SupportedTypes returns the diagram types this parser supports.
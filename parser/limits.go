@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
+
+// ParseOptions limits the resources used to parse a diagram, for sources that
+// cannot be trusted. A zero value means no limit.
+type ParseOptions struct {
+	MaxBytes      int // Maximum size of the source in bytes
+	MaxLines      int // Maximum number of source lines
+	MaxNesting    int // Maximum depth of nested blocks, such as subgraphs, alt blocks or mindmap nodes
+	MaxStatements int // Maximum number of statements in the diagram body
+}
+
+// OptionsParser is implemented by parsers that enforce ParseOptions while parsing.
+// All the built-in parsers do. For registered parsers that do not, ParseWithOptions
+// only enforces the size limits and checks for cancellation before parsing.
+type OptionsParser interface {
+	ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error)
+}
+
+// Limit identifies one of the limits in ParseOptions.
+type Limit int
+
+// Limits that can be exceeded.
+const (
+	LimitBytes Limit = iota + 1
+	LimitLines
+	LimitNesting
+	LimitStatements
+)
+
+func (l Limit) String() string {
+	switch l {
+	case LimitBytes:
+		return "bytes"
+	case LimitLines:
+		return "lines"
+	case LimitNesting:
+		return "nesting depth"
+	case LimitStatements:
+		return "statements"
+	default:
+		return "unknown"
+	}
+}
+
+// LimitError is returned when a source exceeds one of the limits in ParseOptions.
+// Parsing stops at the first limit exceeded and no diagram is returned.
+type LimitError struct {
+	Limit Limit        // The limit exceeded
+	Max   int          // The configured maximum
+	Pos   ast.Position // Where the limit was exceeded, zero for the size limits
+}
+
+// Error implements the error interface.
+func (e *LimitError) Error() string {
+	message := fmt.Sprintf("diagram exceeds the maximum %s of %d", e.Limit, e.Max)
+	if e.Pos.Line > 0 {
+		return fmt.Sprintf("line %d: %s", e.Pos.Line, message)
+	}
+	return message
+}
+
+// limiter enforces ParseOptions while a single source is parsed. Once a limit is
+// exceeded or the context is cancelled, every check fails so the parser unwinds
+// quickly, and err holds the reason.
+type limiter struct {
+	ctx        context.Context
+	opts       ParseOptions
+	statements int
+	depth      int
+	err        error
+}
+
+// newLimiter checks the size of source and returns a limiter for parsing it.
+func newLimiter(ctx context.Context, source string, opts ParseOptions) (*limiter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if opts.MaxBytes > 0 && len(source) > opts.MaxBytes {
+		return nil, &LimitError{Limit: LimitBytes, Max: opts.MaxBytes}
+	}
+	// A trailing newline ends the last line rather than starting another
+	if opts.MaxLines > 0 && strings.Count(strings.TrimSuffix(source, "\n"), "\n")+1 > opts.MaxLines {
+		return nil, &LimitError{Limit: LimitLines, Max: opts.MaxLines}
+	}
+	return &limiter{ctx: ctx, opts: opts}, nil
+}
+
+// statement counts a statement on line lineNum and reports whether parsing may
+// continue.
+func (l *limiter) statement(lineNum int) bool {
+	if l.err != nil {
+		return false
+	}
+	if err := l.ctx.Err(); err != nil {
+		l.err = err
+		return false
+	}
+	l.statements++
+	if l.opts.MaxStatements > 0 && l.statements > l.opts.MaxStatements {
+		l.err = &LimitError{Limit: LimitStatements, Max: l.opts.MaxStatements, Pos: ast.Position{Line: lineNum, Column: 1}}
+		return false
+	}
+	return true
+}
+
+// nesting records a block nested depth levels deep on line lineNum and reports
+// whether parsing may continue.
+func (l *limiter) nesting(depth, lineNum int) bool {
+	if l.err != nil {
+		return false
+	}
+	if l.opts.MaxNesting > 0 && depth > l.opts.MaxNesting {
+		l.err = &LimitError{Limit: LimitNesting, Max: l.opts.MaxNesting, Pos: ast.Position{Line: lineNum, Column: 1}}
+		return false
+	}
+	return true
+}
+
+// enter records entering a nested block on line lineNum, for parsers that handle
+// nesting by recursion, and reports whether parsing may continue. Each call must
+// be paired with a call to leave.
+func (l *limiter) enter(lineNum int) bool {
+	l.depth++
+	return l.nesting(l.depth, lineNum)
+}
+
+// leave records leaving a nested block.
+func (l *limiter) leave() {
+	l.depth--
+}
+
+// parseLimited parses source with parse, enforcing opts. If a limit is exceeded
+// or ctx is cancelled, only the reason is returned.
+func parseLimited(ctx context.Context, source string, opts ParseOptions, parse func(lim *limiter) (ast.Diagram, error)) (ast.Diagram, error) {
+	lim, err := newLimiter(ctx, source, opts)
+	if err != nil {
+		return nil, err
+	}
+	diagram, err := parse(lim)
+	if lim.err != nil {
+		return withOffsets(source, nil, lim.err)
+	}
+	return withOffsets(source, diagram, err)
+}
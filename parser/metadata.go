@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
+
+// parseMetadata parses what every diagram type shares: YAML frontmatter at the
+// start of lines, directives anywhere after it and accessibility statements after
+// the header. It returns the metadata and the index of the diagram header, which is
+// the first line after the frontmatter that is not blank, a comment or a directive.
+// Directives and accessibility statements are blanked out of lines. Problems are added to errs,
+// except when there is no header left to parse, which is returned as an error.
+func parseMetadata(lines []string, diagramType string, errs *ErrorList) (ast.Metadata, int, error) {
+	fm, start, err := parseFrontmatter(lines, diagramType, errs)
+	if err != nil {
+		return ast.Metadata{}, 0, err
+	}
+
+	header := skipPreamble(lines, start)
+	if header == len(lines) {
+		if fm == nil {
+			return ast.Metadata{}, 0, emptySourceError(diagramType)
+		}
+		e := lineError(start, lines[start-1], "missing diagram header after frontmatter")
+		e.DiagramType = diagramType
+		return ast.Metadata{}, 0, e
+	}
+
+	return ast.Metadata{
+		Frontmatter:   fm,
+		Directives:    parseDirectives(lines, start, errs),
+		Accessibility: parseAccessibility(lines, header+1, errs),
+	}, header, nil
+}
+
+// skipPreamble returns the index of the first line from lines[start] that is not
+// blank, a comment or part of a directive, or len(lines) if there is none.
+func skipPreamble(lines []string, start int) int {
+	for i := start; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case isDirective(trimmed):
+			end := directiveEnd(lines, i)
+			if end < 0 {
+				return len(lines)
+			}
+			i = end
+		case trimmed == "" || strings.HasPrefix(trimmed, "%%"):
+			continue
+		default:
+			return i
+		}
+	}
+	return len(lines)
+}
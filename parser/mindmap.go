@@ -1,7 +1,7 @@
 package parser
 
 import (
-	"fmt"
+	"context"
 	"regexp"
 	"strings"
 
@@ -23,21 +23,40 @@ var (
 
 // Parse parses a mindmap diagram source.
 func (p *MindmapParser) Parse(source string) (ast.Diagram, error) {
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses a mindmap diagram source within the limits in opts.
+func (p *MindmapParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseLimited(ctx, source, opts, func(lim *limiter) (ast.Diagram, error) {
+		return p.parse(source, lim)
+	})
+}
+
+func (p *MindmapParser) parse(source string, lim *limiter) (ast.Diagram, error) {
 	lines := strings.Split(source, "\n")
 	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty diagram source")
+		return nil, emptySourceError("mindmap")
+	}
+
+	var errs ErrorList
+	meta, header, err := parseMetadata(lines, "mindmap", &errs)
+	if err != nil {
+		return nil, err
 	}
 
 	diagram := &ast.MindmapDiagram{
-		Type:   "mindmap",
-		Source: source,
-		Pos:    ast.Position{Line: 1, Column: 1},
+		Type:     "mindmap",
+		Source:   source,
+		Pos:      linePos(header+1, lines[header]),
+		End:      ast.SourceEnd(source),
+		Metadata: meta,
 	}
 
 	// Parse header line
-	firstLine := strings.TrimSpace(lines[0])
+	firstLine := strings.TrimSpace(lines[header])
 	if !mindmapHeaderRegex.MatchString(firstLine) {
-		return nil, fmt.Errorf("invalid mindmap diagram header: %s", firstLine)
+		return nil, headerError("mindmap", header+1, lines[header], "'mindmap'")
 	}
 
 	// Build tree structure from indented lines
@@ -46,7 +65,7 @@ func (p *MindmapParser) Parse(source string) (ast.Diagram, error) {
 	indentSize := 0    // Will be detected as 2 or 4
 	rootIndent := -1   // Track root indentation
 
-	for i := 1; i < len(lines); i++ {
+	for i := header + 1; i < len(lines); i++ {
 		line := lines[i]
 
 		// Skip empty lines and comments
@@ -54,6 +73,9 @@ func (p *MindmapParser) Parse(source string) (ast.Diagram, error) {
 		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
 			continue
 		}
+		if !lim.statement(i + 1) {
+			break
+		}
 
 		// Calculate indentation level
 		indent := len(line) - len(strings.TrimLeft(line, " \t"))
@@ -75,11 +97,13 @@ func (p *MindmapParser) Parse(source string) (ast.Diagram, error) {
 				// Additional check: if we're not at level 0, we can't set indent size
 				// This catches cases where first indented line isn't directly after root
 				if lastLevel != 0 {
-					return nil, fmt.Errorf("line %d: cannot establish indentation pattern (not a direct child of root)", i+1)
+					errs.addLine(i+1, line, "cannot establish indentation pattern (not a direct child of root)")
+					continue
 				}
 				indentSize = relativeIndent
 			} else {
-				return nil, fmt.Errorf("line %d: invalid indentation (expected 2 or 4 space indentation style, got %d spaces)", i+1, relativeIndent)
+				errs.addLine(i+1, line, "invalid indentation (expected 2 or 4 space indentation style, got %d spaces)", relativeIndent)
+				continue
 			}
 		}
 
@@ -90,23 +114,32 @@ func (p *MindmapParser) Parse(source string) (ast.Diagram, error) {
 		} else if relativeIndent > 0 {
 			if indentSize == 0 {
 				// This should never happen due to check above, but be defensive
-				return nil, fmt.Errorf("line %d: unexpected indentation before establishing indent size", i+1)
+				errs.addLine(i+1, line, "unexpected indentation before establishing indent size")
+				continue
 			}
 			if relativeIndent%indentSize != 0 {
-				return nil, fmt.Errorf("line %d: inconsistent indentation (expected multiples of %d spaces)", i+1, indentSize)
+				errs.addLine(i+1, line, "inconsistent indentation (expected multiples of %d spaces)", indentSize)
+				continue
 			}
 			level = relativeIndent / indentSize
 		} else {
-			return nil, fmt.Errorf("line %d: invalid indentation (less than root)", i+1)
+			errs.addLine(i+1, line, "invalid indentation (less than root)")
+			continue
+		}
+		// The root is a level of nesting, as a top-level block is in other diagrams
+		if !lim.nesting(level+1, i+1) {
+			break
 		}
 
 		// Check for icon line
-		if iconMatches := mindmapIconRegex.FindStringSubmatch(trimmed); iconMatches != nil {
+		if m := matchTrimmed(mindmapIconRegex, line, i+1); m != nil {
 			if len(nodeStack) == 0 {
-				return nil, fmt.Errorf("line %d: icon definition outside of node", i+1)
+				errs.addLine(i+1, line, "icon definition outside of node")
+				continue
 			}
 			// Add icon to last node
-			nodeStack[len(nodeStack)-1].Icon = strings.TrimSpace(iconMatches[1])
+			last := nodeStack[len(nodeStack)-1]
+			last.Icon, last.IconSpan = m.trimmed(1)
 			continue
 		}
 
@@ -114,30 +147,38 @@ func (p *MindmapParser) Parse(source string) (ast.Diagram, error) {
 		text, shape := parseNodeText(trimmed)
 
 		if text == "" {
-			return nil, fmt.Errorf("line %d: node text cannot be empty", i+1)
+			errs.addLine(i+1, line, "node text cannot be empty")
+			continue
 		}
 
-		// Create new node
+		// Create new node. The text always ends just before any closing shape
+		// marker, so its last occurrence on the line is the one we want.
+		span := textSpan(i+1, line)
+		textStart := indentOf(line) + strings.LastIndex(trimmed, text)
 		node := &ast.MindmapNode{
 			Text:     text,
 			Shape:    shape,
 			Level:    level,
 			Children: make([]*ast.MindmapNode, 0),
-			Pos:      ast.Position{Line: i + 1, Column: 1},
+			Pos:      span.Start,
+			End:      span.End,
+			TextSpan: lineSpan(i+1, textStart, textStart+len(text)),
 		}
 
 		// Determine parent and add to tree
 		if level == 0 {
 			// Root node
 			if diagram.Root != nil {
-				return nil, fmt.Errorf("line %d: multiple root nodes found", i+1)
+				errs.addLine(i+1, line, "multiple root nodes found")
+				continue
 			}
 			diagram.Root = node
 			nodeStack = []*ast.MindmapNode{node}
 		} else {
 			// Child node
 			if len(nodeStack) == 0 {
-				return nil, fmt.Errorf("line %d: child node before root node", i+1)
+				errs.addLine(i+1, line, "child node before root node")
+				continue
 			}
 
 			// Find parent (pop stack until we find correct level)
@@ -146,14 +187,16 @@ func (p *MindmapParser) Parse(source string) (ast.Diagram, error) {
 			}
 
 			if len(nodeStack) == 0 {
-				return nil, fmt.Errorf("line %d: invalid nesting level", i+1)
+				errs.addLine(i+1, line, "invalid nesting level")
+				continue
 			}
 
 			parent := nodeStack[len(nodeStack)-1]
 
 			// Check level increment is valid (can only increase by 1)
 			if level > lastLevel+1 {
-				return nil, fmt.Errorf("line %d: invalid nesting (jumped from level %d to %d)", i+1, lastLevel, level)
+				errs.addLine(i+1, line, "invalid nesting (jumped from level %d to %d)", lastLevel, level)
+				continue
 			}
 
 			parent.Children = append(parent.Children, node)
@@ -164,10 +207,10 @@ func (p *MindmapParser) Parse(source string) (ast.Diagram, error) {
 	}
 
 	if diagram.Root == nil {
-		return nil, fmt.Errorf("mindmap must have a root node")
+		errs.Add(ast.Position{}, "mindmap must have a root node")
 	}
 
-	return diagram, nil
+	return diagram, errs.errFor(diagram.GetType())
 }
 
 // parseNodeText extracts the text and shape from a node line.
@@ -1,7 +1,12 @@
 // Package parser provides parsing functionality for all Mermaid diagram types.
+//
+// Parsers keep no state between calls, so a parser can be reused for any number
+// of diagrams and shared between goroutines. Parse, ParseWithOptions, DetectType
+// and Register are also safe for concurrent use.
 package parser
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -9,6 +14,8 @@ import (
 )
 
 // DiagramParser defines the interface all diagram parsers must implement.
+// The built-in parsers are safe for concurrent use. Registered parsers need not
+// be, as Parse gets a new one from the factory for every diagram.
 type DiagramParser interface {
 	// Parse parses the source and returns a Diagram AST.
 	Parse(source string) (ast.Diagram, error)
@@ -17,125 +24,66 @@ type DiagramParser interface {
 }
 
 // Parse parses a Mermaid diagram from source and returns a Diagram.
-// It automatically detects the diagram type and uses the appropriate parser.
+// It detects the diagram type from the header and uses the parser registered for
+// it with Register.
 func Parse(source string) (ast.Diagram, error) {
+	return ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions is like Parse, but stops with a *LimitError if source exceeds
+// one of the limits in opts, or with the context's error if ctx is cancelled.
+func ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	if _, err := newLimiter(ctx, source, opts); err != nil {
+		return nil, err
+	}
 	if strings.TrimSpace(source) == "" {
-		return nil, fmt.Errorf("empty diagram source")
+		return nil, emptySourceError("")
 	}
 
-	diagType := detectDiagramType(source)
-
-	// Direct parser instantiation based on type
-	var parser DiagramParser
-	switch diagType {
-	case "flowchart", "graph":
-		parser = NewFlowchartParser()
-	case "sequence":
-		parser = NewSequenceParser()
-	case "class":
-		parser = NewClassParser()
-	case "state", "stateDiagram-v2":
-		parser = NewStateParser()
-	case "er":
-		parser = NewERParser()
-	case "gantt":
-		parser = NewGanttParser()
-	case "pie":
-		parser = NewPieParser()
-	case "journey":
-		parser = NewJourneyParser()
-	case "timeline":
-		parser = NewTimelineParser()
-	case "gitGraph":
-		parser = NewGitGraphParser()
-	case "mindmap":
-		parser = NewMindmapParser()
-	case "sankey":
-		parser = NewSankeyParser()
-	case "quadrantChart":
-		parser = NewQuadrantParser()
-	case "xyChart":
-		parser = NewXYChartParser()
-	case "c4Context":
-		parser = NewC4ContextParser()
-	case "c4Container":
-		parser = NewC4ContainerParser()
-	case "c4Component":
-		parser = NewC4ComponentParser()
-	case "c4Dynamic":
-		parser = NewC4DynamicParser()
-	case "c4Deployment":
-		parser = NewC4DeploymentParser()
-	default:
-		// Fallback to GenericDiagram for known types without specific parsers
-		if isKnownDiagramType(diagType) {
-			return ast.NewGenericDiagram(diagType, source, ast.Position{Line: 1, Column: 1}), nil
+	reg, ok := lookup(source)
+	if ok && reg.factory != nil {
+		p := reg.factory()
+		if op, ok := p.(OptionsParser); ok {
+			return op.ParseWithOptions(ctx, source, opts)
 		}
-		supportedTypes := "flowchart, graph, sequence, class, state, stateDiagram-v2, er, gantt, pie, journey, gitGraph, mindmap, timeline, sankey, quadrantChart, xyChart, c4Context, c4Container, c4Component, c4Dynamic, c4Deployment"
-		return nil, fmt.Errorf("unknown or unsupported diagram type %q: expected one of: %s", diagType, supportedTypes)
+		return p.Parse(source)
 	}
 
-	return parser.Parse(source)
-}
-
-// diagramTypeMapping maps Mermaid diagram prefixes to normalized type names.
-// Ordered by specificity (more specific prefixes first).
-var diagramTypeMapping = []struct {
-	prefix string
-	typeID string
-}{
-	{"stateDiagram-v2", "stateDiagram-v2"},
-	{"stateDiagram", "state"},
-	{"sequenceDiagram", "sequence"},
-	{"classDiagram", "class"},
-	{"erDiagram", "er"},
-	{"C4Context", "c4Context"},
-	{"C4Container", "c4Container"},
-	{"C4Component", "c4Component"},
-	{"C4Dynamic", "c4Dynamic"},
-	{"C4Deployment", "c4Deployment"},
-	{"quadrantChart", "quadrantChart"},
-	{"xychart-beta", "xyChart"},
-	{"sankey-beta", "sankey"},
-	{"gitGraph", "gitGraph"},
-	{"timeline", "timeline"},
-	{"mindmap", "mindmap"},
-	{"journey", "journey"},
-	{"flowchart", "flowchart"},
-	{"gantt", "gantt"},
-	{"graph", "graph"},
-	{"pie", "pie"},
-}
-
-// detectDiagramType detects the diagram type from the source.
-func detectDiagramType(source string) string {
-	lines := strings.SplitSeq(source, "\n")
-	for line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
-			continue // Skip empty lines and comments
-		}
-
-		// Check for diagram type keywords in order of specificity
-		for _, mapping := range diagramTypeMapping {
-			if strings.HasPrefix(trimmed, mapping.prefix) {
-				return mapping.typeID
-			}
-		}
-
-		// If we found a non-empty, non-comment line, stop looking
-		break
+	var errs ErrorList
+	meta, _, err := parseMetadata(strings.Split(source, "\n"), "", &errs)
+	if err != nil {
+		return withOffsets(source, nil, err)
 	}
+	lineNum, line := headerLine(source)
 
-	return "unknown"
+	// Fallback to GenericDiagram for types registered without a parser
+	if ok {
+		diagram := ast.NewGenericDiagram(reg.Type, source, linePos(lineNum, line))
+		diagram.Metadata = meta
+		return withOffsets(source, diagram, errs.errFor(reg.Type))
+	}
+	supportedTypes := strings.Join(Types(), ", ")
+	header := strings.Fields(line)[0]
+	e := lineError(lineNum, line, fmt.Sprintf("unknown or unsupported diagram type %q: expected one of: %s", header, supportedTypes))
+	e.Expected = supportedTypes
+	e.Hint = "check the spelling of the diagram header on the first line"
+	return withOffsets(source, nil, e)
 }
 
-// isKnownDiagramType returns true if the type is a known Mermaid diagram type.
-func isKnownDiagramType(diagType string) bool {
-	for _, mapping := range diagramTypeMapping {
-		if mapping.typeID == diagType {
-			return true
+// headerLine returns the first line after any frontmatter that is not blank, a
+// comment or a directive, and its line number. It returns 0 and an empty string if
+// there is no such line.
+func headerLine(source string) (int, string) {
+	lines := strings.Split(source, "\n")
+	start := 0
+	if hasFrontmatter(lines) {
+		start = frontmatterClose(lines) + 1
+		if start == 0 {
+			return 0, ""
 		}
 	}
-	return false
+	if i := skipPreamble(lines, start); i < len(lines) {
+		return i + 1, lines[i]
+	}
+	return 0, ""
 }
@@ -1,7 +1,7 @@
 package parser
 
 import (
-	"fmt"
+	"context"
 	"regexp"
 	"strconv"
 	"strings"
@@ -24,37 +24,53 @@ var (
 
 // Parse parses a pie chart diagram source.
 func (p *PieParser) Parse(source string) (ast.Diagram, error) {
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses a pie chart diagram source within the limits in opts.
+func (p *PieParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseLimited(ctx, source, opts, func(lim *limiter) (ast.Diagram, error) {
+		return p.parse(source, lim)
+	})
+}
+
+func (p *PieParser) parse(source string, lim *limiter) (ast.Diagram, error) {
 	lines := strings.Split(source, "\n")
 	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty diagram source")
+		return nil, emptySourceError("pie")
+	}
+
+	var errs ErrorList
+	meta, header, err := parseMetadata(lines, "pie", &errs)
+	if err != nil {
+		return nil, err
 	}
 
 	diagram := &ast.PieDiagram{
 		Type:        "pie",
 		Source:      source,
 		DataEntries: []ast.PieEntry{},
-		Pos:         ast.Position{Line: 1, Column: 1},
+		Pos:         linePos(header+1, lines[header]),
+		End:         ast.SourceEnd(source),
+		Metadata:    meta,
 	}
 
 	// Parse header line
-	firstLine := strings.TrimSpace(lines[0])
-	matches := pieHeaderRegex.FindStringSubmatch(firstLine)
-	if matches == nil {
-		return nil, fmt.Errorf("invalid pie chart header: %s", firstLine)
+	m := matchTrimmed(pieHeaderRegex, lines[header], header+1)
+	if m == nil {
+		return nil, headerError("pie", header+1, lines[header], "'pie'")
 	}
 
 	// Check for showData modifier
-	if matches[1] == "showData" {
+	if m.groups[1] == "showData" {
 		diagram.ShowData = true
 	}
 
 	// Extract title if present
-	if matches[2] != "" {
-		diagram.Title = strings.TrimSpace(matches[2])
-	}
+	diagram.Title, diagram.TitleSpan = m.trimmed(2)
 
 	// Parse data entries
-	for i := 1; i < len(lines); i++ {
+	for i := header + 1; i < len(lines); i++ {
 		line := lines[i]
 		trimmed := strings.TrimSpace(line)
 
@@ -62,37 +78,47 @@ func (p *PieParser) Parse(source string) (ast.Diagram, error) {
 		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
 			continue
 		}
+		if !lim.statement(i + 1) {
+			break
+		}
 
 		// Parse data entry
-		entryMatches := pieEntryRegex.FindStringSubmatch(trimmed)
-		if entryMatches == nil {
-			return nil, fmt.Errorf("line %d: invalid pie entry format: %s", i+1, trimmed)
+		entry := matchTrimmed(pieEntryRegex, line, i+1)
+		if entry == nil {
+			diagram.Unknown = append(diagram.Unknown, unknownStatement(i+1, line))
+			continue
 		}
 
-		label := entryMatches[1]
-		valueStr := entryMatches[2]
+		label := entry.groups[1]
+		valueStr := entry.groups[2]
 
 		value, err := strconv.ParseFloat(valueStr, 64)
 		if err != nil {
-			return nil, fmt.Errorf("line %d: invalid numeric value: %s", i+1, valueStr)
+			errs.addLine(i+1, line, "invalid numeric value: %s", valueStr)
+			continue
 		}
 
 		if value <= 0 {
-			return nil, fmt.Errorf("line %d: pie chart values must be positive (got %f)", i+1, value)
+			errs.addLine(i+1, line, "pie chart values must be positive (got %f)", value)
+			continue
 		}
 
+		span := textSpan(i+1, line)
 		diagram.DataEntries = append(diagram.DataEntries, ast.PieEntry{
-			Label: label,
-			Value: value,
-			Pos:   ast.Position{Line: i + 1, Column: 1},
+			Label:     label,
+			Value:     value,
+			Pos:       span.Start,
+			End:       span.End,
+			LabelSpan: entry.span(1),
+			ValueSpan: entry.span(2),
 		})
 	}
 
 	if len(diagram.DataEntries) == 0 {
-		return nil, fmt.Errorf("pie chart must have at least one data entry")
+		errs.Add(ast.Position{}, "pie chart must have at least one data entry")
 	}
 
-	return diagram, nil
+	return diagram, errs.errFor(diagram.GetType())
 }
 
 // SupportedTypes returns the diagram types this parser supports.
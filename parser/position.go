@@ -0,0 +1,185 @@
+package parser
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
+
+// Parsers work line by line, so they record positions as line and column only.
+// withOffsets fills in the byte offsets once parsing is done.
+
+// indentOf returns the number of bytes of leading whitespace on a line.
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeftFunc(line, unicode.IsSpace))
+}
+
+// lineSpan returns the span covering bytes [start, end) of a source line.
+func lineSpan(lineNum, start, end int) ast.Span {
+	return ast.Span{
+		Start: ast.Position{Line: lineNum, Column: start + 1},
+		End:   ast.Position{Line: lineNum, Column: end + 1},
+	}
+}
+
+// textSpan returns the span covering the non-blank text of a source line.
+func textSpan(lineNum int, line string) ast.Span {
+	start := indentOf(line)
+	return lineSpan(lineNum, start, start+len(strings.TrimSpace(line)))
+}
+
+// narrow returns the part of span s, which covers text, where sub first appears.
+// sub is usually text with surrounding whitespace or quotes removed. The span is
+// returned unchanged if sub does not appear in text.
+func narrow(s ast.Span, text, sub string) ast.Span {
+	i := strings.Index(text, sub)
+	if !s.IsValid() || i < 0 || s.Start.Line != s.End.Line {
+		return s
+	}
+	s.Start.Column += i
+	s.End.Line = s.Start.Line
+	s.End.Column = s.Start.Column + len(sub)
+	return s
+}
+
+// lineMatch is a regular expression match against text taken from a source line.
+// It remembers where that text starts so match groups can be located in the source.
+type lineMatch struct {
+	groups []string // Matched text, as returned by FindStringSubmatch
+	loc    []int    // Group indexes, as returned by FindStringSubmatchIndex
+	line   int      // Line number (1-indexed)
+	base   int      // Byte index in the line at which the matched text starts
+}
+
+// matchLine matches re against text, which starts at byte base of line lineNum.
+// It returns nil if there is no match.
+func matchLine(re *regexp.Regexp, text string, lineNum, base int) *lineMatch {
+	loc := re.FindStringSubmatchIndex(text)
+	if loc == nil {
+		return nil
+	}
+	groups := make([]string, len(loc)/2)
+	for i := range groups {
+		if loc[2*i] >= 0 {
+			groups[i] = text[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	return &lineMatch{groups: groups, loc: loc, line: lineNum, base: base}
+}
+
+// matchTrimmed matches re against a source line with surrounding whitespace removed.
+func matchTrimmed(re *regexp.Regexp, line string, lineNum int) *lineMatch {
+	return matchLine(re, strings.TrimSpace(line), lineNum, indentOf(line))
+}
+
+// span returns the location of group i, or an unset span if the group did not match.
+func (m *lineMatch) span(i int) ast.Span {
+	if m.loc[2*i] < 0 {
+		return ast.Span{}
+	}
+	return lineSpan(m.line, m.base+m.loc[2*i], m.base+m.loc[2*i+1])
+}
+
+// trimmed returns group i and its location with surrounding whitespace removed.
+func (m *lineMatch) trimmed(i int) (string, ast.Span) {
+	value := strings.TrimSpace(m.groups[i])
+	if value == "" {
+		return "", ast.Span{}
+	}
+	return value, narrow(m.span(i), m.groups[i], value)
+}
+
+// unquoted returns group i and its location with any surrounding double quotes
+// or backticks removed, for names that may be written either bare or quoted.
+func (m *lineMatch) unquoted(i int) (string, ast.Span) {
+	value := m.groups[i]
+	if len(value) < 2 || (value[0] != '"' && value[0] != '`') || value[len(value)-1] != value[0] {
+		return value, m.span(i)
+	}
+	return value[1 : len(value)-1], narrow(m.span(i), value, value[1:len(value)-1])
+}
+
+// withOffsets sets the byte offset of every position in diagram and err from its
+// line and column, then returns them unchanged otherwise.
+func withOffsets(source string, diagram ast.Diagram, err error) (ast.Diagram, error) {
+	starts := LineStarts(source)
+	if diagram != nil {
+		setOffsets(reflect.ValueOf(diagram), starts)
+	}
+	if err != nil {
+		setOffsets(reflect.ValueOf(err), starts)
+	}
+	return diagram, err
+}
+
+// LineStarts returns the byte offset at which each line of source starts, for
+// turning a line and column into an offset.
+func LineStarts(source string) []int {
+	starts := []int{0}
+	for i := 0; i < len(source); i++ {
+		if source[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+var positionType = reflect.TypeOf(ast.Position{})
+
+// setOffsets walks v and sets the Offset of every settable ast.Position in it.
+func setOffsets(v reflect.Value, starts []int) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			setOffsets(v.Elem(), starts)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			setOffsets(v.Index(i), starts)
+		}
+	case reflect.Struct:
+		if v.Type() == positionType {
+			if v.CanSet() {
+				pos := v.Addr().Interface().(*ast.Position)
+				if pos.Line >= 1 && pos.Line <= len(starts) && pos.Column >= 1 {
+					pos.Offset = starts[pos.Line-1] + pos.Column - 1
+				}
+			}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				setOffsets(v.Field(i), starts)
+			}
+		}
+	}
+}
+
+// lineEnd returns the position just past the last non-blank character of a line.
+func lineEnd(lineNum int, line string) ast.Position {
+	return textSpan(lineNum, line).End
+}
+
+// splitTrimmed splits text, located at span s, around sep. It returns each part
+// with surrounding whitespace removed, along with its location.
+func splitTrimmed(text string, s ast.Span, sep string) ([]string, []ast.Span) {
+	parts := strings.Split(text, sep)
+	values := make([]string, len(parts))
+	spans := make([]ast.Span, len(parts))
+	offset := 0
+	for i, part := range parts {
+		values[i] = strings.TrimSpace(part)
+		if s.IsValid() {
+			ps := s
+			ps.Start.Column += offset
+			ps.End = ps.Start
+			ps.End.Column += len(part)
+			spans[i] = narrow(ps, part, values[i])
+		}
+		offset += len(part) + len(sep)
+	}
+	return values, spans
+}
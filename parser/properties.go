@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
+
+// property is one key: value entry of an @{} block.
+type property struct {
+	key   string   // Key, without quotes
+	value string   // Value, without surrounding whitespace or quotes
+	raw   string   // Value as written, including any quotes
+	span  ast.Span // Location of value, unset if it is empty
+}
+
+// parseProperties returns the entries of an @{} block body, which is group i of
+// m. Entries are separated by commas, and keys and values may be quoted, as in
+// `shape: cyl, label: "Database"` or `"type": "database"`. It returns false if
+// an entry is not a key: value pair.
+func parseProperties(m *lineMatch, i int) ([]property, bool) {
+	body, bodyStart := m.groups[i], m.base+m.loc[2*i]
+	var props []property
+
+	for _, entry := range splitUnquoted(body, ',') {
+		text := body[entry[0]:entry[1]]
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		colon := strings.IndexByte(text, ':')
+		key := ""
+		if colon >= 0 {
+			key = unquote(strings.TrimSpace(text[:colon]))
+		}
+		if key == "" {
+			return nil, false
+		}
+
+		// Locate the value, without surrounding whitespace or quotes
+		raw := text[colon+1:]
+		value := strings.TrimSpace(raw)
+		valueStart := bodyStart + entry[0] + colon + 1 + indentOf(raw)
+		if unquoted := unquote(value); unquoted != value {
+			value = unquoted
+			valueStart++
+		}
+		var valueSpan ast.Span
+		if value != "" {
+			valueSpan = lineSpan(m.line, valueStart, valueStart+len(value))
+		}
+
+		props = append(props, property{key: key, value: value, raw: strings.TrimSpace(raw), span: valueSpan})
+	}
+	return props, true
+}
+
+// unquote returns text without the single or double quotes around it, if any.
+func unquote(text string) string {
+	if len(text) >= 2 && (text[0] == '"' || text[0] == '\'') && text[len(text)-1] == text[0] {
+		return text[1 : len(text)-1]
+	}
+	return text
+}
+
+// splitUnquoted returns the start and end of each part of text separated by sep,
+// ignoring separators inside single or double quotes.
+func splitUnquoted(text string, sep byte) [][2]int {
+	var parts [][2]int
+	start := 0
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			parts = append(parts, [2]int{start, i})
+			start = i + 1
+		}
+	}
+	return append(parts, [2]int{start, len(text)})
+}
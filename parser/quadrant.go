@@ -1,7 +1,7 @@
 package parser
 
 import (
-	"fmt"
+	"context"
 	"regexp"
 	"strconv"
 	"strings"
@@ -28,28 +28,47 @@ var (
 
 // Parse parses a quadrant chart diagram source.
 func (p *QuadrantParser) Parse(source string) (ast.Diagram, error) {
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses a quadrant chart diagram source within the limits in opts.
+func (p *QuadrantParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseLimited(ctx, source, opts, func(lim *limiter) (ast.Diagram, error) {
+		return p.parse(source, lim)
+	})
+}
+
+func (p *QuadrantParser) parse(source string, lim *limiter) (ast.Diagram, error) {
 	lines := strings.Split(source, "\n")
 	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty diagram source")
+		return nil, emptySourceError("quadrantChart")
+	}
+
+	var errs ErrorList
+	meta, header, err := parseMetadata(lines, "quadrantChart", &errs)
+	if err != nil {
+		return nil, err
 	}
 
 	diagram := &ast.QuadrantDiagram{
-		Type:   "quadrantChart",
-		Source: source,
-		Points: []ast.QuadrantPoint{},
-		Pos:    ast.Position{Line: 1, Column: 1},
+		Type:     "quadrantChart",
+		Source:   source,
+		Points:   []ast.QuadrantPoint{},
+		Pos:      linePos(header+1, lines[header]),
+		End:      ast.SourceEnd(source),
+		Metadata: meta,
 	}
 
 	// Parse header line
-	firstLine := strings.TrimSpace(lines[0])
+	firstLine := strings.TrimSpace(lines[header])
 	if !quadrantHeaderRegex.MatchString(firstLine) {
-		return nil, fmt.Errorf("invalid quadrant chart header: %s", firstLine)
+		return nil, headerError("quadrantChart", header+1, lines[header], "'quadrantChart'")
 	}
 
 	var xAxisDefined, yAxisDefined bool
 
 	// Parse remaining lines
-	for i := 1; i < len(lines); i++ {
+	for i := header + 1; i < len(lines); i++ {
 		line := lines[i]
 		trimmed := strings.TrimSpace(line)
 
@@ -57,83 +76,97 @@ func (p *QuadrantParser) Parse(source string) (ast.Diagram, error) {
 		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
 			continue
 		}
+		if !lim.statement(i + 1) {
+			break
+		}
+
+		lineNum := i + 1
+		span := textSpan(lineNum, line)
 
 		// Try to match title
-		if matches := quadrantTitleRegex.FindStringSubmatch(trimmed); matches != nil {
-			diagram.Title = strings.TrimSpace(matches[1])
+		if m := matchTrimmed(quadrantTitleRegex, line, lineNum); m != nil {
+			diagram.Title, diagram.TitleSpan = m.trimmed(1)
 			continue
 		}
 
 		// Try to match x-axis
-		if matches := quadrantXAxisRegex.FindStringSubmatch(trimmed); matches != nil {
-			diagram.XAxis = ast.QuadrantAxis{
-				Min: strings.TrimSpace(matches[1]),
-				Max: strings.TrimSpace(matches[2]),
-			}
+		if m := matchTrimmed(quadrantXAxisRegex, line, lineNum); m != nil {
+			diagram.XAxis = quadrantAxis(m, span)
 			xAxisDefined = true
 			continue
 		}
 
 		// Try to match y-axis
-		if matches := quadrantYAxisRegex.FindStringSubmatch(trimmed); matches != nil {
-			diagram.YAxis = ast.QuadrantAxis{
-				Min: strings.TrimSpace(matches[1]),
-				Max: strings.TrimSpace(matches[2]),
-			}
+		if m := matchTrimmed(quadrantYAxisRegex, line, lineNum); m != nil {
+			diagram.YAxis = quadrantAxis(m, span)
 			yAxisDefined = true
 			continue
 		}
 
 		// Try to match quadrant label
-		if matches := quadrantLabelRegex.FindStringSubmatch(trimmed); matches != nil {
-			quadrantNum, _ := strconv.Atoi(matches[1])
-			diagram.QuadrantLabels[quadrantNum-1] = strings.TrimSpace(matches[2])
+		if m := matchTrimmed(quadrantLabelRegex, line, lineNum); m != nil {
+			quadrantNum, _ := strconv.Atoi(m.groups[1])
+			diagram.QuadrantLabels[quadrantNum-1], diagram.QuadrantLabelSpans[quadrantNum-1] = m.trimmed(2)
 			continue
 		}
 
 		// Try to match data point
-		if matches := quadrantPointRegex.FindStringSubmatch(trimmed); matches != nil {
-			name := strings.TrimSpace(matches[1])
-			xStr := matches[2]
-			yStr := matches[3]
+		if m := matchTrimmed(quadrantPointRegex, line, lineNum); m != nil {
+			name, nameSpan := m.trimmed(1)
+			xStr := m.groups[2]
+			yStr := m.groups[3]
 
 			x, err := strconv.ParseFloat(xStr, 64)
 			if err != nil {
-				return nil, fmt.Errorf("line %d: invalid X coordinate: %s", i+1, xStr)
+				errs.addLine(i+1, line, "invalid X coordinate: %s", xStr)
+				continue
 			}
 
 			y, err := strconv.ParseFloat(yStr, 64)
 			if err != nil {
-				return nil, fmt.Errorf("line %d: invalid Y coordinate: %s", i+1, yStr)
+				errs.addLine(i+1, line, "invalid Y coordinate: %s", yStr)
+				continue
 			}
 
 			diagram.Points = append(diagram.Points, ast.QuadrantPoint{
-				Name: name,
-				X:    x,
-				Y:    y,
-				Pos:  ast.Position{Line: i + 1, Column: 1},
+				Name:     name,
+				X:        x,
+				Y:        y,
+				Pos:      span.Start,
+				End:      span.End,
+				NameSpan: nameSpan,
+				XSpan:    m.span(2),
+				YSpan:    m.span(3),
 			})
 			continue
 		}
 
-		// If we reach here, the line doesn't match any known pattern
-		return nil, fmt.Errorf("line %d: invalid quadrant chart syntax: %s", i+1, trimmed)
+		// Keep lines we can't parse so validators can report them
+		diagram.Unknown = append(diagram.Unknown, unknownStatement(i+1, line))
 	}
 
 	// Validate required elements
 	if !xAxisDefined {
-		return nil, fmt.Errorf("quadrant chart must define x-axis")
+		errs.Add(ast.Position{}, "quadrant chart must define x-axis")
 	}
 
 	if !yAxisDefined {
-		return nil, fmt.Errorf("quadrant chart must define y-axis")
+		errs.Add(ast.Position{}, "quadrant chart must define y-axis")
 	}
 
 	if len(diagram.Points) == 0 {
-		return nil, fmt.Errorf("quadrant chart must have at least one data point")
+		errs.Add(ast.Position{}, "quadrant chart must have at least one data point")
 	}
 
-	return diagram, nil
+	return diagram, errs.errFor(diagram.GetType())
+}
+
+// quadrantAxis builds an axis from a match of quadrantXAxisRegex or quadrantYAxisRegex.
+func quadrantAxis(m *lineMatch, span ast.Span) ast.QuadrantAxis {
+	axis := ast.QuadrantAxis{Pos: span.Start, End: span.End}
+	axis.Min, axis.MinSpan = m.trimmed(1)
+	axis.Max, axis.MaxSpan = m.trimmed(2)
+	return axis
 }
 
 // SupportedTypes returns the diagram types this parser supports.
@@ -0,0 +1,162 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// HeaderPrefix maps the keyword starting a diagram header to a diagram type.
+type HeaderPrefix struct {
+	Prefix      string // Keyword the header starts with, e.g. "sequenceDiagram"
+	Type        string // Diagram type reported for the header, defaults to Prefix
+	DisplayName string // Human-readable name of the type, defaults to Type
+}
+
+// Factory returns a new parser for a registered diagram type.
+type Factory func() DiagramParser
+
+type registration struct {
+	HeaderPrefix
+	factory Factory
+}
+
+var registry struct {
+	sync.RWMutex
+	entries []registration // In registration order
+}
+
+// Register makes Parse and DetectType recognise diagrams whose header starts with
+// one of headerPrefixes, and parse them with a parser from factory. When several
+// prefixes match a header, the longest wins. A nil factory parses diagrams as an
+// ast.GenericDiagram.
+//
+// Register is intended to be called from init functions, so packages providing
+// their own diagram types can be enabled by importing them. It panics if a prefix
+// is empty or has already been registered.
+func Register(headerPrefixes []HeaderPrefix, factory Factory) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	for _, hp := range headerPrefixes {
+		if hp.Prefix == "" {
+			panic("parser: Register called with an empty header prefix")
+		}
+		for _, r := range registry.entries {
+			if r.Prefix == hp.Prefix {
+				panic(fmt.Sprintf("parser: Register called twice for header prefix %q", hp.Prefix))
+			}
+		}
+		if hp.Type == "" {
+			hp.Type = hp.Prefix
+		}
+		if hp.DisplayName == "" {
+			hp.DisplayName = hp.Type
+		}
+		registry.entries = append(registry.entries, registration{HeaderPrefix: hp, factory: factory})
+	}
+}
+
+// lookup returns the registration whose prefix best matches the diagram header in
+// source.
+func lookup(source string) (registration, bool) {
+	_, line := headerLine(source)
+	trimmed := strings.TrimSpace(line)
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	var best registration
+	found := false
+	for _, r := range registry.entries {
+		if strings.HasPrefix(trimmed, r.Prefix) && len(r.Prefix) > len(best.Prefix) {
+			best, found = r, true
+		}
+	}
+	return best, found
+}
+
+// DetectType returns the type of the diagram in source, taken from its header
+// after any frontmatter, comments and directives. It returns "unknown" if the
+// header does not match a registered prefix.
+func DetectType(source string) string {
+	if r, ok := lookup(source); ok {
+		return r.Type
+	}
+	return "unknown"
+}
+
+// DisplayName returns the human-readable name of a registered diagram type, or
+// the type itself if it is not registered.
+func DisplayName(diagramType string) string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	for _, r := range registry.entries {
+		if r.Type == diagramType {
+			return r.DisplayName
+		}
+	}
+	return diagramType
+}
+
+// Types returns the registered diagram types in the order they were registered.
+func Types() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	var types []string
+	seen := make(map[string]bool)
+	for _, r := range registry.entries {
+		if !seen[r.Type] {
+			seen[r.Type] = true
+			types = append(types, r.Type)
+		}
+	}
+	return types
+}
+
+func init() {
+	Register([]HeaderPrefix{
+		{Prefix: "flowchart", DisplayName: "Flowchart"},
+		{Prefix: "graph", DisplayName: "Flow Chart"},
+	}, func() DiagramParser { return NewFlowchartParser() })
+	Register([]HeaderPrefix{{Prefix: "sequenceDiagram", Type: "sequence", DisplayName: "Sequence Diagram"}},
+		func() DiagramParser { return NewSequenceParser() })
+	Register([]HeaderPrefix{{Prefix: "classDiagram", Type: "class", DisplayName: "Class Diagram"}},
+		func() DiagramParser { return NewClassParser() })
+	Register([]HeaderPrefix{
+		{Prefix: "stateDiagram", Type: "state", DisplayName: "State Diagram"},
+		{Prefix: "stateDiagram-v2", DisplayName: "State Diagram"},
+	}, func() DiagramParser { return NewStateParser() })
+	Register([]HeaderPrefix{{Prefix: "erDiagram", Type: "er", DisplayName: "ER Diagram"}},
+		func() DiagramParser { return NewERParser() })
+	Register([]HeaderPrefix{{Prefix: "gantt", DisplayName: "Gantt Chart"}},
+		func() DiagramParser { return NewGanttParser() })
+	Register([]HeaderPrefix{{Prefix: "pie", DisplayName: "Pie Chart"}},
+		func() DiagramParser { return NewPieParser() })
+	Register([]HeaderPrefix{{Prefix: "journey", DisplayName: "User Journey"}},
+		func() DiagramParser { return NewJourneyParser() })
+	Register([]HeaderPrefix{{Prefix: "gitGraph", DisplayName: "Git Graph"}},
+		func() DiagramParser { return NewGitGraphParser() })
+	Register([]HeaderPrefix{{Prefix: "mindmap", DisplayName: "Mindmap"}},
+		func() DiagramParser { return NewMindmapParser() })
+	Register([]HeaderPrefix{{Prefix: "timeline", DisplayName: "Timeline"}},
+		func() DiagramParser { return NewTimelineParser() })
+	Register([]HeaderPrefix{{Prefix: "sankey-beta", Type: "sankey", DisplayName: "Sankey Diagram"}},
+		func() DiagramParser { return NewSankeyParser() })
+	Register([]HeaderPrefix{{Prefix: "quadrantChart", DisplayName: "Quadrant Chart"}},
+		func() DiagramParser { return NewQuadrantParser() })
+	Register([]HeaderPrefix{{Prefix: "xychart-beta", Type: "xyChart", DisplayName: "XY Chart"}},
+		func() DiagramParser { return NewXYChartParser() })
+	Register([]HeaderPrefix{{Prefix: "C4Context", Type: "c4Context", DisplayName: "C4 Context Diagram"}},
+		func() DiagramParser { return NewC4ContextParser() })
+	Register([]HeaderPrefix{{Prefix: "C4Container", Type: "c4Container", DisplayName: "C4 Container Diagram"}},
+		func() DiagramParser { return NewC4ContainerParser() })
+	Register([]HeaderPrefix{{Prefix: "C4Component", Type: "c4Component", DisplayName: "C4 Component Diagram"}},
+		func() DiagramParser { return NewC4ComponentParser() })
+	Register([]HeaderPrefix{{Prefix: "C4Dynamic", Type: "c4Dynamic", DisplayName: "C4 Dynamic Diagram"}},
+		func() DiagramParser { return NewC4DynamicParser() })
+	Register([]HeaderPrefix{{Prefix: "C4Deployment", Type: "c4Deployment", DisplayName: "C4 Deployment Diagram"}},
+		func() DiagramParser { return NewC4DeploymentParser() })
+}
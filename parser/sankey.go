@@ -1,7 +1,7 @@
 package parser
 
 import (
-	"fmt"
+	"context"
 	"strconv"
 	"strings"
 
@@ -18,26 +18,45 @@ func NewSankeyParser() *SankeyParser {
 
 // Parse parses a Sankey diagram source.
 func (p *SankeyParser) Parse(source string) (ast.Diagram, error) {
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses a Sankey diagram source within the limits in opts.
+func (p *SankeyParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseLimited(ctx, source, opts, func(lim *limiter) (ast.Diagram, error) {
+		return p.parse(source, lim)
+	})
+}
+
+func (p *SankeyParser) parse(source string, lim *limiter) (ast.Diagram, error) {
 	lines := strings.Split(source, "\n")
 	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty diagram source")
+		return nil, emptySourceError("sankey")
+	}
+
+	var errs ErrorList
+	meta, header, err := parseMetadata(lines, "sankey", &errs)
+	if err != nil {
+		return nil, err
 	}
 
 	diagram := &ast.SankeyDiagram{
-		Type:   "sankey",
-		Source: source,
-		Links:  []ast.SankeyLink{},
-		Pos:    ast.Position{Line: 1, Column: 1},
+		Type:     "sankey",
+		Source:   source,
+		Links:    []ast.SankeyLink{},
+		Pos:      linePos(header+1, lines[header]),
+		End:      ast.SourceEnd(source),
+		Metadata: meta,
 	}
 
 	// Parse header line
-	firstLine := strings.TrimSpace(lines[0])
+	firstLine := strings.TrimSpace(lines[header])
 	if firstLine != "sankey-beta" {
-		return nil, fmt.Errorf("invalid Sankey diagram header: expected 'sankey-beta', got %q", firstLine)
+		return nil, headerError("sankey", header+1, lines[header], "'sankey-beta'")
 	}
 
 	// Parse link lines
-	for i := 1; i < len(lines); i++ {
+	for i := header + 1; i < len(lines); i++ {
 		line := lines[i]
 		trimmed := strings.TrimSpace(line)
 
@@ -45,53 +64,67 @@ func (p *SankeyParser) Parse(source string) (ast.Diagram, error) {
 		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
 			continue
 		}
+		if !lim.statement(i + 1) {
+			break
+		}
 
 		// Parse CSV format: source,target,value
-		parts := strings.Split(trimmed, ",")
+		span := textSpan(i+1, line)
+		parts, spans := splitTrimmed(trimmed, span, ",")
 		if len(parts) != 3 {
-			return nil, fmt.Errorf("line %d: invalid Sankey link format: expected 'source,target,value', got %q", i+1, trimmed)
+			diagram.Unknown = append(diagram.Unknown, unknownStatement(i+1, line))
+			continue
 		}
 
-		source := strings.TrimSpace(parts[0])
-		target := strings.TrimSpace(parts[1])
-		valueStr := strings.TrimSpace(parts[2])
+		source := parts[0]
+		target := parts[1]
+		valueStr := parts[2]
 
 		// Validate source and target are not empty
 		if source == "" {
-			return nil, fmt.Errorf("line %d: source node name cannot be empty", i+1)
+			errs.addLine(i+1, line, "source node name cannot be empty")
+			continue
 		}
 		if target == "" {
-			return nil, fmt.Errorf("line %d: target node name cannot be empty", i+1)
+			errs.addLine(i+1, line, "target node name cannot be empty")
+			continue
 		}
 
 		// Validate source != target (no self-loops)
 		if source == target {
-			return nil, fmt.Errorf("line %d: self-loop detected: source and target cannot be the same (%q)", i+1, source)
+			errs.addLine(i+1, line, "self-loop detected: source and target cannot be the same (%q)", source)
+			continue
 		}
 
 		// Parse and validate value
 		value, err := strconv.ParseFloat(valueStr, 64)
 		if err != nil {
-			return nil, fmt.Errorf("line %d: invalid numeric value: %s", i+1, valueStr)
+			errs.addLine(i+1, line, "invalid numeric value: %s", valueStr)
+			continue
 		}
 
 		if value <= 0 {
-			return nil, fmt.Errorf("line %d: Sankey link value must be positive (got %f)", i+1, value)
+			errs.addLine(i+1, line, "Sankey link value must be positive (got %f)", value)
+			continue
 		}
 
 		diagram.Links = append(diagram.Links, ast.SankeyLink{
-			Source: source,
-			Target: target,
-			Value:  value,
-			Pos:    ast.Position{Line: i + 1, Column: 1},
+			Source:     source,
+			Target:     target,
+			Value:      value,
+			Pos:        span.Start,
+			End:        span.End,
+			SourceSpan: spans[0],
+			TargetSpan: spans[1],
+			ValueSpan:  spans[2],
 		})
 	}
 
 	if len(diagram.Links) == 0 {
-		return nil, fmt.Errorf("sankey diagram must have at least one link")
+		errs.Add(ast.Position{}, "sankey diagram must have at least one link")
 	}
 
-	return diagram, nil
+	return diagram, errs.errFor(diagram.GetType())
 }
 
 // SupportedTypes returns the diagram types this parser supports.
@@ -1,8 +1,13 @@
 package parser
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/sammcj/mermaid-check/ast"
@@ -13,12 +18,14 @@ var (
 	seqHeaderPattern = regexp.MustCompile(`^sequenceDiagram\s*$`)
 	seqCommentPattern = regexp.MustCompile(`^%%(.*)$`)
 
-	// Participant patterns
-	participantPattern = regexp.MustCompile(`^(participant|actor)\s+(\w+)(?:\s+as\s+(.+))?$`)
+	// Participant patterns. Groups: keyword, ID, @{} block body and alias
+	participantPattern = regexp.MustCompile(`^` + participantDecl + `$`)
+	createPattern = regexp.MustCompile(`^create\s+` + participantDecl + `$`)
+	destroyPattern = regexp.MustCompile(`^destroy\s+(` + hyphenIdent + `)$`)
 
 	// Activation patterns
-	activatePattern = regexp.MustCompile(`^activate\s+(\w+)$`)
-	deactivatePattern = regexp.MustCompile(`^deactivate\s+(\w+)$`)
+	activatePattern = regexp.MustCompile(`^activate\s+(` + hyphenIdent + `)$`)
+	deactivatePattern = regexp.MustCompile(`^deactivate\s+(` + hyphenIdent + `)$`)
 
 	// Block patterns
 	loopPattern = regexp.MustCompile(`^loop\s+(.+)$`)
@@ -33,17 +40,30 @@ var (
 	endPattern = regexp.MustCompile(`^end\s*$`)
 
 	// Note patterns (case-insensitive to match Mermaid spec)
-	noteLeftPattern = regexp.MustCompile(`(?i)^note\s+left\s+of\s+(\w+)\s*:\s*(.+)$`)
-	noteRightPattern = regexp.MustCompile(`(?i)^note\s+right\s+of\s+(\w+)\s*:\s*(.+)$`)
-	noteOverPattern = regexp.MustCompile(`(?i)^note\s+over\s+([\w,\s]+)\s*:\s*(.+)$`)
+	noteLeftPattern = regexp.MustCompile(`(?i)^note\s+left\s+of\s+(` + hyphenIdent + `)\s*:\s*(.+)$`)
+	noteRightPattern = regexp.MustCompile(`(?i)^note\s+right\s+of\s+(` + hyphenIdent + `)\s*:\s*(.+)$`)
+	noteOverPattern = regexp.MustCompile(`(?i)^note\s+over\s+([` + identChars + `,\s-]+)\s*:\s*(.+)$`)
 
 	// Box pattern
 	boxPattern = regexp.MustCompile(`^box\s+(?:(\w+)\s+)?(.+)$`)
 
-	// Autonumber pattern
-	autonumberPattern = regexp.MustCompile(`^autonumber\s*$`)
+	// Rect pattern
+	rectPattern = regexp.MustCompile(`^rect\s+(.+)$`)
+
+	// Menu link and property patterns. Groups: participant, then label and URL for
+	// linkPattern, or a JSON object for the others
+	linkPattern = regexp.MustCompile(`^link\s+(` + hyphenIdent + `)\s*:\s*([^@]+?)\s*@\s*(.+)$`)
+	linksPattern = regexp.MustCompile(`^links\s+(` + hyphenIdent + `)\s*:\s*(.+)$`)
+	propertiesPattern = regexp.MustCompile(`^properties\s+(` + hyphenIdent + `)\s*:\s*(.+)$`)
+
+	// Autonumber pattern. Groups: off, or the start and step
+	autonumberPattern = regexp.MustCompile(`^autonumber(?:\s+(off)|\s+(\d+)(?:\s+(\d+))?)?$`)
 )
 
+// participantDecl matches a participant declaration, such as actor A as Alice or
+// participant DB@{ "type": "database" } as Orders.
+const participantDecl = `(participant|actor)\s+(` + hyphenIdent + `)(?:@\{([^}]*)\})?(?:\s+as\s+(.+))?`
+
 // SequenceParser parses Mermaid sequence diagrams.
 type SequenceParser struct{}
 
@@ -54,17 +74,34 @@ func NewSequenceParser() *SequenceParser {
 
 // Parse parses a Mermaid sequence diagram from a string.
 func (p *SequenceParser) Parse(source string) (ast.Diagram, error) {
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses a Mermaid sequence diagram within the limits in opts.
+func (p *SequenceParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseLimited(ctx, source, opts, func(lim *limiter) (ast.Diagram, error) {
+		return p.parse(source, lim)
+	})
+}
+
+func (p *SequenceParser) parse(source string, lim *limiter) (ast.Diagram, error) {
 	lines := strings.Split(source, "\n")
 
 	// Check header
 	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty sequence diagram")
+		return nil, emptySourceError("sequence")
+	}
+
+	var errs ErrorList
+	meta, start, err := parseMetadata(lines, "sequence", &errs)
+	if err != nil {
+		return nil, err
 	}
 
 	// Find first non-comment, non-empty line
 	headerLine := -1
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
+	for i := start; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
 		if trimmed != "" && !strings.HasPrefix(trimmed, "%%") {
 			headerLine = i
 			break
@@ -72,29 +109,26 @@ func (p *SequenceParser) Parse(source string) (ast.Diagram, error) {
 	}
 
 	if headerLine == -1 {
-		return nil, fmt.Errorf("sequence diagram has no content")
+		return nil, emptySourceError("sequence")
 	}
 
 	trimmedHeader := strings.TrimSpace(lines[headerLine])
 	if !seqHeaderPattern.MatchString(trimmedHeader) {
-		return nil, fmt.Errorf("line %d: invalid sequence diagram header, expected 'sequenceDiagram'", headerLine+1)
+		return nil, headerError("sequence", headerLine+1, lines[headerLine], "'sequenceDiagram'")
 	}
 
 	diagram := &ast.SequenceDiagram{
-		Type:   "sequence",
-		Source: source,
-		Pos:    ast.Position{Line: 1, Column: 1},
+		Type:     "sequence",
+		Source:   source,
+		Pos:      linePos(headerLine+1, lines[headerLine]),
+		End:      ast.SourceEnd(source),
+		Metadata: meta,
 	}
 
 	// Parse statements
-	statements, err := p.parseStatements(lines[headerLine+1:], headerLine+2)
-	if err != nil {
-		return nil, err
-	}
-
-	diagram.Statements = statements
+	diagram.Statements = p.parseStatements(lines[headerLine+1:], headerLine+2, &errs, lim)
 
-	return diagram, nil
+	return diagram, errs.errFor(diagram.Type)
 }
 
 // SupportedTypes returns the diagram types this parser handles.
@@ -102,13 +136,13 @@ func (p *SequenceParser) SupportedTypes() []string {
 	return []string{"sequence"}
 }
 
-func (p *SequenceParser) parseStatements(lines []string, startLine int) ([]ast.SeqStmt, error) {
+func (p *SequenceParser) parseStatements(lines []string, startLine int, errs *ErrorList, lim *limiter) []ast.SeqStmt {
 	var statements []ast.SeqStmt
 	lineNum := startLine
 
 	for i := 0; i < len(lines); i++ {
 		trimmed := strings.TrimSpace(lines[i])
-		pos := ast.Position{Line: lineNum, Column: 1}
+		pos := linePos(lineNum, lines[i])
 		lineNum++
 
 		// Skip empty lines
@@ -121,10 +155,18 @@ func (p *SequenceParser) parseStatements(lines []string, startLine int) ([]ast.S
 			continue
 		}
 
-		// Try to parse statement
-		stmt, consumed, err := p.parseStatement(lines[i:], pos, i+startLine)
-		if err != nil {
-			return nil, err
+		if !lim.statement(i + startLine) {
+			break
+		}
+
+		// Try to parse statement, keeping track of how deeply blocks are nested
+		block := isBlockStart(trimmed)
+		if block && !lim.enter(i+startLine) {
+			break
+		}
+		stmt, consumed := p.parseStatement(lines[i:], pos, i+startLine, errs, lim)
+		if block {
+			lim.leave()
 		}
 
 		if stmt != nil {
@@ -138,167 +180,317 @@ func (p *SequenceParser) parseStatements(lines []string, startLine int) ([]ast.S
 		}
 	}
 
-	return statements, nil
+	return statements
 }
 
-func (p *SequenceParser) parseStatement(lines []string, pos ast.Position, lineNum int) (ast.SeqStmt, int, error) {
+func (p *SequenceParser) parseStatement(lines []string, pos ast.Position, lineNum int, errs *ErrorList, lim *limiter) (ast.SeqStmt, int) {
 	if len(lines) == 0 {
-		return nil, 0, nil
+		return nil, 0
 	}
 
-	trimmed := strings.TrimSpace(lines[0])
+	line := lines[0]
+	end := lineEnd(lineNum, line)
 
 	// Participant/actor
-	if matches := participantPattern.FindStringSubmatch(trimmed); matches != nil {
-		return &ast.Participant{
-			ID:    matches[2],
-			Alias: matches[3],
-			Type:  matches[1],
-			Pos:   pos,
-		}, 1, nil
+	if m := matchTrimmed(participantPattern, line, lineNum); m != nil {
+		if participant := newParticipant(m, errs); participant != nil {
+			return participant, 1
+		}
+		return nil, 1
+	}
+
+	// Creation and destruction
+	if m := matchTrimmed(createPattern, line, lineNum); m != nil {
+		if participant := newParticipant(m, errs); participant != nil {
+			return &ast.Create{Participant: *participant, Pos: pos, End: end}, 1
+		}
+		return nil, 1
+	}
+
+	if m := matchTrimmed(destroyPattern, line, lineNum); m != nil {
+		return &ast.Destroy{
+			Participant:     m.groups[1],
+			Pos:             pos,
+			End:             end,
+			ParticipantSpan: m.span(1),
+		}, 1
 	}
 
 	// Activation
-	if matches := activatePattern.FindStringSubmatch(trimmed); matches != nil {
+	if m := matchTrimmed(activatePattern, line, lineNum); m != nil {
 		return &ast.Activation{
-			Participant: matches[1],
-			Active:      true,
-			Pos:         pos,
-		}, 1, nil
+			Participant:     m.groups[1],
+			Active:          true,
+			Pos:             pos,
+			End:             end,
+			ParticipantSpan: m.span(1),
+		}, 1
 	}
 
-	if matches := deactivatePattern.FindStringSubmatch(trimmed); matches != nil {
+	if m := matchTrimmed(deactivatePattern, line, lineNum); m != nil {
 		return &ast.Activation{
-			Participant: matches[1],
-			Active:      false,
-			Pos:         pos,
-		}, 1, nil
+			Participant:     m.groups[1],
+			Active:          false,
+			Pos:             pos,
+			End:             end,
+			ParticipantSpan: m.span(1),
+		}, 1
 	}
 
 	// Loop block
-	if matches := loopPattern.FindStringSubmatch(trimmed); matches != nil {
-		blockLines, consumed, err := p.extractBlock(lines[1:], lineNum+1)
-		if err != nil {
-			return nil, 0, err
-		}
-
-		statements, err := p.parseStatements(blockLines, lineNum+1)
-		if err != nil {
-			return nil, 0, err
-		}
+	if m := matchTrimmed(loopPattern, line, lineNum); m != nil {
+		blockLines, consumed := p.extractBlock(lines, pos, errs)
+		statements := p.parseStatements(blockLines, lineNum+1, errs, lim)
 
 		return &ast.Loop{
-			Label:      matches[1],
+			Label:      m.groups[1],
 			Statements: statements,
 			Pos:        pos,
-		}, consumed + 1, nil
+			End:        blockEnd(lines, lineNum, consumed),
+			LabelSpan:  m.span(1),
+		}, consumed
 	}
 
 	// Alt block
-	if matches := altPattern.FindStringSubmatch(trimmed); matches != nil {
-		return p.parseAltBlock(lines, pos, lineNum, matches[1])
+	if m := matchTrimmed(altPattern, line, lineNum); m != nil {
+		return p.parseAltBlock(lines, pos, lineNum, m, errs, lim)
 	}
 
 	// Opt block
-	if matches := optPattern.FindStringSubmatch(trimmed); matches != nil {
-		blockLines, consumed, err := p.extractBlock(lines[1:], lineNum+1)
-		if err != nil {
-			return nil, 0, err
-		}
-
-		statements, err := p.parseStatements(blockLines, lineNum+1)
-		if err != nil {
-			return nil, 0, err
-		}
+	if m := matchTrimmed(optPattern, line, lineNum); m != nil {
+		blockLines, consumed := p.extractBlock(lines, pos, errs)
+		statements := p.parseStatements(blockLines, lineNum+1, errs, lim)
 
 		return &ast.Opt{
-			Label:      matches[1],
+			Label:      m.groups[1],
 			Statements: statements,
 			Pos:        pos,
-		}, consumed + 1, nil
+			End:        blockEnd(lines, lineNum, consumed),
+			LabelSpan:  m.span(1),
+		}, consumed
 	}
 
 	// Par block
-	if matches := parPattern.FindStringSubmatch(trimmed); matches != nil {
-		return p.parseParBlock(lines, pos, lineNum, matches[1])
+	if m := matchTrimmed(parPattern, line, lineNum); m != nil {
+		return p.parseParBlock(lines, pos, lineNum, m, errs, lim)
 	}
 
 	// Critical block
-	if matches := criticalPattern.FindStringSubmatch(trimmed); matches != nil {
-		return p.parseCriticalBlock(lines, pos, lineNum, matches[1])
+	if m := matchTrimmed(criticalPattern, line, lineNum); m != nil {
+		return p.parseCriticalBlock(lines, pos, lineNum, m, errs, lim)
 	}
 
 	// Break block
-	if matches := breakPattern.FindStringSubmatch(trimmed); matches != nil {
-		blockLines, consumed, err := p.extractBlock(lines[1:], lineNum+1)
-		if err != nil {
-			return nil, 0, err
-		}
-
-		statements, err := p.parseStatements(blockLines, lineNum+1)
-		if err != nil {
-			return nil, 0, err
-		}
+	if m := matchTrimmed(breakPattern, line, lineNum); m != nil {
+		blockLines, consumed := p.extractBlock(lines, pos, errs)
+		statements := p.parseStatements(blockLines, lineNum+1, errs, lim)
 
 		return &ast.Break{
-			Label:      matches[1],
+			Label:      m.groups[1],
 			Statements: statements,
 			Pos:        pos,
-		}, consumed + 1, nil
+			End:        blockEnd(lines, lineNum, consumed),
+			LabelSpan:  m.span(1),
+		}, consumed
 	}
 
 	// Box
-	if matches := boxPattern.FindStringSubmatch(trimmed); matches != nil {
-		return p.parseBoxBlock(lines, pos, lineNum, matches[1], matches[2])
+	if m := matchTrimmed(boxPattern, line, lineNum); m != nil {
+		return p.parseBoxBlock(lines, pos, lineNum, m, errs, lim)
 	}
 
 	// Notes
-	if matches := noteLeftPattern.FindStringSubmatch(trimmed); matches != nil {
+	if m := matchTrimmed(noteLeftPattern, line, lineNum); m != nil {
 		return &ast.Note{
-			Position:     "left of",
-			Participants: []string{matches[1]},
-			Text:         matches[2],
-			Pos:          pos,
-		}, 1, nil
+			Position:         "left of",
+			Participants:     []string{m.groups[1]},
+			Text:             m.groups[2],
+			Pos:              pos,
+			End:              end,
+			ParticipantSpans: []ast.Span{m.span(1)},
+			TextSpan:         m.span(2),
+		}, 1
 	}
 
-	if matches := noteRightPattern.FindStringSubmatch(trimmed); matches != nil {
+	if m := matchTrimmed(noteRightPattern, line, lineNum); m != nil {
 		return &ast.Note{
-			Position:     "right of",
-			Participants: []string{matches[1]},
-			Text:         matches[2],
-			Pos:          pos,
-		}, 1, nil
-	}
-
-	if matches := noteOverPattern.FindStringSubmatch(trimmed); matches != nil {
-		participants := strings.Split(strings.ReplaceAll(matches[1], " ", ""), ",")
+			Position:         "right of",
+			Participants:     []string{m.groups[1]},
+			Text:             m.groups[2],
+			Pos:              pos,
+			End:              end,
+			ParticipantSpans: []ast.Span{m.span(1)},
+			TextSpan:         m.span(2),
+		}, 1
+	}
+
+	if m := matchTrimmed(noteOverPattern, line, lineNum); m != nil {
+		participants, participantSpans := splitTrimmed(m.groups[1], m.span(1), ",")
 		return &ast.Note{
-			Position:     "over",
-			Participants: participants,
-			Text:         matches[2],
-			Pos:          pos,
-		}, 1, nil
+			Position:         "over",
+			Participants:     participants,
+			Text:             m.groups[2],
+			Pos:              pos,
+			End:              end,
+			ParticipantSpans: participantSpans,
+			TextSpan:         m.span(2),
+		}, 1
+	}
+
+	// Rect block
+	if m := matchTrimmed(rectPattern, line, lineNum); m != nil {
+		blockLines, consumed := p.extractBlock(lines, pos, errs)
+		statements := p.parseStatements(blockLines, lineNum+1, errs, lim)
+
+		return &ast.Rect{
+			Colour:     m.groups[1],
+			Statements: statements,
+			Pos:        pos,
+			End:        blockEnd(lines, lineNum, consumed),
+			ColourSpan: m.span(1),
+		}, consumed
+	}
+
+	// Menu links and participant properties
+	if m := matchTrimmed(linkPattern, line, lineNum); m != nil {
+		return &ast.ParticipantLinks{
+			Participant: m.groups[1],
+			Links: []ast.MenuLink{{
+				Label:     m.groups[2],
+				URL:       m.groups[3],
+				LabelSpan: m.span(2),
+				URLSpan:   m.span(3),
+			}},
+			Pos:             pos,
+			End:             end,
+			ParticipantSpan: m.span(1),
+		}, 1
+	}
+
+	if m := matchTrimmed(linksPattern, line, lineNum); m != nil {
+		entries, err := parseJSONObject(m, 2)
+		if err != nil {
+			e := errs.addLine(pos.Line, line, "invalid links for participant '%s': %v", m.groups[1], err)
+			e.Expected = "a JSON object of labels and URLs"
+			return nil, 1
+		}
+		links := make([]ast.MenuLink, len(entries))
+		for i, entry := range entries {
+			links[i] = ast.MenuLink{Label: entry.key, URL: entry.value, LabelSpan: entry.keySpan, URLSpan: entry.valueSpan}
+		}
+		return &ast.ParticipantLinks{
+			Participant:     m.groups[1],
+			Links:           links,
+			JSON:            true,
+			Pos:             pos,
+			End:             end,
+			ParticipantSpan: m.span(1),
+		}, 1
+	}
+
+	if m := matchTrimmed(propertiesPattern, line, lineNum); m != nil {
+		entries, err := parseJSONObject(m, 2)
+		if err != nil {
+			e := errs.addLine(pos.Line, line, "invalid properties for participant '%s': %v", m.groups[1], err)
+			e.Expected = "a JSON object of strings"
+			return nil, 1
+		}
+		properties := make(map[string]string, len(entries))
+		for _, entry := range entries {
+			properties[entry.key] = entry.value
+		}
+		return &ast.ParticipantProperties{
+			Participant:     m.groups[1],
+			Properties:      properties,
+			Pos:             pos,
+			End:             end,
+			ParticipantSpan: m.span(1),
+			PropertiesSpan:  m.span(2),
+		}, 1
 	}
 
 	// Autonumber
-	if autonumberPattern.MatchString(trimmed) {
-		return &ast.Autonumber{
-			Enabled: true,
+	if m := matchTrimmed(autonumberPattern, line, lineNum); m != nil {
+		autonumber := &ast.Autonumber{
+			Enabled: m.groups[1] == "",
+			Start:   1,
+			Step:    1,
 			Pos:     pos,
-		}, 1, nil
+			End:     end,
+		}
+		for i, value := range []*int{&autonumber.Start, &autonumber.Step} {
+			group := i + 2
+			if m.groups[group] == "" {
+				continue
+			}
+			n, err := strconv.Atoi(m.groups[group])
+			if err != nil {
+				errs.addLine(pos.Line, line, "autonumber value '%s' is too large", m.groups[group])
+				return nil, 1
+			}
+			*value = n
+		}
+		autonumber.StartSpan, autonumber.StepSpan = m.span(2), m.span(3)
+		return autonumber, 1
 	}
 
 	// Message (try this last as it's more permissive)
-	if msg := p.parseMessage(trimmed, pos); msg != nil {
-		return msg, 1, nil
+	if msg := p.parseMessage(line, lineNum); msg != nil {
+		return msg, 1
 	}
 
-	// Unknown statement
-	return nil, 0, fmt.Errorf("line %d: unknown sequence diagram statement: %s", pos.Line, trimmed)
+	// Keep lines we can't parse so validators can report them
+	return unknownStatement(pos.Line, lines[0]), 1
 }
 
-func (p *SequenceParser) parseMessage(line string, pos ast.Position) *ast.Message {
+// newParticipant builds a participant from a match of participantPattern or
+// createPattern. A type given in an @{} block replaces the keyword as its type.
+// It reports the block and returns nil if the block is not key: value pairs.
+func newParticipant(m *lineMatch, errs *ErrorList) *ast.Participant {
+	participant := &ast.Participant{
+		ID:       m.groups[2],
+		Type:     m.groups[1],
+		Pos:      m.span(1).Start,
+		End:      m.span(0).End,
+		IDSpan:   m.span(2),
+		TypeSpan: m.span(1),
+	}
+	participant.Alias, participant.AliasSpan = m.trimmed(4)
+
+	if m.loc[6] >= 0 {
+		props, ok := parseProperties(m, 3)
+		if !ok {
+			span := m.span(3)
+			*errs = append(*errs, &ParseError{
+				Pos:      span.Start,
+				End:      span.End,
+				Found:    m.groups[3],
+				Expected: "key: value pairs",
+				Message:  fmt.Sprintf("invalid properties for participant '%s'", m.groups[2]),
+			})
+			return nil
+		}
+		participant.Properties = make(map[string]string)
+		for _, prop := range props {
+			participant.Properties[prop.key] = prop.value
+			if prop.key == "type" {
+				participant.Type, participant.TypeSpan = prop.value, prop.span
+			}
+		}
+	}
+	return participant
+}
+
+// blockEnd returns the end of a block that starts on line lineNum and takes up
+// the first consumed lines.
+func blockEnd(lines []string, lineNum, consumed int) ast.Position {
+	return lineEnd(lineNum+consumed-1, lines[consumed-1])
+}
+
+func (p *SequenceParser) parseMessage(line string, lineNum int) *ast.Message {
+	base := indentOf(line)
+	trimmedLine := strings.TrimSpace(line)
+
 	// Try different arrow patterns
 	arrows := []string{
 		"<<-->>", "<<->>", // Bidirectional
@@ -306,17 +498,18 @@ func (p *SequenceParser) parseMessage(line string, pos ast.Position) *ast.Messag
 	}
 
 	for _, arrow := range arrows {
-		if idx := strings.Index(line, arrow); idx != -1 {
-			from := strings.TrimSpace(line[:idx])
-			rest := strings.TrimSpace(line[idx+len(arrow):])
-
-			// Check for activation/deactivation markers
-			activate := strings.HasSuffix(rest, "+")
-			deactivate := strings.HasSuffix(rest, "-")
+		if idx := strings.Index(trimmedLine, arrow); idx != -1 {
+			from := strings.TrimSpace(trimmedLine[:idx])
+			restStart := idx + len(arrow)
+
+			// A + or - straight after the arrow activates the target or
+			// deactivates the source, as in A->>+B and B-->>-A
+			activate := strings.HasPrefix(trimmedLine[restStart:], "+")
+			deactivate := strings.HasPrefix(trimmedLine[restStart:], "-")
 			if activate || deactivate {
-				rest = strings.TrimSuffix(strings.TrimSuffix(rest, "+"), "-")
-				rest = strings.TrimSpace(rest)
+				restStart++
 			}
+			rest := strings.TrimSpace(trimmedLine[restStart:])
 
 			// Split on colon for message text
 			parts := strings.SplitN(rest, ":", 2)
@@ -331,6 +524,12 @@ func (p *SequenceParser) parseMessage(line string, pos ast.Position) *ast.Messag
 				continue
 			}
 
+			var textLoc ast.Span
+			if text != "" {
+				textStart := restStart + strings.Index(trimmedLine[restStart:], ":") + 1
+				textLoc = narrow(lineSpan(lineNum, base+textStart, base+len(trimmedLine)), trimmedLine[textStart:], text)
+			}
+
 			return &ast.Message{
 				From:       from,
 				To:         to,
@@ -338,7 +537,12 @@ func (p *SequenceParser) parseMessage(line string, pos ast.Position) *ast.Messag
 				Text:       text,
 				Activate:   activate,
 				Deactivate: deactivate,
-				Pos:        pos,
+				Pos:        linePos(lineNum, line),
+				End:        lineEnd(lineNum, line),
+				FromSpan:   narrow(lineSpan(lineNum, base, base+idx), trimmedLine[:idx], from),
+				ToSpan:     narrow(lineSpan(lineNum, base+restStart, base+len(trimmedLine)), trimmedLine[restStart:], to),
+				ArrowSpan:  lineSpan(lineNum, base+idx, base+idx+len(arrow)),
+				TextSpan:   textLoc,
 			}
 		}
 	}
@@ -346,12 +550,23 @@ func (p *SequenceParser) parseMessage(line string, pos ast.Position) *ast.Messag
 	return nil
 }
 
-func (p *SequenceParser) extractBlock(lines []string, startLine int) ([]string, int, error) {
+// isBlockStart reports whether a trimmed line opens a nested block that is closed by 'end'.
+func isBlockStart(trimmed string) bool {
+	return loopPattern.MatchString(trimmed) || altPattern.MatchString(trimmed) ||
+		optPattern.MatchString(trimmed) || parPattern.MatchString(trimmed) ||
+		criticalPattern.MatchString(trimmed) || breakPattern.MatchString(trimmed) ||
+		rectPattern.MatchString(trimmed)
+}
+
+// extractBlock returns the body of the block starting at lines[0] and the number of
+// lines consumed, including the opening line and the closing 'end'. An unclosed block
+// is reported and runs to the end of the diagram.
+func (p *SequenceParser) extractBlock(lines []string, pos ast.Position, errs *ErrorList) ([]string, int) {
 	var blockLines []string //nolint:prealloc // Size cannot be determined beforehand
 	depth := 1
-	consumed := 0
+	consumed := 1
 
-	for _, line := range lines {
+	for _, line := range lines[1:] {
 		consumed++
 		trimmed := strings.TrimSpace(line)
 
@@ -362,9 +577,7 @@ func (p *SequenceParser) extractBlock(lines []string, startLine int) ([]string,
 		}
 
 		// Check for nested blocks
-		if loopPattern.MatchString(trimmed) || altPattern.MatchString(trimmed) ||
-			optPattern.MatchString(trimmed) || parPattern.MatchString(trimmed) ||
-			criticalPattern.MatchString(trimmed) || breakPattern.MatchString(trimmed) {
+		if isBlockStart(trimmed) {
 			depth++
 		}
 
@@ -372,25 +585,30 @@ func (p *SequenceParser) extractBlock(lines []string, startLine int) ([]string,
 		if endPattern.MatchString(trimmed) {
 			depth--
 			if depth == 0 {
-				return blockLines, consumed, nil
+				return blockLines, consumed
 			}
 		}
 
 		blockLines = append(blockLines, line)
 	}
 
-	return nil, 0, fmt.Errorf("line %d: unclosed block, missing 'end'", startLine)
+	e := errs.addLine(pos.Line, lines[0], "unclosed block, missing 'end'")
+	e.Expected = "'end'"
+	return blockLines, consumed
 }
 
-func (p *SequenceParser) parseAltBlock(lines []string, pos ast.Position, lineNum int, firstLabel string) (ast.SeqStmt, int, error) {
+func (p *SequenceParser) parseAltBlock(lines []string, pos ast.Position, lineNum int, m *lineMatch, errs *ErrorList, lim *limiter) (ast.SeqStmt, int) {
 	var conditions []ast.AltCondition
 	currentCondition := ast.AltCondition{
-		Label:  firstLabel,
-		IsElse: false,
+		Label:     m.groups[1],
+		IsElse:    false,
+		Pos:       pos,
+		LabelSpan: m.span(1),
 	}
 
 	consumed := 1
 	depth := 1
+	branchStart := lineNum + 1
 	var currentLines []string
 
 	for i := 1; i < len(lines); i++ {
@@ -404,9 +622,7 @@ func (p *SequenceParser) parseAltBlock(lines []string, pos ast.Position, lineNum
 		}
 
 		// Check for nested blocks
-		if loopPattern.MatchString(trimmed) || altPattern.MatchString(trimmed) ||
-			optPattern.MatchString(trimmed) || parPattern.MatchString(trimmed) ||
-			criticalPattern.MatchString(trimmed) || breakPattern.MatchString(trimmed) {
+		if isBlockStart(trimmed) {
 			depth++
 			currentLines = append(currentLines, lines[i])
 			continue
@@ -415,20 +631,19 @@ func (p *SequenceParser) parseAltBlock(lines []string, pos ast.Position, lineNum
 		// Check for else at same depth
 		if depth == 1 && elsePattern.MatchString(trimmed) {
 			// Save current condition
-			statements, err := p.parseStatements(currentLines, lineNum+1)
-			if err != nil {
-				return nil, 0, err
-			}
-			currentCondition.Statements = statements
+			currentCondition.Statements = p.parseStatements(currentLines, branchStart, errs, lim)
 			conditions = append(conditions, currentCondition)
 
 			// Start else condition
-			matches := elsePattern.FindStringSubmatch(trimmed)
+			m := matchTrimmed(elsePattern, lines[i], lineNum+i)
 			currentCondition = ast.AltCondition{
-				Label:  matches[1],
-				IsElse: true,
+				Label:     m.groups[1],
+				IsElse:    true,
+				Pos:       m.span(0).Start,
+				LabelSpan: m.span(1),
 			}
 			currentLines = nil
+			branchStart = lineNum + i + 1
 			continue
 		}
 
@@ -437,17 +652,14 @@ func (p *SequenceParser) parseAltBlock(lines []string, pos ast.Position, lineNum
 			depth--
 			if depth == 0 {
 				// Save last condition
-				statements, err := p.parseStatements(currentLines, lineNum+1)
-				if err != nil {
-					return nil, 0, err
-				}
-				currentCondition.Statements = statements
+				currentCondition.Statements = p.parseStatements(currentLines, branchStart, errs, lim)
 				conditions = append(conditions, currentCondition)
 
 				return &ast.Alt{
 					Conditions: conditions,
 					Pos:        pos,
-				}, consumed, nil
+					End:        blockEnd(lines, lineNum, consumed),
+				}, consumed
 			}
 			currentLines = append(currentLines, lines[i])
 			continue
@@ -456,17 +668,29 @@ func (p *SequenceParser) parseAltBlock(lines []string, pos ast.Position, lineNum
 		currentLines = append(currentLines, lines[i])
 	}
 
-	return nil, 0, fmt.Errorf("line %d: unclosed alt block, missing 'end'", lineNum)
+	e := errs.addLine(pos.Line, lines[0], "unclosed alt block, missing 'end'")
+	e.Expected = "'end'"
+	currentCondition.Statements = p.parseStatements(currentLines, branchStart, errs, lim)
+	conditions = append(conditions, currentCondition)
+
+	return &ast.Alt{
+		Conditions: conditions,
+		Pos:        pos,
+		End:        blockEnd(lines, lineNum, consumed),
+	}, consumed
 }
 
-func (p *SequenceParser) parseParBlock(lines []string, pos ast.Position, lineNum int, firstLabel string) (ast.SeqStmt, int, error) {
+func (p *SequenceParser) parseParBlock(lines []string, pos ast.Position, lineNum int, m *lineMatch, errs *ErrorList, lim *limiter) (ast.SeqStmt, int) {
 	var branches []ast.ParBranch
 	currentBranch := ast.ParBranch{
-		Label: firstLabel,
+		Label:     m.groups[1],
+		Pos:       pos,
+		LabelSpan: m.span(1),
 	}
 
 	consumed := 1
 	depth := 1
+	branchStart := lineNum + 1
 	var currentLines []string
 
 	for i := 1; i < len(lines); i++ {
@@ -480,9 +704,7 @@ func (p *SequenceParser) parseParBlock(lines []string, pos ast.Position, lineNum
 		}
 
 		// Check for nested blocks
-		if loopPattern.MatchString(trimmed) || altPattern.MatchString(trimmed) ||
-			optPattern.MatchString(trimmed) || parPattern.MatchString(trimmed) ||
-			criticalPattern.MatchString(trimmed) || breakPattern.MatchString(trimmed) {
+		if isBlockStart(trimmed) {
 			depth++
 			currentLines = append(currentLines, lines[i])
 			continue
@@ -491,19 +713,18 @@ func (p *SequenceParser) parseParBlock(lines []string, pos ast.Position, lineNum
 		// Check for and at same depth
 		if depth == 1 && andPattern.MatchString(trimmed) {
 			// Save current branch
-			statements, err := p.parseStatements(currentLines, lineNum+1)
-			if err != nil {
-				return nil, 0, err
-			}
-			currentBranch.Statements = statements
+			currentBranch.Statements = p.parseStatements(currentLines, branchStart, errs, lim)
 			branches = append(branches, currentBranch)
 
 			// Start new branch
-			matches := andPattern.FindStringSubmatch(trimmed)
+			m := matchTrimmed(andPattern, lines[i], lineNum+i)
 			currentBranch = ast.ParBranch{
-				Label: matches[1],
+				Label:     m.groups[1],
+				Pos:       m.span(0).Start,
+				LabelSpan: m.span(1),
 			}
 			currentLines = nil
+			branchStart = lineNum + i + 1
 			continue
 		}
 
@@ -512,17 +733,14 @@ func (p *SequenceParser) parseParBlock(lines []string, pos ast.Position, lineNum
 			depth--
 			if depth == 0 {
 				// Save last branch
-				statements, err := p.parseStatements(currentLines, lineNum+1)
-				if err != nil {
-					return nil, 0, err
-				}
-				currentBranch.Statements = statements
+				currentBranch.Statements = p.parseStatements(currentLines, branchStart, errs, lim)
 				branches = append(branches, currentBranch)
 
 				return &ast.Par{
 					Branches: branches,
 					Pos:      pos,
-				}, consumed, nil
+					End:      blockEnd(lines, lineNum, consumed),
+				}, consumed
 			}
 			currentLines = append(currentLines, lines[i])
 			continue
@@ -531,19 +749,40 @@ func (p *SequenceParser) parseParBlock(lines []string, pos ast.Position, lineNum
 		currentLines = append(currentLines, lines[i])
 	}
 
-	return nil, 0, fmt.Errorf("line %d: unclosed par block, missing 'end'", lineNum)
+	e := errs.addLine(pos.Line, lines[0], "unclosed par block, missing 'end'")
+	e.Expected = "'end'"
+	currentBranch.Statements = p.parseStatements(currentLines, branchStart, errs, lim)
+	branches = append(branches, currentBranch)
+
+	return &ast.Par{
+		Branches: branches,
+		Pos:      pos,
+		End:      blockEnd(lines, lineNum, consumed),
+	}, consumed
 }
 
-func (p *SequenceParser) parseCriticalBlock(lines []string, pos ast.Position, lineNum int, label string) (ast.SeqStmt, int, error) {
+func (p *SequenceParser) parseCriticalBlock(lines []string, pos ast.Position, lineNum int, m *lineMatch, errs *ErrorList, lim *limiter) (ast.SeqStmt, int) {
 	var options []ast.CriticalOption
 	var mainStatements []ast.SeqStmt
 
 	consumed := 1
 	depth := 1
+	branchStart := lineNum + 1
 	var currentLines []string
 	inOption := false
 	var currentOption ast.CriticalOption
 
+	// finish saves the lines collected so far as either the main statements or the current option
+	finish := func() {
+		statements := p.parseStatements(currentLines, branchStart, errs, lim)
+		if inOption {
+			currentOption.Statements = statements
+			options = append(options, currentOption)
+		} else {
+			mainStatements = statements
+		}
+	}
+
 	for i := 1; i < len(lines); i++ {
 		consumed++
 		trimmed := strings.TrimSpace(lines[i])
@@ -555,9 +794,7 @@ func (p *SequenceParser) parseCriticalBlock(lines []string, pos ast.Position, li
 		}
 
 		// Check for nested blocks
-		if loopPattern.MatchString(trimmed) || altPattern.MatchString(trimmed) ||
-			optPattern.MatchString(trimmed) || parPattern.MatchString(trimmed) ||
-			criticalPattern.MatchString(trimmed) || breakPattern.MatchString(trimmed) {
+		if isBlockStart(trimmed) {
 			depth++
 			currentLines = append(currentLines, lines[i])
 			continue
@@ -565,30 +802,19 @@ func (p *SequenceParser) parseCriticalBlock(lines []string, pos ast.Position, li
 
 		// Check for option at same depth
 		if depth == 1 && optionPattern.MatchString(trimmed) {
-			if !inOption {
-				// Save main statements
-				statements, err := p.parseStatements(currentLines, lineNum+1)
-				if err != nil {
-					return nil, 0, err
-				}
-				mainStatements = statements
-				inOption = true
-			} else {
-				// Save previous option
-				statements, err := p.parseStatements(currentLines, lineNum+1)
-				if err != nil {
-					return nil, 0, err
-				}
-				currentOption.Statements = statements
-				options = append(options, currentOption)
-			}
+			// Save main statements or the previous option
+			finish()
+			inOption = true
 
 			// Start new option
-			matches := optionPattern.FindStringSubmatch(trimmed)
+			m := matchTrimmed(optionPattern, lines[i], lineNum+i)
 			currentOption = ast.CriticalOption{
-				Label: matches[1],
+				Label:     m.groups[1],
+				Pos:       m.span(0).Start,
+				LabelSpan: m.span(1),
 			}
 			currentLines = nil
+			branchStart = lineNum + i + 1
 			continue
 		}
 
@@ -596,29 +822,16 @@ func (p *SequenceParser) parseCriticalBlock(lines []string, pos ast.Position, li
 		if endPattern.MatchString(trimmed) {
 			depth--
 			if depth == 0 {
-				if inOption {
-					// Save last option
-					statements, err := p.parseStatements(currentLines, lineNum+1)
-					if err != nil {
-						return nil, 0, err
-					}
-					currentOption.Statements = statements
-					options = append(options, currentOption)
-				} else {
-					// No options, save as main statements
-					statements, err := p.parseStatements(currentLines, lineNum+1)
-					if err != nil {
-						return nil, 0, err
-					}
-					mainStatements = statements
-				}
+				finish()
 
 				return &ast.Critical{
-					Label:      label,
+					Label:      m.groups[1],
 					Options:    options,
 					Statements: mainStatements,
 					Pos:        pos,
-				}, consumed, nil
+					End:        blockEnd(lines, lineNum, consumed),
+					LabelSpan:  m.span(1),
+				}, consumed
 			}
 			currentLines = append(currentLines, lines[i])
 			continue
@@ -627,12 +840,34 @@ func (p *SequenceParser) parseCriticalBlock(lines []string, pos ast.Position, li
 		currentLines = append(currentLines, lines[i])
 	}
 
-	return nil, 0, fmt.Errorf("line %d: unclosed critical block, missing 'end'", lineNum)
+	e := errs.addLine(pos.Line, lines[0], "unclosed critical block, missing 'end'")
+	e.Expected = "'end'"
+	finish()
+
+	return &ast.Critical{
+		Label:      m.groups[1],
+		Options:    options,
+		Statements: mainStatements,
+		Pos:        pos,
+		End:        blockEnd(lines, lineNum, consumed),
+		LabelSpan:  m.span(1),
+	}, consumed
 }
 
-func (p *SequenceParser) parseBoxBlock(lines []string, pos ast.Position, lineNum int, colour, label string) (ast.SeqStmt, int, error) {
+func (p *SequenceParser) parseBoxBlock(lines []string, pos ast.Position, lineNum int, m *lineMatch, errs *ErrorList, lim *limiter) (ast.SeqStmt, int) {
 	var participants []ast.Participant
 	consumed := 1
+	box := func() *ast.Box {
+		return &ast.Box{
+			Colour:       m.groups[1],
+			Label:        m.groups[2],
+			Participants: participants,
+			Pos:          pos,
+			End:          blockEnd(lines, lineNum, consumed),
+			ColourSpan:   m.span(1),
+			LabelSpan:    m.span(2),
+		}
+	}
 
 	for i := 1; i < len(lines); i++ {
 		consumed++
@@ -643,40 +878,91 @@ func (p *SequenceParser) parseBoxBlock(lines []string, pos ast.Position, lineNum
 			continue
 		}
 
+		if !lim.statement(lineNum + i) {
+			break
+		}
+
 		// Check for end
 		if endPattern.MatchString(trimmed) {
-			return &ast.Box{
-				Colour:       colour,
-				Label:        label,
-				Participants: participants,
-				Pos:          pos,
-			}, consumed, nil
+			return box(), consumed
 		}
 
 		// Parse participant
-		if matches := participantPattern.FindStringSubmatch(trimmed); matches != nil {
-			participants = append(participants, ast.Participant{
-				ID:    matches[2],
-				Alias: matches[3],
-				Type:  matches[1],
-				Pos:   ast.Position{Line: lineNum + i, Column: 1},
-			})
+		if m := matchTrimmed(participantPattern, lines[i], lineNum+i); m != nil {
+			if participant := newParticipant(m, errs); participant != nil {
+				participants = append(participants, *participant)
+			}
 		}
 	}
 
-	return nil, 0, fmt.Errorf("line %d: unclosed box, missing 'end'", lineNum)
+	e := errs.addLine(pos.Line, lines[0], "unclosed box, missing 'end'")
+	e.Expected = "'end'"
+	return box(), consumed
 }
 
+// isValidID reports whether id can be used as a participant ID in a message.
 func isValidID(id string) bool {
-	if id == "" {
-		return false
+	return hyphenIdentPattern.MatchString(id)
+}
+
+// jsonEntry is one entry of a JSON object whose values are all strings.
+type jsonEntry struct {
+	key, value         string
+	keySpan, valueSpan ast.Span // Locations excluding quotes
+}
+
+var errJSONEnd = errors.New("unexpected end of JSON input")
+
+// parseJSONObject parses group i of m as a JSON object whose values are all
+// strings, as in links and properties statements. It returns the entries in the
+// order written.
+func parseJSONObject(m *lineMatch, i int) ([]jsonEntry, error) {
+	text, base := m.groups[i], m.base+m.loc[2*i]
+	dec := json.NewDecoder(strings.NewReader(text))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, errors.New("expected a JSON object")
+	}
+
+	// next reads a string and locates it in the source line
+	next := func() (string, ast.Span, error) {
+		start := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return "", ast.Span{}, errJSONEnd
+		} else if err != nil {
+			return "", ast.Span{}, err
+		}
+		value, ok := tok.(string)
+		if !ok {
+			return "", ast.Span{}, fmt.Errorf("expected a string, found %v", tok)
+		}
+		end := int(dec.InputOffset())
+		open := start + strings.IndexByte(text[start:end], '"')
+		return value, lineSpan(m.line, base+open+1, base+end-1), nil
 	}
-	// Check if ID contains only alphanumeric and underscore
-	for _, ch := range id {
-		if (ch < 'a' || ch > 'z') && (ch < 'A' || ch > 'Z') &&
-			(ch < '0' || ch > '9') && ch != '_' {
-			return false
+
+	var entries []jsonEntry
+	for dec.More() {
+		var entry jsonEntry
+		if entry.key, entry.keySpan, err = next(); err != nil {
+			return nil, err
+		}
+		if entry.value, entry.valueSpan, err = next(); err != nil {
+			return nil, err
 		}
+		entries = append(entries, entry)
+	}
+	if _, err := dec.Token(); err == io.EOF {
+		return nil, errJSONEnd
+	} else if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected text after JSON object")
 	}
-	return true
+	return entries, nil
 }
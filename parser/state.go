@@ -1,7 +1,7 @@
 package parser
 
 import (
-	"fmt"
+	"context"
 	"regexp"
 	"strings"
 
@@ -14,18 +14,22 @@ var (
 	stateCommentPattern = regexp.MustCompile(`^%%(.*)$`)
 
 	// State declaration patterns
-	stateDefPattern = regexp.MustCompile(`^state\s+"([^"]+)"\s+as\s+(\w+)\s*$`)
+	stateDefPattern       = regexp.MustCompile(`^state\s+"([^"]+)"\s+as\s+(` + ident + `)\s*$`)
+	stateDeclPattern      = regexp.MustCompile(`^state\s+(` + ident + `)\s*$`)
+	stateDescPattern      = regexp.MustCompile(`^(` + ident + `)\s*:\s*(.+)$`)
+	compositeStartPattern = regexp.MustCompile(`^state\s+(?:"([^"]+)"\s+as\s+)?(` + ident + `)\s*\{\s*$`)
+	compositeEndPattern   = regexp.MustCompile(`^\}\s*$`)
 
 	// Transition patterns
-	transitionPattern = regexp.MustCompile(`^(\w+|\[\*\])\s+-->\s+(\w+|\[\*\])(?:\s*:\s*(.+))?\s*$`)
+	transitionPattern = regexp.MustCompile(`^(` + ident + `|\[\*\])\s+-->\s+(` + ident + `|\[\*\])(?:\s*:\s*(.+))?\s*$`)
 
 	// Special state patterns
-	forkPattern   = regexp.MustCompile(`^state\s+(\w+)\s+<<fork>>\s*$`)
-	joinPattern   = regexp.MustCompile(`^state\s+(\w+)\s+<<join>>\s*$`)
-	choicePattern = regexp.MustCompile(`^state\s+(\w+)\s+<<choice>>\s*$`)
+	forkPattern   = regexp.MustCompile(`^state\s+(` + ident + `)\s+<<fork>>\s*$`)
+	joinPattern   = regexp.MustCompile(`^state\s+(` + ident + `)\s+<<join>>\s*$`)
+	choicePattern = regexp.MustCompile(`^state\s+(` + ident + `)\s+<<choice>>\s*$`)
 
 	// Note patterns
-	stateNotePattern = regexp.MustCompile(`^note\s+(left|right)\s+of\s+(\w+)\s*:\s*(.+)\s*$`)
+	stateNotePattern = regexp.MustCompile(`^note\s+(left|right)\s+of\s+(` + ident + `)\s*:\s*(.+)\s*$`)
 )
 
 // StateParser parses Mermaid state diagrams.
@@ -43,16 +47,33 @@ func (p *StateParser) SupportedTypes() []string {
 
 // Parse parses a Mermaid state diagram from a string.
 func (p *StateParser) Parse(source string) (ast.Diagram, error) {
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses a Mermaid state diagram within the limits in opts.
+func (p *StateParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseLimited(ctx, source, opts, func(lim *limiter) (ast.Diagram, error) {
+		return p.parse(source, lim)
+	})
+}
+
+func (p *StateParser) parse(source string, lim *limiter) (ast.Diagram, error) {
 	lines := strings.Split(source, "\n")
 	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty diagram")
+		return nil, emptySourceError("state")
+	}
+
+	var errs ErrorList
+	meta, headerIdx, err := parseMetadata(lines, "state", &errs)
+	if err != nil {
+		return nil, err
 	}
 
 	// Parse header
-	header := strings.TrimSpace(lines[0])
+	header := strings.TrimSpace(lines[headerIdx])
 	matches := stateHeaderPattern.FindStringSubmatch(header)
 	if matches == nil {
-		return nil, fmt.Errorf("invalid state diagram header: expected 'stateDiagram' or 'stateDiagram-v2'")
+		return nil, headerError("state", headerIdx+1, lines[headerIdx], "'stateDiagram' or 'stateDiagram-v2'")
 	}
 
 	diagramType := "state"
@@ -61,23 +82,24 @@ func (p *StateParser) Parse(source string) (ast.Diagram, error) {
 	}
 
 	diagram := &ast.StateDiagram{
-		Type:   diagramType,
-		Source: source,
-		Pos:    ast.Position{Line: 1, Column: 1},
+		Type:     diagramType,
+		Source:   source,
+		Pos:      linePos(headerIdx+1, lines[headerIdx]),
+		End:      ast.SourceEnd(source),
+		Metadata: meta,
 	}
 
 	// Parse statements
-	statements := p.parseStatements(lines[1:], 1)
-	diagram.Statements = statements
+	diagram.Statements = p.parseStatements(lines[headerIdx+1:], headerIdx+1, &errs, lim)
 
-	return diagram, nil
+	return diagram, errs.errFor(diagram.GetType())
 }
 
-func (p *StateParser) parseStatements(lines []string, startLine int) []ast.StateStmt {
+func (p *StateParser) parseStatements(lines []string, startLine int, errs *ErrorList, lim *limiter) []ast.StateStmt {
 	var statements []ast.StateStmt
 	lineNum := startLine
 
-	for i := range lines {
+	for i := 0; i < len(lines); i++ {
 		lineNum++
 		line := lines[i]
 		trimmed := strings.TrimSpace(line)
@@ -86,67 +108,121 @@ func (p *StateParser) parseStatements(lines []string, startLine int) []ast.State
 		if trimmed == "" {
 			continue
 		}
+		if !lim.statement(lineNum) {
+			break
+		}
+		span := textSpan(lineNum, line)
 
 		// Handle comments
 		if matches := stateCommentPattern.FindStringSubmatch(trimmed); matches != nil {
 			statements = append(statements, &ast.StateComment{
 				Text: strings.TrimSpace(matches[1]),
-				Pos:  ast.Position{Line: lineNum, Column: 1},
+				Pos:  span.Start,
+				End:  span.End,
+			})
+			continue
+		}
+
+		// Handle composite state
+		if m := matchTrimmed(compositeStartPattern, line, lineNum); m != nil {
+			body, consumed, closed := extractCompositeLines(lines[i+1:])
+			if !closed {
+				e := errs.addLine(lineNum, line, "unclosed composite state %s, missing '}'", m.groups[2])
+				e.Expected = "'}'"
+			}
+
+			if !lim.enter(lineNum) {
+				break
+			}
+			nested := p.parseStatements(body, lineNum, errs, lim)
+			lim.leave()
+
+			statements = append(statements, &ast.State{
+				ID:              m.groups[2],
+				Description:     m.groups[1],
+				IsComposite:     true,
+				Nested:          nested,
+				Pos:             span.Start,
+				End:             lineEnd(lineNum+consumed, lines[i+consumed]),
+				IDSpan:          m.span(2),
+				DescriptionSpan: m.span(1),
 			})
+
+			i += consumed
+			lineNum += consumed
 			continue
 		}
 
 		// Handle fork
-		if matches := forkPattern.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(forkPattern, line, lineNum); m != nil {
 			statements = append(statements, &ast.Fork{
-				ID:  matches[1],
-				Pos: ast.Position{Line: lineNum, Column: 1},
+				ID:     m.groups[1],
+				Pos:    span.Start,
+				End:    span.End,
+				IDSpan: m.span(1),
 			})
 			continue
 		}
 
 		// Handle join
-		if matches := joinPattern.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(joinPattern, line, lineNum); m != nil {
 			statements = append(statements, &ast.Join{
-				ID:  matches[1],
-				Pos: ast.Position{Line: lineNum, Column: 1},
+				ID:     m.groups[1],
+				Pos:    span.Start,
+				End:    span.End,
+				IDSpan: m.span(1),
 			})
 			continue
 		}
 
 		// Handle choice
-		if matches := choicePattern.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(choicePattern, line, lineNum); m != nil {
 			statements = append(statements, &ast.Choice{
-				ID:  matches[1],
-				Pos: ast.Position{Line: lineNum, Column: 1},
+				ID:     m.groups[1],
+				Pos:    span.Start,
+				End:    span.End,
+				IDSpan: m.span(1),
 			})
 			continue
 		}
 
 		// Handle state with description
-		if matches := stateDefPattern.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(stateDefPattern, line, lineNum); m != nil {
+			statements = append(statements, &ast.State{
+				ID:              m.groups[2],
+				Description:     m.groups[1],
+				Pos:             span.Start,
+				End:             span.End,
+				IDSpan:          m.span(2),
+				DescriptionSpan: m.span(1),
+			})
+			continue
+		}
+
+		// Handle bare state declaration
+		if m := matchTrimmed(stateDeclPattern, line, lineNum); m != nil {
 			statements = append(statements, &ast.State{
-				ID:          matches[2],
-				Description: matches[1],
-				Pos:         ast.Position{Line: lineNum, Column: 1},
+				ID:     m.groups[1],
+				Pos:    span.Start,
+				End:    span.End,
+				IDSpan: m.span(1),
 			})
 			continue
 		}
 
 		// Handle transitions
-		if matches := transitionPattern.FindStringSubmatch(trimmed); matches != nil {
-			from := matches[1]
-			to := matches[2]
-			label := ""
-			if len(matches) > 3 {
-				label = matches[3]
-			}
+		if m := matchTrimmed(transitionPattern, line, lineNum); m != nil {
+			from := m.groups[1]
+			to := m.groups[2]
+			label, labelSpan := m.trimmed(3)
 
 			// Handle start state
 			if from == "[*]" {
 				statements = append(statements, &ast.StartState{
-					To:  to,
-					Pos: ast.Position{Line: lineNum, Column: 1},
+					To:     to,
+					Pos:    span.Start,
+					End:    span.End,
+					ToSpan: m.span(2),
 				})
 				continue
 			}
@@ -154,36 +230,103 @@ func (p *StateParser) parseStatements(lines []string, startLine int) []ast.State
 			// Handle end state
 			if to == "[*]" {
 				statements = append(statements, &ast.EndState{
-					From: from,
-					Pos:  ast.Position{Line: lineNum, Column: 1},
+					From:     from,
+					Pos:      span.Start,
+					End:      span.End,
+					FromSpan: m.span(1),
 				})
 				continue
 			}
 
 			// Regular transition
 			statements = append(statements, &ast.Transition{
-				From:  from,
-				To:    to,
-				Label: label,
-				Pos:   ast.Position{Line: lineNum, Column: 1},
+				From:      from,
+				To:        to,
+				Label:     label,
+				Pos:       span.Start,
+				End:       span.End,
+				FromSpan:  m.span(1),
+				ToSpan:    m.span(2),
+				LabelSpan: labelSpan,
 			})
 			continue
 		}
 
 		// Handle notes
-		if matches := stateNotePattern.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(stateNotePattern, line, lineNum); m != nil {
+			text, textSpan := m.trimmed(3)
 			statements = append(statements, &ast.StateNote{
-				Position: matches[1] + " of",
-				StateID:  matches[2],
-				Text:     matches[3],
-				Pos:      ast.Position{Line: lineNum, Column: 1},
+				Position:    m.groups[1] + " of",
+				StateID:     m.groups[2],
+				Text:        text,
+				Pos:         span.Start,
+				End:         span.End,
+				StateIDSpan: m.span(2),
+				TextSpan:    textSpan,
+			})
+			continue
+		}
+
+		// Handle state with description (after transitions, whose labels also use ':').
+		// Repeated descriptions for the same state are joined, as Mermaid does.
+		if m := matchTrimmed(stateDescPattern, line, lineNum); m != nil {
+			desc, descSpan := m.trimmed(2)
+			if state := findState(statements, m.groups[1]); state != nil {
+				if state.Description != "" {
+					desc = state.Description + "\n" + desc
+				} else {
+					state.DescriptionSpan = descSpan
+				}
+				state.Description = desc
+				continue
+			}
+			statements = append(statements, &ast.State{
+				ID:              m.groups[1],
+				Description:     desc,
+				Pos:             span.Start,
+				End:             span.End,
+				IDSpan:          m.span(1),
+				DescriptionSpan: descSpan,
 			})
 			continue
 		}
 
-		// Skip lines we can't parse
-		continue
+		// Keep lines we can't parse so validators can report them
+		statements = append(statements, &ast.UnknownStatement{
+			Text: trimmed,
+			Pos:  span.Start,
+			End:  span.End,
+		})
 	}
 
 	return statements
 }
+
+// findState returns the state with the given ID declared in statements, or nil.
+func findState(statements []ast.StateStmt, id string) *ast.State {
+	for _, stmt := range statements {
+		if state, ok := stmt.(*ast.State); ok && state.ID == id {
+			return state
+		}
+	}
+	return nil
+}
+
+// extractCompositeLines returns the body of a composite state up to its matching
+// closing brace, and the number of lines consumed including the brace. If no
+// closing brace is found, all remaining lines are returned and closed is false.
+func extractCompositeLines(lines []string) (body []string, consumed int, closed bool) {
+	depth := 1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if compositeStartPattern.MatchString(trimmed) {
+			depth++
+		} else if compositeEndPattern.MatchString(trimmed) {
+			depth--
+			if depth == 0 {
+				return lines[:i], i + 1, true
+			}
+		}
+	}
+	return lines, len(lines), false
+}
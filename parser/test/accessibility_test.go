@@ -0,0 +1,144 @@
+package parser_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sammcj/mermaid-check/parser"
+)
+
+func TestAccessibilityOnAllDiagramTypes(t *testing.T) {
+	headers := map[string]string{
+		"flowchart":       "flowchart LR",
+		"sequence":        "sequenceDiagram",
+		"class":           "classDiagram",
+		"stateDiagram-v2": "stateDiagram-v2",
+		"er":              "erDiagram",
+		"gantt":           "gantt\n    dateFormat YYYY-MM-DD",
+		"pie":             "pie",
+		"journey":         "journey",
+		"timeline":        "timeline",
+		"gitGraph":        "gitGraph",
+		"mindmap":         "mindmap\n  root",
+		"quadrantChart":   "quadrantChart\n    x-axis Low --> High\n    y-axis Low --> High",
+		"xyChart":         "xychart-beta\n    x-axis [a, b]\n    y-axis \"Total\" 0 --> 5",
+		"c4Context":       "C4Context",
+	}
+	bodies := map[string]string{
+		"flowchart":       "    A --> B",
+		"sequence":        "    A->>B: Hi",
+		"class":           "    class Animal",
+		"stateDiagram-v2": "    [*] --> Still",
+		"er":              "    CUSTOMER ||--o{ ORDER : places",
+		"gantt":           "    section A\n    Task :a1, 2024-01-01, 1d",
+		"pie":             "    \"Dogs\" : 3",
+		"journey":         "    section Work\n    Code: 5: Me",
+		"timeline":        "    2024 : Release",
+		"gitGraph":        "    commit",
+		"mindmap":         "    child",
+		"quadrantChart":   "    A: [0.3, 0.6]",
+		"xyChart":         "    bar [1, 2]",
+		"c4Context":       "    Person(user, \"User\")",
+	}
+
+	for name, header := range headers {
+		t.Run(name, func(t *testing.T) {
+			source := header + "\n    accTitle: Pets\n    accDescr {\n        Which pets\n        people own\n    }\n" + bodies[name]
+			diagram, err := parser.Parse(source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			acc := diagram.GetMetadata().Accessibility
+			if acc == nil {
+				t.Fatal("expected accessibility statements")
+			}
+			if acc.Title != "Pets" {
+				t.Errorf("Title = %q, want %q", acc.Title, "Pets")
+			}
+			if acc.Description != "Which pets\npeople own" {
+				t.Errorf("Description = %q", acc.Description)
+			}
+		})
+	}
+}
+
+func TestAccessibilityForms(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		wantTitle string
+		wantDescr string
+		descrSpan string
+	}{
+		{
+			name:      "single line description",
+			source:    "flowchart LR\n    accTitle: Order flow\n    accDescr: How an order moves\n    A --> B",
+			wantTitle: "Order flow",
+			wantDescr: "How an order moves",
+			descrSpan: "How an order moves",
+		},
+		{
+			name:      "block on one line",
+			source:    "sequenceDiagram\n    accDescr { Greetings }\n    A->>B: Hi",
+			wantDescr: "Greetings",
+			descrSpan: "Greetings",
+		},
+		{
+			name:      "block closed after text",
+			source:    "classDiagram\n    accDescr {\n        Animals\n        and pets }\n    class Animal",
+			wantDescr: "Animals\nand pets",
+			descrSpan: "Animals\n        and pets",
+		},
+		{
+			name:      "later statement wins",
+			source:    "pie\n    accTitle: First\n    accTitle: Second\n    \"A\" : 1",
+			wantTitle: "Second",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagram, err := parser.Parse(tt.source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			acc := diagram.GetMetadata().Accessibility
+			if acc.Title != tt.wantTitle || acc.Description != tt.wantDescr {
+				t.Errorf("got %q, %q, want %q, %q", acc.Title, acc.Description, tt.wantTitle, tt.wantDescr)
+			}
+			if tt.wantTitle != "" {
+				if got := acc.TitleSpan.Text(tt.source); got != tt.wantTitle {
+					t.Errorf("TitleSpan covers %q", got)
+				}
+			}
+			if got := acc.DescriptionSpan.Text(tt.source); got != tt.descrSpan {
+				t.Errorf("DescriptionSpan covers %q, want %q", got, tt.descrSpan)
+			}
+		})
+	}
+}
+
+func TestAccessibilityUnclosedBlock(t *testing.T) {
+	diagram, err := parser.Parse("stateDiagram-v2\n    accDescr {\n    [*] --> Still")
+
+	var pe *parser.ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected a ParseError, got %v", err)
+	}
+	if pe.Pos.Line != 2 || pe.Message != "unclosed accDescr block, missing '}'" {
+		t.Errorf("got line %d %q", pe.Pos.Line, pe.Message)
+	}
+	if diagram == nil {
+		t.Fatal("expected the diagram to be returned alongside the error")
+	}
+}
+
+func TestNoAccessibility(t *testing.T) {
+	diagram, err := parser.Parse("flowchart LR\n    A --> B")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if acc := diagram.GetMetadata().Accessibility; acc != nil {
+		t.Errorf("expected no accessibility statements, got %+v", acc)
+	}
+}
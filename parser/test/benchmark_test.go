@@ -180,14 +180,9 @@ func BenchmarkParseLargeFlowchart(b *testing.B) {
 	}
 }
 
-// NOTE: BenchmarkDetectDiagramType is commented out because detectDiagramType is an unexported function
-// and this file uses black-box testing (package parser_test).
-// This benchmark should be moved to a white-box test file if needed.
-/*
 func BenchmarkDetectDiagramType(b *testing.B) {
 	b.ReportAllocs()
 	for b.Loop() {
-		_ = detectDiagramType(benchmarkFlowchart)
+		_ = parser.DetectType(benchmarkFlowchart)
 	}
 }
-*/
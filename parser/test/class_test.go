@@ -1,6 +1,7 @@
 package parser_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/sammcj/mermaid-check/ast"
@@ -82,3 +83,186 @@ func TestClassParser_SupportedTypes(t *testing.T) {
 		t.Errorf("SupportedTypes() = %v, want [\"class\"]", types)
 	}
 }
+
+func TestClassParser_RelationshipArrows(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected string
+	}{
+		{"Animal <|-- Dog", "inheritance"},
+		{"Dog --|> Animal", "inheritance"},
+		{"Shape <|.. Circle", "realization"},
+		{"Car *-- Wheel", "composition"},
+		{"Pond o-- Duck", "aggregation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			diagram, err := parser.NewClassParser().Parse("classDiagram\n    " + tt.source)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			stmts := diagram.(*ast.ClassDiagram).Statements
+			if len(stmts) != 1 {
+				t.Fatalf("expected 1 statement, got %d", len(stmts))
+			}
+			rel, ok := stmts[0].(*ast.Relationship)
+			if !ok {
+				t.Fatalf("expected *ast.Relationship, got %T", stmts[0])
+			}
+			if rel.Type != tt.expected {
+				t.Errorf("Type = %q, want %q", rel.Type, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClassParser_Declarations(t *testing.T) {
+	source := "classDiagram\n" +
+		"    class List~T~\n" +
+		"    class `My Class`\n" +
+		"    class A[\"Display name\"]\n" +
+		"    class Map~K, V~[\"Dictionary\"] {\n" +
+		"        <<interface>>\n" +
+		"    }\n" +
+		"    class Shape\n" +
+		"    <<abstract>> Shape\n" +
+		"    <<service>> `Payment Gateway`\n" +
+		"    `My Class` <|-- A\n" +
+		"    note for `My Class` \"Spaced\""
+	d, err := parser.NewClassParser().Parse(source)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	stmts := d.(*ast.ClassDiagram).Statements
+
+	tests := []struct {
+		name, generic, label, stereotype string
+	}{
+		{"List", "T", "", ""},
+		{"My Class", "", "", ""},
+		{"A", "", "Display name", ""},
+		{"Map", "K, V", "Dictionary", "interface"},
+		{"Shape", "", "", "abstract"},
+		{"Payment Gateway", "", "", "service"},
+	}
+	if len(stmts) != len(tests)+2 {
+		t.Fatalf("expected %d statements, got %d", len(tests)+2, len(stmts))
+	}
+	for i, tt := range tests {
+		class, ok := stmts[i].(*ast.Class)
+		if !ok {
+			t.Fatalf("statement %d: expected *ast.Class, got %T", i, stmts[i])
+		}
+		if class.Name != tt.name || class.Generic != tt.generic || class.Label != tt.label || class.Stereotype != tt.stereotype {
+			t.Errorf("class %d = {%q %q %q %q}, want {%q %q %q %q}", i,
+				class.Name, class.Generic, class.Label, class.Stereotype, tt.name, tt.generic, tt.label, tt.stereotype)
+		}
+	}
+
+	// Spans exclude the backticks, quotes and tildes around each part
+	class := stmts[1].(*ast.Class)
+	if got, want := class.NameSpan, span(3, 12, 42, 20, 50); got != want {
+		t.Errorf("backtick NameSpan = %+v, want %+v", got, want)
+	}
+	class = stmts[3].(*ast.Class)
+	if got, want := class.GenericSpan, span(5, 15, 94, 19, 98); got != want {
+		t.Errorf("GenericSpan = %+v, want %+v", got, want)
+	}
+	if got, want := class.LabelSpan, span(5, 22, 101, 32, 111); got != want {
+		t.Errorf("LabelSpan = %+v, want %+v", got, want)
+	}
+
+	rel := stmts[6].(*ast.Relationship)
+	if rel.From != "My Class" || rel.To != "A" {
+		t.Errorf("relationship = %s -> %s, want My Class -> A", rel.From, rel.To)
+	}
+	if note := stmts[7].(*ast.ClassNote); note.ClassName != "My Class" {
+		t.Errorf("note ClassName = %q, want %q", note.ClassName, "My Class")
+	}
+}
+
+func TestClassParser_Namespaces(t *testing.T) {
+	source := `classDiagram
+    namespace Billing.Core {
+        class Invoice {
+            -int total
+        }
+        class Receipt
+    }
+    Invoice : +pay()
+    Invoice <|-- Receipt`
+	d, err := parser.NewClassParser().Parse(source)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	stmts := d.(*ast.ClassDiagram).Statements
+	if len(stmts) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(stmts))
+	}
+	ns, ok := stmts[0].(*ast.Namespace)
+	if !ok {
+		t.Fatalf("expected *ast.Namespace, got %T", stmts[0])
+	}
+	if ns.Name != "Billing.Core" || ns.NameSpan != span(2, 15, 27, 27, 39) || ns.End != pos(7, 6, 126) {
+		t.Errorf("namespace = %q at %+v ending %+v", ns.Name, ns.NameSpan, ns.End)
+	}
+	if len(ns.Statements) != 2 {
+		t.Fatalf("expected 2 statements in the namespace, got %d", len(ns.Statements))
+	}
+	// Members declared outside the namespace are added to the class inside it
+	invoice := ns.Statements[0].(*ast.Class)
+	if len(invoice.Members) != 2 || invoice.Members[1].Name != "pay" {
+		t.Errorf("Invoice members = %+v", invoice.Members)
+	}
+	if receipt := ns.Statements[1].(*ast.Class); receipt.Name != "Receipt" {
+		t.Errorf("second class = %q, want Receipt", receipt.Name)
+	}
+
+	_, err = parser.NewClassParser().Parse("classDiagram\n    namespace Billing {\n        class Invoice")
+	list := parser.Errors(err)
+	if len(list) != 1 || list[0].Message != "unclosed namespace Billing, missing '}'" || list[0].Pos.Line != 2 {
+		t.Errorf("unclosed namespace error = %v", err)
+	}
+}
+
+func TestClassParser_Members(t *testing.T) {
+	tests := []struct {
+		text                 string
+		visibility, name     string
+		memberType           string
+		isMethod             bool
+		params               []string
+		isStatic, isAbstract bool
+	}{
+		{"+String owner", "+", "owner", "String", false, nil, false, false},
+		{"-List~int~ ids", "-", "ids", "List~int~", false, nil, false, false},
+		{"count", "", "count", "", false, nil, false, false},
+		{"#int total$", "#", "total", "int", false, nil, true, false},
+		{"+area() double", "+", "area", "double", true, nil, false, false},
+		{"+isMammal()", "+", "isMammal", "", true, nil, false, false},
+		{"+deposit(amount, currency) bool", "+", "deposit", "bool", true, []string{"amount", "currency"}, false, false},
+		{"+void speak()", "+", "speak", "void", true, nil, false, false},
+		{"~draw()*", "~", "draw", "", true, nil, false, true},
+		{"+instances() int$", "+", "instances", "int", true, nil, true, false},
+		{"+create()$ Shape", "+", "create", "Shape", true, nil, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			d, err := parser.NewClassParser().Parse("classDiagram\n    Shape : " + tt.text)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			class := d.(*ast.ClassDiagram).Statements[0].(*ast.Class)
+			if len(class.Members) != 1 {
+				t.Fatalf("expected 1 member, got %d", len(class.Members))
+			}
+			m := class.Members[0]
+			if m.Visibility != tt.visibility || m.Name != tt.name || m.Type != tt.memberType || m.IsMethod != tt.isMethod ||
+				!reflect.DeepEqual(m.Parameters, tt.params) || m.IsStatic != tt.isStatic || m.IsAbstract != tt.isAbstract {
+				t.Errorf("member = %+v", m)
+			}
+		})
+	}
+}
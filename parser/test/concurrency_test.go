@@ -0,0 +1,104 @@
+package parser_test
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/sammcj/mermaid-check/ast"
+	"github.com/sammcj/mermaid-check/parser"
+)
+
+// concurrencySources has one diagram of each type, keyed by the parser for it.
+var concurrencySources = []struct {
+	parser parser.DiagramParser
+	source string
+}{
+	{parser.NewFlowchartParser(), "flowchart LR\n    A[Start] --> B{Check}\n    subgraph inner\n        B --> C((Done))\n    end"},
+	{parser.NewSequenceParser(), "sequenceDiagram\n    participant A\n    loop Retry\n        A->>B: Hi\n    end"},
+	{parser.NewClassParser(), "classDiagram\n    class Animal {\n        +String name\n    }\n    Animal <|-- Dog"},
+	{parser.NewStateParser(), "stateDiagram-v2\n    [*] --> Still\n    state Moving {\n        [*] --> Fast\n    }"},
+	{parser.NewERParser(), "erDiagram\n    CUSTOMER ||--o{ ORDER : places"},
+	{parser.NewGanttParser(), "gantt\n    dateFormat YYYY-MM-DD\n    section A\n    Task :a1, 2024-01-01, 1d"},
+	{parser.NewPieParser(), "pie title Pets\n    \"Dogs\" : 3\n    \"Cats\" : 2"},
+	{parser.NewJourneyParser(), "journey\n    section Work\n    Code: 5: Me"},
+	{parser.NewTimelineParser(), "timeline\n    2024 : Release"},
+	{parser.NewGitGraphParser(), "gitGraph\n    commit\n    branch dev\n    commit"},
+	{parser.NewMindmapParser(), "mindmap\n  root\n    child"},
+	{parser.NewSankeyParser(), "sankey-beta\nA,B,10"},
+	{parser.NewQuadrantParser(), "quadrantChart\n    x-axis Low --> High\n    y-axis Low --> High\n    A: [0.3, 0.6]"},
+	{parser.NewXYChartParser(), "xychart-beta\n    x-axis [a, b]\n    y-axis \"Total\" 0 --> 5\n    bar [1, 2]"},
+	{parser.NewC4ContextParser(), "C4Context\n    Person(user, \"User\")\n    System(sys, \"System\")\n    Rel(user, sys, \"Uses\")"},
+}
+
+// TestParsersConcurrentUse shares a single parser of each type between many
+// goroutines and checks every result matches parsing on its own. Run with -race.
+func TestParsersConcurrentUse(t *testing.T) {
+	want := make([]ast.Diagram, len(concurrencySources))
+	for i, tc := range concurrencySources {
+		diagram, err := tc.parser.Parse(tc.source)
+		if err != nil {
+			t.Fatalf("%T: unexpected error: %v", tc.parser, err)
+		}
+		want[i] = diagram
+	}
+
+	const goroutines = 8
+	const iterations = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines*len(concurrencySources))
+	for g := range goroutines {
+		wg.Go(func() {
+			for n := range iterations {
+				// Stagger the order so different diagram types overlap
+				i := (g + n) % len(concurrencySources)
+				tc := concurrencySources[i]
+
+				got, err := tc.parser.Parse(tc.source)
+				if err != nil {
+					errs <- fmt.Errorf("%T: unexpected error: %w", tc.parser, err)
+					return
+				}
+				if !reflect.DeepEqual(got, want[i]) {
+					errs <- fmt.Errorf("%T: concurrent result differs from sequential result", tc.parser)
+					return
+				}
+
+				// The package-level entry point and registry are shared too
+				if got, err := parser.Parse(tc.source); err != nil || !reflect.DeepEqual(got, want[i]) {
+					errs <- fmt.Errorf("parser.Parse(%T source): result differs, error %v", tc.parser, err)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// TestFlowchartParserReuse checks that nodes defined inline in one diagram do not
+// affect the next diagram parsed by the same parser.
+func TestFlowchartParserReuse(t *testing.T) {
+	p := parser.NewFlowchartParser()
+	source := "flowchart LR\n    A[Start] --> B[End]"
+
+	for i := range 3 {
+		diagram, err := p.Parse(source)
+		if err != nil {
+			t.Fatalf("parse %d: unexpected error: %v", i, err)
+		}
+		var nodes []string
+		for _, stmt := range diagram.(*ast.Flowchart).Statements {
+			if node, ok := stmt.(*ast.NodeDef); ok {
+				nodes = append(nodes, node.ID)
+			}
+		}
+		if !reflect.DeepEqual(nodes, []string{"A", "B"}) {
+			t.Errorf("parse %d: inline node definitions = %v, want [A B]", i, nodes)
+		}
+	}
+}
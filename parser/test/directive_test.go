@@ -0,0 +1,252 @@
+package parser_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sammcj/mermaid-check/ast"
+	"github.com/sammcj/mermaid-check/parser"
+)
+
+// directiveBodies is a small diagram of each type, without any directives.
+var directiveBodies = map[string]string{
+	"flowchart":       "flowchart LR\n    A --> B",
+	"sequence":        "sequenceDiagram\n    A->>B: Hi",
+	"class":           "classDiagram\n    class Animal",
+	"stateDiagram-v2": "stateDiagram-v2\n    [*] --> Still",
+	"er":              "erDiagram\n    CUSTOMER ||--o{ ORDER : places",
+	"gantt":           "gantt\n    dateFormat YYYY-MM-DD\n    section A\n    Task :a1, 2024-01-01, 1d",
+	"pie":             "pie\n    \"Dogs\" : 3",
+	"journey":         "journey\n    section Work\n    Code: 5: Me",
+	"timeline":        "timeline\n    2024 : Release",
+	"gitGraph":        "gitGraph\n    commit",
+	"mindmap":         "mindmap\n  root",
+	"sankey":          "sankey-beta\nA,B,10",
+	"quadrantChart":   "quadrantChart\n    x-axis Low --> High\n    y-axis Low --> High\n    A: [0.3, 0.6]",
+	"xyChart":         "xychart-beta\n    x-axis [a, b]\n    y-axis \"Total\" 0 --> 5\n    bar [1, 2]",
+	"c4Context":       "C4Context\n    Person(user, \"User\")",
+}
+
+func TestDirectiveOnAllDiagramTypes(t *testing.T) {
+	for name, body := range directiveBodies {
+		t.Run(name, func(t *testing.T) {
+			source := "%%{init: {'theme': 'forest'}}%%\n" + body
+			diagram, err := parser.Parse(source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			directives := diagram.GetMetadata().Directives
+			if len(directives) != 1 {
+				t.Fatalf("expected 1 directive, got %d", len(directives))
+			}
+			if directives[0].Config["theme"] != "forest" {
+				t.Errorf("Config[theme] = %v, want forest", directives[0].Config["theme"])
+			}
+		})
+	}
+}
+
+func TestMultilineDirectiveAfterHeader(t *testing.T) {
+	for name, body := range directiveBodies {
+		t.Run(name, func(t *testing.T) {
+			header, rest, _ := strings.Cut(body, "\n")
+			source := header + "\n    %%{init: {\n        \"theme\": \"dark\"\n    }}%%\n" + rest
+			diagram, err := parser.Parse(source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			directives := diagram.GetMetadata().Directives
+			if len(directives) != 1 || directives[0].Config["theme"] != "dark" {
+				t.Fatalf("directives = %+v", directives)
+			}
+			if end := directives[0].End; end.Line != 4 || end.Column != 9 {
+				t.Errorf("End = %+v, want line 4 column 9", end)
+			}
+
+			// The directive's lines must not reach the diagram's own statements
+			var leftovers []any
+			switch d := diagram.(type) {
+			case *ast.Flowchart:
+				for _, stmt := range d.Statements {
+					switch stmt.(type) {
+					case *ast.UnknownStatement, *ast.Comment:
+						leftovers = append(leftovers, stmt)
+					}
+				}
+			case *ast.ClassDiagram:
+				for _, stmt := range d.Statements {
+					if _, ok := stmt.(*ast.UnknownStatement); ok {
+						leftovers = append(leftovers, stmt)
+					}
+				}
+			case *ast.StateDiagram:
+				for _, stmt := range d.Statements {
+					if _, ok := stmt.(*ast.UnknownStatement); ok {
+						leftovers = append(leftovers, stmt)
+					}
+				}
+			}
+			if len(leftovers) > 0 {
+				t.Errorf("directive left statements behind: %+v", leftovers)
+			}
+		})
+	}
+}
+
+func TestDirectiveParsing(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		wantName   string
+		wantConfig map[string]any
+		keySpans   map[string]string // Dotted key path to the text its span covers
+	}{
+		{
+			name:       "single quotes",
+			source:     "%%{init: {'theme': 'base', 'themeVariables': {'primaryColor': '#ff0000'}}}%%\nflowchart LR\n    A --> B",
+			wantName:   "init",
+			wantConfig: map[string]any{"theme": "base", "themeVariables": map[string]any{"primaryColor": "#ff0000"}},
+			keySpans: map[string]string{
+				"theme":                       "'theme'",
+				"themeVariables.primaryColor": "'primaryColor'",
+			},
+		},
+		{
+			name:       "json",
+			source:     "%%{ initialize: { \"logLevel\": 1, \"flowchart\": { \"htmlLabels\": false, \"curve\": \"linear\" }, \"secure\": [\"theme\"] } }%%\nflowchart LR\n    A --> B",
+			wantName:   "initialize",
+			wantConfig: map[string]any{"logLevel": 1, "flowchart": map[string]any{"htmlLabels": false, "curve": "linear"}, "secure": []any{"theme"}},
+			keySpans:   map[string]string{"flowchart.curve": `"curve"`},
+		},
+		{
+			name:       "unquoted keys",
+			source:     "%%{init: {theme: dark, fontSize: 14.5}}%%\nsequenceDiagram\n    A->>B: Hi",
+			wantName:   "init",
+			wantConfig: map[string]any{"theme": "dark", "fontSize": 14.5},
+			keySpans:   map[string]string{"fontSize": "fontSize"},
+		},
+		{
+			name:       "multiple lines",
+			source:     "%%{\n  init: {\n    \"theme\": \"dark\"\n  }\n}%%\nclassDiagram\n    class Animal",
+			wantName:   "init",
+			wantConfig: map[string]any{"theme": "dark"},
+			keySpans:   map[string]string{"theme": `"theme"`},
+		},
+		{
+			name:     "no arguments",
+			source:   "%%{wrap}%%\nsequenceDiagram\n    A->>B: Hi",
+			wantName: "wrap",
+		},
+		{
+			name:       "after frontmatter and comments",
+			source:     "---\ntitle: Flow\n---\n%% A comment\n%%{init: {\"look\": \"handDrawn\"}}%%\n\nflowchart TD\n    A --> B",
+			wantName:   "init",
+			wantConfig: map[string]any{"look": "handDrawn"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagram, err := parser.Parse(tt.source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			directives := diagram.GetMetadata().Directives
+			if len(directives) != 1 {
+				t.Fatalf("expected 1 directive, got %d", len(directives))
+			}
+			d := directives[0]
+			if d.Name != tt.wantName {
+				t.Errorf("Name = %q, want %q", d.Name, tt.wantName)
+			}
+			if !reflect.DeepEqual(d.Config, tt.wantConfig) {
+				t.Errorf("Config = %#v, want %#v", d.Config, tt.wantConfig)
+			}
+			if got := d.NameSpan.Text(tt.source); got != tt.wantName {
+				t.Errorf("NameSpan covers %q", got)
+			}
+			for path, want := range tt.keySpans {
+				var span ast.Span
+				for _, k := range d.Keys {
+					if k.Path == path {
+						span = k.Span
+					}
+				}
+				if got := span.Text(tt.source); got != want {
+					t.Errorf("key %s covers %q, want %q", path, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDirectivesInBody(t *testing.T) {
+	source := "flowchart LR\n    %%{init: {\"theme\": \"dark\"}}%%\n    A --> B\n    %%{init: {\"look\": \"classic\"}}%%"
+
+	diagram, err := parser.Parse(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	directives := diagram.GetMetadata().Directives
+	if len(directives) != 2 {
+		t.Fatalf("expected 2 directives, got %d", len(directives))
+	}
+	if want := (ast.Position{Line: 2, Column: 5, Offset: 17}); directives[0].Pos != want {
+		t.Errorf("Pos = %+v, want %+v", directives[0].Pos, want)
+	}
+	if directives[1].Config["look"] != "classic" {
+		t.Errorf("second directive Config = %v", directives[1].Config)
+	}
+}
+
+func TestDirectiveErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		wantLine int
+		wantMsg  string
+	}{
+		{
+			name:     "missing closing brace",
+			source:   "%%{init: {'theme': 'dark'}%%\nflowchart LR\n    A --> B",
+			wantLine: 1,
+			wantMsg:  "invalid init directive: missing '}'",
+		},
+		{
+			name:     "missing value",
+			source:   "%%{init: {'theme': }}%%\npie\n    \"A\" : 1",
+			wantLine: 1,
+			wantMsg:  "invalid init directive: missing value",
+		},
+		{
+			name:     "not an object",
+			source:   "sequenceDiagram\n    %%{init: dark}%%\n    A->>B: Hi",
+			wantLine: 2,
+			wantMsg:  "invalid init directive: expected a {...} object, got dark",
+		},
+		{
+			name:     "missing colon",
+			source:   "%%{init {'theme': 'dark'}}%%\nerDiagram\n    A ||--o{ B : has",
+			wantLine: 1,
+			wantMsg:  `invalid directive, expected ':' after "init"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagram, err := parser.Parse(tt.source)
+			var pe *parser.ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("expected a ParseError, got %v", err)
+			}
+			if pe.Pos.Line != tt.wantLine || pe.Message != tt.wantMsg {
+				t.Errorf("got line %d %q, want line %d %q", pe.Pos.Line, pe.Message, tt.wantLine, tt.wantMsg)
+			}
+			if diagram == nil {
+				t.Error("expected the diagram to be returned alongside the error")
+			}
+		})
+	}
+}
@@ -249,10 +249,15 @@ func TestERParser_Parse(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "invalid syntax",
+			name: "unknown line",
 			source: `erDiagram
     this is invalid`,
-			wantErr: true,
+			check: func(t *testing.T, d ast.Diagram) {
+				unknown := d.(*ast.ERDiagram).Unknown
+				if len(unknown) != 1 || unknown[0].Text != "this is invalid" {
+					t.Errorf("expected the unknown line to be kept, got %+v", unknown)
+				}
+			},
 		},
 	}
 
@@ -0,0 +1,244 @@
+package parser_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sammcj/mermaid-check/ast"
+	"github.com/sammcj/mermaid-check/parser"
+)
+
+func TestParseCollectsAllErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		source        string
+		expectedLines []int
+	}{
+		{
+			name: "sequence",
+			source: `sequenceDiagram
+    Alice->>Bob: Hello
+    autonumber 99999999999999999999
+    Bob->>Alice: Hi
+    loop Forever`,
+			expectedLines: []int{3, 5},
+		},
+		{
+			name: "class unclosed body",
+			source: `classDiagram
+    class Animal {
+        +name string`,
+			expectedLines: []int{2},
+		},
+		{
+			name: "pie",
+			source: `pie title Pets
+    "Dogs" : 386
+    "Cats" : 0
+    "Rats" : 0.0`,
+			expectedLines: []int{3, 4},
+		},
+		{
+			name: "sankey",
+			source: `sankey-beta
+    A,B,10
+    A,A,5
+    B,C,abc`,
+			expectedLines: []int{3, 4},
+		},
+		{
+			name: "journey",
+			source: `journey
+    title My Day
+    section Morning
+      Wake up: 9: Me
+      Make tea: 0: Me`,
+			expectedLines: []int{4, 5},
+		},
+		{
+			name: "c4",
+			source: `C4Context
+    title System
+    Person(user, "User")
+    Enterprise_Boundary(b0) {
+    }
+    System_Boundary(b1, "Boundary") {
+        Container_Boundary(c1) {
+        }
+    }`,
+			expectedLines: []int{4, 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagram, err := parser.Parse(tt.source)
+			if err == nil {
+				t.Fatal("expected error, got none")
+			}
+			if diagram == nil {
+				t.Fatal("expected partial diagram alongside errors, got nil")
+			}
+
+			errs := parser.Errors(err)
+			if len(errs) != len(tt.expectedLines) {
+				t.Fatalf("expected %d errors, got %d: %v", len(tt.expectedLines), len(errs), errs.Unwrap())
+			}
+			for i, line := range tt.expectedLines {
+				if errs[i].Pos.Line != line {
+					t.Errorf("error %d: expected line %d, got %d (%s)", i, line, errs[i].Pos.Line, errs[i].Message)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePartialAST(t *testing.T) {
+	source := `sequenceDiagram
+    participant Alice
+    Alice->>Bob: Hello
+    autonumber 99999999999999999999
+    Bob->>Alice: Hi`
+
+	diagram, err := parser.Parse(source)
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	seq, ok := diagram.(*ast.SequenceDiagram)
+	if !ok {
+		t.Fatalf("expected *ast.SequenceDiagram, got %T", diagram)
+	}
+
+	messages := 0
+	for _, stmt := range seq.Statements {
+		if _, ok := stmt.(*ast.Message); ok {
+			messages++
+		}
+	}
+	if messages != 2 {
+		t.Errorf("expected both messages to survive the bad line, got %d", messages)
+	}
+}
+
+func TestErrorListUnwrap(t *testing.T) {
+	_, err := parser.Parse(`pie
+    "A" : 0
+    "B" : 0`)
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	var pe *parser.ParseError
+	if !errors.As(err, &pe) {
+		t.Fatal("expected errors.As to find a *parser.ParseError")
+	}
+	if pe.Pos.Line != 2 {
+		t.Errorf("expected first error on line 2, got %d", pe.Pos.Line)
+	}
+}
+
+func TestErrorsNonSyntaxError(t *testing.T) {
+	if errs := parser.Errors(nil); errs != nil {
+		t.Errorf("expected nil for nil error, got %v", errs)
+	}
+	if errs := parser.Errors(errors.New("boom")); errs != nil {
+		t.Errorf("expected nil for plain error, got %v", errs)
+	}
+}
+
+func TestParseErrorDetails(t *testing.T) {
+	_, err := parser.Parse(`sequenceDiagram
+    Alice->>Bob: Hello
+    loop Every minute
+        Bob->>Alice: Hi`)
+
+	var pe *parser.ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected *parser.ParseError, got %T", err)
+	}
+
+	want := parser.ParseError{
+		Pos:         ast.Position{Line: 3, Column: 5, Offset: 43},
+		End:         ast.Position{Line: 3, Column: 22, Offset: 60},
+		DiagramType: "sequence",
+		Found:       "loop Every minute",
+		Expected:    "'end'",
+		Message:     "unclosed block, missing 'end'",
+	}
+	if *pe != want {
+		t.Errorf("got %+v, want %+v", *pe, want)
+	}
+	if pe.Error() != "line 3, column 5: unclosed block, missing 'end'" {
+		t.Errorf("unexpected message %q", pe.Error())
+	}
+}
+
+func TestHeaderErrorsAreTyped(t *testing.T) {
+	tests := []struct {
+		name         string
+		parser       parser.DiagramParser
+		expectedType string
+	}{
+		{"flowchart", parser.NewFlowchartParser(), "flowchart"},
+		{"sequence", parser.NewSequenceParser(), "sequence"},
+		{"class", parser.NewClassParser(), "class"},
+		{"state", parser.NewStateParser(), "state"},
+		{"er", parser.NewERParser(), "er"},
+		{"gantt", parser.NewGanttParser(), "gantt"},
+		{"pie", parser.NewPieParser(), "pie"},
+		{"journey", parser.NewJourneyParser(), "journey"},
+		{"timeline", parser.NewTimelineParser(), "timeline"},
+		{"gitGraph", parser.NewGitGraphParser(), "gitGraph"},
+		{"mindmap", parser.NewMindmapParser(), "mindmap"},
+		{"sankey", parser.NewSankeyParser(), "sankey"},
+		{"quadrant", parser.NewQuadrantParser(), "quadrantChart"},
+		{"xychart", parser.NewXYChartParser(), "xyChart"},
+		{"c4Context", parser.NewC4ContextParser(), "c4Context"},
+		{"c4Deployment", parser.NewC4DeploymentParser(), "c4Deployment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.parser.Parse("  bogus header\n    A")
+
+			var pe *parser.ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("expected *parser.ParseError, got %T: %v", err, err)
+			}
+			if pe.DiagramType != tt.expectedType {
+				t.Errorf("DiagramType = %q, want %q", pe.DiagramType, tt.expectedType)
+			}
+			if pe.Pos != (ast.Position{Line: 1, Column: 3, Offset: 2}) {
+				t.Errorf("Pos = %+v, want line 1, column 3, offset 2", pe.Pos)
+			}
+			if pe.Found != "bogus header" {
+				t.Errorf("Found = %q, want %q", pe.Found, "bogus header")
+			}
+			if pe.Expected == "" {
+				t.Error("expected Expected to be set")
+			}
+		})
+	}
+}
+
+func TestUnknownDiagramTypeError(t *testing.T) {
+	_, err := parser.Parse("%% comment\nflowchrt TD\n    A --> B")
+
+	var pe *parser.ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected *parser.ParseError, got %T", err)
+	}
+	if pe.Pos.Line != 2 || pe.Found != "flowchrt TD" || pe.Hint == "" {
+		t.Errorf("unexpected error details: %+v", pe)
+	}
+}
+
+func TestErrorListDiagramType(t *testing.T) {
+	_, err := parser.Parse("pie\n    \"A\" : x")
+	for _, e := range parser.Errors(err) {
+		if e.DiagramType != "pie" {
+			t.Errorf("DiagramType = %q, want %q", e.DiagramType, "pie")
+		}
+	}
+}
@@ -3,6 +3,7 @@ package parser_test
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/sammcj/mermaid-check/ast"
@@ -45,6 +46,100 @@ func TestParseSimple(t *testing.T) {
 	}
 }
 
+func TestParseHeaderForms(t *testing.T) {
+	tests := []struct {
+		source        string
+		wantType      string
+		wantDirection string
+		wantSpan      ast.Span
+		wantLinks     int
+	}{
+		{"flowchart\n    A --> B", "flowchart", "TB", ast.Span{}, 1},
+		{"graph", "graph", "TB", ast.Span{}, 0},
+		{"graph TD;\n    A --> B", "graph", "TD", span(1, 7, 6, 9, 8), 1},
+		{"flowchart LR %% left to right\n    A --> B", "flowchart", "LR", span(1, 11, 10, 13, 12), 1},
+		{"graph LR;A-->B;B-->C", "graph", "LR", span(1, 7, 6, 9, 8), 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			d, err := parser.NewFlowchartParser().Parse(tt.source)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			diagram := d.(*ast.Flowchart)
+			if diagram.Type != tt.wantType || diagram.Direction != tt.wantDirection || diagram.DirectionSpan != tt.wantSpan {
+				t.Errorf("got %s %s at %v, want %s %s at %v", diagram.Type, diagram.Direction, diagram.DirectionSpan, tt.wantType, tt.wantDirection, tt.wantSpan)
+			}
+			var links int
+			for _, stmt := range diagram.Statements {
+				if _, ok := stmt.(*ast.Link); ok {
+					links++
+				}
+			}
+			if links != tt.wantLinks {
+				t.Errorf("expected %d links, got %d: %+v", tt.wantLinks, links, diagram.Statements)
+			}
+		})
+	}
+
+	for _, source := range []string{"flowchart XY\n    A --> B", "flowcharts LR", "graph TD A --> B"} {
+		if _, err := parser.NewFlowchartParser().Parse(source); err == nil {
+			t.Errorf("expected an error for %q", source)
+		}
+	}
+}
+
+func TestParseStatementSeparators(t *testing.T) {
+	source := "flowchart LR\n    A-->B; B-->C %% done; really\n    D[\"x;y\"] -->|a;b| E;\n    subgraph s; F; end"
+
+	d, err := parser.NewFlowchartParser().Parse(source)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	diagram := d.(*ast.Flowchart)
+
+	want := []struct {
+		text     string
+		pos, end ast.Position
+	}{
+		{"link A B", pos(2, 5, 17), pos(2, 10, 22)},
+		{"link B C", pos(2, 12, 24), pos(2, 17, 29)},
+		{"comment done; really", pos(2, 18, 30), pos(2, 33, 45)},
+		{"node D x;y", pos(3, 5, 50), pos(3, 13, 58)},
+		{"link D E", pos(3, 5, 50), pos(3, 24, 69)},
+		{"subgraph s", pos(4, 5, 75), pos(4, 23, 93)},
+	}
+	if len(diagram.Statements) != len(want) {
+		t.Fatalf("expected %d statements, got %d: %+v", len(want), len(diagram.Statements), diagram.Statements)
+	}
+	for i, w := range want {
+		var text string
+		switch s := diagram.Statements[i].(type) {
+		case *ast.Link:
+			text = "link " + s.From + " " + s.To
+		case *ast.Comment:
+			text = "comment " + s.Text
+		case *ast.NodeDef:
+			text = "node " + s.ID + " " + s.Label
+		case *ast.Subgraph:
+			text = "subgraph " + s.ID
+			if len(s.Statements) != 1 {
+				t.Errorf("expected 1 statement in the subgraph, got %d", len(s.Statements))
+			} else if node, ok := s.Statements[0].(*ast.NodeDef); !ok || node.ID != "F" || node.Pos != pos(4, 17, 87) {
+				t.Errorf("unexpected subgraph statement: %+v", s.Statements[0])
+			}
+		}
+		got := diagram.Statements[i]
+		if text != w.text || got.GetPosition() != w.pos {
+			t.Errorf("statement %d: got %q at %v, want %q at %v", i, text, got.GetPosition(), w.text, w.pos)
+		}
+		if end := reflect.ValueOf(got).Elem().FieldByName("End").Interface(); end != w.end {
+			t.Errorf("statement %d: got end %v, want %v", i, end, w.end)
+		}
+	}
+}
+
 func TestParseTestDataFiles(t *testing.T) {
 	p := parser.NewFlowchartParser()
 
@@ -75,3 +170,496 @@ func TestParseTestDataFiles(t *testing.T) {
 		})
 	}
 }
+
+func TestParseLinkChains(t *testing.T) {
+	source := "flowchart LR\n    A --> B & C -.-> D\n    A & B --> --> C"
+
+	d, err := parser.NewFlowchartParser().Parse(source)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	diagram := d.(*ast.Flowchart)
+
+	want := []struct {
+		from, to, arrow string
+		pos, end        ast.Position
+		toSpan          ast.Span
+	}{
+		{"A", "B", "-->", pos(2, 5, 17), pos(2, 12, 24), span(2, 11, 23, 12, 24)},
+		{"A", "C", "-->", pos(2, 5, 17), pos(2, 16, 28), span(2, 15, 27, 16, 28)},
+		{"B", "D", "-.->", pos(2, 11, 23), pos(2, 23, 35), span(2, 22, 34, 23, 35)},
+		{"C", "D", "-.->", pos(2, 15, 27), pos(2, 23, 35), span(2, 22, 34, 23, 35)},
+	}
+	if len(diagram.Statements) != len(want)+1 {
+		t.Fatalf("expected %d statements, got %d: %+v", len(want)+1, len(diagram.Statements), diagram.Statements)
+	}
+	for i, w := range want {
+		link, ok := diagram.Statements[i].(*ast.Link)
+		if !ok {
+			t.Fatalf("statement %d: expected *ast.Link, got %T", i, diagram.Statements[i])
+		}
+		if link.From != w.from || link.To != w.to || link.Arrow != w.arrow {
+			t.Errorf("statement %d: got %s %s %s, want %s %s %s", i, link.From, link.Arrow, link.To, w.from, w.arrow, w.to)
+		}
+		if link.Pos != w.pos || link.End != w.end || link.ToSpan != w.toSpan {
+			t.Errorf("statement %d: got position %v-%v to %v, want %v-%v to %v", i, link.Pos, link.End, link.ToSpan, w.pos, w.end, w.toSpan)
+		}
+	}
+
+	// A chain with a missing node is not a link
+	if _, ok := diagram.Statements[len(want)].(*ast.UnknownStatement); !ok {
+		t.Errorf("expected an unknown statement, got %T", diagram.Statements[len(want)])
+	}
+}
+
+func TestParseNodeShapes(t *testing.T) {
+	tests := []struct {
+		line      string
+		wantShape string
+		wantKind  ast.NodeShape
+		wantLabel string
+	}{
+		{"A", "", ast.ShapeRect, ""},
+		{"A[Process]", "[]", ast.ShapeRect, "Process"},
+		{"A(Event)", "()", ast.ShapeRounded, "Event"},
+		{"A([Terminal])", "([])", ast.ShapeStadium, "Terminal"},
+		{"A[[Sub]]", "[[]]", ast.ShapeSubroutine, "Sub"},
+		{"A[(Database)]", "[()]", ast.ShapeCylinder, "Database"},
+		{"A((Start))", "(())", ast.ShapeCircle, "Start"},
+		{"A(((Stop)))", "((()))", ast.ShapeDoubleCircle, "Stop"},
+		{"A>Flag]", ">]", ast.ShapeAsymmetric, "Flag"},
+		{"A{Decide}", "{}", ast.ShapeDiamond, "Decide"},
+		{"A{{Prepare}}", "{{}}", ast.ShapeHexagon, "Prepare"},
+		{"A[/Input/]", "[//]", ast.ShapeLeanRight, "Input"},
+		{`A[\Output\]`, `[\\]`, ast.ShapeLeanLeft, "Output"},
+		{`A[/Priority\]`, `[/\]`, ast.ShapeTrapezoid, "Priority"},
+		{`A[\Manual/]`, `[\/]`, ast.ShapeInvTrapezoid, "Manual"},
+		{"A[a/b/]", "[]", ast.ShapeRect, "a/b/"},
+		{"A@{ shape: cyl }", "cyl", ast.ShapeCylinder, ""},
+		{`A@{ shape: database, label: "Orders, live" }`, "database", ast.ShapeCylinder, "Orders, live"},
+		{"A@{shape: hourglass}", "hourglass", ast.ShapeHourglass, ""},
+		{"A@{ label: 'Plain' }", "", ast.ShapeRect, "Plain"},
+		{"A@{ shape: blob }", "blob", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			d, err := parser.NewFlowchartParser().Parse("flowchart LR\n    " + tt.line)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			node, ok := d.(*ast.Flowchart).Statements[0].(*ast.NodeDef)
+			if !ok {
+				t.Fatalf("expected *ast.NodeDef, got %T", d.(*ast.Flowchart).Statements[0])
+			}
+			if node.ID != "A" || node.Shape != tt.wantShape || node.Kind != tt.wantKind || node.Label != tt.wantLabel {
+				t.Errorf("got ID %q, shape %q (%q), label %q, want A, %q (%q), %q",
+					node.ID, node.Shape, node.Kind, node.Label, tt.wantShape, tt.wantKind, tt.wantLabel)
+			}
+		})
+	}
+}
+
+func TestParseNodeLabels(t *testing.T) {
+	tests := []struct {
+		line         string
+		wantShape    string
+		wantLabel    string
+		wantRaw      string
+		wantMarkdown bool
+		wantSpan     ast.Span
+	}{
+		{`A["quoted (with parens)"]`, "[]", "quoted (with parens)", `"quoted (with parens)"`, false, span(2, 8, 20, 28, 40)},
+		{"A[\"`**bold** text`\"]", "[]", "**bold** text", "\"`**bold** text`\"", true, span(2, 9, 21, 22, 34)},
+		{`A[#quot;hi#quot;]`, "[]", `"hi"`, `#quot;hi#quot;`, false, span(2, 7, 19, 21, 33)},
+		{`A(["a] or b) #35;1 #bogus;"])`, "([])", "a] or b) #1 #bogus;", `"a] or b) #35;1 #bogus;"`, false, span(2, 9, 21, 31, 43)},
+		{`A{ "x} { y" }`, "{}", "x} { y", `"x} { y"`, false, span(2, 9, 21, 15, 27)},
+		{`A((" ((circle)) "))`, "(())", " ((circle)) ", `" ((circle)) "`, false, span(2, 9, 21, 21, 33)},
+		{`A@{ shape: rect, label: "Fish #amp; chips" }`, "rect", "Fish & chips", `"Fish #amp; chips"`, false, span(2, 30, 42, 46, 58)},
+		{"A[plain]", "[]", "plain", "plain", false, span(2, 7, 19, 12, 24)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			d, err := parser.NewFlowchartParser().Parse("flowchart LR\n    " + tt.line + " --> B")
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			node, ok := d.(*ast.Flowchart).Statements[0].(*ast.NodeDef)
+			if !ok {
+				t.Fatalf("expected *ast.NodeDef, got %T", d.(*ast.Flowchart).Statements[0])
+			}
+			if node.Shape != tt.wantShape || node.Label != tt.wantLabel || node.RawLabel != tt.wantRaw || node.Markdown != tt.wantMarkdown {
+				t.Errorf("got shape %q, label %q, raw %q, markdown %v, want %q, %q, %q, %v",
+					node.Shape, node.Label, node.RawLabel, node.Markdown, tt.wantShape, tt.wantLabel, tt.wantRaw, tt.wantMarkdown)
+			}
+			if node.LabelSpan != tt.wantSpan {
+				t.Errorf("LabelSpan = %v, want %v", node.LabelSpan, tt.wantSpan)
+			}
+			if link, ok := d.(*ast.Flowchart).Statements[1].(*ast.Link); !ok || link.From != "A" || link.To != "B" {
+				t.Errorf("expected a link from A to B, got %+v", d.(*ast.Flowchart).Statements[1])
+			}
+		})
+	}
+}
+
+func TestParseNodeProperties(t *testing.T) {
+	source := "flowchart LR\n    A@{ shape: cyl, label: \"DB\", icon: \"fa:database\" } --> B[/In/] & C\n    D@{ shape }"
+	d, err := parser.NewFlowchartParser().Parse(source)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	statements := d.(*ast.Flowchart).Statements
+	if len(statements) != 5 {
+		t.Fatalf("expected 5 statements, got %d: %+v", len(statements), statements)
+	}
+
+	db := statements[0].(*ast.NodeDef)
+	if db.Kind != ast.ShapeCylinder || db.Label != "DB" || db.Properties["icon"] != "fa:database" {
+		t.Errorf("unexpected node: %+v", db)
+	}
+	if db.ShapeSpan != span(2, 16, 28, 19, 31) || db.LabelSpan != span(2, 29, 41, 31, 43) {
+		t.Errorf("got shape span %v and label span %v", db.ShapeSpan, db.LabelSpan)
+	}
+	if link := statements[1].(*ast.Link); link.From != "A" || link.To != "B" || link.Pos != pos(2, 5, 17) {
+		t.Errorf("unexpected link: %+v", link)
+	}
+	if in := statements[3].(*ast.NodeDef); in.Kind != ast.ShapeLeanRight || in.ShapeSpan != span(2, 61, 73, 67, 79) {
+		t.Errorf("unexpected node: %+v", in)
+	}
+
+	// An @{} entry that is not a key: value pair makes the line unrecognised
+	if _, ok := statements[4].(*ast.UnknownStatement); !ok {
+		t.Errorf("expected an unknown statement, got %T", statements[4])
+	}
+}
+
+func TestParseEdgeGrammar(t *testing.T) {
+	tests := []struct {
+		line string
+		want ast.Link
+	}{
+		{"A --> B", ast.Link{Arrow: "-->", Head: ast.ArrowPoint, Stroke: ast.StrokeNormal, Length: 1}},
+		{"A --- B", ast.Link{Arrow: "---", Stroke: ast.StrokeNormal, Length: 1}},
+		{"A ----> B", ast.Link{Arrow: "---->", Head: ast.ArrowPoint, Stroke: ast.StrokeNormal, Length: 3}},
+		{"A------B", ast.Link{Arrow: "------", Stroke: ast.StrokeNormal, Length: 4}},
+		{"A -.- B", ast.Link{Arrow: "-.-", Stroke: ast.StrokeDotted, Length: 1}},
+		{"A -....-> B", ast.Link{Arrow: "-....->", Head: ast.ArrowPoint, Stroke: ast.StrokeDotted, Length: 4}},
+		{"A === B", ast.Link{Arrow: "===", Stroke: ast.StrokeThick, Length: 1}},
+		{"A ====> B", ast.Link{Arrow: "====>", Head: ast.ArrowPoint, Stroke: ast.StrokeThick, Length: 3}},
+		{"A --o B", ast.Link{Arrow: "--o", Head: ast.ArrowCircle, Stroke: ast.StrokeNormal, Length: 1}},
+		{"A --x B", ast.Link{Arrow: "--x", Head: ast.ArrowCross, Stroke: ast.StrokeNormal, Length: 1}},
+		{"A o--o B", ast.Link{Arrow: "o--o", Head: ast.ArrowCircle, Tail: ast.ArrowCircle, Stroke: ast.StrokeNormal, Length: 1, BiDir: true}},
+		{"A x==x B", ast.Link{Arrow: "x==x", Head: ast.ArrowCross, Tail: ast.ArrowCross, Stroke: ast.StrokeThick, Length: 1, BiDir: true}},
+		{"A <---> B", ast.Link{Arrow: "<--->", Head: ast.ArrowPoint, Tail: ast.ArrowPoint, Stroke: ast.StrokeNormal, Length: 2, BiDir: true}},
+		{"A ~~~ B", ast.Link{Arrow: "~~~", Stroke: ast.StrokeInvisible, Length: 1}},
+		{"A ~~~~~ B", ast.Link{Arrow: "~~~~~", Stroke: ast.StrokeInvisible, Length: 3}},
+		{"A -->|yes| B", ast.Link{Arrow: "-->", Label: "yes", Head: ast.ArrowPoint, Stroke: ast.StrokeNormal, Length: 1}},
+		{"A -- yes --> B", ast.Link{Arrow: "-->", Label: "yes", Head: ast.ArrowPoint, Stroke: ast.StrokeNormal, Length: 1}},
+		{"A-- two words --->B", ast.Link{Arrow: "--->", Label: "two words", Head: ast.ArrowPoint, Stroke: ast.StrokeNormal, Length: 2}},
+		{"A -- open --- B", ast.Link{Arrow: "---", Label: "open", Stroke: ast.StrokeNormal, Length: 1}},
+		{"A == bold ==> B", ast.Link{Arrow: "==>", Label: "bold", Head: ast.ArrowPoint, Stroke: ast.StrokeThick, Length: 1}},
+		{"A -. maybe ..-> B", ast.Link{Arrow: "-..->", Label: "maybe", Head: ast.ArrowPoint, Stroke: ast.StrokeDotted, Length: 2}},
+		{"A <-- both --> B", ast.Link{Arrow: "<-->", Label: "both", Head: ast.ArrowPoint, Tail: ast.ArrowPoint, Stroke: ast.StrokeNormal, Length: 1, BiDir: true}},
+		{"A e1@--> B", ast.Link{ID: "e1", Arrow: "-->", Head: ast.ArrowPoint, Stroke: ast.StrokeNormal, Length: 1}},
+		{"A e2@-- text --x B", ast.Link{ID: "e2", Arrow: "--x", Label: "text", Head: ast.ArrowCross, Stroke: ast.StrokeNormal, Length: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			d, err := parser.NewFlowchartParser().Parse("flowchart LR\n    " + tt.line)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			link, ok := d.(*ast.Flowchart).Statements[0].(*ast.Link)
+			if !ok {
+				t.Fatalf("expected *ast.Link, got %T", d.(*ast.Flowchart).Statements[0])
+			}
+			want := tt.want
+			want.From, want.To = "A", "B"
+			got := ast.Link{
+				ID: link.ID, From: link.From, To: link.To, Arrow: link.Arrow, Label: link.Label,
+				Head: link.Head, Tail: link.Tail, Stroke: link.Stroke, Length: link.Length, BiDir: link.BiDir,
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got  %+v\nwant %+v", got, want)
+			}
+			if link.ID != "" && !link.IDSpan.IsValid() {
+				t.Error("expected IDSpan to be set")
+			}
+		})
+	}
+}
+
+func TestParseEdgeGrammarInvalid(t *testing.T) {
+	for _, line := range []string{"A -- B", "A ~~~> B", "A -- text ==> B", "A -> B", "A ----"} {
+		t.Run(line, func(t *testing.T) {
+			d, err := parser.NewFlowchartParser().Parse("flowchart LR\n    " + line)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if stmt := d.(*ast.Flowchart).Statements[0]; reflect.TypeOf(stmt) != reflect.TypeFor[*ast.UnknownStatement]() {
+				t.Errorf("expected an unknown statement, got %+v", stmt)
+			}
+		})
+	}
+}
+
+func TestParseEdgeProperties(t *testing.T) {
+	source := "flowchart LR\n    A e1@-- go --> B -.-> C\n    e1@{ animate: true }\n    D@{ animate: true }"
+	d, err := parser.NewFlowchartParser().Parse(source)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	statements := d.(*ast.Flowchart).Statements
+	if len(statements) != 3 {
+		t.Fatalf("expected 3 statements, got %d: %+v", len(statements), statements)
+	}
+
+	link := statements[0].(*ast.Link)
+	if link.Properties["animate"] != "true" {
+		t.Errorf("expected the edge properties to be set, got %v", link.Properties)
+	}
+	if link.IDSpan != span(2, 7, 19, 9, 21) || link.ArrowSpan != span(2, 10, 22, 19, 31) || link.LabelSpan != span(2, 13, 25, 15, 27) {
+		t.Errorf("got spans ID %v, arrow %v, label %v", link.IDSpan, link.ArrowSpan, link.LabelSpan)
+	}
+	if next := statements[1].(*ast.Link); next.From != "B" || next.To != "C" || next.Properties != nil {
+		t.Errorf("unexpected second link: %+v", next)
+	}
+
+	// Without a matching edge ID the block defines a node
+	if node, ok := statements[2].(*ast.NodeDef); !ok || node.ID != "D" {
+		t.Errorf("expected node D, got %+v", statements[2])
+	}
+}
+
+func TestParseStyleStatements(t *testing.T) {
+	source := `flowchart LR
+    A:::warn --> B[Done]:::ok
+    C:::warn
+    style A fill:#f9f,stroke:#333
+    linkStyle 0, 2 stroke:red
+    linkStyle default stroke:blue`
+
+	d, err := parser.NewFlowchartParser().Parse(source)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	statements := d.(*ast.Flowchart).Statements
+
+	var assigns []string
+	for _, stmt := range statements {
+		if a, ok := stmt.(*ast.ClassAssignment); ok {
+			if !a.Shorthand {
+				t.Errorf("expected a shorthand class assignment: %+v", a)
+			}
+			assigns = append(assigns, a.NodeIDs[0]+":"+a.ClassName)
+		}
+	}
+	if want := []string{"A:warn", "B:ok", "C:warn"}; !reflect.DeepEqual(assigns, want) {
+		t.Errorf("class assignments = %v, want %v", assigns, want)
+	}
+	if node, ok := statements[2].(*ast.NodeDef); !ok || node.ID != "B" || node.Label != "Done" {
+		t.Errorf("expected node B to keep its definition, got %+v", statements[2])
+	}
+	if assign := statements[3].(*ast.ClassAssignment); assign.ClassNameSpan != span(2, 28, 40, 30, 42) {
+		t.Errorf("ClassNameSpan = %v", assign.ClassNameSpan)
+	}
+
+	style := statements[6].(*ast.Style)
+	if style.NodeID != "A" || style.Styles["fill"] != "#f9f" || style.Styles["stroke"] != "#333" {
+		t.Errorf("unexpected style: %+v", style)
+	}
+
+	linkStyle := statements[7].(*ast.LinkStyle)
+	if !reflect.DeepEqual(linkStyle.Indexes, []int{0, 2}) || linkStyle.Default || linkStyle.Styles["stroke"] != "red" {
+		t.Errorf("unexpected link style: %+v", linkStyle)
+	}
+	if len(linkStyle.IndexSpans) != 2 || linkStyle.IndexSpans[1] != span(5, 18, 107, 19, 108) {
+		t.Errorf("IndexSpans = %v", linkStyle.IndexSpans)
+	}
+	if linkStyle := statements[8].(*ast.LinkStyle); !linkStyle.Default || linkStyle.Indexes != nil {
+		t.Errorf("unexpected default link style: %+v", linkStyle)
+	}
+}
+
+func TestParseClassShorthandOnDefinedNode(t *testing.T) {
+	source := "flowchart LR\n A[Start]\n A:::warn\n classDef warn fill:red"
+
+	d, err := parser.NewFlowchartParser().Parse(source)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	statements := d.(*ast.Flowchart).Statements
+	if len(statements) != 3 {
+		t.Fatalf("expected 3 statements, got %d: %+v", len(statements), statements)
+	}
+	if node, ok := statements[0].(*ast.NodeDef); !ok || node.ID != "A" || node.Label != "Start" {
+		t.Errorf("expected node A, got %+v", statements[0])
+	}
+	assign, ok := statements[1].(*ast.ClassAssignment)
+	if !ok || !reflect.DeepEqual(assign.NodeIDs, []string{"A"}) || assign.ClassName != "warn" {
+		t.Fatalf("expected only a class assignment for A, got %+v", statements[1])
+	}
+	if assign.Pos != pos(3, 2, 24) {
+		t.Errorf("Pos = %v", assign.Pos)
+	}
+}
+
+func TestParseBareReferenceToDefinedNode(t *testing.T) {
+	source := "flowchart LR\n A[x]\n A\n B"
+
+	d, err := parser.NewFlowchartParser().Parse(source)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	var ids []string
+	for _, stmt := range d.(*ast.Flowchart).Statements {
+		if node, ok := stmt.(*ast.NodeDef); ok {
+			ids = append(ids, node.ID)
+		}
+	}
+	if want := []string{"A", "B"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("node definitions = %v, want %v", ids, want)
+	}
+}
+
+func TestParseClickStatements(t *testing.T) {
+	tests := []struct {
+		line string
+		want ast.Click
+	}{
+		{`click A href "https://example.com"`, ast.Click{NodeID: "A", URL: "https://example.com"}},
+		{`click A "https://example.com" "Open" _blank`, ast.Click{NodeID: "A", URL: "https://example.com", Tooltip: "Open", Target: "_blank"}},
+		{`click A href "https://example.com" _self`, ast.Click{NodeID: "A", URL: "https://example.com", Target: "_self"}},
+		{"click A callback", ast.Click{NodeID: "A", Callback: "callback"}},
+		{`click A callback "Run it"`, ast.Click{NodeID: "A", Callback: "callback", Tooltip: "Run it"}},
+		{`click A call notify("A", 2) "Notify"`, ast.Click{NodeID: "A", Callback: `notify("A", 2)`, Tooltip: "Notify"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			d, err := parser.NewFlowchartParser().Parse("flowchart LR\n    A --> B\n    " + tt.line)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			click, ok := d.(*ast.Flowchart).Statements[1].(*ast.Click)
+			if !ok {
+				t.Fatalf("expected *ast.Click, got %T", d.(*ast.Flowchart).Statements[1])
+			}
+			got := ast.Click{NodeID: click.NodeID, URL: click.URL, Callback: click.Callback, Tooltip: click.Tooltip, Target: click.Target}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if !click.NodeIDSpan.IsValid() || (click.URL != "" && !click.URLSpan.IsValid()) || (click.Callback != "" && !click.CallbackSpan.IsValid()) {
+				t.Errorf("expected spans to be set: %+v", click)
+			}
+		})
+	}
+}
+
+func pos(line, column, offset int) ast.Position {
+	return ast.Position{Line: line, Column: column, Offset: offset}
+}
+
+func span(line, startColumn, startOffset, endColumn, endOffset int) ast.Span {
+	return ast.Span{Start: pos(line, startColumn, startOffset), End: pos(line, endColumn, endOffset)}
+}
+
+func TestParseSubgraphs(t *testing.T) {
+	source := `flowchart LR
+    subgraph api [API Layer]
+        direction TB
+        A --> B
+    end
+    subgraph db
+    end
+    subgraph "Quoted title"
+    end
+    api --> db
+    direction RL`
+
+	d, err := parser.NewFlowchartParser().Parse(source)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	diagram := d.(*ast.Flowchart)
+	if len(diagram.Statements) != 5 {
+		t.Fatalf("expected 5 statements, got %d: %+v", len(diagram.Statements), diagram.Statements)
+	}
+
+	tests := []struct {
+		id, title, direction string
+		idSpan, titleSpan    ast.Span
+		statements           int
+	}{
+		{"api", "API Layer", "TB", span(2, 14, 26, 17, 29), span(2, 19, 31, 28, 40), 1},
+		{"db", "db", "", span(6, 14, 100, 16, 102), span(6, 14, 100, 16, 102), 0},
+		{"", "Quoted title", "", ast.Span{}, span(8, 15, 125, 27, 137), 0},
+	}
+	for i, tt := range tests {
+		sg, ok := diagram.Statements[i].(*ast.Subgraph)
+		if !ok {
+			t.Fatalf("statement %d: expected *ast.Subgraph, got %T", i, diagram.Statements[i])
+		}
+		if sg.ID != tt.id || sg.Title != tt.title || sg.Direction != tt.direction {
+			t.Errorf("statement %d: got ID %q, title %q, direction %q, want %q, %q, %q", i, sg.ID, sg.Title, sg.Direction, tt.id, tt.title, tt.direction)
+		}
+		if sg.IDSpan != tt.idSpan || sg.TitleSpan != tt.titleSpan {
+			t.Errorf("statement %d: got ID span %v, title span %v, want %v, %v", i, sg.IDSpan, sg.TitleSpan, tt.idSpan, tt.titleSpan)
+		}
+		if len(sg.Statements) != tt.statements {
+			t.Errorf("statement %d: expected %d nested statements, got %d", i, tt.statements, len(sg.Statements))
+		}
+	}
+
+	if sg := diagram.Statements[0].(*ast.Subgraph); sg.DirectionSpan != span(3, 19, 60, 21, 62) {
+		t.Errorf("direction span = %v, want %v", sg.DirectionSpan, span(3, 19, 60, 21, 62))
+	}
+	if link, ok := diagram.Statements[3].(*ast.Link); !ok || link.From != "api" || link.To != "db" {
+		t.Errorf("expected a link from api to db, got %+v", diagram.Statements[3])
+	}
+	// direction only applies inside a subgraph
+	if _, ok := diagram.Statements[4].(*ast.UnknownStatement); !ok {
+		t.Errorf("expected a top-level direction to be unknown, got %T", diagram.Statements[4])
+	}
+}
+
+func TestParseUnknownStatements(t *testing.T) {
+	source := `flowchart TD
+    A --> B
+    A -> C
+    subgraph one
+        B ==> ??
+    end`
+
+	d, err := parser.NewFlowchartParser().Parse(source)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	diagram := d.(*ast.Flowchart)
+
+	var unknown []*ast.UnknownStatement
+	for _, stmt := range diagram.Statements {
+		switch s := stmt.(type) {
+		case *ast.UnknownStatement:
+			unknown = append(unknown, s)
+		case *ast.Subgraph:
+			for _, nested := range s.Statements {
+				if u, ok := nested.(*ast.UnknownStatement); ok {
+					unknown = append(unknown, u)
+				}
+			}
+		}
+	}
+
+	if len(unknown) != 2 {
+		t.Fatalf("expected 2 unknown statements, got %d", len(unknown))
+	}
+	if unknown[0].Text != "A -> C" || unknown[0].Pos != (ast.Position{Line: 3, Column: 5, Offset: 29}) {
+		t.Errorf("unexpected first unknown statement: %+v", unknown[0])
+	}
+	if unknown[1].Text != "B ==> ??" || unknown[1].Pos != (ast.Position{Line: 5, Column: 9, Offset: 61}) {
+		t.Errorf("unexpected second unknown statement: %+v", unknown[1])
+	}
+}
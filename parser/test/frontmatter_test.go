@@ -0,0 +1,209 @@
+package parser_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sammcj/mermaid-check/ast"
+	"github.com/sammcj/mermaid-check/parser"
+)
+
+func TestFrontmatterOnAllDiagramTypes(t *testing.T) {
+	bodies := map[string]string{
+		"flowchart":       "flowchart LR\n    A --> B",
+		"sequence":        "sequenceDiagram\n    A->>B: Hi",
+		"class":           "classDiagram\n    class Animal",
+		"stateDiagram-v2": "stateDiagram-v2\n    [*] --> Still",
+		"er":              "erDiagram\n    CUSTOMER ||--o{ ORDER : places",
+		"gantt":           "gantt\n    dateFormat YYYY-MM-DD\n    section A\n    Task :a1, 2024-01-01, 1d",
+		"pie":             "pie\n    \"Dogs\" : 3",
+		"journey":         "journey\n    section Work\n    Code: 5: Me",
+		"timeline":        "timeline\n    2024 : Release",
+		"gitGraph":        "gitGraph\n    commit",
+		"mindmap":         "mindmap\n  root",
+		"sankey":          "sankey-beta\nA,B,10",
+		"quadrantChart":   "quadrantChart\n    x-axis Low --> High\n    y-axis Low --> High\n    A: [0.3, 0.6]",
+		"xyChart":         "xychart-beta\n    x-axis [a, b]\n    y-axis \"Total\" 0 --> 5\n    bar [1, 2]",
+		"c4Context":       "C4Context\n    Person(user, \"User\")",
+	}
+
+	for name, body := range bodies {
+		t.Run(name, func(t *testing.T) {
+			source := "---\ntitle: My diagram\nconfig:\n  theme: forest\n---\n" + body
+			diagram, err := parser.Parse(source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			fm := diagram.GetMetadata().Frontmatter
+			if fm == nil {
+				t.Fatal("expected frontmatter")
+			}
+			if fm.Title != "My diagram" {
+				t.Errorf("Title = %q, want %q", fm.Title, "My diagram")
+			}
+			if fm.Config["theme"] != "forest" {
+				t.Errorf("Config[theme] = %v, want forest", fm.Config["theme"])
+			}
+			if got := fm.TitleSpan.Text(source); got != "My diagram" {
+				t.Errorf("TitleSpan covers %q", got)
+			}
+			if diagram.GetType() == "unknown" {
+				t.Errorf("diagram type not detected after frontmatter")
+			}
+		})
+	}
+}
+
+func TestFrontmatterValues(t *testing.T) {
+	source := `---
+title: "Quoted: title" # trailing comment
+displayMode: compact
+config:
+  theme: base
+  look: handDrawn
+  fontSize: 16
+  htmlLabels: false
+  themeVariables:
+    primaryColor: '#ff0000'
+  secure: [secure, theme]
+  flowchart:
+    curve: basis
+    padding: 7.5
+  description: |
+    line one
+    line two
+---
+
+flowchart TD
+    A --> B`
+
+	diagram, err := parser.Parse(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fc := diagram.(*ast.Flowchart)
+	fm := fc.Frontmatter
+
+	if fm.Title != "Quoted: title" {
+		t.Errorf("Title = %q", fm.Title)
+	}
+	if fm.Values["displayMode"] != "compact" {
+		t.Errorf("displayMode = %v", fm.Values["displayMode"])
+	}
+	want := map[string]any{
+		"theme":          "base",
+		"look":           "handDrawn",
+		"fontSize":       16,
+		"htmlLabels":     false,
+		"themeVariables": map[string]any{"primaryColor": "#ff0000"},
+		"secure":         []any{"secure", "theme"},
+		"flowchart":      map[string]any{"curve": "basis", "padding": 7.5},
+		"description":    "line one\nline two",
+	}
+	if !reflect.DeepEqual(fm.Config, want) {
+		t.Errorf("Config = %#v\nwant %#v", fm.Config, want)
+	}
+
+	if want := (ast.Position{Line: 1, Column: 1, Offset: 0}); fm.Pos != want {
+		t.Errorf("Pos = %+v, want %+v", fm.Pos, want)
+	}
+	if got := fm.TitleSpan.Text(source); got != "Quoted: title" {
+		t.Errorf("TitleSpan covers %q", got)
+	}
+	if pos := fm.KeyPos("config.themeVariables.primaryColor"); pos.Line != 10 || pos.Column != 5 {
+		t.Errorf("KeyPos = %+v, want line 10 column 5", pos)
+	}
+	if fc.Pos.Line != 20 {
+		t.Errorf("header line = %d, want 20", fc.Pos.Line)
+	}
+	if link := fc.Statements[0].(*ast.Link); link.Pos.Line != 21 {
+		t.Errorf("link line = %d, want 21", link.Pos.Line)
+	}
+}
+
+func TestFrontmatterListOfMappings(t *testing.T) {
+	source := "---\nconfig:\n  items:\n  - name: a\n    size: 1\n  - name: b\n---\npie\n    \"A\" : 1"
+
+	diagram, err := parser.Parse(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []any{
+		map[string]any{"name": "a", "size": 1},
+		map[string]any{"name": "b"},
+	}
+	if got := diagram.GetMetadata().Frontmatter.Config["items"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("items = %#v, want %#v", got, want)
+	}
+}
+
+func TestFrontmatterErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		wantLine int
+		wantMsg  string
+		partial  bool // Whether a diagram is still returned
+	}{
+		{
+			name:     "unclosed",
+			source:   "---\ntitle: Oops\nflowchart LR\n    A --> B",
+			wantLine: 1,
+			wantMsg:  "unclosed frontmatter, missing closing '---'",
+		},
+		{
+			name:     "no header",
+			source:   "---\ntitle: Oops\n---\n",
+			wantLine: 3,
+			wantMsg:  "missing diagram header after frontmatter",
+		},
+		{
+			name:     "invalid line",
+			source:   "---\ntitle: Fine\nnot yaml\n---\nflowchart LR\n    A --> B",
+			wantLine: 3,
+			wantMsg:  "invalid frontmatter line, expected 'key: value': not yaml",
+			partial:  true,
+		},
+		{
+			name:     "duplicate key",
+			source:   "---\ntitle: One\ntitle: Two\n---\nsequenceDiagram\n    A->>B: Hi",
+			wantLine: 3,
+			wantMsg:  `duplicate frontmatter key "title"`,
+			partial:  true,
+		},
+		{
+			name:     "bad indentation",
+			source:   "---\nconfig:\n    theme: dark\n  look: classic\n---\npie\n    \"A\" : 1",
+			wantLine: 4,
+			wantMsg:  "unexpected indentation in frontmatter",
+			partial:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagram, err := parser.Parse(tt.source)
+			var pe *parser.ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("expected a ParseError, got %v", err)
+			}
+			if pe.Pos.Line != tt.wantLine || pe.Message != tt.wantMsg {
+				t.Errorf("got line %d %q, want line %d %q", pe.Pos.Line, pe.Message, tt.wantLine, tt.wantMsg)
+			}
+			if (diagram != nil) != tt.partial {
+				t.Errorf("partial diagram = %v, want %v", diagram != nil, tt.partial)
+			}
+		})
+	}
+}
+
+func TestNoFrontmatter(t *testing.T) {
+	diagram, err := parser.Parse("flowchart LR\n    A --> B")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fm := diagram.GetMetadata().Frontmatter; fm != nil {
+		t.Errorf("expected no frontmatter, got %+v", fm)
+	}
+}
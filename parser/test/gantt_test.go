@@ -259,11 +259,16 @@ func TestGanttParser_Parse(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "invalid syntax",
+			name: "unknown line",
 			input: `gantt
     section Work
         invalid line without colon`,
-			wantErr: true,
+			check: func(t *testing.T, d ast.Diagram) {
+				unknown := d.(*ast.GanttDiagram).Unknown
+				if len(unknown) != 1 || unknown[0].Text != "invalid line without colon" {
+					t.Errorf("expected the unknown line to be kept, got %+v", unknown)
+				}
+			},
 		},
 	}
 
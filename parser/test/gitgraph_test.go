@@ -176,6 +176,23 @@ gitGraph
 				}
 			},
 		},
+		{
+			name: "git graph with theme in a JSON directive",
+			input: `%%{init: {"theme": "dark", "gitGraph": {"showBranches": false}}}%%
+gitGraph
+	commit id: "Initial"`,
+			wantErr: false,
+			check: func(t *testing.T, diagram ast.Diagram) {
+				t.Helper()
+				d, ok := diagram.(*ast.GitGraphDiagram)
+				if !ok {
+					t.Fatal("expected *ast.GitGraphDiagram")
+				}
+				if d.Theme != "dark" {
+					t.Errorf("expected theme 'dark', got %s", d.Theme)
+				}
+			},
+		},
 		{
 			name: "git graph with main branch options",
 			input: `gitGraph
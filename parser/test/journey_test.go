@@ -209,21 +209,36 @@ func TestJourneyParser_Parse(t *testing.T) {
 			source: `journey
     section Test
         Task: abc: Actor`,
-			wantErr: true,
+			check: func(t *testing.T, d ast.Diagram) {
+				unknown := d.(*ast.JourneyDiagram).Unknown
+				if len(unknown) != 1 || unknown[0].Text != "Task: abc: Actor" {
+					t.Errorf("expected the unknown line to be kept, got %+v", unknown)
+				}
+			},
 		},
 		{
 			name: "task without actors",
 			source: `journey
     section Test
         Task: 3:`,
-			wantErr: true,
+			check: func(t *testing.T, d ast.Diagram) {
+				unknown := d.(*ast.JourneyDiagram).Unknown
+				if len(unknown) != 1 || unknown[0].Text != "Task: 3:" {
+					t.Errorf("expected the unknown line to be kept, got %+v", unknown)
+				}
+			},
 		},
 		{
 			name: "invalid task format",
 			source: `journey
     section Test
         Invalid task without colons`,
-			wantErr: true,
+			check: func(t *testing.T, d ast.Diagram) {
+				unknown := d.(*ast.JourneyDiagram).Unknown
+				if len(unknown) != 1 || unknown[0].Text != "Invalid task without colons" {
+					t.Errorf("expected the unknown line to be kept, got %+v", unknown)
+				}
+			},
 		},
 		{
 			name: "task with whitespace in actors",
@@ -0,0 +1,257 @@
+package parser_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sammcj/mermaid-check/parser"
+)
+
+func TestParseWithOptionsLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		opts      parser.ParseOptions
+		wantLimit parser.Limit
+		wantLine  int
+	}{
+		{
+			name:      "bytes",
+			source:    "flowchart LR\n    A --> B",
+			opts:      parser.ParseOptions{MaxBytes: 16},
+			wantLimit: parser.LimitBytes,
+		},
+		{
+			name:      "lines",
+			source:    "pie\n    \"A\" : 1\n    \"B\" : 2",
+			opts:      parser.ParseOptions{MaxLines: 2},
+			wantLimit: parser.LimitLines,
+		},
+		{
+			name:      "flowchart statements",
+			source:    "flowchart LR\n    A --> B\n    B --> C\n    C --> D",
+			opts:      parser.ParseOptions{MaxStatements: 2},
+			wantLimit: parser.LimitStatements,
+			wantLine:  4,
+		},
+		{
+			name:      "flowchart subgraphs",
+			source:    "flowchart LR\n    subgraph one\n        subgraph two\n            A --> B\n        end\n    end",
+			opts:      parser.ParseOptions{MaxNesting: 1},
+			wantLimit: parser.LimitNesting,
+			wantLine:  3,
+		},
+		{
+			name:      "sequence blocks",
+			source:    "sequenceDiagram\n    loop Every minute\n        alt ok\n            opt maybe\n                A->>B: Hi\n            end\n        end\n    end",
+			opts:      parser.ParseOptions{MaxNesting: 2},
+			wantLimit: parser.LimitNesting,
+			wantLine:  4,
+		},
+		{
+			name:      "sequence statements in blocks",
+			source:    "sequenceDiagram\n    loop Every minute\n        A->>B: Hi\n        B->>A: Hello\n    end",
+			opts:      parser.ParseOptions{MaxStatements: 2},
+			wantLimit: parser.LimitStatements,
+			wantLine:  4,
+		},
+		{
+			name:      "composite states",
+			source:    "stateDiagram-v2\n    state Outer {\n        state Inner {\n            [*] --> A\n        }\n    }",
+			opts:      parser.ParseOptions{MaxNesting: 1},
+			wantLimit: parser.LimitNesting,
+			wantLine:  3,
+		},
+		{
+			name:      "class members",
+			source:    "classDiagram\n    class Animal {\n        +String name\n        +eat()\n    }",
+			opts:      parser.ParseOptions{MaxStatements: 2},
+			wantLimit: parser.LimitStatements,
+			wantLine:  4,
+		},
+		{
+			name:      "mindmap depth",
+			source:    "mindmap\n  root\n    child\n      grandchild",
+			opts:      parser.ParseOptions{MaxNesting: 2},
+			wantLimit: parser.LimitNesting,
+			wantLine:  4,
+		},
+		{
+			name:      "mindmap root counts towards depth",
+			source:    "mindmap\n  root\n    child",
+			opts:      parser.ParseOptions{MaxNesting: 1},
+			wantLimit: parser.LimitNesting,
+			wantLine:  3,
+		},
+		{
+			name:      "c4 boundaries",
+			source:    "C4Context\n    Enterprise_Boundary(b0, \"Enterprise\") {\n        System_Boundary(b1, \"System\") {\n            Person(user, \"User\")\n        }\n    }",
+			opts:      parser.ParseOptions{MaxNesting: 1},
+			wantLimit: parser.LimitNesting,
+			wantLine:  3,
+		},
+		{
+			name:      "er statements",
+			source:    "erDiagram\n    A ||--o{ B : has\n    B ||--o{ C : has",
+			opts:      parser.ParseOptions{MaxStatements: 1},
+			wantLimit: parser.LimitStatements,
+			wantLine:  3,
+		},
+		{
+			name:      "gantt statements",
+			source:    "gantt\n    dateFormat YYYY-MM-DD\n    section A\n    Task :a1, 2024-01-01, 1d",
+			opts:      parser.ParseOptions{MaxStatements: 2},
+			wantLimit: parser.LimitStatements,
+			wantLine:  4,
+		},
+		{
+			name:      "sankey statements",
+			source:    "sankey-beta\nA,B,10\nB,C,5",
+			opts:      parser.ParseOptions{MaxStatements: 1},
+			wantLimit: parser.LimitStatements,
+			wantLine:  3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagram, err := parser.ParseWithOptions(context.Background(), tt.source, tt.opts)
+			var le *parser.LimitError
+			if !errors.As(err, &le) {
+				t.Fatalf("expected a LimitError, got %v", err)
+			}
+			if le.Limit != tt.wantLimit || le.Pos.Line != tt.wantLine {
+				t.Errorf("got %v limit on line %d, want %v on line %d", le.Limit, le.Pos.Line, tt.wantLimit, tt.wantLine)
+			}
+			if diagram != nil {
+				t.Errorf("expected no diagram, got %T", diagram)
+			}
+		})
+	}
+}
+
+func TestParseWithOptionsWithinLimits(t *testing.T) {
+	sources := []string{
+		"flowchart LR\n    subgraph one\n        A --> B\n    end",
+		"sequenceDiagram\n    loop Every minute\n        A->>B: Hi\n    end",
+		"classDiagram\n    class Animal {\n        +String name\n    }",
+		"stateDiagram-v2\n    state Outer {\n        [*] --> A\n    }",
+		"mindmap\n  root",
+		"journey\n    section Work\n    Code: 5: Me",
+		"timeline\n    2024 : Release",
+		"gitGraph\n    commit",
+		"quadrantChart\n    x-axis Low --> High\n    y-axis Low --> High\n    A: [0.3, 0.6]",
+		"xychart-beta\n    x-axis [a, b]\n    y-axis \"Total\" 0 --> 5\n    bar [1, 2]",
+		"C4Container\n    System_Boundary(b1, \"System\") {\n        Container(api, \"API\")\n    }",
+	}
+	opts := parser.ParseOptions{MaxBytes: 1024, MaxLines: 10, MaxNesting: 1, MaxStatements: 10}
+
+	for _, source := range sources {
+		t.Run(strings.Fields(source)[0], func(t *testing.T) {
+			want, wantErr := parser.Parse(source)
+			got, err := parser.ParseWithOptions(context.Background(), source, opts)
+			if (err == nil) != (wantErr == nil) {
+				t.Fatalf("got error %v, want %v", err, wantErr)
+			}
+			if got == nil || got.GetType() != want.GetType() {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseWithOptionsMindmapDepthBoundary(t *testing.T) {
+	source := "mindmap\n  root\n    child\n      grandchild"
+	opts := parser.ParseOptions{MaxNesting: 3}
+
+	if _, err := parser.ParseWithOptions(context.Background(), source, opts); err != nil {
+		t.Errorf("expected a mindmap exactly %d levels deep to parse, got %v", opts.MaxNesting, err)
+	}
+
+	_, err := parser.ParseWithOptions(context.Background(), source+"\n        greatgrandchild", opts)
+	var le *parser.LimitError
+	if !errors.As(err, &le) || le.Limit != parser.LimitNesting || le.Pos.Line != 5 {
+		t.Errorf("expected a nesting limit error on line 5, got %v", err)
+	}
+}
+
+func TestParseWithOptionsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	diagram, err := parser.ParseWithOptions(ctx, "flowchart LR\n    A --> B", parser.ParseOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if diagram != nil {
+		t.Errorf("expected no diagram, got %T", diagram)
+	}
+
+	// Parsers stop as soon as they notice the cancellation
+	_, err = parser.NewSequenceParser().ParseWithOptions(ctx, "sequenceDiagram\n    A->>B: Hi", parser.ParseOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from the parser, got %v", err)
+	}
+}
+
+func TestParseWithOptionsMaxLinesBoundary(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		wantErr bool
+	}{
+		{"exactly max lines", "pie\n    \"A\" : 1\n    \"B\" : 2", false},
+		{"exactly max lines with trailing newline", "pie\n    \"A\" : 1\n    \"B\" : 2\n", false},
+		{"one line over", "pie\n    \"A\" : 1\n    \"B\" : 2\n    \"C\" : 3", true},
+		{"one line over with trailing newline", "pie\n    \"A\" : 1\n    \"B\" : 2\n    \"C\" : 3\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parser.ParseWithOptions(context.Background(), tt.source, parser.ParseOptions{MaxLines: 3})
+			var le *parser.LimitError
+			if got := errors.As(err, &le) && le.Limit == parser.LimitLines; got != tt.wantErr {
+				t.Errorf("lines LimitError = %v, want %v (err %v)", got, tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseWithOptionsRegisteredParser(t *testing.T) {
+	// dialectParser does not implement OptionsParser, so only the size limits apply
+	source := "dialectDiagram\n    a -> b\n    b -> c"
+	if _, err := parser.ParseWithOptions(context.Background(), source, parser.ParseOptions{MaxStatements: 1}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	_, err := parser.ParseWithOptions(context.Background(), source, parser.ParseOptions{MaxLines: 2})
+	var le *parser.LimitError
+	if !errors.As(err, &le) || le.Limit != parser.LimitLines {
+		t.Errorf("expected a lines LimitError, got %v", err)
+	}
+}
+
+func TestBuiltinParsersHonourOptions(t *testing.T) {
+	parsers := []parser.DiagramParser{
+		parser.NewFlowchartParser(), parser.NewSequenceParser(), parser.NewClassParser(),
+		parser.NewStateParser(), parser.NewERParser(), parser.NewGanttParser(), parser.NewPieParser(),
+		parser.NewJourneyParser(), parser.NewTimelineParser(), parser.NewGitGraphParser(),
+		parser.NewMindmapParser(), parser.NewSankeyParser(), parser.NewQuadrantParser(),
+		parser.NewXYChartParser(), parser.NewC4ContextParser(), parser.NewC4ContainerParser(),
+		parser.NewC4ComponentParser(), parser.NewC4DynamicParser(), parser.NewC4DeploymentParser(),
+	}
+	for _, p := range parsers {
+		if _, ok := p.(parser.OptionsParser); !ok {
+			t.Errorf("%T does not implement OptionsParser", p)
+		}
+	}
+}
+
+func TestLimitErrorMessage(t *testing.T) {
+	err := &parser.LimitError{Limit: parser.LimitNesting, Max: 3}
+	err.Pos.Line = 7
+	if got, want := err.Error(), "line 7: diagram exceeds the maximum nesting depth of 3"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -251,10 +251,6 @@ sequenceDiagram
 	}
 }
 
-// NOTE: TestDetectDiagramType is commented out because detectDiagramType is an unexported function
-// and this file uses black-box testing (package parser_test).
-// This test should be moved to a white-box test file if needed.
-/*
 func TestDetectDiagramType(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -376,6 +372,11 @@ func TestDetectDiagramType(t *testing.T) {
 			source:       "\n\n\nflowchart TD\n    A",
 			expectedType: "flowchart",
 		},
+		{
+			name:         "after frontmatter and directive",
+			source:       "---\ntitle: Flow\n---\n%%{init: {'theme': 'dark'}}%%\nsequenceDiagram\n    A->>B: Hi",
+			expectedType: "sequence",
+		},
 		{
 			name:         "only comments",
 			source:       "%% Comment 1\n%% Comment 2",
@@ -400,14 +401,13 @@ func TestDetectDiagramType(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := detectDiagramType(tt.source)
+			got := parser.DetectType(tt.source)
 			if got != tt.expectedType {
 				t.Errorf("expected %q, got %q", tt.expectedType, got)
 			}
 		})
 	}
 }
-*/
 
 func TestParseWithRealFlowchart(t *testing.T) {
 	source := `flowchart TD
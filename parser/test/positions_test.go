@@ -0,0 +1,170 @@
+package parser_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sammcj/mermaid-check/ast"
+	"github.com/sammcj/mermaid-check/parser"
+)
+
+func TestSourceSpans(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		spans  func(t *testing.T, d ast.Diagram) map[string]ast.Span
+	}{
+		{
+			name:   "flowchart",
+			source: "flowchart LR\n    A[Start] -->|go| B(End)\n    classDef hot fill:#f00\n    class A,B hot",
+			spans: func(t *testing.T, d ast.Diagram) map[string]ast.Span {
+				fc := d.(*ast.Flowchart)
+				link := fc.Statements[1].(*ast.Link)
+				assign := fc.Statements[4].(*ast.ClassAssignment)
+				return map[string]ast.Span{
+					"LR":        fc.DirectionSpan,
+					"A":         link.FromSpan,
+					"B":         link.ToSpan,
+					"-->":       link.ArrowSpan,
+					"go":        link.LabelSpan,
+					"Start":     fc.Statements[0].(*ast.NodeDef).LabelSpan,
+					"End":       fc.Statements[2].(*ast.NodeDef).LabelSpan,
+					"hot":       assign.ClassNameSpan,
+					"fill:#f00": fc.Statements[3].(*ast.ClassDef).StylesSpan,
+				}
+			},
+		},
+		{
+			name:   "sequence",
+			source: "sequenceDiagram\n  participant A as Alice\n  A->>B: Hi there\n  loop Every day\n    B-->>A: Bye\n  end",
+			spans: func(t *testing.T, d ast.Diagram) map[string]ast.Span {
+				seq := d.(*ast.SequenceDiagram)
+				participant := seq.Statements[0].(*ast.Participant)
+				msg := seq.Statements[1].(*ast.Message)
+				loop := seq.Statements[2].(*ast.Loop)
+				return map[string]ast.Span{
+					"Alice":     participant.AliasSpan,
+					"->>":       msg.ArrowSpan,
+					"Hi there":  msg.TextSpan,
+					"Every day": loop.LabelSpan,
+					"-->>":      loop.Statements[0].(*ast.Message).ArrowSpan,
+				}
+			},
+		},
+		{
+			name:   "class",
+			source: "classDiagram\n    class Animal {\n        +String name\n    }\n    Animal <|-- Dog : extends",
+			spans: func(t *testing.T, d ast.Diagram) map[string]ast.Span {
+				cd := d.(*ast.ClassDiagram)
+				class := cd.Statements[0].(*ast.Class)
+				rel := cd.Statements[1].(*ast.Relationship)
+				return map[string]ast.Span{
+					"Animal":  class.NameSpan,
+					"name":    class.Members[0].NameSpan,
+					"String":  class.Members[0].TypeSpan,
+					"Dog":     rel.ToSpan,
+					"extends": rel.LabelSpan,
+				}
+			},
+		},
+		{
+			name:   "er",
+			source: "erDiagram\n    CUSTOMER {\n        string name PK \"full name\"\n    }\n    CUSTOMER ||--o{ ORDER : places",
+			spans: func(t *testing.T, d ast.Diagram) map[string]ast.Span {
+				er := d.(*ast.ERDiagram)
+				attr := er.Entities[0].Attributes[0]
+				return map[string]ast.Span{
+					"string":    attr.TypeSpan,
+					"name":      attr.NameSpan,
+					"full name": attr.CommentSpan,
+					"ORDER":     er.Relationships[0].ToSpan,
+					"places":    er.Relationships[0].LabelSpan,
+				}
+			},
+		},
+		{
+			name:   "c4",
+			source: "C4Context\n    title System Context\n    Person(user, \"Customer\", \"A user\")",
+			spans: func(t *testing.T, d ast.Diagram) map[string]ast.Span {
+				c4 := d.(*ast.C4Diagram)
+				return map[string]ast.Span{
+					"System Context": c4.TitleSpan,
+					"user":           c4.Elements[0].Params[0],
+					"Customer":       c4.Elements[0].Params[1],
+					"A user":         c4.Elements[0].Params[2],
+				}
+			},
+		},
+		{
+			name:   "xychart",
+			source: "xychart-beta\n    x-axis [jan, feb]\n    y-axis \"Revenue\" 0 --> 100\n    bar [10, 20.5]",
+			spans: func(t *testing.T, d ast.Diagram) map[string]ast.Span {
+				xy := d.(*ast.XYChartDiagram)
+				return map[string]ast.Span{
+					"feb":     xy.XAxis.CategorySpans[1],
+					"Revenue": xy.YAxis.LabelSpan,
+					"100":     xy.YAxis.MaxSpan,
+					"20.5":    xy.Series[0].ValueSpans[1],
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagram, err := parser.Parse(tt.source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for want, span := range tt.spans(t, diagram) {
+				if got := span.Text(tt.source); got != want {
+					t.Errorf("span %+v covers %q, want %q", span, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	source := "sequenceDiagram\n    Alice->>Bob: Hello\n    opt Maybe\n        Bob->>Alice: Hi\n    end"
+
+	diagram, err := parser.Parse(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	seq := diagram.(*ast.SequenceDiagram)
+
+	msg := seq.Statements[0].(*ast.Message)
+	if want := (ast.Position{Line: 2, Column: 5, Offset: 20}); msg.Pos != want {
+		t.Errorf("message Pos = %+v, want %+v", msg.Pos, want)
+	}
+	if want := (ast.Position{Line: 2, Column: 23, Offset: 38}); msg.End != want {
+		t.Errorf("message End = %+v, want %+v", msg.End, want)
+	}
+
+	opt := seq.Statements[1].(*ast.Opt)
+	if got := source[opt.Pos.Offset:opt.End.Offset]; got != "opt Maybe\n        Bob->>Alice: Hi\n    end" {
+		t.Errorf("opt block covers %q", got)
+	}
+
+	if seq.End != ast.SourceEnd(source) {
+		t.Errorf("diagram End = %+v, want end of source", seq.End)
+	}
+}
+
+func TestLineStarts(t *testing.T) {
+	tests := []struct {
+		source string
+		want   []int
+	}{
+		{"", []int{0}},
+		{"pie", []int{0}},
+		{"pie\n", []int{0, 4}},
+		{"pie\n    \"A\" : 1\n\n", []int{0, 4, 16, 17}},
+	}
+	for _, tt := range tests {
+		if got := parser.LineStarts(tt.source); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("LineStarts(%q) = %v, want %v", tt.source, got, tt.want)
+		}
+	}
+}
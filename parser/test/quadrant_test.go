@@ -257,13 +257,18 @@ func TestQuadrantParser_Parse(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "invalid quadrant number",
+			name: "unknown quadrant number",
 			source: `quadrantChart
     x-axis Left --> Right
     y-axis Bottom --> Top
     quadrant-5 Invalid
     Point A: [0.5, 0.5]`,
-			wantErr: true,
+			check: func(t *testing.T, d ast.Diagram) {
+				unknown := d.(*ast.QuadrantDiagram).Unknown
+				if len(unknown) != 1 || unknown[0].Text != "quadrant-5 Invalid" {
+					t.Errorf("expected the unknown line to be kept, got %+v", unknown)
+				}
+			},
 		},
 		{
 			name:    "empty source",
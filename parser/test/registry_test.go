@@ -0,0 +1,108 @@
+package parser_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sammcj/mermaid-check/ast"
+	"github.com/sammcj/mermaid-check/parser"
+)
+
+// dialectParser is a minimal parser for an in-house diagram type.
+type dialectParser struct{}
+
+func (p *dialectParser) Parse(source string) (ast.Diagram, error) {
+	return ast.NewGenericDiagram("dialect", source, ast.Position{Line: 1, Column: 1}), nil
+}
+
+func (p *dialectParser) SupportedTypes() []string { return []string{"dialect"} }
+
+func init() {
+	parser.Register([]parser.HeaderPrefix{
+		{Prefix: "dialectDiagram", Type: "dialect", DisplayName: "Dialect Diagram"},
+	}, func() parser.DiagramParser { return &dialectParser{} })
+	parser.Register([]parser.HeaderPrefix{{Prefix: "sketch-beta", Type: "sketch"}}, nil)
+}
+
+func TestRegisteredParser(t *testing.T) {
+	source := "%% In-house dialect\ndialectDiagram\n    a -> b"
+
+	if got := parser.DetectType(source); got != "dialect" {
+		t.Errorf("DetectType() = %q, want dialect", got)
+	}
+	diagram, err := parser.Parse(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diagram.GetType() != "dialect" {
+		t.Errorf("GetType() = %q, want dialect", diagram.GetType())
+	}
+	if got := parser.DisplayName("dialect"); got != "Dialect Diagram" {
+		t.Errorf("DisplayName() = %q, want Dialect Diagram", got)
+	}
+}
+
+func TestRegisteredWithoutParser(t *testing.T) {
+	source := "---\ntitle: Sketch\n---\nsketch-beta\n    box a"
+
+	diagram, err := parser.Parse(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	generic, ok := diagram.(*ast.GenericDiagram)
+	if !ok {
+		t.Fatalf("expected a GenericDiagram, got %T", diagram)
+	}
+	if generic.DiagramType != "sketch" {
+		t.Errorf("DiagramType = %q, want sketch", generic.DiagramType)
+	}
+	if generic.Frontmatter == nil || generic.Frontmatter.Title != "Sketch" {
+		t.Errorf("expected the frontmatter to be parsed, got %+v", generic.Frontmatter)
+	}
+	if got := parser.DisplayName("sketch"); got != "sketch" {
+		t.Errorf("DisplayName() = %q, want sketch", got)
+	}
+}
+
+func TestRegisteredTypes(t *testing.T) {
+	types := parser.Types()
+	for _, want := range []string{"flowchart", "stateDiagram-v2", "c4Deployment", "dialect", "sketch"} {
+		if !slices.Contains(types, want) {
+			t.Errorf("Types() is missing %q: %v", want, types)
+		}
+	}
+
+	_, err := parser.Parse("unknownDiagram\n    a")
+	if err == nil || !strings.Contains(err.Error(), "dialect, sketch") {
+		t.Errorf("expected the registered types to be listed, got %v", err)
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefixes []parser.HeaderPrefix
+	}{
+		{name: "duplicate prefix", prefixes: []parser.HeaderPrefix{{Prefix: "flowchart"}}},
+		{name: "empty prefix", prefixes: []parser.HeaderPrefix{{Type: "nameless"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected Register to panic")
+				}
+			}()
+			parser.Register(tt.prefixes, nil)
+		})
+	}
+}
+
+func TestDetectTypeLongestPrefix(t *testing.T) {
+	// "stateDiagram" also matches, but the longer prefix wins
+	if got := parser.DetectType("stateDiagram-v2\n    [*] --> A"); got != "stateDiagram-v2" {
+		t.Errorf("DetectType() = %q, want stateDiagram-v2", got)
+	}
+}
@@ -3,6 +3,7 @@ package parser_test
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/sammcj/mermaid-check/ast"
@@ -171,3 +172,299 @@ func TestSequenceParser_Messages(t *testing.T) {
 		})
 	}
 }
+
+func TestSequenceParser_Participants(t *testing.T) {
+	p := parser.NewSequenceParser()
+
+	tests := []struct {
+		name      string
+		line      string
+		wantID    string
+		wantAlias string
+		wantType  string
+		wantProps map[string]string
+	}{
+		{"participant", "participant A", "A", "", "participant", nil},
+		{"actor with alias", "actor U as User", "U", "User", "actor", nil},
+		{"multi-word alias", "participant API as Orders API (v2) & friends: \"beta\"", "API", "Orders API (v2) & friends: \"beta\"", "participant", nil},
+		{"typed", `participant DB@{ "type": "database" }`, "DB", "", "database", map[string]string{"type": "database"}},
+		{"typed with alias", `participant Q@{ "type" : "queue" } as Job queue`, "Q", "Job queue", "queue", map[string]string{"type": "queue"}},
+		{"properties without type", `actor U@{ "note": "x, y" }`, "U", "", "actor", map[string]string{"note": "x, y"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagram, err := p.Parse("sequenceDiagram\n    " + tt.line)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			participant, ok := diagram.(*ast.SequenceDiagram).Statements[0].(*ast.Participant)
+			if !ok {
+				t.Fatalf("first statement is not a participant: %T", diagram.(*ast.SequenceDiagram).Statements[0])
+			}
+			if participant.ID != tt.wantID || participant.Alias != tt.wantAlias || participant.Type != tt.wantType {
+				t.Errorf("participant = {%q %q %q}, want {%q %q %q}", participant.ID, participant.Alias, participant.Type, tt.wantID, tt.wantAlias, tt.wantType)
+			}
+			if !reflect.DeepEqual(participant.Properties, tt.wantProps) {
+				t.Errorf("Properties = %v, want %v", participant.Properties, tt.wantProps)
+			}
+			if participant.Pos.Column != 5 || participant.End != pos(2, len(tt.line)+5, len(tt.line)+20) {
+				t.Errorf("participant runs from %+v to %+v", participant.Pos, participant.End)
+			}
+		})
+	}
+}
+
+func TestSequenceParser_ParticipantTypeSpan(t *testing.T) {
+	diagram, err := parser.NewSequenceParser().Parse("sequenceDiagram\n    participant DB@{ \"type\": \"database\" }")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	participant := diagram.(*ast.SequenceDiagram).Statements[0].(*ast.Participant)
+	// sequenceDiagram\n is 16 bytes, and "database" starts at byte 30 of the line
+	if want := span(2, 31, 46, 39, 54); participant.TypeSpan != want {
+		t.Errorf("TypeSpan = %+v, want %+v", participant.TypeSpan, want)
+	}
+}
+
+func TestSequenceParser_CreateDestroy(t *testing.T) {
+	source := `sequenceDiagram
+    Alice->>Bob: Hello
+    create participant Carl as Carl Jr.
+    Alice->>Carl: Hi Carl!
+    create actor D@{ "type": "control" }
+    Carl->>D: Hi!
+    destroy Carl
+    Alice-xCarl: We are too many`
+
+	diagram, err := parser.NewSequenceParser().Parse(source)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	statements := diagram.(*ast.SequenceDiagram).Statements
+	if len(statements) != 7 {
+		t.Fatalf("expected 7 statements, got %d", len(statements))
+	}
+
+	create, ok := statements[1].(*ast.Create)
+	if !ok {
+		t.Fatalf("statement 1 is %T, want *ast.Create", statements[1])
+	}
+	if p := create.Participant; p.ID != "Carl" || p.Alias != "Carl Jr." || p.Type != "participant" {
+		t.Errorf("unexpected created participant: %+v", p)
+	}
+	if create.Pos != pos(3, 5, 43) || create.Participant.Pos != pos(3, 12, 50) || create.End != create.Participant.End {
+		t.Errorf("create runs from %+v to %+v, participant from %+v", create.Pos, create.End, create.Participant.Pos)
+	}
+
+	if create, ok := statements[3].(*ast.Create); !ok || create.Participant.ID != "D" || create.Participant.Type != "control" {
+		t.Errorf("statement 3 = %+v, want a created control D", statements[3])
+	}
+
+	destroy, ok := statements[5].(*ast.Destroy)
+	if !ok {
+		t.Fatalf("statement 5 is %T, want *ast.Destroy", statements[5])
+	}
+	if destroy.Participant != "Carl" || destroy.ParticipantSpan.Start.Line != 7 || destroy.ParticipantSpan.Start.Column != 13 {
+		t.Errorf("unexpected destroy: %+v", destroy)
+	}
+}
+
+func TestSequenceParser_InvalidParticipantProperties(t *testing.T) {
+	source := "sequenceDiagram\n    participant DB@{ database }\n    DB->>A: Hi"
+	diagram, err := parser.NewSequenceParser().Parse(source)
+	errs := parser.Errors(err)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", err)
+	}
+	e := errs[0]
+	if e.Message != "invalid properties for participant 'DB'" || e.Found != " database " || e.Expected != "key: value pairs" {
+		t.Errorf("unexpected error: %+v", e)
+	}
+	if e.Pos != pos(2, 21, 36) {
+		t.Errorf("error at %+v, want 2:21", e.Pos)
+	}
+	if statements := diagram.(*ast.SequenceDiagram).Statements; len(statements) != 1 {
+		t.Errorf("expected only the message to be parsed, got %d statements", len(statements))
+	}
+}
+
+func TestSequenceParser_Rect(t *testing.T) {
+	source := `sequenceDiagram
+    rect rgb(191, 223, 255)
+        Alice->>Bob: Hello
+        alt ok
+            rect rgba(0, 0, 255, .1)
+                Bob->>Alice: Hi
+            end
+        end
+    end
+    Alice->>Bob: Bye`
+
+	diagram, err := parser.NewSequenceParser().Parse(source)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	statements := diagram.(*ast.SequenceDiagram).Statements
+	if len(statements) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(statements))
+	}
+	rect, ok := statements[0].(*ast.Rect)
+	if !ok {
+		t.Fatalf("first statement is %T, want *ast.Rect", statements[0])
+	}
+	if rect.Colour != "rgb(191, 223, 255)" || rect.ColourSpan != span(2, 10, 25, 28, 43) || rect.End != pos(9, 8, 190) {
+		t.Errorf("unexpected rect: %+v", rect)
+	}
+	if len(rect.Statements) != 2 {
+		t.Fatalf("expected 2 statements in the rect, got %d", len(rect.Statements))
+	}
+	alt := rect.Statements[1].(*ast.Alt)
+	if inner, ok := alt.Conditions[0].Statements[0].(*ast.Rect); !ok || inner.Colour != "rgba(0, 0, 255, .1)" || len(inner.Statements) != 1 {
+		t.Errorf("unexpected nested rect: %+v", alt.Conditions[0].Statements[0])
+	}
+}
+
+func TestSequenceParser_MenuLinks(t *testing.T) {
+	source := `sequenceDiagram
+    link Alice: Dashboard @ https://dashboard.contoso.com/alice
+    links Bob: {"Wiki": "https://wiki.contoso.com/bob", "On call": "https://oncall.contoso.com"}
+    properties Bob: {"class": "internal-service-actor", "icon": "@clipboard"}`
+
+	diagram, err := parser.NewSequenceParser().Parse(source)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	statements := diagram.(*ast.SequenceDiagram).Statements
+	if len(statements) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(statements))
+	}
+
+	link := statements[0].(*ast.ParticipantLinks)
+	want := []ast.MenuLink{{
+		Label:     "Dashboard",
+		URL:       "https://dashboard.contoso.com/alice",
+		LabelSpan: span(2, 17, 32, 26, 41),
+		URLSpan:   span(2, 29, 44, 64, 79),
+	}}
+	if link.Participant != "Alice" || link.JSON || !reflect.DeepEqual(link.Links, want) {
+		t.Errorf("unexpected link: %+v", link)
+	}
+
+	links := statements[1].(*ast.ParticipantLinks)
+	want = []ast.MenuLink{
+		{Label: "Wiki", URL: "https://wiki.contoso.com/bob", LabelSpan: span(3, 18, 97, 22, 101), URLSpan: span(3, 26, 105, 54, 133)},
+		{Label: "On call", URL: "https://oncall.contoso.com", LabelSpan: span(3, 58, 137, 65, 144), URLSpan: span(3, 69, 148, 95, 174)},
+	}
+	if links.Participant != "Bob" || !links.JSON || !reflect.DeepEqual(links.Links, want) {
+		t.Errorf("unexpected links: %+v", links)
+	}
+
+	props := statements[2].(*ast.ParticipantProperties)
+	wantProps := map[string]string{"class": "internal-service-actor", "icon": "@clipboard"}
+	if props.Participant != "Bob" || !reflect.DeepEqual(props.Properties, wantProps) {
+		t.Errorf("unexpected properties: %+v", props)
+	}
+}
+
+func TestSequenceParser_InvalidMenuLinks(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`links A: {"Wiki": "https://a"`, "invalid links for participant 'A': unexpected end of JSON input"},
+		{`links A: ["https://a"]`, "invalid links for participant 'A': expected a JSON object"},
+		{`links A: {"Wiki": 5}`, "invalid links for participant 'A': expected a string, found 5"},
+		{`links A: {"Wiki": "https://a"} extra`, "invalid links for participant 'A': unexpected text after JSON object"},
+		{`properties A: {"class" "x"}`, "invalid properties for participant 'A': invalid character '\"' after object key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			_, err := parser.NewSequenceParser().Parse("sequenceDiagram\n    " + tt.line)
+			errs := parser.Errors(err)
+			if len(errs) != 1 || errs[0].Message != tt.want {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSequenceParser_Autonumber(t *testing.T) {
+	tests := []struct {
+		line        string
+		enabled     bool
+		start, step int
+	}{
+		{"autonumber", true, 1, 1},
+		{"autonumber 10", true, 10, 1},
+		{"autonumber 10 5", true, 10, 5},
+		{"autonumber off", false, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			diagram, err := parser.NewSequenceParser().Parse("sequenceDiagram\n    " + tt.line)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			a, ok := diagram.(*ast.SequenceDiagram).Statements[0].(*ast.Autonumber)
+			if !ok {
+				t.Fatalf("first statement is %T, want *ast.Autonumber", diagram.(*ast.SequenceDiagram).Statements[0])
+			}
+			if a.Enabled != tt.enabled || a.Start != tt.start || a.Step != tt.step {
+				t.Errorf("autonumber = {%v %d %d}, want {%v %d %d}", a.Enabled, a.Start, a.Step, tt.enabled, tt.start, tt.step)
+			}
+		})
+	}
+
+	diagram, err := parser.NewSequenceParser().Parse("sequenceDiagram\n    autonumber 10 5")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	a := diagram.(*ast.SequenceDiagram).Statements[0].(*ast.Autonumber)
+	if a.StartSpan != span(2, 16, 31, 18, 33) || a.StepSpan != span(2, 19, 34, 20, 35) {
+		t.Errorf("spans = %+v, %+v", a.StartSpan, a.StepSpan)
+	}
+
+	diagram, err = parser.NewSequenceParser().Parse("sequenceDiagram\n    autonumber on")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if _, ok := diagram.(*ast.SequenceDiagram).Statements[0].(*ast.UnknownStatement); !ok {
+		t.Errorf("expected autonumber on to be kept as an unknown statement, got %T", diagram.(*ast.SequenceDiagram).Statements[0])
+	}
+}
+
+func TestSequenceParser_ActivationMarkers(t *testing.T) {
+	d, err := parser.NewSequenceParser().Parse("sequenceDiagram\n    A->>+B: Hi\n    B-->>-A: Bye -\n    A->>B-c: x+")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	tests := []struct {
+		from, to, text       string
+		activate, deactivate bool
+	}{
+		{"A", "B", "Hi", true, false},
+		{"B", "A", "Bye -", false, true},
+		{"A", "B-c", "x+", false, false},
+	}
+	statements := d.(*ast.SequenceDiagram).Statements
+	if len(statements) != len(tests) {
+		t.Fatalf("expected %d statements, got %d", len(tests), len(statements))
+	}
+	for i, tt := range tests {
+		msg := statements[i].(*ast.Message)
+		if msg.From != tt.from || msg.To != tt.to || msg.Text != tt.text || msg.Activate != tt.activate || msg.Deactivate != tt.deactivate {
+			t.Errorf("message %d = %+v, want %+v", i, msg, tt)
+		}
+	}
+
+	msg := statements[0].(*ast.Message)
+	if got, want := msg.ArrowSpan, span(2, 6, 21, 9, 24); got != want {
+		t.Errorf("ArrowSpan = %+v, want %+v", got, want)
+	}
+	if got, want := msg.ToSpan, span(2, 10, 25, 11, 26); got != want {
+		t.Errorf("ToSpan = %+v, want %+v", got, want)
+	}
+}
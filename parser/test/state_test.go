@@ -72,3 +72,57 @@ func TestStateParser_SupportedTypes(t *testing.T) {
 		t.Errorf("SupportedTypes() = %v, want 2 types", types)
 	}
 }
+
+func TestStateParser_CompositeStates(t *testing.T) {
+	source := `stateDiagram-v2
+    state Moving {
+        [*] --> Slow
+        Slow --> Fast
+    }
+    Moving : Going places
+    Moving : quickly
+    [*] --> Moving
+    stat Typo`
+
+	diagram, err := parser.NewStateParser().Parse(source)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	stmts := diagram.(*ast.StateDiagram).Statements
+	if len(stmts) != 3 {
+		t.Fatalf("expected 3 statements, got %d: %+v", len(stmts), stmts)
+	}
+
+	moving, ok := stmts[0].(*ast.State)
+	if !ok {
+		t.Fatalf("expected *ast.State, got %T", stmts[0])
+	}
+	if !moving.IsComposite || len(moving.Nested) != 2 {
+		t.Errorf("expected composite state with 2 nested statements, got %+v", moving)
+	}
+	if moving.Description != "Going places\nquickly" {
+		t.Errorf("expected joined description, got %q", moving.Description)
+	}
+
+	unknown, ok := stmts[2].(*ast.UnknownStatement)
+	if !ok {
+		t.Fatalf("expected *ast.UnknownStatement, got %T", stmts[2])
+	}
+	if unknown.Text != "stat Typo" || unknown.Pos.Line != 9 || unknown.Pos.Column != 5 {
+		t.Errorf("unexpected unknown statement %+v", unknown)
+	}
+}
+
+func TestStateParser_UnclosedComposite(t *testing.T) {
+	source := `stateDiagram-v2
+    state Moving {
+        [*] --> Slow`
+
+	diagram, err := parser.NewStateParser().Parse(source)
+	if err == nil {
+		t.Fatal("expected error for unclosed composite state")
+	}
+	if diagram == nil {
+		t.Fatal("expected partial diagram")
+	}
+}
@@ -0,0 +1,237 @@
+package parser_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sammcj/mermaid-check/ast"
+	"github.com/sammcj/mermaid-check/parser"
+)
+
+func TestParseUnicodeIdentifiers(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		ids    func(ast.Diagram) []string // Identifiers in the order they are parsed
+		want   []string
+	}{
+		{
+			name: "flowchart",
+			source: `flowchart LR
+    開始[開始] --> проверка{Проверка?}
+    проверка -->|да| café-crème(Café crème)
+    subgraph 子图 [Sous-système]
+        naïve
+    end
+    classDef важный fill:#f96
+    class naïve,café-crème важный
+    style 開始 fill:#fff`,
+			ids: func(d ast.Diagram) []string {
+				var ids []string
+				var walk func([]ast.Statement)
+				walk = func(statements []ast.Statement) {
+					for _, stmt := range statements {
+						switch s := stmt.(type) {
+						case *ast.Link:
+							ids = append(ids, s.From+">"+s.To)
+						case *ast.NodeDef:
+							ids = append(ids, s.ID)
+						case *ast.Subgraph:
+							ids = append(ids, s.ID)
+							walk(s.Statements)
+						case *ast.ClassDef:
+							ids = append(ids, s.Name)
+						case *ast.ClassAssignment:
+							ids = append(ids, s.NodeIDs...)
+							ids = append(ids, s.ClassName)
+						case *ast.Style:
+							ids = append(ids, s.NodeID)
+						}
+					}
+				}
+				walk(d.(*ast.Flowchart).Statements)
+				return ids
+			},
+			want: []string{"開始", "開始>проверка", "проверка", "проверка>café-crème", "café-crème", "子图", "naïve", "важный", "naïve", "café-crème", "важный", "開始"},
+		},
+		{
+			name: "sequence",
+			source: `sequenceDiagram
+    participant Алиса as Alice
+    actor 用户-端
+    Алиса->>用户-端: Привет
+    用户-端-->>Алиса: 你好
+    activate Алиса
+    Note over Алиса,用户-端: Größe
+    Note right of Zoë: Salut`,
+			ids: func(d ast.Diagram) []string {
+				var ids []string
+				for _, stmt := range d.(*ast.SequenceDiagram).Statements {
+					switch s := stmt.(type) {
+					case *ast.Participant:
+						ids = append(ids, s.ID)
+					case *ast.Message:
+						ids = append(ids, s.From+">"+s.To)
+					case *ast.Activation:
+						ids = append(ids, s.Participant)
+					case *ast.Note:
+						ids = append(ids, s.Participants...)
+					}
+				}
+				return ids
+			},
+			want: []string{"Алиса", "用户-端", "Алиса>用户-端", "用户-端>Алиса", "Алиса", "Алиса", "用户-端", "Zoë"},
+		},
+		{
+			name: "er",
+			source: `erDiagram
+    客户 ||--o{ заказ : places
+    "Compte client" {
+        chaîne nom PK
+        entier *numéro
+    }
+    Élément-ligne
+    "Compte client" ||--|| 客户 : owns`,
+			ids: func(d ast.Diagram) []string {
+				er := d.(*ast.ERDiagram)
+				var ids []string
+				for _, e := range er.Entities {
+					ids = append(ids, e.Name)
+					for _, a := range e.Attributes {
+						ids = append(ids, a.Type+" "+a.Name)
+					}
+				}
+				for _, r := range er.Relationships {
+					ids = append(ids, r.From+">"+r.To)
+				}
+				return ids
+			},
+			want: []string{"Compte client", "chaîne nom", "entier numéro", "Élément-ligne", "客户>заказ", "Compte client>客户"},
+		},
+		{
+			name: "state",
+			source: `stateDiagram-v2
+    state "Ожидание" as ожидание
+    ожидание --> 完了 : 終わり
+    state Décision <<choice>>`,
+			ids: func(d ast.Diagram) []string {
+				var ids []string
+				for _, stmt := range d.(*ast.StateDiagram).Statements {
+					switch s := stmt.(type) {
+					case *ast.State:
+						ids = append(ids, s.ID)
+					case *ast.Transition:
+						ids = append(ids, s.From+">"+s.To)
+					case *ast.Choice:
+						ids = append(ids, s.ID)
+					}
+				}
+				return ids
+			},
+			want: []string{"ожидание", "ожидание>完了", "Décision"},
+		},
+		{
+			name: "class",
+			source: `classDiagram
+    class Véhicule {
+        +démarrer()
+    }
+    Véhicule <|-- Автомобиль
+    Автомобиль : +int колёса
+    note for 汽车 "注释"`,
+			ids: func(d ast.Diagram) []string {
+				var ids []string
+				for _, stmt := range d.(*ast.ClassDiagram).Statements {
+					switch s := stmt.(type) {
+					case *ast.Class:
+						ids = append(ids, s.Name)
+						for _, m := range s.Members {
+							ids = append(ids, m.Name)
+						}
+					case *ast.Relationship:
+						ids = append(ids, s.From+">"+s.To)
+					case *ast.ClassNote:
+						ids = append(ids, s.ClassName)
+					}
+				}
+				return ids
+			},
+			want: []string{"Véhicule", "démarrer", "Véhicule>Автомобиль", "Автомобиль", "колёса", "汽车"},
+		},
+		{
+			name: "gitGraph",
+			source: `gitGraph
+    commit
+    branch функция-1
+    checkout функция-1
+    commit
+    checkout main
+    merge функция-1`,
+			ids: func(d ast.Diagram) []string {
+				var ids []string
+				for _, op := range d.(*ast.GitGraphDiagram).Operations {
+					if op.BranchName != "" {
+						ids = append(ids, op.Type+" "+op.BranchName)
+					}
+				}
+				return ids
+			},
+			want: []string{"branch функция-1", "checkout функция-1", "checkout main", "merge функция-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := parser.Parse(tt.source)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if got := tt.ids(d); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("identifiers = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUnicodeIdentifierSpans(t *testing.T) {
+	d, err := parser.Parse("flowchart LR\n    開始 --> café-crème")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	link := d.(*ast.Flowchart).Statements[0].(*ast.Link)
+	// Columns count bytes, and 開始 is six bytes long
+	if got, want := link.FromSpan, span(2, 5, 17, 11, 23); got != want {
+		t.Errorf("FromSpan = %+v, want %+v", got, want)
+	}
+	if got, want := link.ToSpan, span(2, 16, 28, 28, 40); got != want {
+		t.Errorf("ToSpan = %+v, want %+v", got, want)
+	}
+
+	d, err = parser.Parse("erDiagram\n    \"Compte client\" ||--|| 客户 : owns")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	rel := d.(*ast.ERDiagram).Relationships[0]
+	if got, want := rel.FromSpan, span(2, 6, 15, 19, 28); got != want {
+		t.Errorf("quoted FromSpan = %+v, want %+v", got, want)
+	}
+	if got, want := rel.ToSpan, span(2, 28, 37, 34, 43); got != want {
+		t.Errorf("ToSpan = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseHyphenatedIdentifiers(t *testing.T) {
+	// A hyphen joins two parts of an identifier, but never starts an arrow
+	d, err := parser.Parse("flowchart LR\n    a-b-->c---d-e-.->f")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	var got []string
+	for _, stmt := range d.(*ast.Flowchart).Statements {
+		link := stmt.(*ast.Link)
+		got = append(got, link.From+link.Arrow+link.To)
+	}
+	if want := []string{"a-b-->c", "c---d-e", "d-e-.->f"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("links = %q, want %q", got, want)
+	}
+}
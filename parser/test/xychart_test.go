@@ -234,13 +234,18 @@ func TestXYChartParser_Parse(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "unrecognised line",
+			name: "unrecognised line kept",
 			source: `xychart-beta
     x-axis [a, b]
     y-axis "Y" 0 --> 10
     invalid line here
     bar [5, 8]`,
-			wantErr: true,
+			check: func(t *testing.T, d ast.Diagram) {
+				unknown := d.(*ast.XYChartDiagram).Unknown
+				if len(unknown) != 1 || unknown[0].Text != "invalid line here" {
+					t.Errorf("expected the unknown line to be kept, got %+v", unknown)
+				}
+			},
 		},
 	}
 
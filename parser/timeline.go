@@ -1,7 +1,7 @@
 package parser
 
 import (
-	"fmt"
+	"context"
 	"regexp"
 	"strings"
 
@@ -25,34 +25,53 @@ var (
 
 // Parse parses a timeline diagram source.
 func (p *TimelineParser) Parse(source string) (ast.Diagram, error) {
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses a timeline diagram source within the limits in opts.
+func (p *TimelineParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseLimited(ctx, source, opts, func(lim *limiter) (ast.Diagram, error) {
+		return p.parse(source, lim)
+	})
+}
+
+func (p *TimelineParser) parse(source string, lim *limiter) (ast.Diagram, error) {
 	lines := strings.Split(source, "\n")
 	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty diagram source")
+		return nil, emptySourceError("timeline")
+	}
+
+	var errs ErrorList
+	meta, header, err := parseMetadata(lines, "timeline", &errs)
+	if err != nil {
+		return nil, err
 	}
 
 	// Verify header
-	firstLine := strings.TrimSpace(lines[0])
+	firstLine := strings.TrimSpace(lines[header])
 	if firstLine != "timeline" {
-		return nil, fmt.Errorf("invalid timeline header: expected 'timeline', got %q", firstLine)
+		return nil, headerError("timeline", header+1, lines[header], "'timeline'")
 	}
 
 	diagram := &ast.TimelineDiagram{
 		Type:     "timeline",
 		Source:   source,
 		Sections: []ast.TimelineSection{},
-		Pos:      ast.Position{Line: 1, Column: 1},
+		Pos:      linePos(header+1, lines[header]),
+		End:      ast.SourceEnd(source),
+		Metadata: meta,
 	}
 
 	// Start with default section (no name)
 	currentSection := &ast.TimelineSection{
 		Name:    "",
 		Periods: []ast.TimelinePeriod{},
-		Pos:     ast.Position{Line: 1, Column: 1},
+		Pos:     diagram.Pos,
 	}
 
 	var currentPeriod *ast.TimelinePeriod
 
-	for i := 1; i < len(lines); i++ {
+	for i := header + 1; i < len(lines); i++ {
 		line := lines[i]
 		trimmed := strings.TrimSpace(line)
 
@@ -60,17 +79,21 @@ func (p *TimelineParser) Parse(source string) (ast.Diagram, error) {
 		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
 			continue
 		}
+		if !lim.statement(i + 1) {
+			break
+		}
 
 		lineNum := i + 1
+		span := textSpan(lineNum, line)
 
 		// Check for title
-		if matches := timelineTitleRegex.FindStringSubmatch(trimmed); matches != nil {
-			diagram.Title = strings.TrimSpace(matches[1])
+		if m := matchTrimmed(timelineTitleRegex, line, lineNum); m != nil {
+			diagram.Title, diagram.TitleSpan = m.trimmed(1)
 			continue
 		}
 
 		// Check for section
-		if matches := timelineSectionRegex.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(timelineSectionRegex, line, lineNum); m != nil {
 			// Save current period to current section before switching sections
 			if currentPeriod != nil {
 				currentSection.Periods = append(currentSection.Periods, *currentPeriod)
@@ -83,64 +106,74 @@ func (p *TimelineParser) Parse(source string) (ast.Diagram, error) {
 
 			// Start new section
 			currentSection = &ast.TimelineSection{
-				Name:    strings.TrimSpace(matches[1]),
 				Periods: []ast.TimelinePeriod{},
-				Pos:     ast.Position{Line: lineNum, Column: 1},
+				Pos:     span.Start,
+				End:     span.End,
 			}
+			currentSection.Name, currentSection.NameSpan = m.trimmed(1)
 			currentPeriod = nil
 			continue
 		}
 
 		// Check for continuation event (leading colon)
-		if matches := timelineEventRegex.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(timelineEventRegex, line, lineNum); m != nil {
 			if currentPeriod == nil {
-				return nil, fmt.Errorf("line %d: event continuation without time period", lineNum)
+				errs.addLine(lineNum, line, "event continuation without time period")
+				continue
 			}
-			event := strings.TrimSpace(matches[1])
+			event, eventSpan := m.trimmed(1)
 			if event == "" {
-				return nil, fmt.Errorf("line %d: empty event", lineNum)
+				errs.addLine(lineNum, line, "empty event")
+				continue
 			}
 			currentPeriod.Events = append(currentPeriod.Events, event)
+			currentPeriod.EventSpans = append(currentPeriod.EventSpans, eventSpan)
+			currentPeriod.End = span.End
 			continue
 		}
 
 		// Check for period with events
-		if matches := timelinePeriodRegex.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(timelinePeriodRegex, line, lineNum); m != nil {
 			// Save previous period if exists
 			if currentPeriod != nil {
 				currentSection.Periods = append(currentSection.Periods, *currentPeriod)
 			}
 
-			timePeriod := strings.TrimSpace(matches[1])
+			timePeriod, periodSpan := m.trimmed(1)
 			if timePeriod == "" {
-				return nil, fmt.Errorf("line %d: empty time period", lineNum)
+				errs.addLine(lineNum, line, "empty time period")
+				continue
 			}
 
 			// Parse events (colon-separated)
-			eventsStr := strings.TrimSpace(matches[2])
 			events := []string{}
-			if eventsStr != "" {
-				for event := range strings.SplitSeq(eventsStr, ":") {
-					event = strings.TrimSpace(event)
-					if event != "" {
-						events = append(events, event)
-					}
+			var eventSpans []ast.Span
+			parts, partSpans := splitTrimmed(m.groups[2], m.span(2), ":")
+			for j, event := range parts {
+				if event != "" {
+					events = append(events, event)
+					eventSpans = append(eventSpans, partSpans[j])
 				}
 			}
 
 			if len(events) == 0 {
-				return nil, fmt.Errorf("line %d: time period %q has no events", lineNum, timePeriod)
+				errs.addLine(lineNum, line, "time period %q has no events", timePeriod)
+				continue
 			}
 
 			currentPeriod = &ast.TimelinePeriod{
-				TimePeriod: timePeriod,
-				Events:     events,
-				Pos:        ast.Position{Line: lineNum, Column: 1},
+				TimePeriod:     timePeriod,
+				Events:         events,
+				Pos:            span.Start,
+				End:            span.End,
+				TimePeriodSpan: periodSpan,
+				EventSpans:     eventSpans,
 			}
 			continue
 		}
 
-		return nil, fmt.Errorf("line %d: invalid timeline syntax: %s", lineNum, trimmed)
+		// Keep lines we can't parse so validators can report them
+		diagram.Unknown = append(diagram.Unknown, unknownStatement(lineNum, line))
 	}
 
 	// Save last period if exists
@@ -155,10 +188,10 @@ func (p *TimelineParser) Parse(source string) (ast.Diagram, error) {
 
 	// Validate we have at least one period
 	if len(diagram.Sections) == 0 {
-		return nil, fmt.Errorf("timeline must have at least one time period")
+		errs.Add(ast.Position{}, "timeline must have at least one time period")
 	}
 
-	return diagram, nil
+	return diagram, errs.errFor(diagram.GetType())
 }
 
 // SupportedTypes returns the diagram types this parser supports.
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -30,9 +31,26 @@ var (
 
 // Parse parses an XY chart diagram source.
 func (p *XYChartParser) Parse(source string) (ast.Diagram, error) {
+	return p.ParseWithOptions(context.Background(), source, ParseOptions{})
+}
+
+// ParseWithOptions parses an XY chart diagram source within the limits in opts.
+func (p *XYChartParser) ParseWithOptions(ctx context.Context, source string, opts ParseOptions) (ast.Diagram, error) {
+	return parseLimited(ctx, source, opts, func(lim *limiter) (ast.Diagram, error) {
+		return p.parse(source, lim)
+	})
+}
+
+func (p *XYChartParser) parse(source string, lim *limiter) (ast.Diagram, error) {
 	lines := strings.Split(source, "\n")
 	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty diagram source")
+		return nil, emptySourceError("xyChart")
+	}
+
+	var errs ErrorList
+	meta, header, err := parseMetadata(lines, "xyChart", &errs)
+	if err != nil {
+		return nil, err
 	}
 
 	diagram := &ast.XYChartDiagram{
@@ -40,14 +58,16 @@ func (p *XYChartParser) Parse(source string) (ast.Diagram, error) {
 		Orientation: "vertical", // Default orientation
 		Source:      source,
 		Series:      []ast.XYChartSeries{},
-		Pos:         ast.Position{Line: 1, Column: 1},
+		Pos:         linePos(header+1, lines[header]),
+		End:         ast.SourceEnd(source),
+		Metadata:    meta,
 	}
 
 	// Parse header line
-	firstLine := strings.TrimSpace(lines[0])
+	firstLine := strings.TrimSpace(lines[header])
 	matches := xyChartHeaderRegex.FindStringSubmatch(firstLine)
 	if matches == nil {
-		return nil, fmt.Errorf("invalid xychart header: %s", firstLine)
+		return nil, headerError("xyChart", header+1, lines[header], "'xychart-beta'")
 	}
 
 	// Set orientation if specified
@@ -59,7 +79,7 @@ func (p *XYChartParser) Parse(source string) (ast.Diagram, error) {
 	yAxisDefined := false
 
 	// Parse remaining lines
-	for i := 1; i < len(lines); i++ {
+	for i := header + 1; i < len(lines); i++ {
 		line := lines[i]
 		trimmed := strings.TrimSpace(line)
 
@@ -67,168 +87,201 @@ func (p *XYChartParser) Parse(source string) (ast.Diagram, error) {
 		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
 			continue
 		}
+		if !lim.statement(i + 1) {
+			break
+		}
 
 		lineNum := i + 1
+		span := textSpan(lineNum, line)
 
 		// Try to parse title
-		if matches := xyChartTitleRegex.FindStringSubmatch(trimmed); matches != nil {
-			diagram.Title = matches[1]
+		if m := matchTrimmed(xyChartTitleRegex, line, lineNum); m != nil {
+			diagram.Title = m.groups[1]
+			diagram.TitleSpan = m.span(1)
 			continue
 		}
 
 		// Try to parse categorical x-axis
-		if matches := xyChartXAxisCatRegex.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(xyChartXAxisCatRegex, line, lineNum); m != nil {
 			if xAxisDefined {
-				return nil, fmt.Errorf("line %d: x-axis already defined", lineNum)
+				errs.addLine(lineNum, line, "x-axis already defined")
+				continue
 			}
-			categories := parseCategories(matches[1])
+			categories, categorySpans := parseCategories(m.groups[1], m.span(1))
 			diagram.XAxis = ast.XYChartAxis{
-				Categories: categories,
-				IsNumeric:  false,
-				Pos:        ast.Position{Line: lineNum, Column: 1},
+				Categories:    categories,
+				IsNumeric:     false,
+				Pos:           span.Start,
+				End:           span.End,
+				CategorySpans: categorySpans,
 			}
 			xAxisDefined = true
 			continue
 		}
 
 		// Try to parse numeric x-axis
-		if matches := xyChartXAxisNumRegex.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(xyChartXAxisNumRegex, line, lineNum); m != nil {
 			if xAxisDefined {
-				return nil, fmt.Errorf("line %d: x-axis already defined", lineNum)
+				errs.addLine(lineNum, line, "x-axis already defined")
+				continue
 			}
-			minVal, err := strconv.ParseFloat(matches[2], 64)
+			minVal, err := strconv.ParseFloat(m.groups[2], 64)
 			if err != nil {
-				return nil, fmt.Errorf("line %d: invalid x-axis minimum: %s", lineNum, matches[2])
+				errs.addLine(lineNum, line, "invalid x-axis minimum: %s", m.groups[2])
+				continue
 			}
-			maxVal, err := strconv.ParseFloat(matches[3], 64)
+			maxVal, err := strconv.ParseFloat(m.groups[3], 64)
 			if err != nil {
-				return nil, fmt.Errorf("line %d: invalid x-axis maximum: %s", lineNum, matches[3])
+				errs.addLine(lineNum, line, "invalid x-axis maximum: %s", m.groups[3])
+				continue
 			}
 			diagram.XAxis = ast.XYChartAxis{
-				Label:     matches[1],
+				Label:     m.groups[1],
 				Min:       minVal,
 				Max:       maxVal,
 				IsNumeric: true,
-				Pos:       ast.Position{Line: lineNum, Column: 1},
+				Pos:       span.Start,
+				End:       span.End,
+				LabelSpan: m.span(1),
+				MinSpan:   m.span(2),
+				MaxSpan:   m.span(3),
 			}
 			xAxisDefined = true
 			continue
 		}
 
 		// Try to parse categorical y-axis
-		if matches := xyChartYAxisCatRegex.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(xyChartYAxisCatRegex, line, lineNum); m != nil {
 			if yAxisDefined {
-				return nil, fmt.Errorf("line %d: y-axis already defined", lineNum)
+				errs.addLine(lineNum, line, "y-axis already defined")
+				continue
 			}
-			categories := parseCategories(matches[1])
+			categories, categorySpans := parseCategories(m.groups[1], m.span(1))
 			diagram.YAxis = ast.XYChartAxis{
-				Categories: categories,
-				IsNumeric:  false,
-				Pos:        ast.Position{Line: lineNum, Column: 1},
+				Categories:    categories,
+				IsNumeric:     false,
+				Pos:           span.Start,
+				End:           span.End,
+				CategorySpans: categorySpans,
 			}
 			yAxisDefined = true
 			continue
 		}
 
 		// Try to parse numeric y-axis
-		if matches := xyChartYAxisNumRegex.FindStringSubmatch(trimmed); matches != nil {
+		if m := matchTrimmed(xyChartYAxisNumRegex, line, lineNum); m != nil {
 			if yAxisDefined {
-				return nil, fmt.Errorf("line %d: y-axis already defined", lineNum)
+				errs.addLine(lineNum, line, "y-axis already defined")
+				continue
 			}
-			minVal, err := strconv.ParseFloat(matches[2], 64)
+			minVal, err := strconv.ParseFloat(m.groups[2], 64)
 			if err != nil {
-				return nil, fmt.Errorf("line %d: invalid y-axis minimum: %s", lineNum, matches[2])
+				errs.addLine(lineNum, line, "invalid y-axis minimum: %s", m.groups[2])
+				continue
 			}
-			maxVal, err := strconv.ParseFloat(matches[3], 64)
+			maxVal, err := strconv.ParseFloat(m.groups[3], 64)
 			if err != nil {
-				return nil, fmt.Errorf("line %d: invalid y-axis maximum: %s", lineNum, matches[3])
+				errs.addLine(lineNum, line, "invalid y-axis maximum: %s", m.groups[3])
+				continue
 			}
 			diagram.YAxis = ast.XYChartAxis{
-				Label:     matches[1],
+				Label:     m.groups[1],
 				Min:       minVal,
 				Max:       maxVal,
 				IsNumeric: true,
-				Pos:       ast.Position{Line: lineNum, Column: 1},
+				Pos:       span.Start,
+				End:       span.End,
+				LabelSpan: m.span(1),
+				MinSpan:   m.span(2),
+				MaxSpan:   m.span(3),
 			}
 			yAxisDefined = true
 			continue
 		}
 
 		// Try to parse bar series
-		if matches := xyChartBarSeriesRegex.FindStringSubmatch(trimmed); matches != nil {
-			values, err := parseValues(matches[1])
+		if m := matchTrimmed(xyChartBarSeriesRegex, line, lineNum); m != nil {
+			values, valueSpans, err := parseValues(m.groups[1], m.span(1))
 			if err != nil {
-				return nil, fmt.Errorf("line %d: %v", lineNum, err)
+				errs.addLine(lineNum, line, "%v", err)
+				continue
 			}
 			diagram.Series = append(diagram.Series, ast.XYChartSeries{
-				Type:   "bar",
-				Values: values,
-				Pos:    ast.Position{Line: lineNum, Column: 1},
+				Type:       "bar",
+				Values:     values,
+				Pos:        span.Start,
+				End:        span.End,
+				ValueSpans: valueSpans,
 			})
 			continue
 		}
 
 		// Try to parse line series
-		if matches := xyChartLineSeriesRegex.FindStringSubmatch(trimmed); matches != nil {
-			values, err := parseValues(matches[1])
+		if m := matchTrimmed(xyChartLineSeriesRegex, line, lineNum); m != nil {
+			values, valueSpans, err := parseValues(m.groups[1], m.span(1))
 			if err != nil {
-				return nil, fmt.Errorf("line %d: %v", lineNum, err)
+				errs.addLine(lineNum, line, "%v", err)
+				continue
 			}
 			diagram.Series = append(diagram.Series, ast.XYChartSeries{
-				Type:   "line",
-				Values: values,
-				Pos:    ast.Position{Line: lineNum, Column: 1},
+				Type:       "line",
+				Values:     values,
+				Pos:        span.Start,
+				End:        span.End,
+				ValueSpans: valueSpans,
 			})
 			continue
 		}
 
-		// Unknown line format
-		return nil, fmt.Errorf("line %d: unrecognised xychart syntax: %s", lineNum, trimmed)
+		// Keep lines we can't parse so validators can report them
+		diagram.Unknown = append(diagram.Unknown, unknownStatement(lineNum, line))
 	}
 
 	// Validate required elements
 	if !xAxisDefined {
-		return nil, fmt.Errorf("xychart must define an x-axis")
+		errs.Add(ast.Position{}, "xychart must define an x-axis")
 	}
 	if !yAxisDefined {
-		return nil, fmt.Errorf("xychart must define a y-axis")
+		errs.Add(ast.Position{}, "xychart must define a y-axis")
 	}
 	if len(diagram.Series) == 0 {
-		return nil, fmt.Errorf("xychart must have at least one data series")
+		errs.Add(ast.Position{}, "xychart must have at least one data series")
 	}
 
-	return diagram, nil
+	return diagram, errs.errFor(diagram.GetType())
 }
 
-// parseCategories parses a comma-separated list of categories.
-func parseCategories(input string) []string {
-	parts := strings.Split(input, ",")
+// parseCategories parses a comma-separated list of categories located at span s.
+func parseCategories(input string, s ast.Span) ([]string, []ast.Span) {
+	parts, spans := splitTrimmed(input, s, ",")
 	categories := make([]string, 0, len(parts))
-	for _, part := range parts {
-		trimmed := strings.TrimSpace(part)
-		if trimmed != "" {
-			categories = append(categories, trimmed)
+	categorySpans := make([]ast.Span, 0, len(parts))
+	for i, part := range parts {
+		if part != "" {
+			categories = append(categories, part)
+			categorySpans = append(categorySpans, spans[i])
 		}
 	}
-	return categories
+	return categories, categorySpans
 }
 
-// parseValues parses a comma-separated list of numeric values.
-func parseValues(input string) ([]float64, error) {
-	parts := strings.Split(input, ",")
+// parseValues parses a comma-separated list of numeric values located at span s.
+func parseValues(input string, s ast.Span) ([]float64, []ast.Span, error) {
+	parts, spans := splitTrimmed(input, s, ",")
 	values := make([]float64, 0, len(parts))
-	for _, part := range parts {
-		trimmed := strings.TrimSpace(part)
-		if trimmed == "" {
+	valueSpans := make([]ast.Span, 0, len(parts))
+	for i, part := range parts {
+		if part == "" {
 			continue
 		}
-		value, err := strconv.ParseFloat(trimmed, 64)
+		value, err := strconv.ParseFloat(part, 64)
 		if err != nil {
-			return nil, fmt.Errorf("invalid numeric value: %s", trimmed)
+			return nil, nil, fmt.Errorf("invalid numeric value: %s", part)
 		}
 		values = append(values, value)
+		valueSpans = append(valueSpans, spans[i])
 	}
-	return values, nil
+	return values, valueSpans, nil
 }
 
 // SupportedTypes returns the diagram types this parser supports.
@@ -9,6 +9,8 @@ import (
 	mermaid "github.com/sammcj/mermaid-check"
 	"github.com/sammcj/mermaid-check/ast"
 	"github.com/sammcj/mermaid-check/extractor"
+	"github.com/sammcj/mermaid-check/parser"
+	"github.com/sammcj/mermaid-check/validator"
 )
 
 // TestMixedDiagramTypesInMarkdown tests parsing markdown with multiple diagram types.
@@ -66,6 +68,91 @@ func TestValidFlowchart(t *testing.T) {
 	}
 }
 
+// TestFlowchartLinkChains tests that chained and grouped links validate as
+// individual links rather than unrecognised lines.
+func TestFlowchartLinkChains(t *testing.T) {
+	source := "flowchart TD\n    A[Start] --> B --> C[End]\n    A & B --> D & E"
+	diagram, err := mermaid.Parse(source)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	errors := mermaid.Validate(diagram, true)
+	if len(errors) > 0 {
+		t.Errorf("Unexpected validation errors: %v", errors)
+	}
+
+	var links int
+	for _, stmt := range diagram.(*ast.Flowchart).Statements {
+		if _, ok := stmt.(*ast.Link); ok {
+			links++
+		}
+	}
+	if links != 6 {
+		t.Errorf("Expected 6 links, got %d", links)
+	}
+}
+
+// TestFlowchartStyling tests that style, linkStyle, click and ::: statements are
+// parsed and checked against the rest of the diagram.
+func TestFlowchartStyling(t *testing.T) {
+	source := "flowchart TD\n    A:::warn --> B\n    classDef warn fill:#f96\n    style B stroke:#333\n    click A href \"https://example.com\"\n    linkStyle 0 stroke:red"
+	diagram, err := mermaid.Parse(source)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if errors := mermaid.Validate(diagram, true); len(errors) > 0 {
+		t.Errorf("Unexpected validation errors: %v", errors)
+	}
+
+	diagram, err = mermaid.Parse(source + "\n    linkStyle 1 stroke:blue\n    style C fill:#fff")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	errors := mermaid.Validate(diagram, false)
+	if len(errors) != 2 {
+		t.Fatalf("Expected 2 validation errors, got %d: %v", len(errors), errors)
+	}
+}
+
+func TestFlowchartSubgraphLinks(t *testing.T) {
+	source := "flowchart LR\n    subgraph api [API Layer]\n        direction TB\n        handler --> service\n    end\n    subgraph db\n        store[(Store)]\n    end\n    api --> db\n    style api fill:#eef"
+	diagram, err := mermaid.Parse(source)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if errors := mermaid.Validate(diagram, true); len(errors) > 0 {
+		t.Errorf("Unexpected validation errors: %v", errors)
+	}
+
+	diagram, err = mermaid.Parse(source + "\n    api[API]")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	errors := mermaid.Validate(diagram, false)
+	if len(errors) != 1 || errors[0].Line != 11 {
+		t.Fatalf("Expected 1 validation error on line 11, got %v", errors)
+	}
+}
+
+func TestFlowchartQuotedLabels(t *testing.T) {
+	source := "flowchart LR\n    A[\"Start (here)\"] --> B[\"`**Check** [input]`\"]\n    B --> C[Done #40;ok#41;]"
+	diagram, err := mermaid.Parse(source)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if errors := mermaid.Validate(diagram, true); len(errors) > 0 {
+		t.Errorf("Unexpected validation errors: %v", errors)
+	}
+
+	diagram, err = mermaid.Parse(source + "\n    C --> D[Stop (now)]")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if errors := mermaid.Validate(diagram, true); len(errors) != 1 {
+		t.Errorf("Expected 1 validation error, got %v", errors)
+	}
+}
+
 // TestInvalidFlowchart tests an invalid flowchart that parses but has validation errors.
 func TestInvalidFlowchart(t *testing.T) {
 	// Flowcharts in Mermaid implicitly create nodes, so most "undefined" cases are actually valid
@@ -447,6 +534,37 @@ func TestValidateFlowchart(t *testing.T) {
 	}
 }
 
+// TestValidateChecksFrontmatter tests that Validate reports frontmatter problems for any diagram type.
+func TestValidateChecksFrontmatter(t *testing.T) {
+	source := "---\nconfig:\n  theme: sparkly\n---\npie\n    \"A\" : 1"
+
+	diagram, err := mermaid.Parse(source)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+
+	errors := mermaid.Validate(diagram, false)
+	if len(errors) != 1 || errors[0].Line != 3 || errors[0].Severity != validator.SeverityError {
+		t.Errorf("expected one error on line 3 for the theme, got %v", errors)
+	}
+}
+
+// TestValidateAccessibility tests that ValidateAccessibility requires accTitle and accDescr.
+func TestValidateAccessibility(t *testing.T) {
+	diagram, err := mermaid.Parse("flowchart LR\n    accTitle: Flow\n    A --> B")
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+
+	if errors := mermaid.Validate(diagram, true); len(errors) != 0 {
+		t.Errorf("Validate should not require accessibility statements, got %v", errors)
+	}
+	errors := mermaid.ValidateAccessibility(diagram)
+	if len(errors) != 1 || !strings.Contains(errors[0].Message, "accDescr") {
+		t.Errorf("expected one error for the missing accDescr, got %v", errors)
+	}
+}
+
 // TestDefaultRules tests the public DefaultRules function.
 func TestDefaultRules(t *testing.T) {
 	rules := mermaid.DefaultRules()
@@ -497,6 +615,61 @@ func TestParseFileMarkdown(t *testing.T) {
 	}
 }
 
+// TestParseFileMarkdownCollectsErrors tests that ParseFile reports syntax errors
+// from every block with file-relative line numbers.
+func TestParseFileMarkdownCollectsErrors(t *testing.T) {
+	markdown := "# Test Document\n\n" +
+		"```mermaid\nsequenceDiagram\n    Alice->>Bob: Hi\n    loop Forever\n```\n\n" +
+		"```mermaid\npie\n    \"A\" : 0\n```\n"
+
+	tmpfile, err := os.CreateTemp("", "test-*.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	if _, err := tmpfile.WriteString(markdown); err != nil {
+		t.Fatal(err)
+	}
+	tmpfile.Close()
+
+	diagrams, err := mermaid.ParseFile(tmpfile.Name())
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+	if len(diagrams) != 2 {
+		t.Errorf("expected 2 partial diagrams, got %d", len(diagrams))
+	}
+
+	errs := parser.Errors(err)
+	var lines []int
+	for _, e := range errs {
+		lines = append(lines, e.Pos.Line)
+	}
+	// Line 6 is the unclosed sequence loop, line 11 the bad pie entry and the
+	// pie chart's missing data is reported at the start of its block (line 10).
+	expected := []int{6, 11, 10}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected errors on lines %v, got %v", expected, lines)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("expected errors on lines %v, got %v", expected, lines)
+			break
+		}
+	}
+
+	// Offsets are shifted along with the lines
+	for _, e := range errs {
+		if !e.End.IsValid() {
+			continue
+		}
+		if got := markdown[e.Pos.Offset:e.End.Offset]; got != e.Found {
+			t.Errorf("error at %+v covers %q in the file, want %q", e.Pos, got, e.Found)
+		}
+	}
+}
+
 // TestParseFileMermaidWithFences tests ParseFile with a .mmd file containing markdown fences.
 func TestParseFileMermaidWithFences(t *testing.T) {
 	content := "```mermaid\nflowchart LR\n    X --> Y\n```"
@@ -622,3 +795,36 @@ func TestParseFileMarkdownWithInvalidMermaid(t *testing.T) {
 		t.Error("expected error for invalid Mermaid diagram, got nil")
 	}
 }
+
+// TestRegisteredDiagramType checks that a third-party diagram type registered with
+// the parser and validator is detected, parsed and validated end to end.
+func TestRegisteredDiagramType(t *testing.T) {
+	parser.Register([]parser.HeaderPrefix{{Prefix: "boxes-beta", Type: "boxes", DisplayName: "Boxes"}}, nil)
+	validator.Register("boxes", func(diagram ast.Diagram, strict bool) []validator.ValidationError {
+		generic := diagram.(*ast.GenericDiagram)
+		if strict && !strings.Contains(generic.Source, "\n    box ") {
+			return []validator.ValidationError{{Line: 1, Column: 1, Message: "no boxes", Severity: validator.SeverityError}}
+		}
+		return nil
+	})
+
+	markdown := "```mermaid\nboxes-beta\n    empty\n```\n"
+	blocks, err := extractor.ExtractFromMarkdown(markdown)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(blocks) != 1 || blocks[0].DiagramType != "boxes" {
+		t.Fatalf("expected one boxes block, got %+v", blocks)
+	}
+
+	diagram, err := mermaid.Parse(blocks[0].Source)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if errs := mermaid.Validate(diagram, false); len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+	if errs := mermaid.Validate(diagram, true); len(errs) != 1 || errs[0].Message != "no boxes" {
+		t.Errorf("expected the registered validator to run, got %v", errs)
+	}
+}
@@ -0,0 +1,166 @@
+package trace_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/sammcj/mermaid-check/ast"
+	"github.com/sammcj/mermaid-check/parser"
+	"github.com/sammcj/mermaid-check/trace"
+)
+
+// flatten parses a sequence diagram and flattens it.
+func flatten(t *testing.T, source string) []trace.Event {
+	t.Helper()
+	d, err := parser.NewSequenceParser().Parse(source)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	return trace.Flatten(d.(*ast.SequenceDiagram))
+}
+
+func TestFlatten(t *testing.T) {
+	events := flatten(t, `sequenceDiagram
+    Client->>+API: Order
+    alt in stock
+        API->>Stock: Reserve
+    else out of stock
+        loop every hour
+            API->>Supplier: Chase
+        end
+        par email
+            API-)Client: Delayed
+        and sms
+            API-)Client: Delayed
+        end
+    end
+    critical pay
+        API->>Bank: Charge
+    option declined
+        rect rgb(255, 0, 0)
+            API->>Client: Declined
+        end
+    end
+    opt receipt
+        break no email
+            API--xClient: Bounce
+        end
+    end
+    API-->>-Client: Done`)
+
+	tests := []struct {
+		from, to, path string
+		line           int
+	}{
+		{"Client", "API", "", 2},
+		{"API", "Stock", "alt[branch 1]", 4},
+		{"API", "Supplier", "alt[branch 2] > loop", 7},
+		{"API", "Client", "alt[branch 2] > par[branch 1]", 10},
+		{"API", "Client", "alt[branch 2] > par[branch 2]", 12},
+		{"API", "Bank", "critical[branch 1]", 16},
+		{"API", "Client", "critical[branch 2] > rect", 19},
+		{"API", "Client", "opt > break", 24},
+		{"API", "Client", "", 27},
+	}
+	if len(events) != len(tests) {
+		t.Fatalf("expected %d events, got %d", len(tests), len(events))
+	}
+	for i, tt := range tests {
+		e := events[i]
+		if e.From != tt.from || e.To != tt.to || e.Path != tt.path || e.Line != tt.line {
+			t.Errorf("event %d = %s>%s in %q at line %d, want %s>%s in %q at line %d",
+				i, e.From, e.To, e.Path, e.Line, tt.from, tt.to, tt.path, tt.line)
+		}
+		if e.Message == nil || e.Message.Pos.Line != tt.line {
+			t.Errorf("event %d has message %+v", i, e.Message)
+		}
+	}
+
+	want := []trace.Fragment{
+		{Kind: "alt", Label: "out of stock", Branch: 2},
+		{Kind: "loop", Label: "every hour"},
+	}
+	if got := events[2].Context; !reflect.DeepEqual(got, want) {
+		t.Errorf("Context = %+v, want %+v", got, want)
+	}
+	if got := events[6].Context[1].Label; got != "rgb(255, 0, 0)" {
+		t.Errorf("rect label = %q, want the colour", got)
+	}
+}
+
+func TestFlattenAutonumber(t *testing.T) {
+	events := flatten(t, `sequenceDiagram
+    A->>B: Unnumbered
+    autonumber
+    A->>B: One
+    A->>B: Two
+    autonumber off
+    A->>B: Hidden
+    autonumber 10 5
+    A->>B: Ten
+    loop twice
+        A->>B: Fifteen
+    end
+    autonumber
+    A->>B: Twenty`)
+
+	var got []int
+	for _, e := range events {
+		got = append(got, e.Number)
+	}
+	// Every message is counted, even while numbering is off
+	if want := []int{0, 2, 3, 0, 10, 15, 20}; !reflect.DeepEqual(got, want) {
+		t.Errorf("numbers = %v, want %v", got, want)
+	}
+}
+
+func TestFlattenActivations(t *testing.T) {
+	events := flatten(t, `sequenceDiagram
+    A->>B: Idle
+    A->>+B: Start
+    activate A
+    B->>+B: Recurse
+    B-->>-B: Return
+    B-->>-A: Done
+    deactivate A
+    deactivate A
+    A->>B: Idle again`)
+
+	tests := []struct {
+		active               map[string]int
+		activate, deactivate bool
+	}{
+		{nil, false, false},
+		{map[string]int{"B": 1}, true, false},
+		{map[string]int{"A": 1, "B": 2}, true, false},
+		{map[string]int{"A": 1, "B": 2}, false, true},
+		{map[string]int{"A": 1, "B": 1}, false, true},
+		{nil, false, false},
+	}
+	if len(events) != len(tests) {
+		t.Fatalf("expected %d events, got %d", len(tests), len(events))
+	}
+	for i, tt := range tests {
+		e := events[i]
+		if !reflect.DeepEqual(e.Active, tt.active) || e.Activate != tt.activate || e.Deactivate != tt.deactivate {
+			t.Errorf("event %d: active %v, activate %v, deactivate %v, want %v, %v, %v",
+				i, e.Active, e.Activate, e.Deactivate, tt.active, tt.activate, tt.deactivate)
+		}
+	}
+}
+
+func TestEventJSON(t *testing.T) {
+	events := flatten(t, "sequenceDiagram\n    autonumber\n    loop poll\n        A->>+B: Status?\n    end")
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(events); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	want := `[{"number":1,"from":"A","to":"B","arrow":"->>","text":"Status?","context":[{"kind":"loop","label":"poll"}],"path":"loop","active":{"B":1},"activate":true,"line":4,"column":9}]` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("JSON = %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,176 @@
+// Package trace flattens a sequence diagram into the ordered list of messages it
+// sends, for reviewing who calls whom and in what order.
+//
+// Messages are listed in source order, which is the order Mermaid draws them in:
+// the branches of an alt or par block follow one another, and a loop is listed
+// once. Each message records the blocks it is nested in, the number autonumber
+// gives it and the participants that are active when it is sent.
+package trace
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
+
+// Fragment is a block a message is nested in, such as a loop or one branch of
+// an alt block.
+type Fragment struct {
+	Kind   string `json:"kind"`             // "loop", "alt", "opt", "par", "critical", "break" or "rect"
+	Label  string `json:"label,omitempty"`  // Block or branch label, or the colour of a rect block
+	Branch int    `json:"branch,omitempty"` // 1-based branch of an alt, par or critical block, 0 for other blocks
+}
+
+// String returns the fragment as it appears in a context path, such as loop or
+// alt[branch 2]. The main statements of a critical block are branch 1 and its
+// options follow.
+func (f Fragment) String() string {
+	if f.Branch == 0 {
+		return f.Kind
+	}
+	return fmt.Sprintf("%s[branch %d]", f.Kind, f.Branch)
+}
+
+// Event is one message of the diagram.
+type Event struct {
+	Number     int            `json:"number,omitempty"`     // Number shown by autonumber, 0 while it is off
+	From       string         `json:"from"`                 // Source participant ID
+	To         string         `json:"to"`                   // Target participant ID
+	Arrow      string         `json:"arrow"`                // Arrow as written, such as ->> or --x
+	Text       string         `json:"text,omitempty"`       // Message text
+	Context    []Fragment     `json:"context,omitempty"`    // Enclosing blocks, outermost first
+	Path       string         `json:"path,omitempty"`       // Context joined as in alt[branch 2] > loop, "" at the top level
+	Active     map[string]int `json:"active,omitempty"`     // Activation depth of each active participant while the message is sent
+	Activate   bool           `json:"activate,omitempty"`   // The message activates its target
+	Deactivate bool           `json:"deactivate,omitempty"` // The message deactivates its source
+	Line       int            `json:"line"`                 // Line of the message in the diagram source
+	Column     int            `json:"column"`               // Column of the message in the diagram source
+	Message    *ast.Message   `json:"-"`                    // The message statement
+}
+
+// Flatten returns the messages of a sequence diagram in the order they are sent.
+//
+// As in Mermaid, autonumber counts every message, even while numbering is off,
+// and a message activating its target with + counts as sent to the activated
+// target, while one deactivating its source with - is sent before the source is
+// deactivated.
+func Flatten(diagram *ast.SequenceDiagram) []Event {
+	f := &flattener{number: 1, step: 1, active: make(map[string]int)}
+	f.walk(diagram.Statements, nil)
+	return f.events
+}
+
+// flattener holds the state of a diagram walk.
+type flattener struct {
+	events    []Event
+	numbering bool           // autonumber is on
+	number    int            // Number of the next message
+	step      int            // Increment between messages
+	active    map[string]int // Activation depth of each participant
+}
+
+func (f *flattener) walk(statements []ast.SeqStmt, context []Fragment) {
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *ast.Message:
+			f.message(s, context)
+
+		case *ast.Activation:
+			if s.Active {
+				f.active[s.Participant]++
+			} else {
+				f.deactivate(s.Participant)
+			}
+
+		case *ast.Autonumber:
+			f.numbering = s.Enabled
+			if s.StartSpan.IsValid() {
+				f.number = s.Start
+			}
+			if s.StepSpan.IsValid() {
+				f.step = s.Step
+			}
+
+		case *ast.Loop:
+			f.walk(s.Statements, with(context, Fragment{Kind: "loop", Label: s.Label}))
+		case *ast.Opt:
+			f.walk(s.Statements, with(context, Fragment{Kind: "opt", Label: s.Label}))
+		case *ast.Break:
+			f.walk(s.Statements, with(context, Fragment{Kind: "break", Label: s.Label}))
+		case *ast.Rect:
+			f.walk(s.Statements, with(context, Fragment{Kind: "rect", Label: s.Colour}))
+
+		case *ast.Alt:
+			for i, cond := range s.Conditions {
+				f.walk(cond.Statements, with(context, Fragment{Kind: "alt", Label: cond.Label, Branch: i + 1}))
+			}
+		case *ast.Par:
+			for i, branch := range s.Branches {
+				f.walk(branch.Statements, with(context, Fragment{Kind: "par", Label: branch.Label, Branch: i + 1}))
+			}
+		case *ast.Critical:
+			f.walk(s.Statements, with(context, Fragment{Kind: "critical", Label: s.Label, Branch: 1}))
+			for i, opt := range s.Options {
+				f.walk(opt.Statements, with(context, Fragment{Kind: "critical", Label: opt.Label, Branch: i + 2}))
+			}
+		}
+	}
+}
+
+func (f *flattener) message(msg *ast.Message, context []Fragment) {
+	if msg.Activate {
+		f.active[msg.To]++
+	}
+	event := Event{
+		From:       msg.From,
+		To:         msg.To,
+		Arrow:      msg.Arrow,
+		Text:       msg.Text,
+		Context:    context,
+		Path:       path(context),
+		Activate:   msg.Activate,
+		Deactivate: msg.Deactivate,
+		Line:       msg.Pos.Line,
+		Column:     msg.Pos.Column,
+		Message:    msg,
+	}
+	if f.numbering {
+		event.Number = f.number
+	}
+	f.number += f.step
+	if len(f.active) > 0 {
+		event.Active = maps.Clone(f.active)
+	}
+	f.events = append(f.events, event)
+
+	if msg.Deactivate {
+		f.deactivate(msg.From)
+	}
+}
+
+// deactivate closes the latest activation of a participant. Deactivating one
+// that is not active leaves it inactive.
+func (f *flattener) deactivate(id string) {
+	if f.active[id] > 1 {
+		f.active[id]--
+	} else {
+		delete(f.active, id)
+	}
+}
+
+// path joins the fragments of a context, outermost first.
+func path(context []Fragment) string {
+	parts := make([]string, len(context))
+	for i, f := range context {
+		parts[i] = f.String()
+	}
+	return strings.Join(parts, " > ")
+}
+
+// with returns context with fragment appended, without sharing storage with
+// context so that sibling blocks do not overwrite each other's paths.
+func with(context []Fragment, fragment Fragment) []Fragment {
+	return append(context[:len(context):len(context)], fragment)
+}
@@ -0,0 +1,44 @@
+package validator
+
+import (
+	"github.com/sammcj/mermaid-check/ast"
+)
+
+// RequireAccessibility reports diagrams without an accTitle or an accDescr, which
+// screen readers rely on to describe a diagram. It applies to every diagram type
+// and is not part of the default or strict rules, so it must be enabled
+// explicitly, e.g. with the --require-accessibility flag of the CLI.
+type RequireAccessibility struct {
+	Severity Severity // Severity of each missing statement
+}
+
+// Name returns the name of this validation rule.
+func (r *RequireAccessibility) Name() string { return "require-accessibility" }
+
+// ValidateDiagram checks that a diagram has both an accTitle and an accDescr.
+func (r *RequireAccessibility) ValidateDiagram(diagram ast.Diagram) []ValidationError {
+	acc := diagram.GetMetadata().Accessibility
+	if acc == nil {
+		acc = &ast.Accessibility{}
+	}
+
+	var errors []ValidationError
+	pos := diagram.GetPosition()
+	if acc.Title == "" {
+		errors = append(errors, ValidationError{
+			Line:     pos.Line,
+			Column:   pos.Column,
+			Message:  "diagram has no accessible title, add an 'accTitle:' statement",
+			Severity: r.Severity,
+		})
+	}
+	if acc.Description == "" {
+		errors = append(errors, ValidationError{
+			Line:     pos.Line,
+			Column:   pos.Column,
+			Message:  "diagram has no accessible description, add an 'accDescr:' statement or 'accDescr { ... }' block",
+			Severity: r.Severity,
+		})
+	}
+	return errors
+}
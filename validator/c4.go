@@ -27,12 +27,19 @@ func DefaultC4Rules() []C4Rule {
 		&C4ValidRelationshipReferencesRule{},
 		&ValidBoundaryIDsRule{},
 		&ValidStyleReferencesRule{},
+		&c4UnknownLines{UnknownLines[*ast.C4Diagram]{Severity: SeverityWarning}},
 	}
 }
 
 // StrictC4Rules returns strict validation rules for C4 diagrams.
 func StrictC4Rules() []C4Rule {
-	return DefaultC4Rules()
+	return []C4Rule{
+		&NoDuplicateElementIDsRule{},
+		&C4ValidRelationshipReferencesRule{},
+		&ValidBoundaryIDsRule{},
+		&ValidStyleReferencesRule{},
+		&c4UnknownLines{UnknownLines[*ast.C4Diagram]{Severity: SeverityError}},
+	}
 }
 
 // NoDuplicateElementIDsRule checks that all element IDs are unique.
@@ -2,6 +2,7 @@ package validator
 
 import (
 	"fmt"
+	"iter"
 
 	"github.com/sammcj/mermaid-check/ast"
 )
@@ -25,7 +26,7 @@ func (r *NoDuplicateClasses) ValidateClass(diagram *ast.ClassDiagram) []Validati
 	var errors []ValidationError
 	seen := make(map[string]ast.Position)
 
-	for _, stmt := range diagram.Statements {
+	for stmt := range allClassStatements(diagram.Statements) {
 		if class, ok := stmt.(*ast.Class); ok {
 			if pos, exists := seen[class.Name]; exists {
 				errors = append(errors, ValidationError{
@@ -59,14 +60,14 @@ func (r *ValidClassReferences) ValidateClass(diagram *ast.ClassDiagram) []Valida
 	definedClasses := make(map[string]bool)
 
 	// First pass: collect explicitly defined classes
-	for _, stmt := range diagram.Statements {
+	for stmt := range allClassStatements(diagram.Statements) {
 		if class, ok := stmt.(*ast.Class); ok {
 			definedClasses[class.Name] = true
 		}
 	}
 
 	// Second pass: collect classes implicitly defined in relationships
-	for _, stmt := range diagram.Statements {
+	for stmt := range allClassStatements(diagram.Statements) {
 		if rel, ok := stmt.(*ast.Relationship); ok {
 			definedClasses[rel.From] = true
 			definedClasses[rel.To] = true
@@ -74,7 +75,7 @@ func (r *ValidClassReferences) ValidateClass(diagram *ast.ClassDiagram) []Valida
 	}
 
 	// Check notes
-	for _, stmt := range diagram.Statements {
+	for stmt := range allClassStatements(diagram.Statements) {
 		if note, ok := stmt.(*ast.ClassNote); ok {
 			if !definedClasses[note.ClassName] {
 				errors = append(errors, ValidationError{
@@ -106,9 +107,10 @@ func (r *ValidMemberVisibility) ValidateClass(diagram *ast.ClassDiagram) []Valid
 		"-": true, // private
 		"#": true, // protected
 		"~": true, // package
+		"":  true, // none given
 	}
 
-	for _, stmt := range diagram.Statements {
+	for stmt := range allClassStatements(diagram.Statements) {
 		if class, ok := stmt.(*ast.Class); ok {
 			for _, member := range class.Members {
 				if !validVisibility[member.Visibility] {
@@ -146,7 +148,7 @@ func (r *ValidRelationshipType) ValidateClass(diagram *ast.ClassDiagram) []Valid
 		"realization":  true,
 	}
 
-	for _, stmt := range diagram.Statements {
+	for stmt := range allClassStatements(diagram.Statements) {
 		if rel, ok := stmt.(*ast.Relationship); ok {
 			if !validTypes[rel.Type] {
 				errors = append(errors, ValidationError{
@@ -162,6 +164,26 @@ func (r *ValidRelationshipType) ValidateClass(diagram *ast.ClassDiagram) []Valid
 	return errors
 }
 
+// allClassStatements returns every statement in a class diagram, in source order,
+// including those in namespaces.
+func allClassStatements(statements []ast.ClassStmt) iter.Seq[ast.ClassStmt] {
+	return func(yield func(ast.ClassStmt) bool) {
+		walkClassStatements(statements, yield)
+	}
+}
+
+func walkClassStatements(statements []ast.ClassStmt, yield func(ast.ClassStmt) bool) bool {
+	for _, stmt := range statements {
+		if !yield(stmt) {
+			return false
+		}
+		if ns, ok := stmt.(*ast.Namespace); ok && !walkClassStatements(ns.Statements, yield) {
+			return false
+		}
+	}
+	return true
+}
+
 // ClassDefaultRules returns the default set of validation rules for class diagrams.
 func ClassDefaultRules() []ClassRule {
 	return []ClassRule{
@@ -169,12 +191,19 @@ func ClassDefaultRules() []ClassRule {
 		&ValidClassReferences{},
 		&ValidMemberVisibility{},
 		&ValidRelationshipType{},
+		&NoUnknownStatements{Severity: SeverityWarning},
 	}
 }
 
 // ClassStrictRules returns a strict set of validation rules for class diagrams.
 func ClassStrictRules() []ClassRule {
-	return ClassDefaultRules()
+	return []ClassRule{
+		&NoDuplicateClasses{},
+		&ValidClassReferences{},
+		&ValidMemberVisibility{},
+		&ValidRelationshipType{},
+		&NoUnknownStatements{Severity: SeverityError},
+	}
 }
 
 // NewClass creates a new class diagram validator with the given rules.
@@ -0,0 +1,305 @@
+package validator
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
+
+// configKind is the type of value a Mermaid config key takes.
+type configKind int
+
+const (
+	configAny configKind = iota
+	configString
+	configBool
+	configNumber
+	configMap
+	configList
+	configVariables // A mapping of strings, numbers and booleans
+)
+
+func (k configKind) String() string {
+	switch k {
+	case configString:
+		return "a string"
+	case configBool:
+		return "a boolean"
+	case configNumber:
+		return "a number"
+	case configMap, configVariables:
+		return "a mapping"
+	case configList:
+		return "a list"
+	default:
+		return "any value"
+	}
+}
+
+// configKey describes a key in the Mermaid config schema.
+type configKey struct {
+	kind   configKind
+	values []string     // Allowed values, if the key is an enumeration
+	fields configSchema // Keys of a mapping, nil if they are not checked
+}
+
+// configSchema maps the keys of a config mapping to their descriptions.
+type configSchema map[string]configKey
+
+// with adds keys of the given kind to the schema.
+func (s configSchema) with(kind configKind, names ...string) configSchema {
+	for _, name := range names {
+		s[name] = configKey{kind: kind}
+	}
+	return s
+}
+
+// enum adds a string key that takes one of the given values.
+func (s configSchema) enum(name string, values ...string) configSchema {
+	s[name] = configKey{kind: configString, values: values}
+	return s
+}
+
+// diagramConfig returns the schema of a per-diagram config section, starting with
+// the keys shared by all of them.
+func diagramConfig() configSchema {
+	return configSchema{}.with(configBool, "useMaxWidth").with(configNumber, "useWidth")
+}
+
+var curves = []string{
+	"basis", "bumpX", "bumpY", "cardinal", "catmullRom", "linear", "monotoneX",
+	"monotoneY", "natural", "step", "stepAfter", "stepBefore",
+}
+
+// diagramConfigSections are the per-diagram sections of the Mermaid config. A nil
+// schema means the keys of the section are not checked.
+var diagramConfigSections = map[string]configSchema{
+	"flowchart": diagramConfig().
+		with(configNumber, "titleTopMargin", "diagramPadding", "nodeSpacing", "rankSpacing", "padding", "wrappingWidth").
+		with(configBool, "arrowMarkerAbsolute", "htmlLabels", "inheritDir").
+		with(configMap, "subGraphTitleMargin").
+		enum("curve", curves...).
+		enum("defaultRenderer", "dagre-d3", "dagre-wrapper", "elk"),
+	"sequence": diagramConfig().
+		with(configNumber, "activationWidth", "diagramMarginX", "diagramMarginY", "actorMargin", "width", "height",
+			"boxMargin", "boxTextMargin", "noteMargin", "messageMargin", "bottomMarginAdj", "wrapPadding",
+			"labelBoxWidth", "labelBoxHeight").
+		with(configBool, "arrowMarkerAbsolute", "hideUnusedParticipants", "mirrorActors", "forceMenus",
+			"rightAngles", "showSequenceNumbers", "wrap").
+		with(configAny, "actorFontSize", "actorFontFamily", "actorFontWeight", "noteFontSize", "noteFontFamily",
+			"noteFontWeight", "messageFontSize", "messageFontFamily", "messageFontWeight").
+		enum("messageAlign", "left", "center", "right").
+		enum("noteAlign", "left", "center", "right"),
+	"gantt": diagramConfig().
+		with(configNumber, "titleTopMargin", "barHeight", "barGap", "topPadding", "rightPadding", "leftPadding",
+			"gridLineStartPadding", "fontSize", "sectionFontSize", "numberSectionStyles").
+		with(configString, "axisFormat", "tickInterval").
+		with(configBool, "topAxis").
+		enum("displayMode", "", "compact").
+		enum("weekday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"),
+	"pie": diagramConfig().with(configNumber, "textPosition"),
+	"er": diagramConfig().
+		with(configNumber, "titleTopMargin", "diagramPadding", "minEntityWidth", "minEntityHeight", "entityPadding",
+			"fontSize", "nodeSpacing", "rankSpacing").
+		with(configString, "stroke", "fill").
+		enum("layoutDirection", "TB", "BT", "LR", "RL"),
+	"class": diagramConfig().
+		with(configNumber, "titleTopMargin", "dividerMargin", "padding", "textHeight", "nodeSpacing", "rankSpacing",
+			"diagramPadding").
+		with(configBool, "arrowMarkerAbsolute", "htmlLabels", "hideEmptyMembersBox").
+		enum("defaultRenderer", "dagre-d3", "dagre-wrapper", "elk"),
+	"state": diagramConfig().
+		with(configNumber, "titleTopMargin", "dividerMargin", "sizeUnit", "padding", "textHeight", "titleShift",
+			"noteMargin", "forkWidth", "forkHeight", "miniPadding", "fontSizeFactor", "fontSize", "labelHeight",
+			"edgeLengthFactor", "compositTitleSize", "radius", "nodeSpacing", "rankSpacing").
+		with(configBool, "arrowMarkerAbsolute").
+		enum("defaultRenderer", "dagre-d3", "dagre-wrapper", "elk"),
+	"journey": diagramConfig().
+		with(configNumber, "diagramMarginX", "diagramMarginY", "leftMargin", "width", "height", "boxMargin",
+			"boxTextMargin", "noteMargin", "messageMargin", "bottomMarginAdj", "activationWidth", "taskMargin").
+		with(configBool, "rightAngles").
+		with(configAny, "taskFontSize", "taskFontFamily", "textPlacement").
+		with(configList, "actorColours", "sectionFills", "sectionColours").
+		enum("messageAlign", "left", "center", "right"),
+	"timeline": diagramConfig().
+		with(configNumber, "diagramMarginX", "diagramMarginY", "leftMargin", "width", "height", "padding",
+			"boxMargin", "boxTextMargin", "noteMargin", "messageMargin", "bottomMarginAdj", "activationWidth",
+			"taskMargin").
+		with(configBool, "rightAngles", "disableMulticolor").
+		with(configAny, "taskFontSize", "taskFontFamily", "textPlacement").
+		with(configList, "actorColours", "sectionFills", "sectionColours").
+		enum("messageAlign", "left", "center", "right"),
+	"gitGraph": diagramConfig().
+		with(configNumber, "titleTopMargin", "diagramPadding", "mainBranchOrder").
+		with(configString, "nodeLabel", "mainBranchName").
+		with(configBool, "showCommitLabel", "showBranches", "rotateCommitLabel", "parallelCommits", "arrowMarkerAbsolute"),
+	"mindmap": diagramConfig().with(configNumber, "padding", "maxNodeWidth"),
+	"quadrantChart": diagramConfig().
+		with(configNumber, "chartWidth", "chartHeight", "titleFontSize", "titlePadding", "quadrantPadding",
+			"xAxisLabelPadding", "yAxisLabelPadding", "xAxisLabelFontSize", "yAxisLabelFontSize",
+			"quadrantLabelFontSize", "quadrantTextTopPadding", "pointTextPadding", "pointLabelFontSize",
+			"pointRadius", "quadrantInternalBorderStrokeWidth", "quadrantExternalBorderStrokeWidth").
+		enum("xAxisPosition", "top", "bottom").
+		enum("yAxisPosition", "left", "right"),
+	"xyChart": diagramConfig().
+		with(configNumber, "width", "height", "titleFontSize", "titlePadding", "plotReservedSpacePercent").
+		with(configBool, "showTitle", "showDataLabel").
+		with(configMap, "xAxis", "yAxis").
+		enum("chartOrientation", "vertical", "horizontal"),
+	"sankey": diagramConfig().
+		with(configNumber, "width", "height").
+		with(configBool, "showValues").
+		with(configString, "linkColor", "prefix", "suffix").
+		enum("nodeAlignment", "left", "right", "center", "justify"),
+	"architecture": nil,
+	"block":        nil,
+	"c4":           nil,
+	"kanban":       nil,
+	"packet":       nil,
+	"radar":        nil,
+	"requirement":  nil,
+	"treemap":      nil,
+}
+
+// mermaidConfig lists the top-level keys of the Mermaid config schema.
+var mermaidConfig = func() configSchema {
+	keys := configSchema{
+		"theme":          {kind: configString, values: []string{"default", "base", "dark", "forest", "neutral", "null"}},
+		"logLevel":       {kind: configAny, values: []string{"trace", "debug", "info", "warn", "error", "fatal", "0", "1", "2", "3", "4", "5"}},
+		"themeVariables": {kind: configVariables},
+	}
+	keys.
+		with(configString, "themeCSS", "layout", "fontFamily", "altFontFamily", "deterministicIDSeed").
+		with(configNumber, "handDrawnSeed", "maxTextSize", "maxEdges").
+		with(configBool, "darkMode", "htmlLabels", "startOnLoad", "arrowMarkerAbsolute", "legacyMathML",
+			"forceLegacyMathML", "deterministicIds", "wrap", "markdownAutoWrap", "suppressErrorRendering").
+		with(configMap, "elk", "dompurifyConfig").
+		with(configList, "secure").
+		with(configAny, "fontSize").
+		enum("look", "classic", "handDrawn").
+		enum("securityLevel", "strict", "loose", "antiscript", "sandbox")
+	for section, fields := range diagramConfigSections {
+		keys[section] = configKey{kind: configMap, fields: fields}
+	}
+	return keys
+}()
+
+// validateConfig checks a Mermaid config object against the config schema.
+// Unknown keys are reported as warnings, and values of the wrong type as errors.
+// path is the dotted path of config itself, and pos returns the position of the
+// key at a dotted path.
+func validateConfig(config map[string]any, path string, pos func(path string) ast.Position) []*ValidationError {
+	errors := checkConfig(config, mermaidConfig, path, pos)
+	sortBySource(errors)
+	return errors
+}
+
+// sortBySource sorts errors found while walking keys in sorted order into the
+// order of the keys in the source.
+func sortBySource(errors []*ValidationError) {
+	slices.SortStableFunc(errors, func(a, b *ValidationError) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+}
+
+func checkConfig(config map[string]any, schema configSchema, path string, pos func(path string) ast.Position) []*ValidationError {
+	var errors []*ValidationError
+	report := func(keyPath string, severity Severity, message string) {
+		p := pos(keyPath)
+		errors = append(errors, &ValidationError{
+			Line:     p.Line,
+			Column:   p.Column,
+			Message:  message,
+			Severity: severity,
+		})
+	}
+
+	for _, key := range sortedKeys(config) {
+		keyPath := joinPath(path, key)
+		spec, ok := schema[key]
+		if !ok {
+			report(keyPath, SeverityWarning, fmt.Sprintf("unknown Mermaid config key %q", keyPath))
+			continue
+		}
+		if err := checkConfigValue(config[key], keyPath, spec); err != "" {
+			report(keyPath, SeverityError, err)
+			continue
+		}
+		nested, _ := config[key].(map[string]any)
+		switch {
+		case spec.kind == configVariables:
+			// Variables are free-form, but must be plain values
+			for _, name := range sortedKeys(nested) {
+				switch nested[name].(type) {
+				case map[string]any, []any:
+					varPath := joinPath(keyPath, name)
+					report(varPath, SeverityError, fmt.Sprintf("theme variable %q must be a string, number or boolean", varPath))
+				}
+			}
+		case spec.fields != nil:
+			errors = append(errors, checkConfig(nested, spec.fields, keyPath, pos)...)
+		}
+	}
+	return errors
+}
+
+// checkConfigValue returns a message describing why value does not suit spec, or
+// an empty string if it does. Null values are always accepted.
+func checkConfigValue(value any, path string, spec configKey) string {
+	if value == nil {
+		return ""
+	}
+	if !configKindMatches(value, spec.kind) {
+		return fmt.Sprintf("config key %q must be %s, got %v", path, spec.kind, value)
+	}
+	if len(spec.values) > 0 && !slices.Contains(spec.values, fmt.Sprint(value)) {
+		return fmt.Sprintf("invalid value %v for config key %q: expected one of: %s", value, path, strings.Join(spec.values, ", "))
+	}
+	return ""
+}
+
+func configKindMatches(value any, kind configKind) bool {
+	switch kind {
+	case configString:
+		_, ok := value.(string)
+		return ok
+	case configBool:
+		_, ok := value.(bool)
+		return ok
+	case configNumber:
+		switch value.(type) {
+		case int, float64:
+			return true
+		}
+		return false
+	case configMap, configVariables:
+		_, ok := value.(map[string]any)
+		return ok
+	case configList:
+		_, ok := value.([]any)
+		return ok
+	default:
+		return true
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// sortedKeys returns the keys of m in order, so errors are reported consistently.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,34 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
+
+// knownDirectives are the directive names Mermaid recognises.
+var knownDirectives = map[string]bool{
+	"init":       true,
+	"initialize": true,
+	"wrap":       true,
+}
+
+// ValidateDirectives checks the %%{...}%% directives of a diagram. Unknown
+// directives are reported as warnings. The config of init directives is checked
+// against the Mermaid config schema.
+func ValidateDirectives(directives []*ast.Directive) []*ValidationError {
+	var errors []*ValidationError
+	for _, d := range directives {
+		if !knownDirectives[d.Name] {
+			errors = append(errors, &ValidationError{
+				Line:     d.NameSpan.Start.Line,
+				Column:   d.NameSpan.Start.Column,
+				Message:  fmt.Sprintf("unknown directive %q", d.Name),
+				Severity: SeverityWarning,
+			})
+			continue
+		}
+		errors = append(errors, validateConfig(d.Config, "", d.KeyPos)...)
+	}
+	return errors
+}
@@ -31,13 +31,18 @@ func ERDefaultRules() []ERRule {
 		&NoDuplicateEntitiesRule{},
 		&ValidRelationshipReferencesRule{},
 		&ValidAttributeKeysRule{},
+		&UnknownLines[*ast.ERDiagram]{Severity: SeverityWarning},
 	}
 }
 
 // ERStrictRules returns strict validation rules for ER diagrams.
 func ERStrictRules() []ERRule {
-	rules := ERDefaultRules()
-	return rules
+	return []ERRule{
+		&NoDuplicateEntitiesRule{},
+		&ValidRelationshipReferencesRule{},
+		&ValidAttributeKeysRule{},
+		&UnknownLines[*ast.ERDiagram]{Severity: SeverityError},
+	}
 }
 
 // NoDuplicateEntitiesRule checks for duplicate entity names in ER diagram.
@@ -0,0 +1,55 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
+
+// frontmatterKeys are the top-level keys Mermaid reads from frontmatter.
+var frontmatterKeys = map[string]bool{
+	"title":       true,
+	"config":      true,
+	"displayMode": true,
+}
+
+// ValidateFrontmatter checks the frontmatter of a diagram. Unknown keys are
+// reported as warnings. Config keys are checked against the Mermaid config schema.
+// It returns nil if fm is nil.
+func ValidateFrontmatter(fm *ast.Frontmatter) []*ValidationError {
+	if fm == nil {
+		return nil
+	}
+
+	var errors []*ValidationError
+	report := func(path string, severity Severity, format string, args ...any) {
+		p := fm.KeyPos(path)
+		errors = append(errors, &ValidationError{
+			Line:     p.Line,
+			Column:   p.Column,
+			Message:  fmt.Sprintf(format, args...),
+			Severity: severity,
+		})
+	}
+
+	for _, key := range sortedKeys(fm.Values) {
+		if !frontmatterKeys[key] {
+			report(key, SeverityWarning, "unknown frontmatter key %q", key)
+		}
+	}
+
+	switch fm.Values["title"].(type) {
+	case map[string]any, []any:
+		report("title", SeverityError, "frontmatter title must be a string")
+	}
+
+	if config, ok := fm.Values["config"]; ok && config != nil {
+		if _, isMap := config.(map[string]any); !isMap {
+			report("config", SeverityError, "frontmatter config must be a mapping")
+		}
+	}
+	errors = append(errors, validateConfig(fm.Config, "config", fm.KeyPos)...)
+	sortBySource(errors)
+
+	return errors
+}
@@ -33,14 +33,19 @@ func GanttDefaultRules() []GanttRule {
 		&ValidTaskReferencesRule{},
 		&ValidDateFormatRule{},
 		&ValidTaskStatusRule{},
+		&UnknownLines[*ast.GanttDiagram]{Severity: SeverityWarning},
 	}
 }
 
 // GanttStrictRules returns strict validation rules for Gantt diagrams.
 func GanttStrictRules() []GanttRule {
-	rules := GanttDefaultRules()
-	// Add strict-only rules here if needed
-	return rules
+	return []GanttRule{
+		&NoDuplicateTaskIDsRule{},
+		&ValidTaskReferencesRule{},
+		&ValidDateFormatRule{},
+		&ValidTaskStatusRule{},
+		&UnknownLines[*ast.GanttDiagram]{Severity: SeverityError},
+	}
 }
 
 // NoDuplicateTaskIDsRule checks for duplicate task IDs in Gantt chart.
@@ -30,14 +30,19 @@ func GitGraphDefaultRules() []GitGraphRule {
 		&ValidBranchReferencesRule{},
 		&ValidCommitReferencesRule{},
 		&ValidCommitTypeRule{},
+		&UnknownLines[*ast.GitGraphDiagram]{Severity: SeverityWarning},
 	}
 }
 
 // GitGraphStrictRules returns strict validation rules for git graph diagrams.
 func GitGraphStrictRules() []GitGraphRule {
-	rules := GitGraphDefaultRules()
-	// Add strict-only rules here if needed
-	return rules
+	return []GitGraphRule{
+		&NoDuplicateBranchNamesRule{},
+		&ValidBranchReferencesRule{},
+		&ValidCommitReferencesRule{},
+		&ValidCommitTypeRule{},
+		&UnknownLines[*ast.GitGraphDiagram]{Severity: SeverityError},
+	}
 }
 
 // NoDuplicateBranchNamesRule checks for duplicate branch names.
@@ -30,14 +30,17 @@ func JourneyDefaultRules() []JourneyRule {
 	return []JourneyRule{
 		&ValidTaskScoresRule{},
 		&TasksHaveActorsRule{},
+		&UnknownLines[*ast.JourneyDiagram]{Severity: SeverityWarning},
 	}
 }
 
 // JourneyStrictRules returns strict validation rules for journey diagrams.
 func JourneyStrictRules() []JourneyRule {
-	rules := JourneyDefaultRules()
-	// Add strict-only rules here if needed
-	return rules
+	return []JourneyRule{
+		&ValidTaskScoresRule{},
+		&TasksHaveActorsRule{},
+		&UnknownLines[*ast.JourneyDiagram]{Severity: SeverityError},
+	}
 }
 
 // ValidTaskScoresRule checks that all task scores are within valid range (1-5).
@@ -0,0 +1,191 @@
+package validator
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sammcj/mermaid-check/ast"
+	"github.com/sammcj/mermaid-check/graph"
+)
+
+// AcyclicMarker is the comment text that marks a flowchart as acyclic, for the
+// NoUnexpectedCycles rule: %% mermaid-check: acyclic
+const AcyclicMarker = "mermaid-check: acyclic"
+
+// NoIsolatedNodes reports nodes with no links to or from them, which are often
+// left over from an earlier version of the diagram.
+type NoIsolatedNodes struct{}
+
+// Name returns the name of this validation rule.
+func (r *NoIsolatedNodes) Name() string { return "no-isolated-nodes" }
+
+// Validate checks that every node has at least one link.
+func (r *NoIsolatedNodes) Validate(flowchart *ast.Flowchart) []ValidationError {
+	g := graph.New(flowchart)
+	var errors []ValidationError
+	for _, node := range g.Nodes() {
+		if len(g.Successors(node.ID)) == 0 && len(g.Predecessors(node.ID)) == 0 {
+			errors = append(errors, nodeWarning(node, "node '%s' has no links", node.ID))
+		}
+	}
+	return errors
+}
+
+// NoUnreachableNodes reports nodes that cannot be reached from any source node,
+// one with no links into it. Such nodes are only reachable through a cycle.
+type NoUnreachableNodes struct{}
+
+// Name returns the name of this validation rule.
+func (r *NoUnreachableNodes) Name() string { return "no-unreachable-nodes" }
+
+// Validate checks that every node can be reached from a source node.
+func (r *NoUnreachableNodes) Validate(flowchart *ast.Flowchart) []ValidationError {
+	g := graph.New(flowchart)
+	var errors []ValidationError
+	for _, id := range g.Unreachable(g.Sources()...) {
+		node, _ := g.Node(id)
+		errors = append(errors, nodeWarning(node, "node '%s' is not reachable from any start node", id))
+	}
+	return errors
+}
+
+// NoUnexpectedCycles reports cycles in flowcharts marked as acyclic with a
+// "%% mermaid-check: acyclic" comment. Flowcharts without the comment may loop.
+type NoUnexpectedCycles struct{}
+
+// Name returns the name of this validation rule.
+func (r *NoUnexpectedCycles) Name() string { return "no-unexpected-cycles" }
+
+// Validate checks that a flowchart marked as acyclic has no cycles.
+func (r *NoUnexpectedCycles) Validate(flowchart *ast.Flowchart) []ValidationError {
+	if !isMarkedAcyclic(flowchart) {
+		return nil
+	}
+	g := graph.New(flowchart)
+	var errors []ValidationError
+	for _, cycle := range g.Cycles() {
+		node, _ := g.Node(cycle[0])
+		errors = append(errors, nodeWarning(node, "cycle through %s in a flowchart marked acyclic", strings.Join(cycle, ", ")))
+	}
+	// Cycles come in topological order, so report them in source order instead
+	slices.SortStableFunc(errors, func(a, b ValidationError) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+	return errors
+}
+
+func isMarkedAcyclic(flowchart *ast.Flowchart) bool {
+	for stmt := range allStatements(flowchart.Statements) {
+		if c, ok := stmt.(*ast.Comment); ok && c.Text == AcyclicMarker {
+			return true
+		}
+	}
+	return false
+}
+
+// NoUnusedClasses reports classDef statements whose class is never applied to a
+// node. The "default" class applies to every node, so it is always used.
+type NoUnusedClasses struct{}
+
+// Name returns the name of this validation rule.
+func (r *NoUnusedClasses) Name() string { return "no-unused-classes" }
+
+// Validate checks that every class defined is applied to a node.
+func (r *NoUnusedClasses) Validate(flowchart *ast.Flowchart) []ValidationError {
+	used := map[string]bool{"default": true}
+	for stmt := range allStatements(flowchart.Statements) {
+		if s, ok := stmt.(*ast.ClassAssignment); ok {
+			used[s.ClassName] = true
+		}
+	}
+
+	var errors []ValidationError
+	for stmt := range allStatements(flowchart.Statements) {
+		s, ok := stmt.(*ast.ClassDef)
+		if !ok || used[s.Name] {
+			continue
+		}
+		pos := s.Pos
+		if s.NameSpan.IsValid() {
+			pos = s.NameSpan.Start
+		}
+		errors = append(errors, ValidationError{
+			Line:     pos.Line,
+			Column:   pos.Column,
+			Message:  fmt.Sprintf("class '%s' is defined but never used", s.Name),
+			Severity: SeverityWarning,
+		})
+	}
+	return errors
+}
+
+// NoEmptySubgraphs reports subgraphs with nothing in them but comments and a
+// direction, which Mermaid draws as an empty box.
+type NoEmptySubgraphs struct{}
+
+// Name returns the name of this validation rule.
+func (r *NoEmptySubgraphs) Name() string { return "no-empty-subgraphs" }
+
+// Validate checks that every subgraph has content.
+func (r *NoEmptySubgraphs) Validate(flowchart *ast.Flowchart) []ValidationError {
+	var errors []ValidationError
+	for stmt := range allStatements(flowchart.Statements) {
+		s, ok := stmt.(*ast.Subgraph)
+		if !ok || !isEmptySubgraph(s) {
+			continue
+		}
+		errors = append(errors, ValidationError{
+			Line:     s.Pos.Line,
+			Column:   s.Pos.Column,
+			Message:  fmt.Sprintf("subgraph '%s' is empty", s.Title),
+			Severity: SeverityWarning,
+		})
+	}
+	return errors
+}
+
+func isEmptySubgraph(subgraph *ast.Subgraph) bool {
+	for _, stmt := range subgraph.Statements {
+		if _, ok := stmt.(*ast.Comment); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeWarning returns a warning at the position a node first appears.
+func nodeWarning(node *graph.Node, format string, args ...any) ValidationError {
+	return ValidationError{
+		Line:     node.Pos.Line,
+		Column:   node.Pos.Column,
+		Message:  fmt.Sprintf(format, args...),
+		Severity: SeverityWarning,
+	}
+}
+
+// LintRules returns the optional flowchart lint rules. They report structural
+// problems that Mermaid renders without complaint, so most are in neither the
+// default nor the strict rules. NoUndefinedClasses is also a default rule, and is
+// listed here so that it can be run on its own alongside the others.
+func LintRules() []Rule {
+	return []Rule{
+		&NoIsolatedNodes{},
+		&NoUnreachableNodes{},
+		&NoUnexpectedCycles{},
+		&NoUndefinedClasses{},
+		&NoUnusedClasses{},
+		&NoEmptySubgraphs{},
+	}
+}
+
+// LintRule returns the lint rule with the given name, such as "no-isolated-nodes".
+func LintRule(name string) (Rule, bool) {
+	for _, rule := range LintRules() {
+		if rule.Name() == name {
+			return rule, true
+		}
+	}
+	return nil, false
+}
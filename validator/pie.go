@@ -30,14 +30,17 @@ func PieDefaultRules() []PieRule {
 	return []PieRule{
 		&NoDuplicateLabelsRule{},
 		&PositiveValuesRule{},
+		&UnknownLines[*ast.PieDiagram]{Severity: SeverityWarning},
 	}
 }
 
 // PieStrictRules returns strict validation rules for pie diagrams.
 func PieStrictRules() []PieRule {
-	rules := PieDefaultRules()
-	// Add strict-only rules here if needed
-	return rules
+	return []PieRule{
+		&NoDuplicateLabelsRule{},
+		&PositiveValuesRule{},
+		&UnknownLines[*ast.PieDiagram]{Severity: SeverityError},
+	}
 }
 
 // NoDuplicateLabelsRule checks for duplicate labels in pie chart.
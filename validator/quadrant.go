@@ -33,14 +33,20 @@ func QuadrantDefaultRules() []QuadrantRule {
 		&QuadrantXAxisDefinedRule{},
 		&QuadrantYAxisDefinedRule{},
 		&MinimumPointsRule{},
+		&UnknownLines[*ast.QuadrantDiagram]{Severity: SeverityWarning},
 	}
 }
 
 // QuadrantStrictRules returns strict validation rules for quadrant diagrams.
 func QuadrantStrictRules() []QuadrantRule {
-	rules := QuadrantDefaultRules()
-	// Add strict-only rules here if needed
-	return rules
+	return []QuadrantRule{
+		&ValidCoordinatesRule{},
+		&NoDuplicatePointNamesRule{},
+		&QuadrantXAxisDefinedRule{},
+		&QuadrantYAxisDefinedRule{},
+		&MinimumPointsRule{},
+		&UnknownLines[*ast.QuadrantDiagram]{Severity: SeverityError},
+	}
 }
 
 // ValidCoordinatesRule checks that all coordinates are between 0.0 and 1.0.
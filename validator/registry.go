@@ -0,0 +1,46 @@
+package validator
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
+
+// ValidateFunc validates a diagram, applying stricter style checks if strict is set.
+type ValidateFunc func(diagram ast.Diagram, strict bool) []ValidationError
+
+var registry struct {
+	sync.RWMutex
+	validators map[string]ValidateFunc
+}
+
+// Register sets the validation used for diagrams of the given type, as reported
+// by their GetType method. It is intended to be called from init functions,
+// alongside parser.Register, and takes precedence over the built-in validation
+// for the type. It panics if fn is nil or the type has already been registered.
+func Register(diagramType string, fn ValidateFunc) {
+	if fn == nil {
+		panic("validator: Register called with a nil ValidateFunc")
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.validators[diagramType]; ok {
+		panic(fmt.Sprintf("validator: Register called twice for diagram type %q", diagramType))
+	}
+	if registry.validators == nil {
+		registry.validators = make(map[string]ValidateFunc)
+	}
+	registry.validators[diagramType] = fn
+}
+
+// Lookup returns the validation registered for a diagram type.
+func Lookup(diagramType string) (ValidateFunc, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	fn, ok := registry.validators[diagramType]
+	return fn, ok
+}
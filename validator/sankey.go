@@ -32,14 +32,19 @@ func SankeyDefaultRules() []SankeyRule {
 		&SankeyNoSelfLoopsRule{},
 		&SankeyValidNodeReferencesRule{},
 		&SankeyMinimumLinksRule{},
+		&UnknownLines[*ast.SankeyDiagram]{Severity: SeverityWarning},
 	}
 }
 
 // SankeyStrictRules returns strict validation rules for Sankey diagrams.
 func SankeyStrictRules() []SankeyRule {
-	rules := SankeyDefaultRules()
-	// Add strict-only rules here if needed
-	return rules
+	return []SankeyRule{
+		&SankeyPositiveValuesRule{},
+		&SankeyNoSelfLoopsRule{},
+		&SankeyValidNodeReferencesRule{},
+		&SankeyMinimumLinksRule{},
+		&UnknownLines[*ast.SankeyDiagram]{Severity: SeverityError},
+	}
 }
 
 // SankeyPositiveValuesRule checks that all link values are positive.
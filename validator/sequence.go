@@ -2,6 +2,11 @@ package validator
 
 import (
 	"fmt"
+	"iter"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/sammcj/mermaid-check/ast"
 )
@@ -39,60 +44,35 @@ func (r *ValidParticipantReferences) ValidateSequence(diagram *ast.SequenceDiagr
 }
 
 func (r *ValidParticipantReferences) collectParticipants(statements []ast.SeqStmt, defined map[string]bool, referenced map[string]*ast.Position) {
-	for _, stmt := range statements {
+	reference := func(id string, pos ast.Position) {
+		if _, exists := referenced[id]; !exists {
+			referenced[id] = &pos
+		}
+	}
+
+	for stmt := range allSeqStatements(statements) {
 		switch s := stmt.(type) {
 		case *ast.Participant:
 			defined[s.ID] = true
 
+		case *ast.Create:
+			defined[s.Participant.ID] = true
+
+		case *ast.Destroy:
+			reference(s.Participant, s.Pos)
+
 		case *ast.Message:
-			if _, exists := referenced[s.From]; !exists {
-				pos := s.Pos
-				referenced[s.From] = &pos
-			}
-			if _, exists := referenced[s.To]; !exists {
-				pos := s.Pos
-				referenced[s.To] = &pos
-			}
+			reference(s.From, s.Pos)
+			reference(s.To, s.Pos)
 
 		case *ast.Activation:
-			if _, exists := referenced[s.Participant]; !exists {
-				pos := s.Pos
-				referenced[s.Participant] = &pos
-			}
+			reference(s.Participant, s.Pos)
 
 		case *ast.Note:
 			for _, p := range s.Participants {
-				if _, exists := referenced[p]; !exists {
-					pos := s.Pos
-					referenced[p] = &pos
-				}
+				reference(p, s.Pos)
 			}
 
-		case *ast.Loop:
-			r.collectParticipants(s.Statements, defined, referenced)
-
-		case *ast.Alt:
-			for _, cond := range s.Conditions {
-				r.collectParticipants(cond.Statements, defined, referenced)
-			}
-
-		case *ast.Opt:
-			r.collectParticipants(s.Statements, defined, referenced)
-
-		case *ast.Par:
-			for _, branch := range s.Branches {
-				r.collectParticipants(branch.Statements, defined, referenced)
-			}
-
-		case *ast.Critical:
-			r.collectParticipants(s.Statements, defined, referenced)
-			for _, opt := range s.Options {
-				r.collectParticipants(opt.Statements, defined, referenced)
-			}
-
-		case *ast.Break:
-			r.collectParticipants(s.Statements, defined, referenced)
-
 		case *ast.Box:
 			for _, p := range s.Participants {
 				defined[p.ID] = true
@@ -118,58 +98,31 @@ func (r *NoDuplicateParticipants) ValidateSequence(diagram *ast.SequenceDiagram)
 }
 
 func (r *NoDuplicateParticipants) checkDuplicates(statements []ast.SeqStmt, seen map[string]ast.Position, errors *[]ValidationError) {
-	for _, stmt := range statements {
+	check := func(p *ast.Participant) {
+		if firstPos, exists := seen[p.ID]; exists {
+			*errors = append(*errors, ValidationError{
+				Line:     p.Pos.Line,
+				Column:   p.Pos.Column,
+				Message:  fmt.Sprintf("duplicate participant ID '%s', first defined at line %d", p.ID, firstPos.Line),
+				Severity: SeverityError,
+			})
+		} else {
+			seen[p.ID] = p.Pos
+		}
+	}
+
+	for stmt := range allSeqStatements(statements) {
 		switch s := stmt.(type) {
 		case *ast.Participant:
-			if firstPos, exists := seen[s.ID]; exists {
-				*errors = append(*errors, ValidationError{
-					Line:     s.Pos.Line,
-					Column:   s.Pos.Column,
-					Message:  fmt.Sprintf("duplicate participant ID '%s', first defined at line %d", s.ID, firstPos.Line),
-					Severity: SeverityError,
-				})
-			} else {
-				seen[s.ID] = s.Pos
-			}
+			check(s)
 
-		case *ast.Box:
-			for _, p := range s.Participants {
-				if firstPos, exists := seen[p.ID]; exists {
-					*errors = append(*errors, ValidationError{
-						Line:     p.Pos.Line,
-						Column:   p.Pos.Column,
-						Message:  fmt.Sprintf("duplicate participant ID '%s', first defined at line %d", p.ID, firstPos.Line),
-						Severity: SeverityError,
-					})
-				} else {
-					seen[p.ID] = p.Pos
-				}
-			}
-
-		case *ast.Loop:
-			r.checkDuplicates(s.Statements, seen, errors)
+		case *ast.Create:
+			check(&s.Participant)
 
-		case *ast.Alt:
-			for _, cond := range s.Conditions {
-				r.checkDuplicates(cond.Statements, seen, errors)
-			}
-
-		case *ast.Opt:
-			r.checkDuplicates(s.Statements, seen, errors)
-
-		case *ast.Par:
-			for _, branch := range s.Branches {
-				r.checkDuplicates(branch.Statements, seen, errors)
-			}
-
-		case *ast.Critical:
-			r.checkDuplicates(s.Statements, seen, errors)
-			for _, opt := range s.Options {
-				r.checkDuplicates(opt.Statements, seen, errors)
+		case *ast.Box:
+			for i := range s.Participants {
+				check(&s.Participants[i])
 			}
-
-		case *ast.Break:
-			r.checkDuplicates(s.Statements, seen, errors)
 		}
 	}
 }
@@ -202,42 +155,14 @@ func (r *ValidMessageArrows) ValidateSequence(diagram *ast.SequenceDiagram) []Va
 }
 
 func (r *ValidMessageArrows) checkArrows(statements []ast.SeqStmt, validArrows map[string]bool, errors *[]ValidationError) {
-	for _, stmt := range statements {
-		switch s := stmt.(type) {
-		case *ast.Message:
-			if !validArrows[s.Arrow] {
-				*errors = append(*errors, ValidationError{
-					Line:     s.Pos.Line,
-					Column:   s.Pos.Column,
-					Message:  fmt.Sprintf("invalid message arrow '%s'", s.Arrow),
-					Severity: SeverityError,
-				})
-			}
-
-		case *ast.Loop:
-			r.checkArrows(s.Statements, validArrows, errors)
-
-		case *ast.Alt:
-			for _, cond := range s.Conditions {
-				r.checkArrows(cond.Statements, validArrows, errors)
-			}
-
-		case *ast.Opt:
-			r.checkArrows(s.Statements, validArrows, errors)
-
-		case *ast.Par:
-			for _, branch := range s.Branches {
-				r.checkArrows(branch.Statements, validArrows, errors)
-			}
-
-		case *ast.Critical:
-			r.checkArrows(s.Statements, validArrows, errors)
-			for _, opt := range s.Options {
-				r.checkArrows(opt.Statements, validArrows, errors)
-			}
-
-		case *ast.Break:
-			r.checkArrows(s.Statements, validArrows, errors)
+	for stmt := range allSeqStatements(statements) {
+		if s, ok := stmt.(*ast.Message); ok && !validArrows[s.Arrow] {
+			*errors = append(*errors, ValidationError{
+				Line:     s.Pos.Line,
+				Column:   s.Pos.Column,
+				Message:  fmt.Sprintf("invalid message arrow '%s'", s.Arrow),
+				Severity: SeverityError,
+			})
 		}
 	}
 }
@@ -263,11 +188,14 @@ func (r *ValidNotePositions) ValidateSequence(diagram *ast.SequenceDiagram) []Va
 }
 
 func (r *ValidNotePositions) collectAllParticipants(statements []ast.SeqStmt, participants map[string]bool) {
-	for _, stmt := range statements {
+	for stmt := range allSeqStatements(statements) {
 		switch s := stmt.(type) {
 		case *ast.Participant:
 			participants[s.ID] = true
 
+		case *ast.Create:
+			participants[s.Participant.ID] = true
+
 		case *ast.Message:
 			participants[s.From] = true
 			participants[s.To] = true
@@ -279,76 +207,254 @@ func (r *ValidNotePositions) collectAllParticipants(statements []ast.SeqStmt, pa
 			for _, p := range s.Participants {
 				participants[p.ID] = true
 			}
+		}
+	}
+}
 
-		case *ast.Loop:
-			r.collectAllParticipants(s.Statements, participants)
-
-		case *ast.Alt:
-			for _, cond := range s.Conditions {
-				r.collectAllParticipants(cond.Statements, participants)
+func (r *ValidNotePositions) checkNotes(statements []ast.SeqStmt, participants map[string]bool, errors *[]ValidationError) {
+	for stmt := range allSeqStatements(statements) {
+		s, ok := stmt.(*ast.Note)
+		if !ok {
+			continue
+		}
+		for _, p := range s.Participants {
+			if !participants[p] {
+				*errors = append(*errors, ValidationError{
+					Line:     s.Pos.Line,
+					Column:   s.Pos.Column,
+					Message:  fmt.Sprintf("note references undefined participant '%s'", p),
+					Severity: SeverityWarning,
+				})
 			}
+		}
+	}
+}
 
-		case *ast.Opt:
-			r.collectAllParticipants(s.Statements, participants)
+// participantTypes holds the participant types Mermaid can draw.
+var participantTypes = map[string]bool{
+	"participant": true, "actor": true, "boundary": true, "control": true,
+	"entity": true, "database": true, "collections": true, "queue": true,
+}
 
-		case *ast.Par:
-			for _, branch := range s.Branches {
-				r.collectAllParticipants(branch.Statements, participants)
-			}
+// ValidParticipantTypes checks that participant types given in an @{} block, as
+// in participant DB@{ "type": "database" }, are ones Mermaid can draw.
+type ValidParticipantTypes struct{}
 
-		case *ast.Critical:
-			r.collectAllParticipants(s.Statements, participants)
-			for _, opt := range s.Options {
-				r.collectAllParticipants(opt.Statements, participants)
-			}
+// Name returns the name of this validation rule.
+func (r *ValidParticipantTypes) Name() string { return "valid-participant-types" }
 
-		case *ast.Break:
-			r.collectAllParticipants(s.Statements, participants)
+// ValidateSequence checks participant types.
+func (r *ValidParticipantTypes) ValidateSequence(diagram *ast.SequenceDiagram) []ValidationError {
+	var errors []ValidationError
+	check := func(p *ast.Participant) {
+		if p.Type == "" || participantTypes[p.Type] {
+			return
+		}
+		pos := p.Pos
+		if p.TypeSpan.IsValid() {
+			pos = p.TypeSpan.Start
 		}
+		errors = append(errors, ValidationError{
+			Line:     pos.Line,
+			Column:   pos.Column,
+			Message:  fmt.Sprintf("unknown type '%s' for participant '%s'", p.Type, p.ID),
+			Severity: SeverityError,
+		})
 	}
-}
 
-func (r *ValidNotePositions) checkNotes(statements []ast.SeqStmt, participants map[string]bool, errors *[]ValidationError) {
-	for _, stmt := range statements {
+	for stmt := range allSeqStatements(diagram.Statements) {
 		switch s := stmt.(type) {
-		case *ast.Note:
-			for _, p := range s.Participants {
-				if !participants[p] {
-					*errors = append(*errors, ValidationError{
-						Line:     s.Pos.Line,
-						Column:   s.Pos.Column,
-						Message:  fmt.Sprintf("note references undefined participant '%s'", p),
-						Severity: SeverityWarning,
-					})
-				}
+		case *ast.Participant:
+			check(s)
+		case *ast.Create:
+			check(&s.Participant)
+		case *ast.Box:
+			for i := range s.Participants {
+				check(&s.Participants[i])
 			}
+		}
+	}
+	return errors
+}
 
-		case *ast.Loop:
-			r.checkNotes(s.Statements, participants, errors)
+// Colours a rect block may use. Names such as lightblue are accepted without
+// checking them against the CSS list.
+var (
+	rgbColourPattern  = regexp.MustCompile(`^(rgba?)\(([^)]*)\)$`)
+	hexColourPattern  = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3,4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+	colourNamePattern = regexp.MustCompile(`^[a-zA-Z]+$`)
+)
 
-		case *ast.Alt:
-			for _, cond := range s.Conditions {
-				r.checkNotes(cond.Statements, participants, errors)
-			}
+// ValidRectColours checks that rect blocks have a well-formed background colour,
+// as in rect rgb(191, 223, 255) or rect rgba(0, 0, 255, .1).
+type ValidRectColours struct{}
+
+// Name returns the name of this validation rule.
+func (r *ValidRectColours) Name() string { return "valid-rect-colours" }
+
+// ValidateSequence checks rect colours.
+func (r *ValidRectColours) ValidateSequence(diagram *ast.SequenceDiagram) []ValidationError {
+	var errors []ValidationError
+	for stmt := range allSeqStatements(diagram.Statements) {
+		s, ok := stmt.(*ast.Rect)
+		if !ok || isValidColour(s.Colour) {
+			continue
+		}
+		pos := s.Pos
+		if s.ColourSpan.IsValid() {
+			pos = s.ColourSpan.Start
+		}
+		errors = append(errors, ValidationError{
+			Line:     pos.Line,
+			Column:   pos.Column,
+			Message:  fmt.Sprintf("invalid rect colour '%s', expected rgb(r, g, b), rgba(r, g, b, a), a hex code or a colour name", s.Colour),
+			Severity: SeverityError,
+		})
+	}
+	return errors
+}
+
+// isValidColour reports whether colour is an rgb() or rgba() colour with values
+// in range, a hex code or a colour name.
+func isValidColour(colour string) bool {
+	if hexColourPattern.MatchString(colour) || colourNamePattern.MatchString(colour) {
+		return true
+	}
+	m := rgbColourPattern.FindStringSubmatch(colour)
+	if m == nil {
+		return false
+	}
+	values := strings.Split(m[2], ",")
+	if len(values) != len(m[1]) {
+		return false
+	}
+	for i, value := range values {
+		value = strings.TrimSpace(value)
+		limit := 255.0
+		if i == 3 {
+			limit = 1
+		}
+		if percent, ok := strings.CutSuffix(value, "%"); ok {
+			value, limit = percent, 100
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || n < 0 || n > limit {
+			return false
+		}
+	}
+	return true
+}
 
-		case *ast.Opt:
-			r.checkNotes(s.Statements, participants, errors)
+// ValidMenuLinks checks that the URLs in link and links statements parse.
+type ValidMenuLinks struct{}
 
-		case *ast.Par:
-			for _, branch := range s.Branches {
-				r.checkNotes(branch.Statements, participants, errors)
+// Name returns the name of this validation rule.
+func (r *ValidMenuLinks) Name() string { return "valid-menu-links" }
+
+// ValidateSequence checks menu link URLs.
+func (r *ValidMenuLinks) ValidateSequence(diagram *ast.SequenceDiagram) []ValidationError {
+	var errors []ValidationError
+	for stmt := range allSeqStatements(diagram.Statements) {
+		s, ok := stmt.(*ast.ParticipantLinks)
+		if !ok {
+			continue
+		}
+		for _, link := range s.Links {
+			problem := urlProblem(link.URL)
+			if problem == "" {
+				continue
+			}
+			pos := s.Pos
+			if link.URLSpan.IsValid() {
+				pos = link.URLSpan.Start
 			}
+			errors = append(errors, ValidationError{
+				Line:     pos.Line,
+				Column:   pos.Column,
+				Message:  fmt.Sprintf("invalid URL '%s' for menu link '%s' of participant '%s': %s", link.URL, link.Label, s.Participant, problem),
+				Severity: SeverityError,
+			})
+		}
+	}
+	return errors
+}
+
+// urlProblem describes why rawURL cannot be used as a link, or returns "" if it can.
+func urlProblem(rawURL string) string {
+	if rawURL == "" {
+		return "it is empty"
+	}
+	// url.Parse accepts almost any text as a relative reference, so require an
+	// absolute URL as Mermaid opens it as written
+	u, err := url.ParseRequestURI(rawURL)
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err.Error()
+	} else if err != nil {
+		return err.Error()
+	}
+	switch {
+	case u.Scheme == "":
+		return "it has no scheme"
+	case (u.Scheme == "http" || u.Scheme == "https") && u.Host == "":
+		return "it has no host"
+	}
+	return ""
+}
 
-		case *ast.Critical:
-			r.checkNotes(s.Statements, participants, errors)
-			for _, opt := range s.Options {
-				r.checkNotes(opt.Statements, participants, errors)
+// allSeqStatements returns every statement in a sequence diagram, in source order,
+// including those nested in loop, alt, opt, par, critical, break and rect blocks.
+func allSeqStatements(statements []ast.SeqStmt) iter.Seq[ast.SeqStmt] {
+	return func(yield func(ast.SeqStmt) bool) {
+		walkSeqStatements(statements, yield)
+	}
+}
+
+func walkSeqStatements(statements []ast.SeqStmt, yield func(ast.SeqStmt) bool) bool {
+	for _, stmt := range statements {
+		if !yield(stmt) {
+			return false
+		}
+		for _, nested := range nestedSeqStatements(stmt) {
+			if !walkSeqStatements(nested, yield) {
+				return false
 			}
+		}
+	}
+	return true
+}
 
-		case *ast.Break:
-			r.checkNotes(s.Statements, participants, errors)
+// nestedSeqStatements returns the statement lists inside a block, one for each
+// branch, or nil if stmt is not a block.
+func nestedSeqStatements(stmt ast.SeqStmt) [][]ast.SeqStmt {
+	switch s := stmt.(type) {
+	case *ast.Loop:
+		return [][]ast.SeqStmt{s.Statements}
+	case *ast.Opt:
+		return [][]ast.SeqStmt{s.Statements}
+	case *ast.Break:
+		return [][]ast.SeqStmt{s.Statements}
+	case *ast.Rect:
+		return [][]ast.SeqStmt{s.Statements}
+	case *ast.Alt:
+		nested := make([][]ast.SeqStmt, len(s.Conditions))
+		for i, cond := range s.Conditions {
+			nested[i] = cond.Statements
 		}
+		return nested
+	case *ast.Par:
+		nested := make([][]ast.SeqStmt, len(s.Branches))
+		for i, branch := range s.Branches {
+			nested[i] = branch.Statements
+		}
+		return nested
+	case *ast.Critical:
+		nested := [][]ast.SeqStmt{s.Statements}
+		for _, opt := range s.Options {
+			nested = append(nested, opt.Statements)
+		}
+		return nested
 	}
+	return nil
 }
 
 // SequenceDefaultRules returns default validation rules for sequence diagrams.
@@ -358,10 +464,33 @@ func SequenceDefaultRules() []SequenceRule {
 		&NoDuplicateParticipants{},
 		&ValidMessageArrows{},
 		&ValidNotePositions{},
+		&ValidParticipantTypes{},
+		&ValidRectColours{},
+		&ValidMenuLinks{},
+		&NoInactiveDeactivations{},
+		&NoOpenActivations{},
+		&NoMessagesAfterDestroy{},
+		&NoCreateAfterUse{},
+		&BalancedBranchActivations{},
+		&NoUnknownStatements{Severity: SeverityWarning},
 	}
 }
 
 // SequenceStrictRules returns strict validation rules for sequence diagrams.
 func SequenceStrictRules() []SequenceRule {
-	return SequenceDefaultRules()
+	return []SequenceRule{
+		&ValidParticipantReferences{},
+		&NoDuplicateParticipants{},
+		&ValidMessageArrows{},
+		&ValidNotePositions{},
+		&ValidParticipantTypes{},
+		&ValidRectColours{},
+		&ValidMenuLinks{},
+		&NoInactiveDeactivations{},
+		&NoOpenActivations{},
+		&NoMessagesAfterDestroy{},
+		&NoCreateAfterUse{},
+		&BalancedBranchActivations{},
+		&NoUnknownStatements{Severity: SeverityError},
+	}
 }
@@ -0,0 +1,289 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
+
+// activationChange is one activation or deactivation of a participant, from an
+// activate or deactivate statement or a message with a + or - marker.
+type activationChange struct {
+	participant string
+	active      bool
+	pos         ast.Position
+}
+
+// activationChanges returns the activation changes a statement makes, ignoring
+// any in nested blocks. A message activates its target, as in A->>+B, or
+// deactivates its source, as in B-->>-A.
+func activationChanges(stmt ast.SeqStmt) []activationChange {
+	switch s := stmt.(type) {
+	case *ast.Activation:
+		return []activationChange{{s.Participant, s.Active, spanStart(s.ParticipantSpan, s.Pos)}}
+	case *ast.Message:
+		if s.Activate {
+			return []activationChange{{s.To, true, spanStart(s.ToSpan, s.Pos)}}
+		}
+		if s.Deactivate {
+			return []activationChange{{s.From, false, spanStart(s.FromSpan, s.Pos)}}
+		}
+	}
+	return nil
+}
+
+// NoInactiveDeactivations checks that participants are only deactivated while
+// active. Mermaid refuses to draw a diagram that deactivates an inactive one.
+// Blocks are followed in source order, as Mermaid draws them.
+type NoInactiveDeactivations struct{}
+
+// Name returns the name of this validation rule.
+func (r *NoInactiveDeactivations) Name() string { return "no-inactive-deactivations" }
+
+// ValidateSequence checks deactivations.
+func (r *NoInactiveDeactivations) ValidateSequence(diagram *ast.SequenceDiagram) []ValidationError {
+	var errors []ValidationError
+	active := make(map[string]int)
+	for stmt := range allSeqStatements(diagram.Statements) {
+		for _, change := range activationChanges(stmt) {
+			if change.active {
+				active[change.participant]++
+				continue
+			}
+			if active[change.participant] == 0 {
+				errors = append(errors, ValidationError{
+					Line:     change.pos.Line,
+					Column:   change.pos.Column,
+					Message:  fmt.Sprintf("participant '%s' is deactivated but is not active", change.participant),
+					Severity: SeverityError,
+				})
+				continue
+			}
+			active[change.participant]--
+		}
+	}
+	return errors
+}
+
+// NoOpenActivations checks that every activation is closed by the end of the
+// diagram. Mermaid draws an open activation to the bottom of the lifeline.
+type NoOpenActivations struct{}
+
+// Name returns the name of this validation rule.
+func (r *NoOpenActivations) Name() string { return "no-open-activations" }
+
+// ValidateSequence checks that activations are closed.
+func (r *NoOpenActivations) ValidateSequence(diagram *ast.SequenceDiagram) []ValidationError {
+	// Open activations in the order they were made. A deactivation closes the
+	// most recent activation of the participant.
+	var open []activationChange
+	for stmt := range allSeqStatements(diagram.Statements) {
+		for _, change := range activationChanges(stmt) {
+			if change.active {
+				open = append(open, change)
+				continue
+			}
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i].participant == change.participant {
+					open = append(open[:i], open[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+
+	var errors []ValidationError
+	for _, change := range open {
+		errors = append(errors, ValidationError{
+			Line:     change.pos.Line,
+			Column:   change.pos.Column,
+			Message:  fmt.Sprintf("activation of participant '%s' is never closed", change.participant),
+			Severity: SeverityWarning,
+		})
+	}
+	return errors
+}
+
+// NoMessagesAfterDestroy checks that a destroyed participant sends and receives
+// no messages after the one that destroys it, which is the first message to or
+// from it after the destroy statement.
+type NoMessagesAfterDestroy struct{}
+
+// Name returns the name of this validation rule.
+func (r *NoMessagesAfterDestroy) Name() string { return "no-messages-after-destroy" }
+
+// ValidateSequence checks messages to and from destroyed participants.
+func (r *NoMessagesAfterDestroy) ValidateSequence(diagram *ast.SequenceDiagram) []ValidationError {
+	var errors []ValidationError
+	destroyed := make(map[string]ast.Position) // Position of each destroy statement
+	gone := make(map[string]bool)              // Whether the destroying message has been sent
+	check := func(id, direction string, span ast.Span, pos ast.Position) {
+		destroyPos, ok := destroyed[id]
+		if !ok {
+			return
+		}
+		if !gone[id] {
+			gone[id] = true
+			return
+		}
+		pos = spanStart(span, pos)
+		errors = append(errors, ValidationError{
+			Line:     pos.Line,
+			Column:   pos.Column,
+			Message:  fmt.Sprintf("message %s participant '%s' after it is destroyed at line %d", direction, id, destroyPos.Line),
+			Severity: SeverityError,
+		})
+	}
+
+	for stmt := range allSeqStatements(diagram.Statements) {
+		switch s := stmt.(type) {
+		case *ast.Destroy:
+			destroyed[s.Participant] = s.Pos
+			gone[s.Participant] = false
+
+		case *ast.Create:
+			delete(destroyed, s.Participant.ID)
+
+		case *ast.Message:
+			check(s.From, "from", s.FromSpan, s.Pos)
+			if s.To != s.From {
+				check(s.To, "to", s.ToSpan, s.Pos)
+			}
+		}
+	}
+	return errors
+}
+
+// NoCreateAfterUse checks that a participant is not created part way through the
+// diagram after it has already appeared in a message, note or other statement.
+type NoCreateAfterUse struct{}
+
+// Name returns the name of this validation rule.
+func (r *NoCreateAfterUse) Name() string { return "no-create-after-use" }
+
+// ValidateSequence checks create statements.
+func (r *NoCreateAfterUse) ValidateSequence(diagram *ast.SequenceDiagram) []ValidationError {
+	var errors []ValidationError
+	used := make(map[string]ast.Position)
+	use := func(id string, pos ast.Position) {
+		if _, ok := used[id]; !ok {
+			used[id] = pos
+		}
+	}
+
+	for stmt := range allSeqStatements(diagram.Statements) {
+		switch s := stmt.(type) {
+		case *ast.Create:
+			if firstPos, ok := used[s.Participant.ID]; ok {
+				pos := spanStart(s.Participant.IDSpan, s.Pos)
+				errors = append(errors, ValidationError{
+					Line:     pos.Line,
+					Column:   pos.Column,
+					Message:  fmt.Sprintf("participant '%s' is created after it is first used at line %d", s.Participant.ID, firstPos.Line),
+					Severity: SeverityError,
+				})
+			}
+		case *ast.Message:
+			use(s.From, s.Pos)
+			use(s.To, s.Pos)
+		case *ast.Activation:
+			use(s.Participant, s.Pos)
+		case *ast.Destroy:
+			use(s.Participant, s.Pos)
+		case *ast.Note:
+			for _, p := range s.Participants {
+				use(p, s.Pos)
+			}
+		case *ast.ParticipantLinks:
+			use(s.Participant, s.Pos)
+		case *ast.ParticipantProperties:
+			use(s.Participant, s.Pos)
+		}
+	}
+	return errors
+}
+
+// BalancedBranchActivations checks that each branch of an alt or par block
+// closes the activations it opens and closes none it did not open. Mermaid draws
+// branches one after another, so an activation left open in one branch carries
+// on into the next.
+type BalancedBranchActivations struct{}
+
+// Name returns the name of this validation rule.
+func (r *BalancedBranchActivations) Name() string { return "balanced-branch-activations" }
+
+// ValidateSequence checks activations in alt and par branches.
+func (r *BalancedBranchActivations) ValidateSequence(diagram *ast.SequenceDiagram) []ValidationError {
+	var errors []ValidationError
+	for stmt := range allSeqStatements(diagram.Statements) {
+		switch s := stmt.(type) {
+		case *ast.Alt:
+			for i, cond := range s.Conditions {
+				keyword := "alt"
+				if i > 0 {
+					keyword = "else"
+				}
+				errors = append(errors, r.checkBranch(keyword, cond.Label, cond.Pos, cond.Statements)...)
+			}
+		case *ast.Par:
+			for i, branch := range s.Branches {
+				keyword := "par"
+				if i > 0 {
+					keyword = "and"
+				}
+				errors = append(errors, r.checkBranch(keyword, branch.Label, branch.Pos, branch.Statements)...)
+			}
+		}
+	}
+	return errors
+}
+
+func (r *BalancedBranchActivations) checkBranch(keyword, label string, pos ast.Position, statements []ast.SeqStmt) []ValidationError {
+	// Net activations of each participant, in the order they first change
+	var order []string
+	net := make(map[string]int)
+	for stmt := range allSeqStatements(statements) {
+		for _, change := range activationChanges(stmt) {
+			if _, ok := net[change.participant]; !ok {
+				order = append(order, change.participant)
+			}
+			if change.active {
+				net[change.participant]++
+			} else {
+				net[change.participant]--
+			}
+		}
+	}
+
+	branch := keyword + " branch"
+	if label != "" {
+		branch += fmt.Sprintf(" '%s'", label)
+	}
+	var errors []ValidationError
+	for _, id := range order {
+		var message string
+		switch {
+		case net[id] > 0:
+			message = fmt.Sprintf("participant '%s' is activated in the %s but not deactivated", id, branch)
+		case net[id] < 0:
+			message = fmt.Sprintf("participant '%s' is deactivated in the %s but not activated", id, branch)
+		default:
+			continue
+		}
+		errors = append(errors, ValidationError{
+			Line:     pos.Line,
+			Column:   pos.Column,
+			Message:  message,
+			Severity: SeverityWarning,
+		})
+	}
+	return errors
+}
+
+// spanStart returns the start of span, or fallback if span is unset.
+func spanStart(span ast.Span, fallback ast.Position) ast.Position {
+	if span.IsValid() {
+		return span.Start
+	}
+	return fallback
+}
@@ -88,12 +88,17 @@ func StateDefaultRules() []StateRule {
 	return []StateRule{
 		&NoDuplicateStates{},
 		&ValidStateReferences{},
+		&NoUnknownStatements{Severity: SeverityWarning},
 	}
 }
 
 // StateStrictRules returns a strict set of validation rules for state diagrams.
 func StateStrictRules() []StateRule {
-	return StateDefaultRules()
+	return []StateRule{
+		&NoDuplicateStates{},
+		&ValidStateReferences{},
+		&NoUnknownStatements{Severity: SeverityError},
+	}
 }
 
 // NewState creates a new state diagram validator with the given rules.
@@ -0,0 +1,60 @@
+package validator_test
+
+import (
+	"testing"
+
+	"github.com/sammcj/mermaid-check/parser"
+	"github.com/sammcj/mermaid-check/validator"
+)
+
+func TestRequireAccessibility(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{
+			name:   "title and description",
+			source: "flowchart LR\n    accTitle: Flow\n    accDescr: A to B\n    A --> B",
+		},
+		{
+			name:   "block description",
+			source: "pie\n    accTitle: Pets\n    accDescr {\n        Pets people own\n    }\n    \"Dogs\" : 3",
+		},
+		{
+			name:   "missing both",
+			source: "sequenceDiagram\n    A->>B: Hi",
+			want: []string{
+				"diagram has no accessible title, add an 'accTitle:' statement",
+				"diagram has no accessible description, add an 'accDescr:' statement or 'accDescr { ... }' block",
+			},
+		},
+		{
+			name:   "missing description",
+			source: "erDiagram\n    accTitle: Orders\n    CUSTOMER ||--o{ ORDER : places",
+			want: []string{
+				"diagram has no accessible description, add an 'accDescr:' statement or 'accDescr { ... }' block",
+			},
+		},
+	}
+
+	rule := &validator.RequireAccessibility{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagram, err := parser.Parse(tt.source)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+
+			got := rule.ValidateDiagram(diagram)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d errors, want %d: %v", len(got), len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				if got[i].Message != want || got[i].Line != 1 || got[i].Severity != validator.SeverityError {
+					t.Errorf("error %d = %+v, want %q on line 1", i, got[i], want)
+				}
+			}
+		})
+	}
+}
@@ -390,14 +390,14 @@ func TestValidateC4(t *testing.T) {
 
 func TestDefaultC4Rules(t *testing.T) {
 	rules := validator.DefaultC4Rules()
-	if len(rules) != 4 {
-		t.Errorf("expected 4 default rules, got %d", len(rules))
+	if len(rules) != 5 {
+		t.Errorf("expected 5 default rules, got %d", len(rules))
 	}
 }
 
 func TestStrictC4Rules(t *testing.T) {
 	rules := validator.StrictC4Rules()
-	if len(rules) != 4 {
-		t.Errorf("expected 4 strict rules, got %d", len(rules))
+	if len(rules) != 5 {
+		t.Errorf("expected 5 strict rules, got %d", len(rules))
 	}
 }
@@ -35,6 +35,19 @@ func TestNoDuplicateClasses(t *testing.T) {
 			},
 			wantErrors: 1,
 		},
+		{
+			name: "duplicate in namespace",
+			diagram: &ast.ClassDiagram{
+				Type: "class",
+				Statements: []ast.ClassStmt{
+					&ast.Class{Name: "Animal", Pos: ast.Position{Line: 2, Column: 1}},
+					&ast.Namespace{Name: "Zoo", Statements: []ast.ClassStmt{
+						&ast.Class{Name: "Animal", Pos: ast.Position{Line: 4, Column: 5}},
+					}},
+				},
+			},
+			wantErrors: 1,
+		},
 	}
 
 	rule := &validator.NoDuplicateClasses{}
@@ -219,15 +232,15 @@ func TestValidRelationshipType(t *testing.T) {
 
 func TestClassDefaultRules(t *testing.T) {
 	rules := validator.ClassDefaultRules()
-	if len(rules) != 4 {
-		t.Errorf("ClassDefaultRules() returned %d rules, want 4", len(rules))
+	if len(rules) != 5 {
+		t.Errorf("ClassDefaultRules() returned %d rules, want 5", len(rules))
 	}
 }
 
 func TestClassStrictRules(t *testing.T) {
 	rules := validator.ClassStrictRules()
-	if len(rules) != 4 {
-		t.Errorf("ClassStrictRules() returned %d rules, want 4", len(rules))
+	if len(rules) != 5 {
+		t.Errorf("ClassStrictRules() returned %d rules, want 5", len(rules))
 	}
 }
 
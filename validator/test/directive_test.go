@@ -0,0 +1,103 @@
+package validator_test
+
+import (
+	"testing"
+
+	"github.com/sammcj/mermaid-check/parser"
+	"github.com/sammcj/mermaid-check/validator"
+)
+
+func TestValidateDirectives(t *testing.T) {
+	tests := []struct {
+		name      string
+		directive string
+		want      []validator.ValidationError
+	}{
+		{
+			name:      "valid",
+			directive: `%%{init: {"theme": "base", "securityLevel": "loose", "themeVariables": {"primaryColor": "#fff", "fontSize": 16}, "flowchart": {"curve": "basis", "htmlLabels": true}, "sequence": {"mirrorActors": false}}}%%`,
+		},
+		{
+			name:      "wrap directive",
+			directive: "%%{wrap}%%",
+		},
+		{
+			name:      "unknown directive",
+			directive: "%%{config: {}}%%",
+			want: []validator.ValidationError{
+				{Line: 1, Column: 4, Message: `unknown directive "config"`, Severity: validator.SeverityWarning},
+			},
+		},
+		{
+			name:      "unknown theme",
+			directive: "%%{init: {'theme': 'midnight'}}%%",
+			want: []validator.ValidationError{
+				{Line: 1, Column: 11, Message: `invalid value midnight for config key "theme": expected one of: default, base, dark, forest, neutral, null`, Severity: validator.SeverityError},
+			},
+		},
+		{
+			name:      "invalid security level",
+			directive: "%%{init: {'securityLevel': 'none'}}%%",
+			want: []validator.ValidationError{
+				{Line: 1, Column: 11, Message: `invalid value none for config key "securityLevel": expected one of: strict, loose, antiscript, sandbox`, Severity: validator.SeverityError},
+			},
+		},
+		{
+			name:      "source order",
+			directive: "%%{init: {\n  'theme': 'midnight',\n  'securityLevel': 'none'\n}}%%",
+			want: []validator.ValidationError{
+				{Line: 2, Column: 3, Message: `invalid value midnight for config key "theme": expected one of: default, base, dark, forest, neutral, null`, Severity: validator.SeverityError},
+				{Line: 3, Column: 3, Message: `invalid value none for config key "securityLevel": expected one of: strict, loose, antiscript, sandbox`, Severity: validator.SeverityError},
+			},
+		},
+		{
+			name:      "nested theme variable",
+			directive: "%%{init: {'themeVariables': {'primaryColor': {'r': 1}}}}%%",
+			want: []validator.ValidationError{
+				{Line: 1, Column: 30, Message: `theme variable "themeVariables.primaryColor" must be a string, number or boolean`, Severity: validator.SeverityError},
+			},
+		},
+		{
+			name:      "per-diagram keys",
+			directive: "%%{init: {'flowchart': {'curve': 'wavy', 'nodeSpacing': 'wide', 'colour': 'red'}}}%%",
+			want: []validator.ValidationError{
+				{Line: 1, Column: 25, Message: `invalid value wavy for config key "flowchart.curve": expected one of: basis, bumpX, bumpY, cardinal, catmullRom, linear, monotoneX, monotoneY, natural, step, stepAfter, stepBefore`, Severity: validator.SeverityError},
+				{Line: 1, Column: 42, Message: `config key "flowchart.nodeSpacing" must be a number, got wide`, Severity: validator.SeverityError},
+				{Line: 1, Column: 65, Message: `unknown Mermaid config key "flowchart.colour"`, Severity: validator.SeverityWarning},
+			},
+		},
+		{
+			name:      "section not a mapping",
+			directive: "%%{init: {'sequence': true}}%%",
+			want: []validator.ValidationError{
+				{Line: 1, Column: 11, Message: `config key "sequence" must be a mapping, got true`, Severity: validator.SeverityError},
+			},
+		},
+		{
+			name:      "unknown key",
+			directive: "%%{init: {'colour': 'red'}}%%",
+			want: []validator.ValidationError{
+				{Line: 1, Column: 11, Message: `unknown Mermaid config key "colour"`, Severity: validator.SeverityWarning},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagram, err := parser.Parse(tt.directive + "\nflowchart LR\n    A --> B")
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+
+			got := validator.ValidateDirectives(diagram.GetMetadata().Directives)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d errors, want %d: %v", len(got), len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				if *got[i] != want {
+					t.Errorf("error %d = %+v, want %+v", i, *got[i], want)
+				}
+			}
+		})
+	}
+}
@@ -182,8 +182,8 @@ func TestERDefaultRules(t *testing.T) {
 	if len(rules) == 0 {
 		t.Error("ERDefaultRules() returned empty slice")
 	}
-	if len(rules) != 3 {
-		t.Errorf("expected 3 default rules, got %d", len(rules))
+	if len(rules) != 4 {
+		t.Errorf("expected 4 default rules, got %d", len(rules))
 	}
 }
 
@@ -0,0 +1,99 @@
+package validator_test
+
+import (
+	"testing"
+
+	"github.com/sammcj/mermaid-check/parser"
+	"github.com/sammcj/mermaid-check/validator"
+)
+
+func TestValidateFrontmatter(t *testing.T) {
+	tests := []struct {
+		name        string
+		frontmatter string
+		want        []validator.ValidationError
+	}{
+		{
+			name:        "valid",
+			frontmatter: "title: Flow\ndisplayMode: compact\nconfig:\n  theme: forest\n  look: handDrawn\n  htmlLabels: true\n  maxTextSize: 90000\n  themeVariables:\n    primaryColor: '#fff'\n  flowchart:\n    curve: basis",
+		},
+		{
+			name:        "unknown top-level key",
+			frontmatter: "title: Flow\ntheme: dark",
+			want: []validator.ValidationError{
+				{Line: 3, Column: 1, Message: `unknown frontmatter key "theme"`, Severity: validator.SeverityWarning},
+			},
+		},
+		{
+			name:        "unknown config key",
+			frontmatter: "config:\n  colour: red",
+			want: []validator.ValidationError{
+				{Line: 3, Column: 3, Message: `unknown Mermaid config key "config.colour"`, Severity: validator.SeverityWarning},
+			},
+		},
+		{
+			name:        "invalid theme",
+			frontmatter: "config:\n  theme: sparkly",
+			want: []validator.ValidationError{
+				{Line: 3, Column: 3, Message: `invalid value sparkly for config key "config.theme": expected one of: default, base, dark, forest, neutral, null`, Severity: validator.SeverityError},
+			},
+		},
+		{
+			name:        "wrong types",
+			frontmatter: "config:\n  htmlLabels: yes please\n  flowchart: basis",
+			want: []validator.ValidationError{
+				{Line: 3, Column: 3, Message: `config key "config.htmlLabels" must be a boolean, got yes please`, Severity: validator.SeverityError},
+				{Line: 4, Column: 3, Message: `config key "config.flowchart" must be a mapping, got basis`, Severity: validator.SeverityError},
+			},
+		},
+		{
+			name:        "source order",
+			frontmatter: "config:\n  theme: sparkly\n  securityLevel: none\naccent: red",
+			want: []validator.ValidationError{
+				{Line: 3, Column: 3, Message: `invalid value sparkly for config key "config.theme": expected one of: default, base, dark, forest, neutral, null`, Severity: validator.SeverityError},
+				{Line: 4, Column: 3, Message: `invalid value none for config key "config.securityLevel": expected one of: strict, loose, antiscript, sandbox`, Severity: validator.SeverityError},
+				{Line: 5, Column: 1, Message: `unknown frontmatter key "accent"`, Severity: validator.SeverityWarning},
+			},
+		},
+		{
+			name:        "config not a mapping",
+			frontmatter: "config: dark",
+			want: []validator.ValidationError{
+				{Line: 2, Column: 1, Message: "frontmatter config must be a mapping", Severity: validator.SeverityError},
+			},
+		},
+		{
+			name:        "title not a string",
+			frontmatter: "title:\n  - one\n  - two",
+			want: []validator.ValidationError{
+				{Line: 2, Column: 1, Message: "frontmatter title must be a string", Severity: validator.SeverityError},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := "---\n" + tt.frontmatter + "\n---\nflowchart LR\n    A --> B"
+			diagram, err := parser.Parse(source)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+
+			got := validator.ValidateFrontmatter(diagram.GetMetadata().Frontmatter)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d errors, want %d: %v", len(got), len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				if *got[i] != want {
+					t.Errorf("error %d = %+v, want %+v", i, *got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateFrontmatterNil(t *testing.T) {
+	if got := validator.ValidateFrontmatter(nil); got != nil {
+		t.Errorf("expected no errors, got %v", got)
+	}
+}
@@ -0,0 +1,110 @@
+package validator_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sammcj/mermaid-check/ast"
+	"github.com/sammcj/mermaid-check/parser"
+	"github.com/sammcj/mermaid-check/validator"
+)
+
+func TestLintRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   validator.Rule
+		source string
+		want   []string
+	}{
+		{
+			name:   "isolated nodes",
+			rule:   &validator.NoIsolatedNodes{},
+			source: "flowchart LR\n    A --> B\n    C[Orphan]\n    subgraph s\n        D\n    end",
+			want:   []string{"3:5 node 'C' has no links", "5:9 node 'D' has no links"},
+		},
+		{
+			name:   "unreachable nodes",
+			rule:   &validator.NoUnreachableNodes{},
+			source: "flowchart LR\n    A --> B\n    C --> D\n    D --> C\n    B --> C",
+			want:   nil,
+		},
+		{
+			name:   "unreachable cycle",
+			rule:   &validator.NoUnreachableNodes{},
+			source: "flowchart LR\n    A --> B\n    C --> D\n    D --> C",
+			want:   []string{"3:5 node 'C' is not reachable from any start node", "3:11 node 'D' is not reachable from any start node"},
+		},
+		{
+			name:   "cycle in acyclic flowchart",
+			rule:   &validator.NoUnexpectedCycles{},
+			source: "flowchart LR\n    %% mermaid-check: acyclic\n    A --> B --> C\n    C --> B\n    D --> D",
+			want:   []string{"3:11 cycle through B, C in a flowchart marked acyclic", "5:5 cycle through D in a flowchart marked acyclic"},
+		},
+		{
+			name:   "cycle in unmarked flowchart",
+			rule:   &validator.NoUnexpectedCycles{},
+			source: "flowchart LR\n    %% acyclic\n    A --> B --> A",
+			want:   nil,
+		},
+		{
+			name:   "undefined classes",
+			rule:   &validator.NoUndefinedClasses{},
+			source: "flowchart LR\n    A:::missing --> B\n    class B other\n    class A used\n    classDef used fill:#f96",
+			want:   []string{`2:9 node "A" references undefined class "missing"`, `3:13 class statement references undefined class "other"`},
+		},
+		{
+			name:   "unused classes",
+			rule:   &validator.NoUnusedClasses{},
+			source: "flowchart LR\n    A:::used --> B\n    class B other\n    classDef used fill:#f96\n    classDef other fill:#fff\n    classDef spare fill:#000\n    classDef default fill:#eee",
+			want:   []string{"6:14 class 'spare' is defined but never used"},
+		},
+		{
+			name:   "empty subgraphs",
+			rule:   &validator.NoEmptySubgraphs{},
+			source: "flowchart LR\n    subgraph outer\n        subgraph inner [Inner]\n            direction TB\n            %% nothing yet\n        end\n    end\n    subgraph full\n        A\n    end",
+			want:   []string{"3:9 subgraph 'Inner' is empty"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := parser.NewFlowchartParser().Parse(tt.source)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			errors := tt.rule.Validate(d.(*ast.Flowchart))
+			var got []string
+			for _, e := range errors {
+				if e.Severity != validator.SeverityWarning {
+					t.Errorf("expected a warning, got %v", e.Severity)
+				}
+				got = append(got, fmt.Sprintf("%d:%d %s", e.Line, e.Column, e.Message))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintRuleNames(t *testing.T) {
+	want := []string{"no-isolated-nodes", "no-unreachable-nodes", "no-unexpected-cycles", "no-undefined-classes", "no-unused-classes", "no-empty-subgraphs"}
+
+	var names []string
+	for _, rule := range validator.LintRules() {
+		names = append(names, rule.Name())
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("LintRules() names = %v, want %v", names, want)
+	}
+
+	for _, name := range want {
+		if rule, ok := validator.LintRule(name); !ok || rule.Name() != name {
+			t.Errorf("LintRule(%q) = %v, %v", name, rule, ok)
+		}
+	}
+	if _, ok := validator.LintRule("no-undefined-nodes"); ok {
+		t.Error("expected LintRule to only find lint rules")
+	}
+}
@@ -0,0 +1,51 @@
+package validator_test
+
+import (
+	"testing"
+
+	"github.com/sammcj/mermaid-check/ast"
+	"github.com/sammcj/mermaid-check/validator"
+)
+
+func TestRegister(t *testing.T) {
+	validate := func(diagram ast.Diagram, strict bool) []validator.ValidationError {
+		return []validator.ValidationError{{Line: 1, Column: 1, Message: diagram.GetType(), Severity: validator.SeverityWarning}}
+	}
+	validator.Register("registry-test", validate)
+
+	fn, ok := validator.Lookup("registry-test")
+	if !ok {
+		t.Fatal("expected the validator to be registered")
+	}
+	got := fn(ast.NewGenericDiagram("registry-test", "", ast.Position{Line: 1, Column: 1}), false)
+	if len(got) != 1 || got[0].Message != "registry-test" {
+		t.Errorf("registered validator returned %v", got)
+	}
+	if _, ok := validator.Lookup("unregistered"); ok {
+		t.Error("expected no validator for an unregistered type")
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	validator.Register("registry-dup", func(ast.Diagram, bool) []validator.ValidationError { return nil })
+
+	tests := []struct {
+		name        string
+		diagramType string
+		fn          validator.ValidateFunc
+	}{
+		{name: "duplicate type", diagramType: "registry-dup", fn: func(ast.Diagram, bool) []validator.ValidationError { return nil }},
+		{name: "nil func", diagramType: "registry-nil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected Register to panic")
+				}
+			}()
+			validator.Register(tt.diagramType, tt.fn)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package validator_test
 import (
 	"testing"
 
+	"github.com/sammcj/mermaid-check/ast"
 	"github.com/sammcj/mermaid-check/validator"
 )
 
@@ -17,6 +18,14 @@ func TestRuleNames(t *testing.T) {
 		{"NoDuplicateParticipants", &validator.NoDuplicateParticipants{}, "no-duplicate-participants"},
 		{"ValidMessageArrows", &validator.ValidMessageArrows{}, "valid-message-arrows"},
 		{"ValidNotePositions", &validator.ValidNotePositions{}, "valid-note-positions"},
+		{"ValidParticipantTypes", &validator.ValidParticipantTypes{}, "valid-participant-types"},
+		{"ValidRectColours", &validator.ValidRectColours{}, "valid-rect-colours"},
+		{"ValidMenuLinks", &validator.ValidMenuLinks{}, "valid-menu-links"},
+		{"NoInactiveDeactivations", &validator.NoInactiveDeactivations{}, "no-inactive-deactivations"},
+		{"NoOpenActivations", &validator.NoOpenActivations{}, "no-open-activations"},
+		{"NoMessagesAfterDestroy", &validator.NoMessagesAfterDestroy{}, "no-messages-after-destroy"},
+		{"NoCreateAfterUse", &validator.NoCreateAfterUse{}, "no-create-after-use"},
+		{"BalancedBranchActivations", &validator.BalancedBranchActivations{}, "balanced-branch-activations"},
 
 		// Class rules
 		{"NoDuplicateClasses", &validator.NoDuplicateClasses{}, "no-duplicate-classes"},
@@ -25,6 +34,10 @@ func TestRuleNames(t *testing.T) {
 		// State rules
 		{"NoDuplicateStates", &validator.NoDuplicateStates{}, "no-duplicate-states"},
 		{"ValidStateReferences", &validator.ValidStateReferences{}, "valid-state-references"},
+
+		// Shared rules
+		{"NoUnknownStatements", &validator.NoUnknownStatements{}, "no-unknown-statements"},
+		{"UnknownLines", &validator.UnknownLines[*ast.PieDiagram]{}, "no-unknown-statements"},
 	}
 
 	for _, tt := range tests {
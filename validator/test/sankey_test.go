@@ -223,7 +223,7 @@ func TestSankeyDefaultRules(t *testing.T) {
 	if len(rules) == 0 {
 		t.Error("validator.SankeyDefaultRules() returned empty slice")
 	}
-	expectedRuleCount := 4
+	expectedRuleCount := 5
 	if len(rules) != expectedRuleCount {
 		t.Errorf("expected %d rules, got %d", expectedRuleCount, len(rules))
 	}
@@ -0,0 +1,102 @@
+package validator_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sammcj/mermaid-check/ast"
+	"github.com/sammcj/mermaid-check/parser"
+	"github.com/sammcj/mermaid-check/validator"
+)
+
+func TestSequenceLifecycleRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   validator.SequenceRule
+		source string
+		want   []string
+	}{
+		{
+			name:   "balanced activations",
+			rule:   &validator.NoInactiveDeactivations{},
+			source: "sequenceDiagram\n    A->>+B: Hi\n    activate B\n    deactivate B\n    B-->>-A: Bye",
+			want:   nil,
+		},
+		{
+			name:   "deactivating inactive participants",
+			rule:   &validator.NoInactiveDeactivations{},
+			source: "sequenceDiagram\n    A->>+B: Hi\n    B-->>-A: Bye\n    B-->>-A: Again\n    deactivate A",
+			want:   []string{"4:5 participant 'B' is deactivated but is not active", "5:16 participant 'A' is deactivated but is not active"},
+		},
+		{
+			name:   "activations closed",
+			rule:   &validator.NoOpenActivations{},
+			source: "sequenceDiagram\n    activate A\n    A->>+B: Hi\n    B-->>-A: Bye\n    deactivate A",
+			want:   nil,
+		},
+		{
+			name:   "activations left open",
+			rule:   &validator.NoOpenActivations{},
+			source: "sequenceDiagram\n    activate A\n    loop every minute\n        A->>+B: Poll\n        A->>+B: Poll again\n    end\n    B-->>-A: Done",
+			want:   []string{"2:14 activation of participant 'A' is never closed", "4:14 activation of participant 'B' is never closed"},
+		},
+		{
+			name:   "destroying message",
+			rule:   &validator.NoMessagesAfterDestroy{},
+			source: "sequenceDiagram\n    create participant C\n    A->>C: Hi\n    destroy C\n    A-xC: Bye\n    A->>B: Carry on",
+			want:   nil,
+		},
+		{
+			name:   "messages after destroy",
+			rule:   &validator.NoMessagesAfterDestroy{},
+			source: "sequenceDiagram\n    destroy C\n    C->>A: Bye\n    A->>C: Still there?\n    opt retry\n        C-->>A: Yes\n    end",
+			want:   []string{"4:9 message to participant 'C' after it is destroyed at line 2", "6:9 message from participant 'C' after it is destroyed at line 2"},
+		},
+		{
+			name:   "create before use",
+			rule:   &validator.NoCreateAfterUse{},
+			source: "sequenceDiagram\n    A->>B: Hi\n    create actor C\n    A->>C: Welcome",
+			want:   nil,
+		},
+		{
+			name:   "create after use",
+			rule:   &validator.NoCreateAfterUse{},
+			source: "sequenceDiagram\n    Note over A,C: Start\n    alt new\n        create participant C\n    end",
+			want:   []string{"4:28 participant 'C' is created after it is first used at line 2"},
+		},
+		{
+			name:   "balanced branches",
+			rule:   &validator.BalancedBranchActivations{},
+			source: "sequenceDiagram\n    alt ok\n        A->>+B: Hi\n        B-->>-A: Bye\n    else\n        A->>B: Nothing\n    end",
+			want:   nil,
+		},
+		{
+			name:   "unbalanced branches",
+			rule:   &validator.BalancedBranchActivations{},
+			source: "sequenceDiagram\n    activate B\n    alt ok\n        A->>+B: Hi\n    else failed\n        deactivate B\n    end\n    par first\n        A->>+C: One\n    and\n        C-->>-A: Two\n    end",
+			want: []string{
+				"3:5 participant 'B' is activated in the alt branch 'ok' but not deactivated",
+				"5:5 participant 'B' is deactivated in the else branch 'failed' but not activated",
+				"8:5 participant 'C' is activated in the par branch 'first' but not deactivated",
+				"10:5 participant 'C' is deactivated in the and branch but not activated",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := parser.NewSequenceParser().Parse(tt.source)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			var got []string
+			for _, e := range tt.rule.ValidateSequence(d.(*ast.SequenceDiagram)) {
+				got = append(got, fmt.Sprintf("%d:%d %s", e.Line, e.Column, e.Message))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
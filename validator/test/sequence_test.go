@@ -1,9 +1,12 @@
 package validator_test
 
 import (
+	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/sammcj/mermaid-check/ast"
+	"github.com/sammcj/mermaid-check/parser"
 	"github.com/sammcj/mermaid-check/validator"
 )
 
@@ -49,6 +52,60 @@ func TestNoDuplicateParticipants(t *testing.T) {
 	}
 }
 
+func TestNoDuplicateCreatedParticipants(t *testing.T) {
+	diagram := &ast.SequenceDiagram{
+		Type: "sequence",
+		Statements: []ast.SeqStmt{
+			&ast.Participant{ID: "Alice", Pos: ast.Position{Line: 2, Column: 5}},
+			&ast.Create{Participant: ast.Participant{ID: "Carl", Pos: ast.Position{Line: 3, Column: 12}}},
+			&ast.Destroy{Participant: "Carl", Pos: ast.Position{Line: 4, Column: 5}},
+			&ast.Create{Participant: ast.Participant{ID: "Alice", Pos: ast.Position{Line: 5, Column: 12}}},
+		},
+	}
+
+	errors := (&validator.NoDuplicateParticipants{}).ValidateSequence(diagram)
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, got %v", errors)
+	}
+	if e := errors[0]; e.Line != 5 || e.Column != 12 || e.Message != "duplicate participant ID 'Alice', first defined at line 2" {
+		t.Errorf("unexpected error: %+v", e)
+	}
+}
+
+func TestValidParticipantTypes(t *testing.T) {
+	diagram := &ast.SequenceDiagram{
+		Type: "sequence",
+		Statements: []ast.SeqStmt{
+			&ast.Participant{ID: "A", Type: "participant", Pos: ast.Position{Line: 2, Column: 5}},
+			&ast.Participant{ID: "DB", Type: "database", Pos: ast.Position{Line: 3, Column: 5}},
+			&ast.Participant{ID: "B", Pos: ast.Position{Line: 4, Column: 5}},
+			&ast.Participant{
+				ID: "C", Type: "cloud", Pos: ast.Position{Line: 5, Column: 5},
+				TypeSpan: ast.Span{Start: ast.Position{Line: 5, Column: 29}, End: ast.Position{Line: 5, Column: 34}},
+			},
+			&ast.Loop{Label: "retry", Statements: []ast.SeqStmt{
+				&ast.Create{Participant: ast.Participant{ID: "W", Type: "worker", Pos: ast.Position{Line: 7, Column: 16}}},
+			}},
+			&ast.Box{Label: "Group", Participants: []ast.Participant{
+				{ID: "Q", Type: "queue", Pos: ast.Position{Line: 10, Column: 9}},
+			}},
+		},
+	}
+
+	errors := (&validator.ValidParticipantTypes{}).ValidateSequence(diagram)
+	want := []string{"5:29 unknown type 'cloud' for participant 'C'", "7:16 unknown type 'worker' for participant 'W'"}
+	var got []string
+	for _, e := range errors {
+		if e.Severity != validator.SeverityError {
+			t.Errorf("expected an error, got %v", e.Severity)
+		}
+		got = append(got, fmt.Sprintf("%d:%d %s", e.Line, e.Column, e.Message))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
 func TestValidMessageArrows(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -388,3 +445,118 @@ func TestValidNotePositionsExtended(t *testing.T) {
 		})
 	}
 }
+
+func TestValidRectColours(t *testing.T) {
+	tests := []struct {
+		colour string
+		valid  bool
+	}{
+		{"rgb(191, 223, 255)", true},
+		{"rgba(0, 0, 255, .1)", true},
+		{"rgb(100%, 50%, 0%)", true},
+		{"rgba(0,0,0,50%)", true},
+		{"#eef", true},
+		{"#1e90ffcc", true},
+		{"lightblue", true},
+		{"rgb(256, 0, 0)", false},
+		{"rgb(0, 0)", false},
+		{"rgba(0, 0, 0)", false},
+		{"rgba(0, 0, 0, 2)", false},
+		{"rgb(a, b, c)", false},
+		{"#ee", false},
+		{"light blue", false},
+		{"rgb(0, 0, 0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.colour, func(t *testing.T) {
+			diagram := &ast.SequenceDiagram{
+				Type: "sequence",
+				Statements: []ast.SeqStmt{
+					&ast.Opt{Label: "maybe", Statements: []ast.SeqStmt{
+						&ast.Rect{
+							Colour:     tt.colour,
+							Pos:        ast.Position{Line: 3, Column: 9},
+							ColourSpan: ast.Span{Start: ast.Position{Line: 3, Column: 14}, End: ast.Position{Line: 3, Column: 14 + len(tt.colour)}},
+						},
+					}},
+				},
+			}
+			errors := (&validator.ValidRectColours{}).ValidateSequence(diagram)
+			if tt.valid {
+				if len(errors) != 0 {
+					t.Errorf("unexpected errors: %v", errors)
+				}
+				return
+			}
+			want := "invalid rect colour '" + tt.colour + "', expected rgb(r, g, b), rgba(r, g, b, a), a hex code or a colour name"
+			if len(errors) != 1 || errors[0].Message != want || errors[0].Line != 3 || errors[0].Column != 14 {
+				t.Errorf("errors = %v, want %q at 3:14", errors, want)
+			}
+		})
+	}
+}
+
+func TestValidMenuLinks(t *testing.T) {
+	diagram := &ast.SequenceDiagram{
+		Type: "sequence",
+		Statements: []ast.SeqStmt{
+			&ast.ParticipantLinks{
+				Participant: "Alice",
+				Links: []ast.MenuLink{
+					{Label: "Dashboard", URL: "https://dashboard.contoso.com/alice"},
+					{Label: "Mail", URL: "mailto:alice@contoso.com"},
+				},
+				Pos: ast.Position{Line: 2, Column: 5},
+			},
+			&ast.Rect{Colour: "#eee", Statements: []ast.SeqStmt{
+				&ast.ParticipantLinks{
+					Participant: "Bob",
+					Links: []ast.MenuLink{
+						{Label: "Wiki", URL: "https://wiki contoso.com", URLSpan: ast.Span{Start: ast.Position{Line: 4, Column: 30}, End: ast.Position{Line: 4, Column: 53}}},
+						{Label: "Empty", URL: ""},
+						{Label: "Port", URL: "http://host:port"},
+						{Label: "Dash", URL: "not a url"},
+						{Label: "Docs", URL: "/docs/bob.html"},
+						{Label: "Home", URL: "https:///bob"},
+					},
+					JSON: true,
+					Pos:  ast.Position{Line: 4, Column: 9},
+				},
+			}},
+		},
+	}
+
+	want := []string{
+		`4:30 invalid URL 'https://wiki contoso.com' for menu link 'Wiki' of participant 'Bob': invalid character " " in host name`,
+		"4:9 invalid URL '' for menu link 'Empty' of participant 'Bob': it is empty",
+		`4:9 invalid URL 'http://host:port' for menu link 'Port' of participant 'Bob': invalid port ":port" after host`,
+		"4:9 invalid URL 'not a url' for menu link 'Dash' of participant 'Bob': invalid URI for request",
+		"4:9 invalid URL '/docs/bob.html' for menu link 'Docs' of participant 'Bob': it has no scheme",
+		"4:9 invalid URL 'https:///bob' for menu link 'Home' of participant 'Bob': it has no host",
+	}
+	var got []string
+	for _, e := range (&validator.ValidMenuLinks{}).ValidateSequence(diagram) {
+		got = append(got, fmt.Sprintf("%d:%d %s", e.Line, e.Column, e.Message))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestValidMenuLinksParsed(t *testing.T) {
+	source := "sequenceDiagram\n    participant A\n    link A: Dash @ not a url"
+
+	d, err := parser.NewSequenceParser().Parse(source)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	var got []string
+	for _, e := range (&validator.ValidMenuLinks{}).ValidateSequence(d.(*ast.SequenceDiagram)) {
+		got = append(got, fmt.Sprintf("%d:%d %s", e.Line, e.Column, e.Message))
+	}
+	want := []string{"3:20 invalid URL 'not a url' for menu link 'Dash' of participant 'A': invalid URI for request"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
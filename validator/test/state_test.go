@@ -113,15 +113,15 @@ func TestValidStateReferences(t *testing.T) {
 
 func TestStateDefaultRules(t *testing.T) {
 	rules := validator.StateDefaultRules()
-	if len(rules) != 2 {
-		t.Errorf("StateDefaultRules() returned %d rules, want 2", len(rules))
+	if len(rules) != 3 {
+		t.Errorf("StateDefaultRules() returned %d rules, want 3", len(rules))
 	}
 }
 
 func TestStateStrictRules(t *testing.T) {
 	rules := validator.StateStrictRules()
-	if len(rules) != 2 {
-		t.Errorf("StateStrictRules() returned %d rules, want 2", len(rules))
+	if len(rules) != 3 {
+		t.Errorf("StateStrictRules() returned %d rules, want 3", len(rules))
 	}
 }
 
@@ -340,7 +340,7 @@ func TestNoEmptyPeriodsRule(t *testing.T) {
 func TestTimelineDefaultRules(t *testing.T) {
 	rules := validator.TimelineDefaultRules()
 
-	expectedRules := 2
+	expectedRules := 3
 	if len(rules) != expectedRules {
 		t.Errorf("expected %d default rules, got %d", expectedRules, len(rules))
 	}
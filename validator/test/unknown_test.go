@@ -0,0 +1,364 @@
+package validator_test
+
+import (
+	"testing"
+
+	"github.com/sammcj/mermaid-check/ast"
+	"github.com/sammcj/mermaid-check/parser"
+	"github.com/sammcj/mermaid-check/validator"
+)
+
+func TestNoUnknownStatements(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		wantLines  []int
+		wantColumn int
+	}{
+		{
+			name: "flowchart typo",
+			source: `flowchart TD
+    A --> B
+    A -> C`,
+			wantLines:  []int{3},
+			wantColumn: 5,
+		},
+		{
+			name: "flowchart typo in subgraph",
+			source: `flowchart TD
+    subgraph one
+      A -> B
+    end`,
+			wantLines:  []int{3},
+			wantColumn: 7,
+		},
+		{
+			name: "state typo",
+			source: `stateDiagram-v2
+    [*] --> Still
+    stat Moving`,
+			wantLines:  []int{3},
+			wantColumn: 5,
+		},
+		{
+			name: "state typo in composite state",
+			source: `stateDiagram-v2
+    state Moving {
+        [*] --> Slow
+        Slow => Fast
+    }`,
+			wantLines:  []int{4},
+			wantColumn: 9,
+		},
+		{
+			name: "class typo",
+			source: `classDiagram
+    class Animal
+    Animal <|-- Duck
+    clas Fish`,
+			wantLines:  []int{4},
+			wantColumn: 5,
+		},
+		{
+			name: "class body typo",
+			source: `classDiagram
+    class Animal {
+        +name string
+        ??? oops
+    }`,
+			wantLines:  []int{4},
+			wantColumn: 9,
+		},
+		{
+			name: "class namespace typo",
+			source: `classDiagram
+    namespace Zoo {
+        class Animal
+        Animal -> Keeper
+    }`,
+			wantLines:  []int{4},
+			wantColumn: 9,
+		},
+		{
+			name: "sequence typo",
+			source: `sequenceDiagram
+    Alice->>Bob: Hi
+    participnt Carol`,
+			wantLines:  []int{3},
+			wantColumn: 5,
+		},
+		{
+			name: "sequence typo in loop",
+			source: `sequenceDiagram
+    loop Every minute
+        participnt Carol
+    end`,
+			wantLines:  []int{3},
+			wantColumn: 9,
+		},
+		{
+			name: "er typo",
+			source: `erDiagram
+    CUSTOMER ||--o{ ORDER : places
+    CUSTOMER --> ORDER`,
+			wantLines:  []int{3},
+			wantColumn: 5,
+		},
+		{
+			name: "gantt typo",
+			source: `gantt
+    dateFormat YYYY-MM-DD
+    section Work
+        Task A :a1, 2024-01-01, 3d
+        Task B 2024-01-04`,
+			wantLines:  []int{5},
+			wantColumn: 9,
+		},
+		{
+			name: "pie typo",
+			source: `pie
+    "Dogs" : 386
+    Cats : 85`,
+			wantLines:  []int{3},
+			wantColumn: 5,
+		},
+		{
+			name: "journey typo",
+			source: `journey
+    section Morning
+      Wake up: 3: Me
+      Make tea 5 Me`,
+			wantLines:  []int{4},
+			wantColumn: 7,
+		},
+		{
+			name: "gitGraph typo",
+			source: `gitGraph
+    commit
+    comit`,
+			wantLines:  []int{3},
+			wantColumn: 5,
+		},
+		{
+			name: "c4 typo",
+			source: `C4Context
+    Person(user, "User")
+    Persn(admin, "Admin")`,
+			wantLines:  []int{3},
+			wantColumn: 5,
+		},
+		{
+			name: "c4 typo in boundary",
+			source: `C4Context
+    System_Boundary(b1, "Boundary") {
+        Sytem(sys, "System")
+    }`,
+			wantLines:  []int{3},
+			wantColumn: 9,
+		},
+		{
+			name: "xychart typo",
+			source: `xychart-beta
+    x-axis [a, b]
+    y-axis "Y" 0 --> 10
+    bar [5, 8]
+    bra [1, 2]`,
+			wantLines:  []int{5},
+			wantColumn: 5,
+		},
+		{
+			name: "quadrant typo",
+			source: `quadrantChart
+    x-axis Low --> High
+    y-axis Low --> High
+    Point A: [0.5, 0.5]
+    Point B 0.2`,
+			wantLines:  []int{5},
+			wantColumn: 5,
+		},
+		{
+			name: "sankey typo",
+			source: `sankey-beta
+    A,B,10
+    B,C`,
+			wantLines:  []int{3},
+			wantColumn: 5,
+		},
+		{
+			name: "timeline typo",
+			source: `timeline
+    2020 : Founded
+    2021 Launched`,
+			wantLines:  []int{3},
+			wantColumn: 5,
+		},
+		{
+			name: "valid flowchart",
+			source: `flowchart TD
+    A --> B`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagram, err := parser.Parse(tt.source)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			for _, severity := range []validator.Severity{validator.SeverityWarning, validator.SeverityError} {
+				rule := &validator.NoUnknownStatements{Severity: severity}
+				var errors []validator.ValidationError
+				switch d := diagram.(type) {
+				case *ast.Flowchart:
+					errors = rule.Validate(d)
+				case *ast.StateDiagram:
+					errors = rule.ValidateState(d)
+				case *ast.ClassDiagram:
+					errors = rule.ValidateClass(d)
+				case *ast.SequenceDiagram:
+					errors = rule.ValidateSequence(d)
+				case *ast.ERDiagram:
+					errors = validateUnknownLines(d, severity)
+				case *ast.GanttDiagram:
+					errors = validateUnknownLines(d, severity)
+				case *ast.PieDiagram:
+					errors = validateUnknownLines(d, severity)
+				case *ast.JourneyDiagram:
+					errors = validateUnknownLines(d, severity)
+				case *ast.GitGraphDiagram:
+					errors = validateUnknownLines(d, severity)
+				case *ast.C4Diagram:
+					errors = validateUnknownLines(d, severity)
+				case *ast.XYChartDiagram:
+					errors = validateUnknownLines(d, severity)
+				case *ast.QuadrantDiagram:
+					errors = validateUnknownLines(d, severity)
+				case *ast.SankeyDiagram:
+					errors = validateUnknownLines(d, severity)
+				case *ast.TimelineDiagram:
+					errors = validateUnknownLines(d, severity)
+				default:
+					t.Fatalf("unexpected diagram type %T", diagram)
+				}
+
+				if len(errors) != len(tt.wantLines) {
+					t.Fatalf("got %d errors, want %d: %v", len(errors), len(tt.wantLines), errors)
+				}
+				for i, e := range errors {
+					if e.Line != tt.wantLines[i] {
+						t.Errorf("error %d: line = %d, want %d", i, e.Line, tt.wantLines[i])
+					}
+					if e.Column != tt.wantColumn {
+						t.Errorf("error %d: column = %d, want %d", i, e.Column, tt.wantColumn)
+					}
+					if e.Severity != severity {
+						t.Errorf("error %d: severity = %v, want %v", i, e.Severity, severity)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNoUnknownStatementsInRuleSets(t *testing.T) {
+	sources := map[string]string{
+		"flowchart": "flowchart TD\n    A -> B",
+		"sequence":  "sequenceDiagram\n    participnt Carol",
+		"er":        "erDiagram\n    CUSTOMER --> ORDER",
+		"gantt":     "gantt\n    Task B\n    dateFormat YYYY-MM-DD\n    section Work\n        Task A :a1, 2024-01-01, 3d",
+		"pie":       "pie\n    Cats : 85\n    \"Dogs\" : 386",
+		"journey":   "journey\n    Make tea 5 Me\n    section Morning\n      Wake up: 3: Me",
+		"gitGraph":  "gitGraph\n    comit\n    commit",
+		"c4":        "C4Context\n    Persn(admin, \"Admin\")",
+		"xychart":   "xychart-beta\n    bra [1, 2]\n    x-axis [a, b]\n    y-axis \"Y\" 0 --> 10\n    bar [5, 8]",
+		"quadrant":  "quadrantChart\n    Point B 0.2\n    x-axis Low --> High\n    y-axis Low --> High\n    Point A: [0.5, 0.5]",
+		"sankey":    "sankey-beta\n    B,C\n    A,B,10",
+		"timeline":  "timeline\n    2021 Launched\n    2020 : Founded",
+	}
+
+	for name, source := range sources {
+		diagram, err := parser.Parse(source)
+		if err != nil {
+			t.Fatalf("%s: Parse() error = %v", name, err)
+		}
+
+		for _, strict := range []bool{false, true} {
+			want := validator.SeverityWarning
+			if strict {
+				want = validator.SeverityError
+			}
+
+			found := false
+			for _, e := range validateWithRuleSet(t, diagram, strict) {
+				if e.Line == 2 && e.Severity == want {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("%s (strict %v): expected %v for unknown statement on line 2", name, strict, want)
+			}
+		}
+	}
+}
+
+// validateWithRuleSet runs the default or strict rule set of the diagram's type.
+func validateWithRuleSet(t *testing.T, diagram ast.Diagram, strict bool) []validator.ValidationError {
+	t.Helper()
+
+	var errors []*validator.ValidationError
+	switch d := diagram.(type) {
+	case *ast.Flowchart:
+		rules := validator.DefaultRules()
+		if strict {
+			rules = validator.StrictRules()
+		}
+		return validator.New(rules...).Validate(d)
+	case *ast.SequenceDiagram:
+		rules := validator.SequenceDefaultRules()
+		if strict {
+			rules = validator.SequenceStrictRules()
+		}
+		return validator.NewSequence(rules...).ValidateDiagram(d)
+	case *ast.C4Diagram:
+		rules := validator.DefaultC4Rules()
+		if strict {
+			rules = validator.StrictC4Rules()
+		}
+		return validator.ValidateC4(d, rules)
+	case *ast.ERDiagram:
+		errors = validator.ValidateER(d, strict)
+	case *ast.GanttDiagram:
+		errors = validator.ValidateGantt(d, strict)
+	case *ast.PieDiagram:
+		errors = validator.ValidatePie(d, strict)
+	case *ast.JourneyDiagram:
+		errors = validator.ValidateJourney(d, strict)
+	case *ast.GitGraphDiagram:
+		errors = validator.ValidateGitGraph(d, strict)
+	case *ast.XYChartDiagram:
+		errors = validator.ValidateXYChart(d, strict)
+	case *ast.QuadrantDiagram:
+		errors = validator.ValidateQuadrant(d, strict)
+	case *ast.SankeyDiagram:
+		errors = validator.ValidateSankey(d, strict)
+	case *ast.TimelineDiagram:
+		errors = validator.ValidateTimeline(d, strict)
+	default:
+		t.Fatalf("unexpected diagram type %T", diagram)
+	}
+
+	var result []validator.ValidationError
+	for _, err := range errors {
+		result = append(result, *err)
+	}
+	return result
+}
+
+func validateUnknownLines[D ast.Diagram](diagram D, severity validator.Severity) []validator.ValidationError {
+	var errors []validator.ValidationError
+	for _, err := range (&validator.UnknownLines[D]{Severity: severity}).Validate(diagram) {
+		errors = append(errors, *err)
+	}
+	return errors
+}
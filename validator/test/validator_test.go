@@ -1,9 +1,11 @@
 package validator_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/sammcj/mermaid-check/ast"
+	"github.com/sammcj/mermaid-check/parser"
 	"github.com/sammcj/mermaid-check/validator"
 )
 
@@ -98,13 +100,18 @@ func TestNoParenthesesInLabels(t *testing.T) {
 	tests := []struct {
 		name      string
 		label     string
+		rawLabel  string
 		wantError bool
 	}{
-		{"no parentheses", "Simple Label", false},
-		{"with parentheses", "Label (with note)", true},
-		{"opening paren only", "Label (incomplete", true},
-		{"closing paren only", "Label incomplete)", true},
-		{"empty label", "", false},
+		{"no parentheses", "Simple Label", "", false},
+		{"with parentheses", "Label (with note)", "", true},
+		{"opening paren only", "Label (incomplete", "", true},
+		{"closing paren only", "Label incomplete)", "", true},
+		{"empty label", "", "", false},
+		{"quoted label", "Label (with note)", `"Label (with note)"`, false},
+		{"markdown label", "**Label** (with note)", "\"`**Label** (with note)`\"", false},
+		{"entity codes", "Label (with note)", "Label #40;with note#41;", false},
+		{"unquoted raw label", "Label (with note)", "Label (with note)", true},
 	}
 
 	for _, tt := range tests {
@@ -113,7 +120,7 @@ func TestNoParenthesesInLabels(t *testing.T) {
 				Type:      "flowchart",
 				Direction: "TD",
 				Statements: []ast.Statement{
-					&ast.NodeDef{ID: "A", Label: tt.label, Pos: ast.Position{Line: 2}},
+					&ast.NodeDef{ID: "A", Label: tt.label, RawLabel: tt.rawLabel, Pos: ast.Position{Line: 2}},
 				},
 			}
 
@@ -214,6 +221,227 @@ func TestNoDuplicateNodeIDs(t *testing.T) {
 			t.Errorf("expected 1 validation error for duplicate across subgraph, got %d", len(errors))
 		}
 	})
+
+	t.Run("subgraph IDs", func(t *testing.T) {
+		flowchart := &ast.Flowchart{
+			Type:      "flowchart",
+			Direction: "TD",
+			Statements: []ast.Statement{
+				&ast.NodeDef{ID: "api", Label: "API", Pos: ast.Position{Line: 2, Column: 5}},
+				&ast.Subgraph{
+					ID:    "api",
+					Title: "API Layer",
+					Statements: []ast.Statement{
+						&ast.Subgraph{ID: "db", Title: "db", Pos: ast.Position{Line: 4, Column: 9}},
+					},
+					Pos:    ast.Position{Line: 3, Column: 5},
+					IDSpan: ast.Span{Start: ast.Position{Line: 3, Column: 14}, End: ast.Position{Line: 3, Column: 17}},
+				},
+				&ast.Subgraph{ID: "db", Title: "Storage", Pos: ast.Position{Line: 7, Column: 5}},
+				&ast.Subgraph{Title: "Untitled", Pos: ast.Position{Line: 9, Column: 5}},
+				&ast.Subgraph{Title: "Untitled", Pos: ast.Position{Line: 11, Column: 5}},
+			},
+		}
+
+		errors := rule.Validate(flowchart)
+		want := []validator.ValidationError{
+			{Line: 3, Column: 14, Message: "subgraph ID 'api' is already used by a node (first defined at line 2)", Severity: validator.SeverityError},
+			{Line: 7, Column: 5, Message: "subgraph ID 'db' is already used by a subgraph (first defined at line 4)", Severity: validator.SeverityError},
+		}
+		if !reflect.DeepEqual(errors, want) {
+			t.Errorf("errors = %+v, want %+v", errors, want)
+		}
+	})
+}
+
+func TestNoDuplicateNodeIDsParsed(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"class shorthand on a defined node", "flowchart LR\n A[Start]\n A:::warn\n classDef warn fill:red"},
+		{"bare reference after the definition", "flowchart LR\n A[x]\n B\n A --> B\n A"},
+		{"bare declaration before the definition", "flowchart LR\n A\n A[x]\n A --> B"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := parser.NewFlowchartParser().Parse(tt.source)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			v := validator.New(validator.DefaultRules()...)
+			if errors := v.Validate(d.(*ast.Flowchart)); len(errors) > 0 {
+				t.Errorf("unexpected validation errors: %v", errors)
+			}
+		})
+	}
+}
+
+func TestValidNodeShapes(t *testing.T) {
+	rule := &validator.ValidNodeShapes{}
+
+	if rule.Name() != "valid-node-shapes" {
+		t.Errorf("Name() = %q, want %q", rule.Name(), "valid-node-shapes")
+	}
+
+	flowchart := &ast.Flowchart{
+		Type:      "flowchart",
+		Direction: "TD",
+		Statements: []ast.Statement{
+			&ast.NodeDef{ID: "A", Shape: "cyl", Kind: ast.ShapeCylinder, Pos: ast.Position{Line: 2, Column: 5}},
+			&ast.NodeDef{ID: "B", Pos: ast.Position{Line: 3, Column: 5}},
+			&ast.Subgraph{
+				Title: "Storage",
+				Statements: []ast.Statement{
+					&ast.NodeDef{
+						ID:        "C",
+						Shape:     "blob",
+						Pos:       ast.Position{Line: 5, Column: 9},
+						ShapeSpan: ast.Span{Start: ast.Position{Line: 5, Column: 20}, End: ast.Position{Line: 5, Column: 24}},
+					},
+				},
+				Pos: ast.Position{Line: 4},
+			},
+		},
+	}
+
+	errors := rule.Validate(flowchart)
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 validation error, got %d: %v", len(errors), errors)
+	}
+	if errors[0].Line != 5 || errors[0].Column != 20 || errors[0].Message != "unknown shape 'blob' for node 'C'" {
+		t.Errorf("unexpected validation error: %+v", errors[0])
+	}
+}
+
+func TestNoUndefinedReferences(t *testing.T) {
+	rule := &validator.NoUndefinedReferences{}
+
+	if rule.Name() != "no-undefined-references" {
+		t.Errorf("Name() = %q, want %q", rule.Name(), "no-undefined-references")
+	}
+
+	flowchart := &ast.Flowchart{
+		Type:      "flowchart",
+		Direction: "TD",
+		Statements: []ast.Statement{
+			&ast.NodeDef{ID: "A", Pos: ast.Position{Line: 2}},
+			&ast.Subgraph{
+				ID:    "inner",
+				Title: "Inner",
+				Statements: []ast.Statement{
+					&ast.Link{From: "B", To: "C", Pos: ast.Position{Line: 4}},
+				},
+			},
+			&ast.Style{NodeID: "C", Pos: ast.Position{Line: 6}},
+			&ast.Style{NodeID: "inner", Pos: ast.Position{Line: 6}},
+			&ast.Style{NodeID: "D", Pos: ast.Position{Line: 7, Column: 5}},
+			&ast.Click{NodeID: "E", Pos: ast.Position{Line: 8, Column: 5}},
+			&ast.ClassAssignment{NodeIDs: []string{"A", "F"}, ClassName: "warn", Pos: ast.Position{Line: 9, Column: 5}},
+		},
+	}
+
+	errors := rule.Validate(flowchart)
+	var messages []string
+	for _, err := range errors {
+		messages = append(messages, err.Message)
+		if err.Severity != validator.SeverityWarning {
+			t.Errorf("expected a warning, got %v", err.Severity)
+		}
+	}
+	want := []string{
+		`style references undefined node "D"`,
+		`click references undefined node "E"`,
+		`class references undefined node "F"`,
+	}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("messages = %q, want %q", messages, want)
+	}
+}
+
+func TestValidLinkStyleIndexes(t *testing.T) {
+	rule := &validator.ValidLinkStyleIndexes{}
+
+	if rule.Name() != "valid-link-style-indexes" {
+		t.Errorf("Name() = %q, want %q", rule.Name(), "valid-link-style-indexes")
+	}
+
+	tests := []struct {
+		name       string
+		linkStyle  *ast.LinkStyle
+		wantErrors int
+	}{
+		{"in range", &ast.LinkStyle{Indexes: []int{0, 1}}, 0},
+		{"default", &ast.LinkStyle{Default: true}, 0},
+		{"out of range", &ast.LinkStyle{Indexes: []int{1, 2, 5}}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flowchart := &ast.Flowchart{
+				Type:      "flowchart",
+				Direction: "TD",
+				Statements: []ast.Statement{
+					&ast.Link{From: "A", To: "B"},
+					&ast.Subgraph{Statements: []ast.Statement{&ast.Link{From: "B", To: "C"}}},
+					tt.linkStyle,
+				},
+			}
+			errors := rule.Validate(flowchart)
+			if len(errors) != tt.wantErrors {
+				t.Fatalf("expected %d errors, got %d: %v", tt.wantErrors, len(errors), errors)
+			}
+			if len(errors) > 0 && errors[0].Message != "linkStyle index 2 is out of range, the diagram has 2 links" {
+				t.Errorf("unexpected message: %q", errors[0].Message)
+			}
+		})
+	}
+}
+
+func TestValidLinkStyleIndexesOverflow(t *testing.T) {
+	source := "flowchart LR\n A --> B\n linkStyle 0,99999999999999999999 stroke:red"
+
+	d, err := parser.NewFlowchartParser().Parse(source)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	errors := (&validator.ValidLinkStyleIndexes{}).Validate(d.(*ast.Flowchart))
+	want := []validator.ValidationError{
+		{Line: 3, Column: 14, Message: "linkStyle index 99999999999999999999 is out of range, the diagram has 1 links", Severity: validator.SeverityError},
+	}
+	if !reflect.DeepEqual(errors, want) {
+		t.Errorf("errors = %+v, want %+v", errors, want)
+	}
+}
+
+func TestNoUndefinedClasses(t *testing.T) {
+	rule := &validator.NoUndefinedClasses{}
+
+	if rule.Name() != "no-undefined-classes" {
+		t.Errorf("Name() = %q, want %q", rule.Name(), "no-undefined-classes")
+	}
+
+	flowchart := &ast.Flowchart{
+		Type:      "flowchart",
+		Direction: "TD",
+		Statements: []ast.Statement{
+			&ast.ClassAssignment{NodeIDs: []string{"A"}, ClassName: "warn", Shorthand: true, Pos: ast.Position{Line: 2}},
+			&ast.ClassAssignment{NodeIDs: []string{"B"}, ClassName: "ok", Shorthand: true, Pos: ast.Position{Line: 3}},
+			&ast.ClassAssignment{NodeIDs: []string{"A", "B"}, ClassName: "muted", Pos: ast.Position{Line: 4}},
+			&ast.ClassDef{Name: "warn", Pos: ast.Position{Line: 5}},
+		},
+	}
+
+	errors := rule.Validate(flowchart)
+	if len(errors) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %v", len(errors), errors)
+	}
+	if errors[0].Line != 3 || errors[0].Message != `node "B" references undefined class "ok"` {
+		t.Errorf("unexpected first error: %+v", errors[0])
+	}
+	if errors[1].Line != 4 || errors[1].Message != `class statement references undefined class "muted"` {
+		t.Errorf("unexpected second error: %+v", errors[1])
+	}
 }
 
 func TestValidator(t *testing.T) {
@@ -337,7 +337,7 @@ func TestXYChartDefaultRules(t *testing.T) {
 	if len(rules) == 0 {
 		t.Error("validator.XYChartDefaultRules() returned empty slice")
 	}
-	expectedRuleCount := 5 // XAxisDefined, YAxisDefined, MinimumSeries, ValidSeriesLength, ValidOrientation
+	expectedRuleCount := 6 // XAxisDefined, YAxisDefined, MinimumSeries, ValidSeriesLength, ValidOrientation, UnknownLines
 	if len(rules) != expectedRuleCount {
 		t.Errorf("expected %d rules, got %d", expectedRuleCount, len(rules))
 	}
@@ -30,14 +30,17 @@ func TimelineDefaultRules() []TimelineRule {
 	return []TimelineRule{
 		&PeriodsHaveEventsRule{},
 		&NoEmptyPeriodsRule{},
+		&UnknownLines[*ast.TimelineDiagram]{Severity: SeverityWarning},
 	}
 }
 
 // TimelineStrictRules returns strict validation rules for timeline diagrams.
 func TimelineStrictRules() []TimelineRule {
-	rules := TimelineDefaultRules()
-	// Add strict-only rules here if needed
-	return rules
+	return []TimelineRule{
+		&PeriodsHaveEventsRule{},
+		&NoEmptyPeriodsRule{},
+		&UnknownLines[*ast.TimelineDiagram]{Severity: SeverityError},
+	}
 }
 
 // PeriodsHaveEventsRule checks that all periods have at least one event.
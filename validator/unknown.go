@@ -0,0 +1,143 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/sammcj/mermaid-check/ast"
+)
+
+// NoUnknownStatements reports lines the parser could not recognise. These are
+// usually typos, such as "A -> B" in a flowchart, that Mermaid refuses to render.
+// It applies to flowcharts, sequence, class and state diagrams. UnknownLines
+// reports them for the other diagram types.
+type NoUnknownStatements struct {
+	Severity Severity // Severity of each reported line
+}
+
+// Name returns the name of this validation rule.
+func (r *NoUnknownStatements) Name() string { return "no-unknown-statements" }
+
+// Validate checks a flowchart for unrecognised lines.
+func (r *NoUnknownStatements) Validate(flowchart *ast.Flowchart) []ValidationError {
+	var errors []ValidationError
+	r.checkFlowchart(flowchart.Statements, &errors)
+	return errors
+}
+
+func (r *NoUnknownStatements) checkFlowchart(statements []ast.Statement, errors *[]ValidationError) {
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *ast.UnknownStatement:
+			*errors = append(*errors, r.report(s))
+		case *ast.Subgraph:
+			r.checkFlowchart(s.Statements, errors)
+		}
+	}
+}
+
+// ValidateClass checks a class diagram for unrecognised lines.
+func (r *NoUnknownStatements) ValidateClass(diagram *ast.ClassDiagram) []ValidationError {
+	var errors []ValidationError
+	for stmt := range allClassStatements(diagram.Statements) {
+		if u, ok := stmt.(*ast.UnknownStatement); ok {
+			errors = append(errors, r.report(u))
+		}
+	}
+	return errors
+}
+
+// ValidateState checks a state diagram for unrecognised lines.
+func (r *NoUnknownStatements) ValidateState(diagram *ast.StateDiagram) []ValidationError {
+	var errors []ValidationError
+	r.checkState(diagram.Statements, &errors)
+	return errors
+}
+
+func (r *NoUnknownStatements) checkState(statements []ast.StateStmt, errors *[]ValidationError) {
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *ast.UnknownStatement:
+			*errors = append(*errors, r.report(s))
+		case *ast.State:
+			r.checkState(s.Nested, errors)
+		}
+	}
+}
+
+// ValidateSequence checks a sequence diagram for unrecognised lines.
+func (r *NoUnknownStatements) ValidateSequence(diagram *ast.SequenceDiagram) []ValidationError {
+	var errors []ValidationError
+	for stmt := range allSeqStatements(diagram.Statements) {
+		if u, ok := stmt.(*ast.UnknownStatement); ok {
+			errors = append(errors, r.report(u))
+		}
+	}
+	return errors
+}
+
+func (r *NoUnknownStatements) report(u *ast.UnknownStatement) ValidationError {
+	return ValidationError{
+		Line:     u.Pos.Line,
+		Column:   u.Pos.Column,
+		Message:  fmt.Sprintf("unrecognised statement %q", u.Text),
+		Severity: r.Severity,
+	}
+}
+
+// UnknownLines is NoUnknownStatements for diagram types that keep unrecognised
+// lines in their Unknown field rather than among their statements, such as
+// *ast.PieDiagram. It fits the rule set of each of those types.
+type UnknownLines[D ast.Diagram] struct {
+	Severity Severity // Severity of each reported line
+}
+
+// Name returns the name of this validation rule.
+func (r *UnknownLines[D]) Name() string { return "no-unknown-statements" }
+
+// Validate checks a diagram for unrecognised lines.
+func (r *UnknownLines[D]) Validate(diagram D) []*ValidationError {
+	var unknown []*ast.UnknownStatement
+	switch d := any(diagram).(type) {
+	case *ast.ERDiagram:
+		unknown = d.Unknown
+	case *ast.GanttDiagram:
+		unknown = d.Unknown
+	case *ast.PieDiagram:
+		unknown = d.Unknown
+	case *ast.JourneyDiagram:
+		unknown = d.Unknown
+	case *ast.TimelineDiagram:
+		unknown = d.Unknown
+	case *ast.GitGraphDiagram:
+		unknown = d.Unknown
+	case *ast.SankeyDiagram:
+		unknown = d.Unknown
+	case *ast.QuadrantDiagram:
+		unknown = d.Unknown
+	case *ast.XYChartDiagram:
+		unknown = d.Unknown
+	case *ast.C4Diagram:
+		unknown = d.Unknown
+	}
+
+	rule := NoUnknownStatements{Severity: r.Severity}
+	var errors []*ValidationError
+	for _, u := range unknown {
+		err := rule.report(u)
+		errors = append(errors, &err)
+	}
+	return errors
+}
+
+// c4UnknownLines fits UnknownLines to C4Rule, which returns errors by value.
+type c4UnknownLines struct {
+	UnknownLines[*ast.C4Diagram]
+}
+
+func (r *c4UnknownLines) Validate(d *ast.C4Diagram) []ValidationError {
+	var errors []ValidationError
+	for _, err := range r.UnknownLines.Validate(d) {
+		errors = append(errors, *err)
+	}
+	return errors
+}
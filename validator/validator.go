@@ -3,6 +3,8 @@ package validator
 
 import (
 	"fmt"
+	"iter"
+	"strconv"
 	"strings"
 
 	"github.com/sammcj/mermaid-check/ast"
@@ -179,6 +181,10 @@ func (r *NoUndefinedNodes) collectDefinedNodes(statements []ast.Statement, defin
 			defined[s.From] = true
 			defined[s.To] = true
 		case *ast.Subgraph:
+			// Links may connect to a subgraph by its ID
+			if s.ID != "" {
+				defined[s.ID] = true
+			}
 			r.collectDefinedNodes(s.Statements, defined)
 		}
 	}
@@ -210,7 +216,9 @@ func (r *NoUndefinedNodes) checkLinks(statements []ast.Statement, defined map[st
 	}
 }
 
-// NoParenthesesInLabels checks that node labels don't contain parentheses.
+// NoParenthesesInLabels checks that node labels don't contain parentheses. Quoted
+// labels, such as A["Start (here)"], may contain them, as may labels that write
+// them as the entity codes #40; and #41;.
 type NoParenthesesInLabels struct{}
 
 // Name returns the name of this validation rule.
@@ -227,7 +235,15 @@ func (r *NoParenthesesInLabels) checkStatements(statements []ast.Statement, erro
 	for _, stmt := range statements {
 		switch s := stmt.(type) {
 		case *ast.NodeDef:
-			if strings.Contains(s.Label, "(") || strings.Contains(s.Label, ")") {
+			// Check the label as written, as #40; and #41; are legal
+			label := s.Label
+			if s.RawLabel != "" {
+				label = s.RawLabel
+			}
+			if strings.HasPrefix(label, `"`) {
+				continue
+			}
+			if strings.Contains(label, "(") || strings.Contains(label, ")") {
 				*errors = append(*errors, ValidationError{
 					Line:     s.Pos.Line,
 					Column:   s.Pos.Column,
@@ -241,40 +257,273 @@ func (r *NoParenthesesInLabels) checkStatements(statements []ast.Statement, erro
 	}
 }
 
-// NoDuplicateNodeIDs checks that node IDs are unique.
+// NoDuplicateNodeIDs checks that node and subgraph IDs are unique.
 type NoDuplicateNodeIDs struct{}
 
 // Name returns the name of this validation rule.
 func (r *NoDuplicateNodeIDs) Name() string { return "no-duplicate-node-ids" }
 
-// Validate checks that all node IDs are unique within the flowchart.
+// Validate checks that all node and subgraph IDs are unique within the flowchart.
+// A node redefined is a warning, but an ID shared with a subgraph is an error as
+// Mermaid cannot render the diagram.
 func (r *NoDuplicateNodeIDs) Validate(flowchart *ast.Flowchart) []ValidationError {
-	nodePositions := make(map[string]ast.Position)
+	definitions := make(map[string]idDefinition)
 	var errors []ValidationError
 
-	r.checkDuplicates(flowchart.Statements, nodePositions, &errors)
+	r.checkDuplicates(flowchart.Statements, definitions, &errors)
 
 	return errors
 }
 
-func (r *NoDuplicateNodeIDs) checkDuplicates(statements []ast.Statement, positions map[string]ast.Position, errors *[]ValidationError) {
+// idDefinition records where an ID was first defined, and by what.
+type idDefinition struct {
+	pos  ast.Position
+	kind string // "node" or "subgraph"
+	bare bool   // A node declared by its ID alone
+}
+
+func (r *NoDuplicateNodeIDs) checkDuplicates(statements []ast.Statement, definitions map[string]idDefinition, errors *[]ValidationError) {
 	for _, stmt := range statements {
 		switch s := stmt.(type) {
 		case *ast.NodeDef:
-			if firstPos, exists := positions[s.ID]; exists {
+			// A bare ID declares a node without a shape or label, so it does
+			// not clash with the node's definition before or after it
+			bare := s.Shape == "" && s.Label == "" && s.Properties == nil
+			first, exists := definitions[s.ID]
+			switch {
+			case !exists || first.kind == "node" && first.bare && !bare:
+				definitions[s.ID] = idDefinition{pos: s.Pos, kind: "node", bare: bare}
+			case first.kind == "node" && bare:
+			default:
+				r.check(s.ID, "node", s.Pos, definitions, errors)
+			}
+		case *ast.Subgraph:
+			if s.ID != "" {
+				pos := s.Pos
+				if s.IDSpan.IsValid() {
+					pos = s.IDSpan.Start
+				}
+				r.check(s.ID, "subgraph", pos, definitions, errors)
+			}
+			r.checkDuplicates(s.Statements, definitions, errors)
+		}
+	}
+}
+
+func (r *NoDuplicateNodeIDs) check(id, kind string, pos ast.Position, definitions map[string]idDefinition, errors *[]ValidationError) {
+	first, exists := definitions[id]
+	if !exists {
+		definitions[id] = idDefinition{pos: pos, kind: kind}
+		return
+	}
+	if kind == "node" && first.kind == "node" {
+		*errors = append(*errors, ValidationError{
+			Line:     pos.Line,
+			Column:   pos.Column,
+			Message:  fmt.Sprintf("duplicate node ID '%s' (first defined at line %d)", id, first.pos.Line),
+			Severity: SeverityWarning,
+		})
+		return
+	}
+	*errors = append(*errors, ValidationError{
+		Line:     pos.Line,
+		Column:   pos.Column,
+		Message:  fmt.Sprintf("%s ID '%s' is already used by a %s (first defined at line %d)", kind, id, first.kind, first.pos.Line),
+		Severity: SeverityError,
+	})
+}
+
+// ValidNodeShapes checks that node shapes are ones Mermaid knows, such as the
+// name in A@{ shape: cyl }.
+type ValidNodeShapes struct{}
+
+// Name returns the name of this validation rule.
+func (r *ValidNodeShapes) Name() string { return "valid-node-shapes" }
+
+// Validate checks that every node shape was recognised by the parser.
+func (r *ValidNodeShapes) Validate(flowchart *ast.Flowchart) []ValidationError {
+	var errors []ValidationError
+	r.checkStatements(flowchart.Statements, &errors)
+	return errors
+}
+
+func (r *ValidNodeShapes) checkStatements(statements []ast.Statement, errors *[]ValidationError) {
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *ast.NodeDef:
+			if s.Shape != "" && s.Kind == "" {
+				pos := s.Pos
+				if s.ShapeSpan.IsValid() {
+					pos = s.ShapeSpan.Start
+				}
 				*errors = append(*errors, ValidationError{
-					Line:     s.Pos.Line,
-					Column:   s.Pos.Column,
-					Message:  fmt.Sprintf("duplicate node ID '%s' (first defined at line %d)", s.ID, firstPos.Line),
-					Severity: SeverityWarning,
+					Line:     pos.Line,
+					Column:   pos.Column,
+					Message:  fmt.Sprintf("unknown shape '%s' for node '%s'", s.Shape, s.ID),
+					Severity: SeverityError,
 				})
-			} else {
-				positions[s.ID] = s.Pos
 			}
 		case *ast.Subgraph:
-			r.checkDuplicates(s.Statements, positions, errors)
+			r.checkStatements(s.Statements, errors)
+		}
+	}
+}
+
+// NoUndefinedReferences checks that style, click and class statements name nodes
+// that appear in the flowchart.
+type NoUndefinedReferences struct{}
+
+// Name returns the name of this validation rule.
+func (r *NoUndefinedReferences) Name() string { return "no-undefined-references" }
+
+// Validate checks the node IDs named by style, click and class statements.
+func (r *NoUndefinedReferences) Validate(flowchart *ast.Flowchart) []ValidationError {
+	checker := NewReferenceChecker("node")
+	for stmt := range allStatements(flowchart.Statements) {
+		switch s := stmt.(type) {
+		case *ast.NodeDef:
+			checker.Add(s.ID)
+		case *ast.Link:
+			checker.Add(s.From)
+			checker.Add(s.To)
+		case *ast.Subgraph:
+			if s.ID != "" {
+				checker.Add(s.ID)
+			}
+		}
+	}
+
+	var errors []ValidationError
+	check := func(id string, span ast.Span, pos ast.Position, context string) {
+		if span.IsValid() {
+			pos = span.Start
+		}
+		if err := checker.Check(id, pos, context); err != nil {
+			err.Severity = SeverityWarning
+			errors = append(errors, *err)
+		}
+	}
+	for stmt := range allStatements(flowchart.Statements) {
+		switch s := stmt.(type) {
+		case *ast.Style:
+			check(s.NodeID, s.NodeIDSpan, s.Pos, "style")
+		case *ast.Click:
+			check(s.NodeID, s.NodeIDSpan, s.Pos, "click")
+		case *ast.ClassAssignment:
+			for i, id := range s.NodeIDs {
+				var span ast.Span
+				if i < len(s.NodeIDSpans) {
+					span = s.NodeIDSpans[i]
+				}
+				check(id, span, s.Pos, "class")
+			}
+		}
+	}
+	return errors
+}
+
+// ValidLinkStyleIndexes checks that linkStyle statements refer to links that
+// exist. Mermaid refuses to render a diagram with an out of range index.
+type ValidLinkStyleIndexes struct{}
+
+// Name returns the name of this validation rule.
+func (r *ValidLinkStyleIndexes) Name() string { return "valid-link-style-indexes" }
+
+// Validate checks each linkStyle index against the number of links.
+func (r *ValidLinkStyleIndexes) Validate(flowchart *ast.Flowchart) []ValidationError {
+	var links int
+	var linkStyles []*ast.LinkStyle
+	for stmt := range allStatements(flowchart.Statements) {
+		switch s := stmt.(type) {
+		case *ast.Link:
+			links++
+		case *ast.LinkStyle:
+			linkStyles = append(linkStyles, s)
+		}
+	}
+
+	var errors []ValidationError
+	for _, s := range linkStyles {
+		for i, index := range s.Indexes {
+			if index >= 0 && index < links {
+				continue
+			}
+			pos := s.Pos
+			if i < len(s.IndexSpans) && s.IndexSpans[i].IsValid() {
+				pos = s.IndexSpans[i].Start
+			}
+			// An index too large for an int is reported as written
+			text := strconv.Itoa(index)
+			if i < len(s.RawIndexes) {
+				text = s.RawIndexes[i]
+			}
+			errors = append(errors, ValidationError{
+				Line:     pos.Line,
+				Column:   pos.Column,
+				Message:  fmt.Sprintf("linkStyle index %s is out of range, the diagram has %d links", text, links),
+				Severity: SeverityError,
+			})
+		}
+	}
+	return errors
+}
+
+// NoUndefinedClasses checks that classes applied to nodes, with a class statement
+// or the A:::class shorthand, are defined by a classDef.
+type NoUndefinedClasses struct{}
+
+// Name returns the name of this validation rule.
+func (r *NoUndefinedClasses) Name() string { return "no-undefined-classes" }
+
+// Validate checks every class assignment against the classDef statements.
+func (r *NoUndefinedClasses) Validate(flowchart *ast.Flowchart) []ValidationError {
+	checker := NewReferenceChecker("class")
+	for stmt := range allStatements(flowchart.Statements) {
+		if s, ok := stmt.(*ast.ClassDef); ok {
+			checker.Add(s.Name)
+		}
+	}
+
+	var errors []ValidationError
+	for stmt := range allStatements(flowchart.Statements) {
+		s, ok := stmt.(*ast.ClassAssignment)
+		if !ok {
+			continue
+		}
+		pos := s.Pos
+		if s.ClassNameSpan.IsValid() {
+			pos = s.ClassNameSpan.Start
+		}
+		context := "class statement"
+		if s.Shorthand && len(s.NodeIDs) == 1 {
+			context = fmt.Sprintf("node %q", s.NodeIDs[0])
+		}
+		if err := checker.Check(s.ClassName, pos, context); err != nil {
+			err.Severity = SeverityWarning
+			errors = append(errors, *err)
+		}
+	}
+	return errors
+}
+
+// allStatements yields the statements of a flowchart in source order, including
+// those nested in subgraphs.
+func allStatements(statements []ast.Statement) iter.Seq[ast.Statement] {
+	return func(yield func(ast.Statement) bool) {
+		walkStatements(statements, yield)
+	}
+}
+
+func walkStatements(statements []ast.Statement, yield func(ast.Statement) bool) bool {
+	for _, stmt := range statements {
+		if !yield(stmt) {
+			return false
+		}
+		if s, ok := stmt.(*ast.Subgraph); ok && !walkStatements(s.Statements, yield) {
+			return false
 		}
 	}
+	return true
 }
 
 // DefaultRules returns the default set of validation rules.
@@ -283,6 +532,11 @@ func DefaultRules() []Rule {
 		&ValidDirection{},
 		&NoUndefinedNodes{},
 		&NoDuplicateNodeIDs{},
+		&ValidNodeShapes{},
+		&NoUndefinedReferences{},
+		&ValidLinkStyleIndexes{},
+		&NoUndefinedClasses{},
+		&NoUnknownStatements{Severity: SeverityWarning},
 	}
 }
 
@@ -293,5 +547,10 @@ func StrictRules() []Rule {
 		&NoUndefinedNodes{},
 		&NoDuplicateNodeIDs{},
 		&NoParenthesesInLabels{},
+		&ValidNodeShapes{},
+		&NoUndefinedReferences{},
+		&ValidLinkStyleIndexes{},
+		&NoUndefinedClasses{},
+		&NoUnknownStatements{Severity: SeverityError},
 	}
 }
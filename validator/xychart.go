@@ -33,14 +33,20 @@ func XYChartDefaultRules() []XYChartRule {
 		&XYChartMinimumSeriesRule{},
 		&XYChartValidSeriesLengthRule{},
 		&XYChartValidOrientationRule{},
+		&UnknownLines[*ast.XYChartDiagram]{Severity: SeverityWarning},
 	}
 }
 
 // XYChartStrictRules returns strict validation rules for XY chart diagrams.
 func XYChartStrictRules() []XYChartRule {
-	rules := XYChartDefaultRules()
-	// Add strict-only rules here if needed
-	return rules
+	return []XYChartRule{
+		&XYChartXAxisDefinedRule{},
+		&XYChartYAxisDefinedRule{},
+		&XYChartMinimumSeriesRule{},
+		&XYChartValidSeriesLengthRule{},
+		&XYChartValidOrientationRule{},
+		&UnknownLines[*ast.XYChartDiagram]{Severity: SeverityError},
+	}
 }
 
 // XYChartXAxisDefinedRule checks that x-axis is defined.